@@ -0,0 +1,139 @@
+// Package apiversion serves every route under /v1 and /v2. Unprefixed routes
+// are an alias of v1, so existing clients keep working until v1 is sunset.
+package apiversion
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// API versions, oldest first. Handlers read the version of a request with
+// FromContext to keep older payloads for older clients.
+const (
+	V1 = "v1"
+	V2 = "v2"
+)
+
+// Versions lists every served version, oldest first.
+var Versions = []string{V1, V2}
+
+// Latest is the version new clients should use.
+const Latest = V2
+
+// Default is the version of unprefixed routes.
+const Default = V1
+
+// unversioned paths are served as they are, e.g. for Prometheus scrapes.
+var unversioned = map[string]bool{"/metrics": true}
+
+var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "api_version_requests_total",
+	Help: "HTTP requests by API version. alias is true for unprefixed routes.",
+}, []string{"version", "alias"})
+
+// Deprecation announces that a version will be removed.
+type Deprecation struct {
+	// At is when the version was deprecated, sent in the Deprecation header.
+	At time.Time
+	// Sunset is when the version stops being served, sent in the Sunset header.
+	Sunset time.Time
+}
+
+// Policy lists the deprecated versions.
+type Policy struct {
+	Deprecated map[string]Deprecation
+}
+
+// DefaultPolicy deprecates v1 as of v1DeprecatedAt, or nothing when it is
+// zero. A zero v1Sunset gives clients six months to move to v2.
+func DefaultPolicy(v1DeprecatedAt time.Time, v1Sunset time.Time) Policy {
+	if v1DeprecatedAt.IsZero() {
+		return Policy{}
+	}
+	if v1Sunset.IsZero() {
+		v1Sunset = v1DeprecatedAt.AddDate(0, 6, 0)
+	}
+	return Policy{Deprecated: map[string]Deprecation{
+		V1: {At: v1DeprecatedAt, Sunset: v1Sunset},
+	}}
+}
+
+type contextKey int
+
+const (
+	versionKey contextKey = iota
+	prefixKey
+)
+
+// NewContext returns a copy of ctx carrying the API version, as the
+// middleware records it.
+func NewContext(ctx context.Context, version string) context.Context {
+	return context.WithValue(ctx, versionKey, version)
+}
+
+// FromContext returns the API version of a request, Default outside the middleware.
+func FromContext(ctx context.Context) string {
+	if version, ok := ctx.Value(versionKey).(string); ok {
+		return version
+	}
+	return Default
+}
+
+// Path returns the path as the client requested it, including the version
+// prefix the middleware strips, so links stay within the client's version.
+func Path(r *http.Request) string {
+	prefix, _ := r.Context().Value(prefixKey).(string)
+	return prefix + r.URL.Path
+}
+
+// split separates a version prefix from a path, e.g. /v2/bounce_rules.
+func split(path string) (string, string, bool) {
+	for _, version := range Versions {
+		prefix := "/" + version
+		if path == prefix {
+			return version, "/", true
+		}
+		if strings.HasPrefix(path, prefix+"/") {
+			return version, strings.TrimPrefix(path, prefix), true
+		}
+	}
+	return Default, path, false
+}
+
+// Middleware strips the version prefix so every version shares one route
+// tree, records the version in the request context and counts it. Responses
+// of deprecated versions carry the Deprecation and Sunset headers. It must
+// run before routing.
+func (p Policy) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if unversioned[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		version, path, prefixed := split(r.URL.Path)
+		ctx := NewContext(r.Context(), version)
+		if prefixed {
+			ctx = context.WithValue(ctx, prefixKey, "/"+version)
+		}
+		r = r.WithContext(ctx)
+		u := *r.URL
+		u.Path = path
+		u.RawPath = ""
+		r.URL = &u
+
+		requests.WithLabelValues(version, fmt.Sprint(!prefixed)).Inc()
+		if deprecation, ok := p.Deprecated[version]; ok {
+			// RFC 9745 and RFC 8594
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecation.At.Unix()))
+			w.Header().Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,82 @@
+package apiversion
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type seen struct {
+	path    string
+	version string
+	link    string
+}
+
+func serve(p Policy, target string) (*httptest.ResponseRecorder, seen) {
+	var s seen
+	handler := p.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s = seen{path: r.URL.Path, version: FromContext(r.Context()), link: Path(r)}
+	}))
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+	return rr, s
+}
+
+func TestMiddlewareStripsVersionPrefix(t *testing.T) {
+	log.Print("Testing versioned paths reach the shared routes with their version in the context")
+	p := DefaultPolicy(time.Time{}, time.Time{})
+
+	_, s := serve(p, "/v2/bounce_rules/7?fields=id")
+	assert.Equal(t, seen{path: "/bounce_rules/7", version: V2, link: "/v2/bounce_rules/7"}, s)
+
+	_, s = serve(p, "/v1")
+	assert.Equal(t, seen{path: "/", version: V1, link: "/v1/"}, s)
+
+	_, s = serve(p, "/throughput_rules")
+	assert.Equal(t, seen{path: "/throughput_rules", version: V1, link: "/throughput_rules"}, s, "unprefixed paths should be v1")
+
+	_, s = serve(p, "/v2x/bounce_rules")
+	assert.Equal(t, "/v2x/bounce_rules", s.path, "should only strip whole path segments")
+}
+
+func TestMiddlewareAnnouncesDeprecation(t *testing.T) {
+	log.Print("Testing deprecated versions answer with Deprecation and Sunset headers")
+	deprecatedAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 1, 31, 12, 0, 0, 0, time.UTC)
+	p := DefaultPolicy(deprecatedAt, sunset)
+
+	for _, target := range []string{"/v1/bounce_rules", "/bounce_rules"} {
+		rr, _ := serve(p, target)
+		assert.Equal(t, "@1792368000", rr.Header().Get("Deprecation"), target)
+		assert.Equal(t, "Sun, 31 Jan 2027 12:00:00 GMT", rr.Header().Get("Sunset"), target)
+	}
+
+	rr, _ := serve(p, "/v2/bounce_rules")
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+	assert.Empty(t, rr.Header().Get("Sunset"))
+
+	assert.Equal(t, time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC), DefaultPolicy(deprecatedAt, time.Time{}).Deprecated[V1].Sunset, "should default to six months")
+
+	rr, _ = serve(DefaultPolicy(time.Time{}, time.Time{}), "/v1/bounce_rules")
+	assert.Empty(t, rr.Header().Get("Deprecation"), "should not deprecate v1 without a date")
+	assert.Empty(t, rr.Header().Get("Sunset"))
+}
+
+func TestMiddlewareCountsVersions(t *testing.T) {
+	log.Print("Testing requests are counted by API version")
+	p := Policy{}
+	v2 := testutil.ToFloat64(requests.WithLabelValues(V2, "false"))
+	alias := testutil.ToFloat64(requests.WithLabelValues(V1, "true"))
+
+	serve(p, "/v2/throughput_rules")
+	serve(p, "/throughput_rules")
+	serve(p, "/metrics")
+
+	assert.Equal(t, v2+1, testutil.ToFloat64(requests.WithLabelValues(V2, "false")))
+	assert.Equal(t, alias+1, testutil.ToFloat64(requests.WithLabelValues(V1, "true")), "should not count metric scrapes")
+}
@@ -0,0 +1,196 @@
+package auth
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gobrm/bulk"
+	"gobrm/problem"
+	"gobrm/render"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// defaultUsageLimit and maxUsageLimit bound the usage audit returned per request.
+const (
+	defaultUsageLimit = 100
+	maxUsageLimit     = 1000
+)
+
+// API serves API key management and the key usage audit. Each rule manager
+// mounts it on its own router, so URL parameters are read through URLParam.
+type API struct {
+	DB       *sql.DB
+	URLParam func(r *http.Request, key string) string
+}
+
+// keyListColumns and usageListColumns order the fields of API key lists in YAML and CSV.
+var (
+	keyListColumns   = bulk.Columns(Key{})
+	usageListColumns = bulk.Columns(Usage{})
+)
+
+type keyRequest struct {
+	Name      string    `json:"name"`
+	Grants    []string  `json:"grants"`
+	ExpiresAt null.Time `json:"expires_at"`
+}
+
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Respond(w, r, code, message)
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	if code == http.StatusNoContent {
+		w.WriteHeader(code)
+		return
+	}
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+// parseKeyRequest validates a new key's name and grants.
+func parseKeyRequest(request keyRequest) ([]Grant, []problem.FieldError) {
+	var fields []problem.FieldError
+	if strings.TrimSpace(request.Name) == "" {
+		fields = append(fields, problem.FieldError{Field: "name", Message: "is required"})
+	}
+	if len(request.Grants) == 0 {
+		fields = append(fields, problem.FieldError{Field: "grants", Message: "must name at least one role"})
+	}
+
+	grants := make([]Grant, 0, len(request.Grants))
+	for i, name := range request.Grants {
+		grant, err := ParseGrant(name)
+		if err != nil {
+			fields = append(fields, problem.FieldError{Field: "grants[" + strconv.Itoa(i) + "]", Message: err.Error()})
+			continue
+		}
+		grants = append(grants, grant)
+	}
+	return grants, fields
+}
+
+func (api *API) GetKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := getKeys(r.Context(), api.DB)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.List(w, r, keys, keyListColumns)
+}
+
+// CreateKey issues a key. The key is only ever returned from this call.
+func (api *API) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var request keyRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&request); err != nil {
+		problem.Write(w, r, problem.Validation("Invalid API key request payload", problem.DecodeErrors(err)))
+		return
+	}
+	defer r.Body.Close()
+
+	grants, fields := parseKeyRequest(request)
+	if len(fields) > 0 {
+		problem.Write(w, r, problem.Validation("Invalid API key", fields))
+		return
+	}
+
+	var createdBy string
+	if identity, ok := FromContext(r.Context()); ok {
+		createdBy = identity.Subject
+	}
+	key, err := CreateKey(r.Context(), api.DB, strings.TrimSpace(request.Name), grants, createdBy, request.ExpiresAt)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, key)
+}
+
+func (api *API) GetKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	key, err := getKey(r.Context(), api.DB, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "API key not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, key)
+}
+
+// RevokeKey stops a key from being accepted. The key and its usage audit are kept.
+func (api *API) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := revokeKey(r.Context(), api.DB, id); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "API key not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// GetKeyUsage returns the requests made with a key, newest first.
+func (api *API) GetKeyUsage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	limit := defaultUsageLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxUsageLimit {
+			problem.Write(w, r, problem.Validation("Invalid usage query", []problem.FieldError{
+				{Field: "limit", Message: "must be between 1 and " + strconv.Itoa(maxUsageLimit)},
+			}))
+			return
+		}
+	}
+
+	if _, err := getKey(r.Context(), api.DB, id); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "API key not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	usage, err := getUsage(r.Context(), api.DB, id, limit)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.List(w, r, usage, usageListColumns)
+}
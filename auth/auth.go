@@ -0,0 +1,324 @@
+// Package auth authenticates clients with API keys stored hashed in the
+// database or with JWTs signed by a key in a local JWKS file, and authorizes
+// each request by the roles the client holds in the subsystem it touches.
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"gobrm/apiversion"
+	"gobrm/problem"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Role is what a client may do in a subsystem. Each role includes the ones
+// before it.
+type Role int
+
+const (
+	// Viewer reads rules, their history and releases.
+	Viewer Role = iota + 1
+	// Editor changes rules and proposes change requests.
+	Editor
+	// Approver reviews change requests and publishes and rolls out releases.
+	Approver
+	// Admin manages webhooks and, for every subsystem, API keys.
+	Admin
+)
+
+var roleNames = map[Role]string{Viewer: "viewer", Editor: "editor", Approver: "approver", Admin: "admin"}
+
+func (r Role) String() string {
+	return roleNames[r]
+}
+
+// ParseRole reads a role name such as "editor".
+func ParseRole(name string) (Role, error) {
+	for role, roleName := range roleNames {
+		if roleName == name {
+			return role, nil
+		}
+	}
+	return 0, fmt.Errorf("role must be one of viewer, editor, approver or admin")
+}
+
+// Subsystems a role is scoped to. AllSubsystems also covers the routes
+// shared by both, such as webhooks, batches and API keys.
+const (
+	Bounce        = "bounce"
+	Throughput    = "throughput"
+	AllSubsystems = "*"
+)
+
+// Grant is a role in one subsystem or, with AllSubsystems, in every one.
+type Grant struct {
+	Subsystem string
+	Role      Role
+}
+
+// ParseGrant reads a grant such as "bounce:editor". A role on its own,
+// e.g. "admin", is granted in every subsystem.
+func ParseGrant(value string) (Grant, error) {
+	subsystem, roleName := AllSubsystems, value
+	if i := strings.Index(value, ":"); i >= 0 {
+		subsystem, roleName = value[:i], value[i+1:]
+	}
+	switch subsystem {
+	case Bounce, Throughput, AllSubsystems:
+	default:
+		return Grant{}, fmt.Errorf("subsystem must be one of bounce, throughput or *")
+	}
+	role, err := ParseRole(roleName)
+	if err != nil {
+		return Grant{}, err
+	}
+	return Grant{Subsystem: subsystem, Role: role}, nil
+}
+
+func (g Grant) String() string {
+	return g.Subsystem + ":" + g.Role.String()
+}
+
+func (g Grant) MarshalText() ([]byte, error) {
+	return []byte(g.String()), nil
+}
+
+func (g *Grant) UnmarshalText(text []byte) error {
+	grant, err := ParseGrant(string(text))
+	if err != nil {
+		return err
+	}
+	*g = grant
+	return nil
+}
+
+// Identity is an authenticated client.
+type Identity struct {
+	// Subject names the client in change requests, releases and logs:
+	// "api-key:<name>" for API keys, the sub claim for JWTs.
+	Subject string
+	// KeyID is the API key the client used, 0 for a JWT.
+	KeyID  int
+	Grants []Grant
+}
+
+// Can reports whether the identity holds at least the role in the subsystem.
+func (i Identity) Can(subsystem string, role Role) bool {
+	for _, grant := range i.Grants {
+		if (grant.Subsystem == subsystem || grant.Subsystem == AllSubsystems) && grant.Role >= role {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey int
+
+const identityKey contextKey = 0
+
+// WithIdentity returns a context carrying the authenticated client.
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey, identity)
+}
+
+// FromContext returns the authenticated client of a request, if any.
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey).(Identity)
+	return identity, ok
+}
+
+// Requirement is the role a request needs in a subsystem.
+type Requirement struct {
+	Subsystem string
+	Role      Role
+}
+
+// publicPaths are served without credentials so Prometheus and client
+// generators need none.
+var publicPaths = map[string]bool{"/metrics": true, "/openapi.json": true}
+
+// reviews are the writes of change request reviews and release rollouts.
+var reviews = regexp.MustCompile(`^/(bounce|throughput)_rule_(change_requests/[0-9]+/(approve|reject)|releases/?|releases/rollout(/rollback)?)$`)
+
+// RequirementFor returns the role a route needs, or false for public routes.
+// Reads need a viewer, writes an editor, reviews and releases an approver,
+// and webhook changes and API keys an admin. Routes shared by both
+// subsystems need the role in every subsystem, though Authenticator.Events
+// narrows /events to the subsystem a server streams.
+func RequirementFor(method string, path string) (Requirement, bool) {
+	if publicPaths[path] {
+		return Requirement{}, false
+	}
+
+	subsystem := AllSubsystems
+	switch {
+	case strings.HasPrefix(path, "/bounce_rule"):
+		subsystem = Bounce
+	case strings.HasPrefix(path, "/throughput_rule"):
+		subsystem = Throughput
+	}
+
+	switch {
+	case strings.HasPrefix(path, "/api_keys"):
+		return Requirement{Subsystem: subsystem, Role: Admin}, true
+	case method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions:
+		return Requirement{Subsystem: subsystem, Role: Viewer}, true
+	case strings.HasPrefix(path, "/webhooks"):
+		return Requirement{Subsystem: subsystem, Role: Admin}, true
+	case reviews.MatchString(path):
+		return Requirement{Subsystem: subsystem, Role: Approver}, true
+	default:
+		return Requirement{Subsystem: subsystem, Role: Editor}, true
+	}
+}
+
+func (req Requirement) String() string {
+	if req.Subsystem == AllSubsystems {
+		return fmt.Sprintf("the %s role in every subsystem", req.Role)
+	}
+	return fmt.Sprintf("the %s role for %s rules", req.Role, req.Subsystem)
+}
+
+// Config enables authentication. Without a JWKS file only API keys and
+// client certificates are accepted.
+type Config struct {
+	Enabled bool
+	// JWKSFile holds the public keys JWTs are verified with.
+	JWKSFile string
+	// Issuer and Audience, when set, must match the iss and aud claims.
+	Issuer   string
+	Audience string
+	// ClientCertificates grants roles to mutual TLS clients by common name.
+	ClientCertificates []ClientCertificate
+}
+
+// Authenticator checks the credentials of every request.
+type Authenticator struct {
+	DB *sql.DB
+	// JWKS verifies JWTs; nil rejects them.
+	JWKS     *JWKS
+	Issuer   string
+	Audience string
+	// Certificates holds the grants of client certificates by common name.
+	Certificates map[string][]Grant
+	// Events is the subsystem whose changes /events streams, so reading it
+	// needs the viewer role there; it defaults to AllSubsystems.
+	Events string
+}
+
+// New creates an authenticator for the config, loading its JWKS file.
+func New(db *sql.DB, config Config) (*Authenticator, error) {
+	a := &Authenticator{DB: db, Issuer: config.Issuer, Audience: config.Audience, Certificates: map[string][]Grant{}}
+	for _, certificate := range config.ClientCertificates {
+		a.Certificates[certificate.CommonName] = certificate.Grants
+	}
+	if config.JWKSFile != "" {
+		jwks, err := LoadJWKS(config.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		a.JWKS = jwks
+	}
+	return a, nil
+}
+
+// errUnauthenticated is returned for missing, unknown, revoked and expired
+// credentials alike, so clients cannot probe which keys exist.
+var errUnauthenticated = errors.New("A valid API key or bearer token is required")
+
+// credentials reads an API key or JWT from the Authorization or X-API-Key header.
+func credentials(authorization string, apiKey string) string {
+	if apiKey != "" {
+		return apiKey
+	}
+	if len(authorization) > 7 && strings.EqualFold(authorization[:7], "Bearer ") {
+		return strings.TrimSpace(authorization[7:])
+	}
+	return ""
+}
+
+// Authenticate identifies the client holding an API key or JWT.
+func (a *Authenticator) Authenticate(ctx context.Context, token string) (Identity, error) {
+	switch {
+	case token == "":
+		return Identity{}, errUnauthenticated
+	case strings.HasPrefix(token, KeyPrefix):
+		return keyIdentity(ctx, a.DB, token)
+	case a.JWKS != nil:
+		claims, err := a.JWKS.Verify(token, a.Issuer, a.Audience)
+		if err != nil {
+			log.Printf("Rejected JWT: %v", err)
+			return Identity{}, errUnauthenticated
+		}
+		return claims.identity(), nil
+	default:
+		return Identity{}, errUnauthenticated
+	}
+}
+
+// Middleware authenticates every request to a non-public route by its
+// client certificate or its credentials, puts the identity in the request
+// context and answers 401 or 403 when the client
+// lacks the role the route needs. Requests made with an API key are
+// recorded in its usage audit, including those it was denied. It must run
+// after the version prefix is stripped and before request validation.
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requirement, ok := RequirementFor(r.Method, r.URL.Path)
+		if ok && r.URL.Path == "/events" && a.Events != "" {
+			requirement.Subsystem = a.Events
+		}
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, ok := a.certificateIdentity(r.TLS)
+		var err error
+		if !ok {
+			identity, err = a.Authenticate(r.Context(), credentials(r.Header.Get("Authorization"), r.Header.Get("X-API-Key")))
+		}
+		switch {
+		case err == errUnauthenticated:
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gobrm"`)
+			problem.Respond(w, r, http.StatusUnauthorized, err.Error())
+			return
+		case err != nil:
+			problem.Respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		r = r.WithContext(WithIdentity(r.Context(), identity))
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		if identity.KeyID != 0 {
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				recordUsage(a.DB, Usage{
+					KeyID:      identity.KeyID,
+					Method:     r.Method,
+					Path:       apiversion.Path(r),
+					Status:     status,
+					RequestID:  middleware.GetReqID(r.Context()),
+					RemoteAddr: r.RemoteAddr,
+				})
+			}()
+		}
+
+		if !identity.Can(requirement.Subsystem, requirement.Role) {
+			problem.Respond(ww, r, http.StatusForbidden, fmt.Sprintf("%s needs %s", identity.Subject, requirement))
+			return
+		}
+		next.ServeHTTP(ww, r)
+	})
+}
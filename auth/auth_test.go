@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gobrm/apiversion"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestParseGrant(t *testing.T) {
+	log.Print("Testing grants are read with and without a subsystem")
+	grant, err := ParseGrant("bounce:editor")
+	assert.NoError(t, err)
+	assert.Equal(t, Grant{Subsystem: Bounce, Role: Editor}, grant)
+
+	grant, err = ParseGrant("admin")
+	assert.NoError(t, err)
+	assert.Equal(t, Grant{Subsystem: AllSubsystems, Role: Admin}, grant)
+	assert.Equal(t, "*:admin", grant.String())
+
+	_, err = ParseGrant("mail:viewer")
+	assert.Error(t, err)
+	_, err = ParseGrant("throughput:owner")
+	assert.Error(t, err)
+}
+
+func TestIdentityCan(t *testing.T) {
+	log.Print("Testing roles include lower roles and are scoped to their subsystem")
+	identity := Identity{Grants: []Grant{{Subsystem: Bounce, Role: Approver}, {Subsystem: AllSubsystems, Role: Viewer}}}
+
+	assert.True(t, identity.Can(Bounce, Editor))
+	assert.True(t, identity.Can(Bounce, Approver))
+	assert.False(t, identity.Can(Bounce, Admin))
+	assert.True(t, identity.Can(Throughput, Viewer))
+	assert.False(t, identity.Can(Throughput, Editor))
+	assert.True(t, identity.Can(AllSubsystems, Viewer))
+	assert.False(t, identity.Can(AllSubsystems, Editor), "a bounce grant should not cover shared routes")
+}
+
+func TestRequirementFor(t *testing.T) {
+	log.Print("Testing routes need the role matching what they do")
+	for _, tc := range []struct {
+		method   string
+		path     string
+		expected Requirement
+	}{
+		{"GET", "/bounce_rules", Requirement{Bounce, Viewer}},
+		{"DELETE", "/bounce_rules/1", Requirement{Bounce, Editor}},
+		{"POST", "/throughput_rule_change_requests", Requirement{Throughput, Editor}},
+		{"POST", "/throughput_rule_change_requests/4/approve", Requirement{Throughput, Approver}},
+		{"POST", "/bounce_rule_change_requests/4/apply", Requirement{Bounce, Editor}},
+		{"POST", "/bounce_rule_releases", Requirement{Bounce, Approver}},
+		{"PUT", "/bounce_rule_releases/rollout", Requirement{Bounce, Approver}},
+		{"GET", "/webhooks", Requirement{AllSubsystems, Viewer}},
+		{"PUT", "/webhooks/2", Requirement{AllSubsystems, Admin}},
+		{"POST", "/batch", Requirement{AllSubsystems, Editor}},
+		{"GET", "/api_keys/3/usage", Requirement{AllSubsystems, Admin}},
+	} {
+		requirement, ok := RequirementFor(tc.method, tc.path)
+		assert.True(t, ok, tc.path)
+		assert.Equal(t, tc.expected, requirement, tc.method+" "+tc.path)
+	}
+
+	_, ok := RequirementFor("GET", "/metrics")
+	assert.False(t, ok, "metrics should be public")
+}
+
+var keyColumnNames = []string{"id", "name", "key_prefix", "grants", "created_by", "created_at", "expires_at", "revoked_at", "last_used_at", "key_hash"}
+
+func serve(a *Authenticator, method string, path string, header string, value string) *httptest.ResponseRecorder {
+	handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity, _ := FromContext(r.Context())
+		w.Write([]byte(identity.Subject))
+	}))
+	req := httptest.NewRequest(method, path, nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestMiddlewareAuthenticatesAPIKeys(t *testing.T) {
+	log.Print("Testing API keys are checked against their hash and their use is audited")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	key, prefix, err := GenerateKey()
+	assert.NoError(t, err)
+	now := time.Now()
+	expectKey := func() {
+		mock.ExpectQuery("SELECT .* FROM api_key WHERE key_prefix = \\?").
+			WithArgs(prefix).
+			WillReturnRows(sqlmock.NewRows(keyColumnNames).
+				AddRow(1, "deployer", prefix, "bounce:editor", "", now, nil, nil, nil, hashKey(key)))
+	}
+
+	a := &Authenticator{DB: db}
+
+	expectKey()
+	mock.ExpectExec("INSERT INTO api_key_usage").
+		WithArgs(1, "DELETE", "/bounce_rules/7", 200, "", "192.0.2.1:1234").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE api_key SET last_used_at").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	rr := serve(a, "DELETE", "/bounce_rules/7", "X-API-Key", key)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "api-key:deployer", rr.Body.String())
+
+	expectKey()
+	mock.ExpectExec("INSERT INTO api_key_usage").
+		WithArgs(1, "DELETE", "/throughput_rules/7", 403, "", "192.0.2.1:1234").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("UPDATE api_key SET last_used_at").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	rr = serve(a, "DELETE", "/throughput_rules/7", "Authorization", "Bearer "+key)
+	assert.Equal(t, http.StatusForbidden, rr.Code, "should not grant roles in another subsystem")
+	assert.Contains(t, rr.Body.String(), "needs the editor role for throughput rules")
+
+	wrongKey := key[:len(key)-1] + "0"
+	if wrongKey == key {
+		wrongKey = key[:len(key)-1] + "1"
+	}
+	expectKey()
+	rr = serve(a, "GET", "/bounce_rules", "X-API-Key", wrongKey)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code, "should reject a key whose hash does not match")
+	assert.Equal(t, `Bearer realm="gobrm"`, rr.Header().Get("WWW-Authenticate"))
+
+	rr = serve(a, "GET", "/bounce_rules", "", "")
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	rr = serve(a, "GET", "/metrics", "", "")
+	assert.Equal(t, http.StatusOK, rr.Code, "public paths need no credentials")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestKeyActive(t *testing.T) {
+	log.Print("Testing revoked and expired keys are not active")
+	now := time.Now()
+	assert.True(t, Key{}.Active(now))
+	assert.False(t, Key{RevokedAt: null.TimeFrom(now.Add(-time.Hour))}.Active(now))
+	assert.False(t, Key{ExpiresAt: null.TimeFrom(now.Add(-time.Second))}.Active(now))
+	assert.True(t, Key{ExpiresAt: null.TimeFrom(now.Add(time.Hour))}.Active(now))
+}
+
+func encode(v interface{}) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func sign(t *testing.T, alg string, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	signingInput := encode(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"}) + "." + encode(claims)
+	digest := crypto.SHA256.New()
+	digest.Write([]byte(signingInput))
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest.Sum(nil))
+		assert.NoError(t, err)
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest.Sum(nil))
+		assert.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func bigInt(n *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(n.Bytes())
+}
+
+func TestJWKSVerify(t *testing.T) {
+	log.Print("Testing JWTs are verified against the JWKS and their claims checked")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	jwks, err := ParseJWKS([]byte(fmt.Sprintf(`{"keys": [
+		{"kty": "RSA", "kid": "rsa", "use": "sig", "n": %q, "e": %q},
+		{"kty": "EC", "kid": "ec", "crv": "P-256", "x": %q, "y": %q}
+	]}`, bigInt(rsaKey.N), bigInt(big.NewInt(int64(rsaKey.E))), bigInt(ecKey.X), bigInt(ecKey.Y))))
+	assert.NoError(t, err)
+
+	claims := map[string]interface{}{
+		"sub":   "alice@example.com",
+		"iss":   "https://id.example.com",
+		"aud":   []string{"gobrm", "other"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"throughput:approver", "mail:owner"},
+	}
+
+	verified, err := jwks.Verify(sign(t, "RS256", "rsa", rsaKey, claims), "https://id.example.com", "gobrm")
+	assert.NoError(t, err)
+	identity := verified.identity()
+	assert.Equal(t, "alice@example.com", identity.Subject)
+	assert.Equal(t, []Grant{{Subsystem: Throughput, Role: Approver}}, identity.Grants, "should ignore roles of other applications")
+
+	_, err = jwks.Verify(sign(t, "ES256", "ec", ecKey, claims), "", "")
+	assert.NoError(t, err)
+
+	_, err = jwks.Verify(sign(t, "RS256", "ec", rsaKey, claims), "", "")
+	assert.Error(t, err, "should reject a signature by another key")
+	_, err = jwks.Verify(sign(t, "RS256", "rsa", rsaKey, claims), "https://evil.example.com", "")
+	assert.Error(t, err, "should check the issuer")
+	_, err = jwks.Verify(sign(t, "RS256", "rsa", rsaKey, claims), "", "billing")
+	assert.Error(t, err, "should check the audience")
+
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+	_, err = jwks.Verify(sign(t, "RS256", "rsa", rsaKey, claims), "", "")
+	assert.EqualError(t, err, "token has expired")
+
+	unsigned := encode(map[string]string{"alg": "none"}) + "." + encode(claims) + "."
+	_, err = jwks.Verify(unsigned, "", "")
+	assert.Error(t, err, "should reject unsigned tokens")
+}
+
+func TestCreateKeyValidatesRequest(t *testing.T) {
+	log.Print("Testing API keys need a name and valid grants")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	api := &API{DB: db}
+	req := httptest.NewRequest("POST", "/v2/api_keys", strings.NewReader(`{"name": " ", "grants": ["bounce:owner"]}`))
+	req = req.WithContext(apiversion.NewContext(req.Context(), apiversion.V2))
+	rr := httptest.NewRecorder()
+	api.CreateKey(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"field":"name"`)
+	assert.Contains(t, rr.Body.String(), `"field":"grants[0]"`)
+}
+
+func TestMiddlewareAuthenticatesClientCertificates(t *testing.T) {
+	log.Print("Testing verified client certificates are mapped to their grants by common name")
+	certificate, err := ParseClientCertificate("deployer=bounce:editor+throughput:viewer")
+	assert.NoError(t, err)
+	assert.Equal(t, ClientCertificate{CommonName: "deployer", Grants: []Grant{{Bounce, Editor}, {Throughput, Viewer}}}, certificate)
+	_, err = ParseClientCertificate("deployer=bounce:owner")
+	assert.Error(t, err)
+
+	a, err := New(nil, Config{ClientCertificates: []ClientCertificate{certificate}})
+	assert.NoError(t, err)
+	serveTLS := func(method string, path string, commonName string, verified bool) *httptest.ResponseRecorder {
+		handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, _ := FromContext(r.Context())
+			w.Write([]byte(identity.Subject))
+		}))
+		req := httptest.NewRequest(method, path, nil)
+		peer := &x509.Certificate{Subject: pkix.Name{CommonName: commonName}}
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{peer}}
+		if verified {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{peer}}
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serveTLS("POST", "/bounce_rules", "deployer", true)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "cert:deployer", rr.Body.String())
+	assert.Equal(t, http.StatusForbidden, serveTLS("POST", "/throughput_rules", "deployer", true).Code)
+	assert.Equal(t, http.StatusUnauthorized, serveTLS("GET", "/bounce_rules", "deployer", false).Code, "should only trust verified certificates")
+	assert.Equal(t, http.StatusUnauthorized, serveTLS("GET", "/bounce_rules", "stranger", true).Code, "should need credentials for unknown common names")
+}
+
+func TestMiddlewareScopesEventsToStreamedSubsystem(t *testing.T) {
+	log.Print("Testing /events needs the viewer role in the subsystem the server streams")
+	certificate, err := ParseClientCertificate("mta=bounce:viewer")
+	assert.NoError(t, err)
+	a, err := New(nil, Config{ClientCertificates: []ClientCertificate{certificate}})
+	assert.NoError(t, err)
+	serveEvents := func() int {
+		handler := a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest("GET", "/events", nil)
+		peer := &x509.Certificate{Subject: pkix.Name{CommonName: "mta"}}
+		req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{peer}, VerifiedChains: [][]*x509.Certificate{{peer}}}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, serveEvents(), "should need every subsystem when streaming both")
+	a.Events = Bounce
+	assert.Equal(t, http.StatusOK, serveEvents())
+	a.Events = Throughput
+	assert.Equal(t, http.StatusForbidden, serveEvents())
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	grpccredentials "google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientCertificate grants roles to the clients presenting a certificate
+// with a common name. Only certificates verified against the client CA of
+// a mutual TLS server are trusted.
+type ClientCertificate struct {
+	CommonName string
+	Grants     []Grant
+}
+
+// ParseClientCertificate reads a common name and its grants, e.g.
+// "deployer=bounce:editor+throughput:viewer".
+func ParseClientCertificate(value string) (ClientCertificate, error) {
+	i := strings.LastIndex(value, "=")
+	if i <= 0 {
+		return ClientCertificate{}, fmt.Errorf("client certificate %q is not of the form <common name>=<grant>[+<grant>]", value)
+	}
+
+	certificate := ClientCertificate{CommonName: strings.TrimSpace(value[:i])}
+	for _, name := range strings.Split(value[i+1:], "+") {
+		grant, err := ParseGrant(strings.TrimSpace(name))
+		if err != nil {
+			return ClientCertificate{}, fmt.Errorf("client certificate %q: %w", certificate.CommonName, err)
+		}
+		certificate.Grants = append(certificate.Grants, grant)
+	}
+	return certificate, nil
+}
+
+// UnmarshalText lets config files and the environment set client certificates.
+func (c *ClientCertificate) UnmarshalText(text []byte) error {
+	certificate, err := ParseClientCertificate(string(text))
+	if err != nil {
+		return err
+	}
+	*c = certificate
+	return nil
+}
+
+// certificateIdentity identifies a client by the verified certificate it
+// presented, if its common name has grants.
+func (a *Authenticator) certificateIdentity(state *tls.ConnectionState) (Identity, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return Identity{}, false
+	}
+	commonName := state.VerifiedChains[0][0].Subject.CommonName
+	grants, ok := a.Certificates[commonName]
+	if !ok {
+		return Identity{}, false
+	}
+	return Identity{Subject: "cert:" + commonName, Grants: grants}, true
+}
+
+// peerState returns the TLS connection state of a gRPC call, or nil for
+// plaintext calls.
+func peerState(ctx context.Context) *tls.ConnectionState {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(grpccredentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	return &info.State
+}
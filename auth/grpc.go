@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// readMethods are the prefixes of the gRPC methods a viewer may call.
+var readMethods = []string{"Get", "List", "Classify", "Watch"}
+
+// grpcRequirement returns the role a gRPC method needs, or false for the
+// health and reflection services.
+func grpcRequirement(fullMethod string) (Requirement, bool) {
+	service, method := fullMethod, ""
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		service, method = fullMethod[:i], fullMethod[i+1:]
+	}
+
+	var subsystem string
+	switch {
+	case strings.HasSuffix(service, ".BounceRules"):
+		subsystem = Bounce
+	case strings.HasSuffix(service, ".ThroughputRules"):
+		subsystem = Throughput
+	default:
+		return Requirement{}, false
+	}
+
+	for _, prefix := range readMethods {
+		if strings.HasPrefix(method, prefix) {
+			return Requirement{Subsystem: subsystem, Role: Viewer}, true
+		}
+	}
+	return Requirement{Subsystem: subsystem, Role: Editor}, true
+}
+
+// authorize authenticates a gRPC call from its client certificate or its
+// authorization or x-api-key metadata and checks it may call the method. The returned function
+// records the call in the usage audit of its API key.
+func (a *Authenticator) authorize(ctx context.Context, fullMethod string) (context.Context, func(error), error) {
+	requirement, ok := grpcRequirement(fullMethod)
+	if !ok {
+		return ctx, func(error) {}, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	first := func(key string) string {
+		if values := md.Get(key); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	identity, ok := a.certificateIdentity(peerState(ctx))
+	var err error
+	if !ok {
+		identity, err = a.Authenticate(ctx, credentials(first("authorization"), first("x-api-key")))
+	}
+	switch {
+	case err == errUnauthenticated:
+		return ctx, nil, status.Error(codes.Unauthenticated, err.Error())
+	case err != nil:
+		return ctx, nil, status.Error(codes.Internal, "failed to check credentials")
+	}
+
+	record := func(err error) {
+		if identity.KeyID == 0 {
+			return
+		}
+		usage := Usage{KeyID: identity.KeyID, Method: "GRPC", Path: fullMethod, Status: int(status.Code(err))}
+		if p, ok := peer.FromContext(ctx); ok {
+			usage.RemoteAddr = p.Addr.String()
+		}
+		recordUsage(a.DB, usage)
+	}
+
+	if !identity.Can(requirement.Subsystem, requirement.Role) {
+		err := status.Errorf(codes.PermissionDenied, "%s needs %s", identity.Subject, requirement)
+		record(err)
+		return ctx, nil, err
+	}
+	return WithIdentity(ctx, identity), record, nil
+}
+
+// UnaryInterceptor authenticates and authorizes unary gRPC calls like
+// Middleware does HTTP requests.
+func (a *Authenticator) UnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, record, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := handler(ctx, req)
+	record(err)
+	return resp, err
+}
+
+// authorizedStream carries the caller's identity to the stream handler.
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor authenticates and authorizes streaming gRPC calls such
+// as the Watch streams.
+func (a *Authenticator) StreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, record, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	err = handler(srv, authorizedStream{ServerStream: ss, ctx: ctx})
+	record(err)
+	return err
+}
+
+// ServerOptions installs the interceptors on a gRPC server.
+func (a *Authenticator) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(a.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(a.StreamInterceptor),
+	}
+}
@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew is how far the exp and nbf claims may be off from our clock.
+const clockSkew = time.Minute
+
+// JWKS holds the public keys JWTs are verified with, by key ID. Only RSA
+// (RS256, RS384, RS512) and EC (ES256, ES384, ES512) keys are supported.
+type JWKS struct {
+	keys map[string]crypto.PublicKey
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS reads a JWKS file, e.g. one exported from the identity provider.
+func LoadJWKS(path string) (*JWKS, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWKS file: %w", err)
+	}
+	jwks, err := ParseJWKS(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return jwks, nil
+}
+
+// ParseJWKS reads a JSON Web Key Set. Keys only meant for encryption are skipped.
+func ParseJWKS(data []byte) (*JWKS, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("JWKS is not valid JSON: %w", err)
+	}
+
+	jwks := &JWKS{keys: map[string]crypto.PublicKey{}}
+	for i, key := range set.Keys {
+		if key.Use == "enc" {
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %d: %w", i, err)
+		}
+		jwks.keys[key.Kid] = publicKey
+	}
+	if len(jwks.keys) == 0 {
+		return nil, errors.New("JWKS has no signing keys")
+	}
+	return jwks, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("key parameter is not base64url")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+// Claims are the JWT claims the rule managers read. Roles lists grants in
+// the form API keys use, e.g. "bounce:editor" or "admin"; roles of other
+// applications are ignored.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	Roles     []string `json:"roles"`
+}
+
+// audience is a single audience or a list of them.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (c Claims) identity() Identity {
+	identity := Identity{Subject: c.Subject, Grants: []Grant{}}
+	for _, role := range c.Roles {
+		if grant, err := ParseGrant(role); err == nil {
+			identity.Grants = append(identity.Grants, grant)
+		}
+	}
+	return identity
+}
+
+var hashes = map[string]crypto.Hash{
+	"RS256": crypto.SHA256, "RS384": crypto.SHA384, "RS512": crypto.SHA512,
+	"ES256": crypto.SHA256, "ES384": crypto.SHA384, "ES512": crypto.SHA512,
+}
+
+// Verify checks a compact JWT's signature and its exp and nbf claims, and
+// its iss and aud claims when issuer and aud are set.
+func (s *JWKS) Verify(token string, issuer string, aud string) (Claims, error) {
+	var claims Claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("token is not a compact JWS")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims, fmt.Errorf("header: %w", err)
+	}
+	hash, ok := hashes[header.Alg]
+	if !ok {
+		return claims, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errors.New("signature is not base64url")
+	}
+
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	digest := h.Sum(nil)
+
+	verified := false
+	for kid, key := range s.keys {
+		if header.Kid != "" && kid != header.Kid {
+			continue
+		}
+		if verifySignature(key, header.Alg, hash, digest, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return claims, fmt.Errorf("signature does not match key %q", header.Kid)
+	}
+
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return claims, fmt.Errorf("claims: %w", err)
+	}
+	now := time.Now()
+	switch {
+	case claims.ExpiresAt == 0:
+		return claims, errors.New("token has no exp claim")
+	case now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)):
+		return claims, errors.New("token has expired")
+	case claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)):
+		return claims, errors.New("token is not valid yet")
+	case issuer != "" && claims.Issuer != issuer:
+		return claims, fmt.Errorf("issuer %q is not trusted", claims.Issuer)
+	case aud != "" && !contains(claims.Audience, aud):
+		return claims, fmt.Errorf("token is not meant for audience %q", aud)
+	case claims.Subject == "":
+		return claims, errors.New("token has no sub claim")
+	}
+	return claims, nil
+}
+
+var curveAlgorithms = map[int]string{256: "ES256", 384: "ES384", 521: "ES512"}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return errors.New("not base64url")
+	}
+	return json.Unmarshal(data, v)
+}
+
+func verifySignature(key crypto.PublicKey, alg string, hash crypto.Hash, digest []byte, signature []byte) bool {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		return strings.HasPrefix(alg, "RS") && rsa.VerifyPKCS1v15(key, hash, digest, signature) == nil
+	case *ecdsa.PublicKey:
+		// Each ES algorithm has its own curve. Signatures are r and s
+		// concatenated, each the size of the curve.
+		bits := key.Curve.Params().BitSize
+		size := (bits + 7) / 8
+		if alg != curveAlgorithms[bits] || len(signature) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		return ecdsa.Verify(key, digest, r, s)
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// CREATE TABLE api_key (
+//   id INT NOT NULL AUTO_INCREMENT,
+//   name VARCHAR(255) NOT NULL,
+//   key_prefix CHAR(8) NOT NULL,
+//   key_hash CHAR(64) NOT NULL,
+//   grants VARCHAR(1024) NOT NULL,
+//   created_by VARCHAR(255) NOT NULL DEFAULT '',
+//   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   expires_at DATETIME NULL,
+//   revoked_at DATETIME NULL,
+//   last_used_at DATETIME NULL,
+//   PRIMARY KEY (id),
+//   UNIQUE KEY (key_prefix)
+// );
+//
+// CREATE TABLE api_key_usage (
+//   id BIGINT NOT NULL AUTO_INCREMENT,
+//   api_key_id INT NOT NULL,
+//   method VARCHAR(16) NOT NULL,
+//   path VARCHAR(2048) NOT NULL,
+//   status INT NOT NULL,
+//   request_id VARCHAR(255) NOT NULL DEFAULT '',
+//   remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+//   used_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   PRIMARY KEY (id),
+//   INDEX (api_key_id, used_at),
+//   FOREIGN KEY (api_key_id) REFERENCES api_key(id) ON DELETE CASCADE
+// );
+
+// KeyPrefix starts every API key, e.g. gobrm_1a2b3c4d_<secret>. The eight
+// hex digits after it find the key; only a SHA-256 hash of the whole key is
+// stored.
+const KeyPrefix = "gobrm_"
+
+// Key is an API key. The key itself is only returned when it is created.
+type Key struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	Grants     []Grant   `json:"grants"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  null.Time `json:"expires_at"`
+	RevokedAt  null.Time `json:"revoked_at"`
+	LastUsedAt null.Time `json:"last_used_at"`
+	Key        string    `json:"key,omitempty"`
+}
+
+// Active reports whether the key may still be used.
+func (k Key) Active(now time.Time) bool {
+	return !k.RevokedAt.Valid && (!k.ExpiresAt.Valid || now.Before(k.ExpiresAt.Time))
+}
+
+// Usage is one request made with an API key. gRPC calls are recorded with
+// the method GRPC, the full method name as path and the gRPC status code.
+type Usage struct {
+	ID         int64     `json:"id"`
+	KeyID      int       `json:"api_key_id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	RequestID  string    `json:"request_id"`
+	RemoteAddr string    `json:"remote_addr"`
+	UsedAt     time.Time `json:"used_at"`
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateKey returns a new random key and the prefix it is found by.
+func GenerateKey() (string, string, error) {
+	b := make([]byte, 36)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("generating API key: %w", err)
+	}
+	prefix := hex.EncodeToString(b[:4])
+	return KeyPrefix + prefix + "_" + hex.EncodeToString(b[4:]), prefix, nil
+}
+
+// keyLookupPrefix returns the prefix of a well-formed key.
+func keyLookupPrefix(key string) (string, bool) {
+	parts := strings.Split(strings.TrimPrefix(key, KeyPrefix), "_")
+	if len(parts) != 2 || len(parts[0]) != 8 {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func joinGrants(grants []Grant) string {
+	names := make([]string, len(grants))
+	for i, grant := range grants {
+		names[i] = grant.String()
+	}
+	return strings.Join(names, ",")
+}
+
+func splitGrants(value string) ([]Grant, error) {
+	grants := []Grant{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		grant, err := ParseGrant(name)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	return grants, nil
+}
+
+const keyColumns = "id, name, key_prefix, grants, created_by, created_at, expires_at, revoked_at, last_used_at"
+
+func scanKey(row interface{ Scan(...interface{}) error }, hash *string) (Key, error) {
+	var k Key
+	var grants string
+	dest := []interface{}{&k.ID, &k.Name, &k.Prefix, &grants, &k.CreatedBy, &k.CreatedAt, &k.ExpiresAt, &k.RevokedAt, &k.LastUsedAt}
+	if hash != nil {
+		dest = append(dest, hash)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return k, err
+	}
+	var err error
+	k.Grants, err = splitGrants(grants)
+	return k, err
+}
+
+// keyIdentity finds the active key matching a presented key.
+func keyIdentity(ctx context.Context, db *sql.DB, presented string) (Identity, error) {
+	prefix, ok := keyLookupPrefix(presented)
+	if !ok {
+		return Identity{}, errUnauthenticated
+	}
+
+	var hash string
+	row := db.QueryRowContext(ctx, "SELECT "+keyColumns+", key_hash FROM api_key WHERE key_prefix = ?", prefix)
+	key, err := scanKey(row, &hash)
+	switch {
+	case err == sql.ErrNoRows:
+		return Identity{}, errUnauthenticated
+	case err != nil:
+		return Identity{}, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(hashKey(presented))) != 1 || !key.Active(time.Now()) {
+		return Identity{}, errUnauthenticated
+	}
+	return Identity{Subject: "api-key:" + key.Name, KeyID: key.ID, Grants: key.Grants}, nil
+}
+
+func getKeys(ctx context.Context, db *sql.DB) ([]Key, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+keyColumns+" FROM api_key ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []Key{}
+	for rows.Next() {
+		k, err := scanKey(rows, nil)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func getKey(ctx context.Context, db *sql.DB, id int) (Key, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+keyColumns+" FROM api_key WHERE id = ?", id)
+	return scanKey(row, nil)
+}
+
+// CreateKey stores a new key with the given name and grants and returns it,
+// including the key itself, which cannot be read again.
+func CreateKey(ctx context.Context, db *sql.DB, name string, grants []Grant, createdBy string, expiresAt null.Time) (Key, error) {
+	key, prefix, err := GenerateKey()
+	if err != nil {
+		return Key{}, err
+	}
+
+	result, err := db.ExecContext(ctx,
+		"INSERT INTO api_key (name, key_prefix, key_hash, grants, created_by, expires_at) VALUES (?, ?, ?, ?, ?, ?)",
+		name, prefix, hashKey(key), joinGrants(grants), createdBy, expiresAt)
+	if err != nil {
+		return Key{}, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return Key{}, err
+	}
+
+	created, err := getKey(ctx, db, int(id))
+	if err != nil {
+		return Key{}, err
+	}
+	created.Key = key
+	return created, nil
+}
+
+// revokeKey stops a key from being accepted. Keys are kept, not deleted, so
+// their usage stays auditable.
+func revokeKey(ctx context.Context, db *sql.DB, id int) error {
+	result, err := db.ExecContext(ctx, "UPDATE api_key SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		// An already revoked key is matched but not changed
+		if _, err := getKey(ctx, db, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getUsage(ctx context.Context, db *sql.DB, keyID int, limit int) ([]Usage, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT id, api_key_id, method, path, status, request_id, remote_addr, used_at FROM api_key_usage WHERE api_key_id = ? ORDER BY id DESC LIMIT ?",
+		keyID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []Usage{}
+	for rows.Next() {
+		var u Usage
+		if err := rows.Scan(&u.ID, &u.KeyID, &u.Method, &u.Path, &u.Status, &u.RequestID, &u.RemoteAddr, &u.UsedAt); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	return usage, rows.Err()
+}
+
+// recordUsage adds a request to the audit of its key. It runs after the
+// response, so failures are only logged.
+func recordUsage(db *sql.DB, u Usage) {
+	// The request context may already be canceled, e.g. after an event stream
+	ctx := context.Background()
+	_, err := db.ExecContext(ctx,
+		"INSERT INTO api_key_usage (api_key_id, method, path, status, request_id, remote_addr) VALUES (?, ?, ?, ?, ?, ?)",
+		u.KeyID, u.Method, u.Path, u.Status, u.RequestID, u.RemoteAddr)
+	if err == nil {
+		_, err = db.ExecContext(ctx, "UPDATE api_key SET last_used_at = NOW() WHERE id = ?", u.KeyID)
+	}
+	if err != nil {
+		log.Printf("Failed to record usage of API key %d: %v", u.KeyID, err)
+	}
+}
@@ -0,0 +1,120 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/problem"
+)
+
+// API serves POST /batch for the rule types of its appliers, keyed by the
+// operation type, e.g. "bounce_rule".
+type API struct {
+	DB       *sql.DB
+	Appliers map[string]Applier
+	// RequireChangeRequests disables batches like every other direct rule write.
+	RequireChangeRequests bool
+}
+
+type request struct {
+	Operations []Operation `json:"operations"`
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+// RuleError makes the field errors of a rule that failed validation name the
+// rule field of the operation, e.g. rule.priority.
+func RuleError(err error) error {
+	var validationErr changerequest.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	fields := make([]problem.FieldError, len(validationErr.Fields))
+	for i, field := range validationErr.Fields {
+		fields[i] = problem.FieldError{Field: "rule." + field.Field, Message: field.Message}
+	}
+	validationErr.Fields = fields
+	return validationErr
+}
+
+// respondWithBatchError writes a problem for a failed batch. When an operation
+// failed it is named by failed_operation, and its field errors are prefixed
+// with its position, e.g. operations[2].rule.priority.
+func respondWithBatchError(w http.ResponseWriter, r *http.Request, err error) {
+	var stepErr StepError
+	if !errors.As(err, &stepErr) {
+		var validationErr changerequest.ValidationError
+		if errors.As(err, &validationErr) {
+			problem.Invalid(w, r, validationErr)
+			return
+		}
+		problem.Respond(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var p problem.Problem
+	var validationErr changerequest.ValidationError
+	var conflictErr concurrency.ConflictError
+	var duplicateErr problem.DuplicateError
+	switch {
+	case errors.As(stepErr.Err, &validationErr):
+		fields := make([]problem.FieldError, len(validationErr.Fields))
+		for i, field := range validationErr.Fields {
+			fields[i] = problem.FieldError{Field: fmt.Sprintf("operations[%d].%s", stepErr.Index, field.Field), Message: field.Message}
+		}
+		p = problem.Validation(stepErr.Error(), fields)
+	case errors.Is(stepErr.Err, sql.ErrNoRows):
+		p = problem.New(http.StatusNotFound, fmt.Sprintf("Operation %d (%s) failed: the rule was not found", stepErr.Index, stepErr.Operation))
+	case errors.As(stepErr.Err, &conflictErr):
+		p = concurrency.ConflictProblem(conflictErr, stepErr.Error())
+	case errors.As(stepErr.Err, &duplicateErr):
+		p = problem.Duplicate(duplicateErr, stepErr.Error())
+		p.Errors[0].Field = fmt.Sprintf("operations[%d].rule.%s", stepErr.Index, duplicateErr.Field)
+	default:
+		p = problem.New(http.StatusInternalServerError, stepErr.Error())
+	}
+
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions["failed_operation"] = stepErr.Index
+	problem.Write(w, r, p)
+}
+
+// Execute applies an ordered list of rule operations atomically and answers
+// with the result of each, or with a problem naming the operation that failed.
+func (api *API) Execute(w http.ResponseWriter, r *http.Request) {
+	if api.RequireChangeRequests {
+		problem.Respond(w, r, http.StatusForbidden, "Direct rule changes are disabled, propose a change request instead")
+		return
+	}
+
+	var req request
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	defer r.Body.Close()
+	if err := decoder.Decode(&req); err != nil {
+		problem.Write(w, r, problem.Validation("Invalid batch request payload", problem.DecodeErrors(err)))
+		return
+	}
+
+	response, err := Run(context.Background(), api.DB, api.Appliers, req.Operations)
+	if err != nil {
+		respondWithBatchError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, response)
+}
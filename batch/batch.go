@@ -0,0 +1,190 @@
+package batch
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/problem"
+
+	"github.com/volatiletech/null/v8"
+)
+
+// Operations a batch can perform on a rule.
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// MaxOperations bounds how many rows a single batch may lock.
+const MaxOperations = 1000
+
+// Operation is one step of a batch, e.g.
+// {"op": "update", "type": "bounce_rule", "id": 3, "if_match_version": 2, "rule": {...}}.
+type Operation struct {
+	Op   string `json:"op"`
+	Type string `json:"type"`
+	ID   int    `json:"id,omitempty"`
+	// IfMatchVersion works like If-Match: the update or delete fails unless
+	// the rule still has this version.
+	IfMatchVersion *int            `json:"if_match_version,omitempty"`
+	Rule           json.RawMessage `json:"rule,omitempty"`
+}
+
+// Precondition is the version the operation requires, if any.
+func (op Operation) Precondition() concurrency.Precondition {
+	if op.IfMatchVersion == nil {
+		return nil
+	}
+	return concurrency.Precondition{*op.IfMatchVersion}
+}
+
+func (op Operation) String() string {
+	if op.Op == OpCreate {
+		return op.Op + " " + op.Type
+	}
+	return fmt.Sprintf("%s %s %d", op.Op, op.Type, op.ID)
+}
+
+// Applier performs batch operations on one rule type. Each rule manager
+// implements it on top of the same functions its direct CRUD uses.
+type Applier interface {
+	// Apply performs the operation, including its *_rule_change audit row,
+	// inside tx. It returns the ID of the rule and the rule as stored, or as
+	// it was before a delete.
+	Apply(ctx context.Context, tx *sql.Tx, op Operation) (int, interface{}, error)
+}
+
+// Result is the outcome of one operation of a committed batch.
+type Result struct {
+	Index int         `json:"index"`
+	Op    string      `json:"op"`
+	Type  string      `json:"type"`
+	ID    int         `json:"id"`
+	Rule  interface{} `json:"rule"`
+}
+
+// Response lists the results of a committed batch in operation order.
+type Response struct {
+	BatchID string   `json:"batch_id"`
+	Results []Result `json:"results"`
+}
+
+// StepError names the operation that failed and rolled back its batch.
+type StepError struct {
+	Index     int
+	Operation Operation
+	Err       error
+}
+
+func (e StepError) Error() string {
+	return fmt.Sprintf("Operation %d (%s) failed: %s", e.Index, e.Operation, e.Err)
+}
+
+func (e StepError) Unwrap() error {
+	return e.Err
+}
+
+type contextKey struct{}
+
+// WithID marks the changes made with the context as part of a batch.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the batch the context's changes belong to, or null outside a batch.
+// Rule managers store it on every audit row they write.
+func ID(ctx context.Context) null.String {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return null.NewString(id, ok)
+}
+
+// newID returns a random version 4 UUID.
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating batch ID: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// invalid reports an offending field of a batch or one of its operations.
+func invalid(field string, message string) changerequest.ValidationError {
+	return changerequest.ValidationError{Message: field + " " + message, Fields: []problem.FieldError{{Field: field, Message: message}}}
+}
+
+// validate checks the shape of every operation before any is applied, so a
+// malformed last step does not cost a transaction.
+func validate(appliers map[string]Applier, operations []Operation) error {
+	if len(operations) == 0 {
+		return invalid("operations", "must not be empty")
+	}
+	if len(operations) > MaxOperations {
+		return invalid("operations", fmt.Sprintf("must not have more than %d entries", MaxOperations))
+	}
+
+	types := make([]string, 0, len(appliers))
+	for ruleType := range appliers {
+		types = append(types, ruleType)
+	}
+	sort.Strings(types)
+
+	for i, op := range operations {
+		var err error
+		switch {
+		case appliers[op.Type] == nil:
+			err = invalid("type", "must be one of "+strings.Join(types, ", "))
+		case op.Op != OpCreate && op.Op != OpUpdate && op.Op != OpDelete:
+			err = invalid("op", "must be one of create, update or delete")
+		case op.Op != OpCreate && op.ID < 1:
+			err = invalid("id", "is required to "+op.Op+" a rule")
+		case op.Op != OpDelete && (len(op.Rule) == 0 || string(op.Rule) == "null"):
+			err = invalid("rule", "is required to "+op.Op+" a rule")
+		}
+		if err != nil {
+			return StepError{Index: i, Operation: op, Err: err}
+		}
+	}
+	return nil
+}
+
+// Run applies the operations in order in one transaction and links their
+// audit rows with a new batch ID. Either every operation is applied or, when
+// one fails, none are and the returned StepError names it.
+func Run(ctx context.Context, db *sql.DB, appliers map[string]Applier, operations []Operation) (Response, error) {
+	if err := validate(appliers, operations); err != nil {
+		return Response{}, err
+	}
+
+	id, err := newID()
+	if err != nil {
+		return Response{}, err
+	}
+	ctx = WithID(ctx, id)
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Response{}, err
+	}
+	defer tx.Rollback()
+
+	response := Response{BatchID: id, Results: make([]Result, 0, len(operations))}
+	for i, op := range operations {
+		ruleID, rule, err := appliers[op.Type].Apply(ctx, tx, op)
+		if err != nil {
+			return Response{}, StepError{Index: i, Operation: op, Err: err}
+		}
+		response.Results = append(response.Results, Result{Index: i, Op: op.Op, Type: op.Type, ID: ruleID, Rule: rule})
+	}
+
+	return response, tx.Commit()
+}
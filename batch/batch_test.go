@@ -0,0 +1,179 @@
+package batch
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gobrm/apiversion"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/problem"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeApplier records the batch IDs it was applied with and fails the given operations.
+type fakeApplier struct {
+	batchIDs []string
+	failures map[int]error
+}
+
+func (f *fakeApplier) Apply(ctx context.Context, tx *sql.Tx, op Operation) (int, interface{}, error) {
+	f.batchIDs = append(f.batchIDs, ID(ctx).String)
+	if err := f.failures[op.ID]; err != nil {
+		return 0, nil, err
+	}
+	id := op.ID
+	if op.Op == OpCreate {
+		id = 99
+	}
+	return id, map[string]interface{}{"id": id}, nil
+}
+
+func TestRunCommitsEveryOperation(t *testing.T) {
+	log.Print("Testing a batch applies its operations in one transaction with one batch ID")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	bounceRules, throughputRules := &fakeApplier{}, &fakeApplier{}
+	response, err := Run(context.Background(), db, map[string]Applier{"bounce_rule": bounceRules, "throughput_rule": throughputRules}, []Operation{
+		{Op: OpCreate, Type: "bounce_rule", Rule: json.RawMessage(`{}`)},
+		{Op: OpUpdate, Type: "throughput_rule", ID: 4, Rule: json.RawMessage(`{}`)},
+		{Op: OpDelete, Type: "bounce_rule", ID: 7},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, response.BatchID, 36)
+	assert.Equal(t, []Result{
+		{Index: 0, Op: OpCreate, Type: "bounce_rule", ID: 99, Rule: map[string]interface{}{"id": 99}},
+		{Index: 1, Op: OpUpdate, Type: "throughput_rule", ID: 4, Rule: map[string]interface{}{"id": 4}},
+		{Index: 2, Op: OpDelete, Type: "bounce_rule", ID: 7, Rule: map[string]interface{}{"id": 7}},
+	}, response.Results)
+	assert.Equal(t, []string{response.BatchID, response.BatchID}, bounceRules.batchIDs, "should link every change to the batch")
+	assert.Equal(t, []string{response.BatchID}, throughputRules.batchIDs)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestRunRollsBackOnFailure(t *testing.T) {
+	log.Print("Testing a failed operation rolls back its batch and is named in the error")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	applier := &fakeApplier{failures: map[int]error{8: sql.ErrNoRows}}
+	_, err = Run(context.Background(), db, map[string]Applier{"bounce_rule": applier}, []Operation{
+		{Op: OpDelete, Type: "bounce_rule", ID: 7},
+		{Op: OpDelete, Type: "bounce_rule", ID: 8},
+		{Op: OpDelete, Type: "bounce_rule", ID: 9},
+	})
+
+	var stepErr StepError
+	assert.True(t, errors.As(err, &stepErr))
+	assert.Equal(t, 1, stepErr.Index)
+	assert.Equal(t, "Operation 1 (delete bounce_rule 8) failed: sql: no rows in result set", err.Error())
+	assert.Len(t, applier.batchIDs, 2, "should stop at the failing operation")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestRunValidatesBeforeStarting(t *testing.T) {
+	log.Print("Testing malformed operations are rejected before the transaction starts")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	appliers := map[string]Applier{"bounce_rule": &fakeApplier{}, "throughput_rule": &fakeApplier{}}
+	for _, operations := range [][]Operation{
+		{},
+		{{Op: OpCreate, Type: "webhook", Rule: json.RawMessage(`{}`)}},
+		{{Op: "upsert", Type: "bounce_rule", Rule: json.RawMessage(`{}`)}},
+		{{Op: OpDelete, Type: "bounce_rule"}},
+		{{Op: OpDelete, Type: "bounce_rule", ID: 1}, {Op: OpUpdate, Type: "bounce_rule", ID: 1}},
+	} {
+		_, err := Run(context.Background(), db, appliers, operations)
+		assert.Errorf(t, err, "should reject %v", operations)
+	}
+
+	_, err = Run(context.Background(), db, appliers, []Operation{{Op: OpCreate, Type: "webhook", Rule: json.RawMessage(`{}`)}})
+	assert.Equal(t, StepError{
+		Index:     0,
+		Operation: Operation{Op: OpCreate, Type: "webhook", Rule: json.RawMessage(`{}`)},
+		Err:       invalid("type", "must be one of bounce_rule, throughput_rule"),
+	}, err)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestExecuteNamesTheFailingOperation(t *testing.T) {
+	log.Print("Testing failed batches answer with a problem naming the operation")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	applier := &fakeApplier{failures: map[int]error{
+		2: RuleError(changerequest.ValidationError{Message: "Missing required fields: priority", Fields: []problem.FieldError{{Field: "priority", Message: "is required"}}}),
+		3: concurrency.ConflictError{CurrentVersion: 5},
+		4: problem.DuplicateError{Field: "mx_domain", Value: "example.com"},
+	}}
+	api := &API{DB: db, Appliers: map[string]Applier{"bounce_rule": applier}}
+
+	execute := func(body string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		req, _ := http.NewRequest("POST", "/v2/batch", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		api.Execute(rr, req.WithContext(apiversion.NewContext(req.Context(), apiversion.V2)))
+		var problem map[string]interface{}
+		json.Unmarshal(rr.Body.Bytes(), &problem)
+		return rr, problem
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	rr, body := execute(`{"operations": [{"op": "delete", "type": "bounce_rule", "id": 1}, {"op": "update", "type": "bounce_rule", "id": 2, "rule": {}}]}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, float64(1), body["failed_operation"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "operations[1].rule.priority", "message": "is required"}}, body["errors"])
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	rr, body = execute(`{"operations": [{"op": "delete", "type": "bounce_rule", "id": 3, "if_match_version": 4}]}`)
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	assert.Equal(t, problem.TypeVersionConflict, body["type"])
+	assert.Equal(t, float64(5), body["current_version"])
+	assert.Equal(t, float64(0), body["failed_operation"])
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	rr, body = execute(`{"operations": [{"op": "update", "type": "bounce_rule", "id": 4, "rule": {}}]}`)
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.Equal(t, problem.TypeDuplicate, body["type"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "operations[0].rule.mx_domain", "message": "is already taken"}}, body["errors"])
+
+	rr, _ = execute(`{"operations": [], "dry_run": true}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "should reject unknown fields")
+
+	api.RequireChangeRequests = true
+	rr, _ = execute(`{"operations": [{"op": "delete", "type": "bounce_rule", "id": 1}]}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
@@ -1,40 +1,158 @@
 package bouncerule
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"gobrm/apiversion"
+	"gobrm/auth"
+	"gobrm/bulk"
+	"gobrm/changequery"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/config"
+	"gobrm/events"
+	"gobrm/grpcserver"
+	"gobrm/idempotency"
+	"gobrm/models"
+	"gobrm/openapi"
+	"gobrm/patch"
+	"gobrm/problem"
+	"gobrm/ratelimit"
+	"gobrm/release"
+	"gobrm/render"
+	"gobrm/rulequery"
+	"gobrm/schedule"
+	"gobrm/shutdown"
+	"gobrm/tlsserver"
+	"gobrm/webhook"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-chi/chi/v5"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	Router *mux.Router
-	DB     *sql.DB
+	Router     *mux.Router
+	DB         *sql.DB
+	Dispatcher *webhook.Dispatcher
+	Scheduler  *schedule.Scheduler
+	// RequireChangeRequests disables direct bounce rule writes so every change goes through review.
+	RequireChangeRequests bool
+	// InitialRolloutPercentage is the share of consumers a newly published
+	// release reaches; set it before Initialize.
+	InitialRolloutPercentage int
+	// GRPCAddr is where Run serves the gRPC API, e.g. ":9090"; empty disables it.
+	GRPCAddr string
+	// IdempotencyWindow is how long a standalone server remembers
+	// Idempotency-Key requests; set it before Initialize.
+	IdempotencyWindow time.Duration
+	// Idempotency replays retried writes of a standalone server.
+	Idempotency *idempotency.Store
+	// V1DeprecatedAt is when a standalone server deprecated v1, announced in
+	// the Deprecation header of v1 responses; zero does not deprecate v1.
+	V1DeprecatedAt time.Time
+	// V1Sunset is when a standalone server stops serving v1, announced in the
+	// Sunset header of v1 responses; zero is six months after V1DeprecatedAt.
+	V1Sunset time.Time
+	// Auth enables authentication of a standalone server; set it before Initialize.
+	Auth          auth.Config
+	authenticator *auth.Authenticator
+	// TLS sets how a standalone server is reached; set it before Run.
+	TLS tlsserver.Config
+	// Timeouts bound the requests of a standalone server; set it before Run.
+	Timeouts tlsserver.Timeouts
+	// ShutdownTimeout bounds the graceful shutdown of a standalone server;
+	// it defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+	// Draining ends the change streams when it is closed, as the server
+	// shuts down. Run sets it for a standalone server.
+	Draining <-chan struct{}
+	// RateLimit sets the client budgets of a standalone server; set it before Initialize.
+	RateLimit      ratelimit.Config
+	rateLimiter    *ratelimit.Limiter
+	apiKeys        *auth.API
+	webhooks       *webhook.API
+	changeRequests *changerequest.API
+	releases       *release.API
+	transitions    *schedule.API
 }
 
 // TODO: look into Chi for router and gorilla mux differences (not compatible with standard)
 
 // Create database connection and wire up routes.
-func (a *App) Initialize(user, password, dbname string) {
-	connectionString := fmt.Sprintf("%s:%s@/%s?parseTime=true", user, password, dbname)
+func (a *App) Initialize(mysql config.MySQL) {
+	log.Printf("Connecting to %s...", mysql.RedactedDSN())
 
-	log.Printf("Connecting to %s...", connectionString)
-
-	db, err := sql.Open("mysql", connectionString)
+	db, err := mysql.Open()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	a.InitializeWithDB(db)
+	a.Router.Use(problem.RequestID)
+	a.rateLimiter = ratelimit.New(a.RateLimit)
+	a.Router.Use(a.rateLimiter.Unauthenticated)
+	a.initializeAuth(db)
+	a.Router.Use(a.rateLimiter.Middleware)
+	a.initializeSharedRoutes()
+	a.initializeOpenAPI()
+	a.Idempotency = &idempotency.Store{DB: db, Window: a.IdempotencyWindow}
+	a.Router.Use(a.Idempotency.Middleware)
+}
+
+// InitializeWithDB wires up the bounce rule routes on an existing connection
+// pool. Metrics, webhooks and /events are left to the caller so the rule
+// manager can serve them once for both subsystems.
+func (a *App) InitializeWithDB(db *sql.DB) {
 	a.DB = db
+	a.Dispatcher = &webhook.Dispatcher{DB: db}
+	a.Scheduler = &schedule.Scheduler{
+		DB:       db,
+		RuleType: models.TableNames.BounceRule,
+		Source:   bounceRuleTransitions,
+		Record:   recordBounceRuleTransition,
+	}
+	a.transitions = &schedule.API{DB: db, Source: bounceRuleTransitions}
+	a.webhooks = &webhook.API{
+		DB: db,
+		URLParam: func(r *http.Request, key string) string {
+			return mux.Vars(r)[key]
+		},
+	}
+	a.changeRequests = &changerequest.API{
+		DB:       db,
+		RuleType: models.TableNames.BounceRule,
+		Applier:  bounceRuleApplier{},
+		URLParam: func(r *http.Request, key string) string {
+			return mux.Vars(r)[key]
+		},
+	}
+	a.releases = &release.API{
+		DB:                db,
+		RuleType:          models.TableNames.BounceRule,
+		Snapshot:          snapshotBounceRules,
+		InitialPercentage: a.InitialRolloutPercentage,
+		URLParam: func(r *http.Request, key string) string {
+			return mux.Vars(r)[key]
+		},
+	}
 	a.Router = mux.NewRouter()
+	a.Router.NotFoundHandler = http.HandlerFunc(problem.NotFound)
+	a.Router.MethodNotAllowedHandler = http.HandlerFunc(problem.MethodNotAllowed)
 	a.Router.Use(prometheusMiddleware)
 	a.initializeRoutes()
 }
@@ -56,24 +174,163 @@ func prometheusMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// routePrefixes are the paths every bounce rule route lives under.
+var routePrefixes = []string{
+	"/bounce_rules",
+	"/bounce_rule_transitions",
+	"/bounce_rule_changes",
+	"/bounce_rule_change_requests",
+	"/bounce_rule_releases",
+	"/bounce_rule_events",
+}
+
+// Mount serves the bounce rule routes from another router.
+func (a *App) Mount(r chi.Router) {
+	for _, prefix := range routePrefixes {
+		r.Handle(prefix, a.Router)
+		r.Handle(prefix+"/*", a.Router)
+	}
+}
+
 func (a *App) initializeRoutes() {
-	a.Router.Path("/metrics").Handler(promhttp.Handler())
 	a.Router.HandleFunc("/bounce_rules", a.getBounceRules).Methods("GET")
-	a.Router.HandleFunc("/bounce_rules", a.createBounceRule).Methods("POST")
+	a.Router.HandleFunc("/bounce_rules", a.directChange(a.createBounceRule)).Methods("POST")
+	a.Router.HandleFunc("/bounce_rules/classify", a.classifyBounce).Methods("GET")
+	a.Router.HandleFunc("/bounce_rules/export", a.exportBounceRules).Methods("GET")
+	a.Router.HandleFunc("/bounce_rules/import", a.directChange(a.importBounceRules)).Methods("POST")
 	a.Router.HandleFunc("/bounce_rules/{id:[0-9]+}", a.getBounceRule).Methods("GET")
-	a.Router.HandleFunc("/bounce_rules/{id:[0-9]+}", a.updateBounceRule).Methods("PUT")
-	a.Router.HandleFunc("/bounce_rules/{id:[0-9]+}", a.deleteBounceRule).Methods("DELETE")
+	a.Router.HandleFunc("/bounce_rules/{id:[0-9]+}", a.directChange(a.updateBounceRule)).Methods("PUT")
+	a.Router.HandleFunc("/bounce_rules/{id:[0-9]+}", a.directChange(a.patchBounceRule)).Methods("PATCH")
+	a.Router.HandleFunc("/bounce_rules/{id:[0-9]+}", a.directChange(a.deleteBounceRule)).Methods("DELETE")
+	a.Router.HandleFunc("/bounce_rule_transitions", a.transitions.GetUpcomingTransitions).Methods("GET")
 	a.Router.HandleFunc("/bounce_rule_changes", a.getBounceRuleChanges).Methods("GET")
 	a.Router.HandleFunc("/bounce_rule_changes/{id:[0-9]+}", a.getBounceRuleChangesForBounceRule).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_change_requests", a.changeRequests.GetChangeRequests).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_change_requests", a.changeRequests.CreateChangeRequest).Methods("POST")
+	a.Router.HandleFunc("/bounce_rule_change_requests/{id:[0-9]+}", a.changeRequests.GetChangeRequest).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_change_requests/{id:[0-9]+}", a.changeRequests.UpdateChangeRequest).Methods("PUT")
+	a.Router.HandleFunc("/bounce_rule_change_requests/{id:[0-9]+}/submit", a.changeRequests.SubmitChangeRequest).Methods("POST")
+	a.Router.HandleFunc("/bounce_rule_change_requests/{id:[0-9]+}/approve", a.changeRequests.ApproveChangeRequest).Methods("POST")
+	a.Router.HandleFunc("/bounce_rule_change_requests/{id:[0-9]+}/reject", a.changeRequests.RejectChangeRequest).Methods("POST")
+	a.Router.HandleFunc("/bounce_rule_change_requests/{id:[0-9]+}/apply", a.changeRequests.ApplyChangeRequest).Methods("POST")
+	a.Router.HandleFunc("/bounce_rule_releases", a.releases.GetReleases).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_releases", a.releases.Publish).Methods("POST")
+	a.Router.HandleFunc("/bounce_rule_releases/latest", a.releases.GetLatestRelease).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_releases/current", a.releases.GetAssignedRelease).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_releases/rollout", a.releases.GetRollout).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_releases/rollout", a.releases.UpdateRollout).Methods("PUT")
+	a.Router.HandleFunc("/bounce_rule_releases/rollout/rollback", a.releases.RollbackRollout).Methods("POST")
+	a.Router.HandleFunc("/bounce_rule_releases/{version:[0-9]+}", a.releases.GetRelease).Methods("GET")
+	a.Router.HandleFunc("/bounce_rule_events", a.streamBounceRuleChanges).Methods("GET")
 }
 
-// Start up the application.
-func (a *App) Run(addr string) {
-	log.Fatal(http.ListenAndServe(addr, a.Router))
+// initializeAuth authenticates every request when Auth is enabled and
+// serves API key management either way.
+func (a *App) initializeAuth(db *sql.DB) {
+	a.apiKeys = &auth.API{
+		DB: db,
+		URLParam: func(r *http.Request, key string) string {
+			return mux.Vars(r)[key]
+		},
+	}
+	if !a.Auth.Enabled {
+		return
+	}
+
+	authenticator, err := auth.New(db, a.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	authenticator.Events = auth.Bounce
+	a.authenticator = authenticator
+	a.Router.Use(authenticator.Middleware)
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+// initializeSharedRoutes adds the routes a standalone bounce rule server
+// serves for itself.
+func (a *App) initializeSharedRoutes() {
+	a.Router.Path("/metrics").Handler(promhttp.Handler())
+	a.Router.HandleFunc("/events", a.streamBounceRuleChanges).Methods("GET")
+	a.Router.HandleFunc("/webhooks", a.webhooks.GetSubscriptions).Methods("GET")
+	a.Router.HandleFunc("/webhooks", idempotency.Redacted(a.webhooks.CreateSubscription)).Methods("POST")
+	a.Router.HandleFunc("/webhooks/{id:[0-9]+}", a.webhooks.GetSubscription).Methods("GET")
+	a.Router.HandleFunc("/webhooks/{id:[0-9]+}", idempotency.Redacted(a.webhooks.UpdateSubscription)).Methods("PUT")
+	a.Router.HandleFunc("/webhooks/{id:[0-9]+}", a.webhooks.DeleteSubscription).Methods("DELETE")
+	a.Router.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", a.webhooks.GetDeliveries).Methods("GET")
+	a.Router.HandleFunc("/webhooks/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}/redeliver", a.webhooks.Redeliver).Methods("POST")
+	a.Router.HandleFunc("/api_keys", a.apiKeys.GetKeys).Methods("GET")
+	a.Router.HandleFunc("/api_keys", idempotency.Redacted(a.apiKeys.CreateKey)).Methods("POST")
+	a.Router.HandleFunc("/api_keys/{id:[0-9]+}", a.apiKeys.GetKey).Methods("GET")
+	a.Router.HandleFunc("/api_keys/{id:[0-9]+}", a.apiKeys.RevokeKey).Methods("DELETE")
+	a.Router.HandleFunc("/api_keys/{id:[0-9]+}/usage", a.apiKeys.GetKeyUsage).Methods("GET")
+}
+
+// initializeOpenAPI serves the part of the OpenAPI document a standalone
+// bounce rule server implements and validates requests against it.
+func (a *App) initializeOpenAPI() {
+	doc, err := openapi.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc = openapi.Subset(doc, "/bounce_rule", "/events", "/webhooks", "/api_keys", "/metrics", "/openapi.json")
+
+	validator, err := openapi.NewValidator(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.Router.Use(validator.Middleware)
+	a.Router.HandleFunc("/openapi.json", openapi.Handler(doc)).Methods("GET")
+}
+
+// Start up the application, the webhook dispatcher, the transition scheduler,
+// the idempotency key purge, the rate limit pruning, the TLS file watcher
+// and, with a GRPCAddr, the gRPC API. It serves TLS unless TLS explicitly
+// allows plaintext. On SIGTERM or SIGINT it stops accepting requests,
+// finishes those in flight, ends the change streams, stops the workers and
+// closes the connection pool before returning.
+func (a *App) Run(addr string) error {
+	credentials, err := tlsserver.New(a.TLS)
+	if err != nil {
+		return err
+	}
+	m := shutdown.New(a.ShutdownTimeout)
+	a.Draining = m.Draining()
+	m.Go(a.Dispatcher.Run)
+	m.Go(a.Scheduler.Run)
+	m.Go(a.Idempotency.Run)
+	m.Go(a.rateLimiter.Run)
+	if credentials != nil {
+		m.Go(func(ctx context.Context) { watchTLS(ctx, credentials) })
+	}
+	if a.GRPCAddr != "" {
+		var opts []grpc.ServerOption
+		if a.authenticator != nil {
+			opts = a.authenticator.ServerOptions()
+		}
+		if credentials != nil {
+			opts = append(opts, credentials.GRPCOption())
+		}
+		grpcServer := grpcserver.New(opts...)
+		a.RegisterGRPC(grpcServer)
+		m.Serve("gRPC", func() error { return grpcServer.ListenAndServe(a.GRPCAddr) }, grpcServer.Shutdown)
+	}
+	// mux runs middleware after matching a route, so the version prefix is
+	// stripped before the router sees the request
+	server := tlsserver.NewServer(addr, apiversion.DefaultPolicy(a.V1DeprecatedAt, a.V1Sunset).Middleware(a.Router), credentials, a.Timeouts)
+	m.Serve("HTTP", func() error { return tlsserver.Serve(server) }, server.Shutdown)
+	m.Close(a.DB)
+	return m.Run(shutdown.Signals...)
+}
+
+// watchTLS reloads the TLS files of a standalone server when they change.
+func watchTLS(ctx context.Context, credentials *tlsserver.Credentials) {
+	if err := credentials.Watch(ctx); err != nil {
+		log.Printf("Failed to watch TLS files, they will not be reloaded: %v", err)
+	}
+}
+
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Respond(w, r, code, message)
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -83,93 +340,309 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(response)
 }
 
+// directChange rejects direct bounce rule writes when they must go through change requests.
+func (a *App) directChange(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if a.RequireChangeRequests {
+			respondWithError(w, r, http.StatusForbidden, "Direct bounce rule changes are disabled, propose a change request instead")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// getBounceRules lists the bounce rules matching the search parameters, or
+// with ?active=true only those in effect now, in match order.
 func (a *App) getBounceRules(w http.ResponseWriter, r *http.Request) {
+	params, err := rulequery.Parse(r.URL.Query(), bounceRuleSearchFields)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return
+	}
+
+	var mods []qm.QueryMod
+	defaultOrder := "id"
+	if active, _ := strconv.ParseBool(r.URL.Query().Get("active")); active {
+		mods = append(mods, schedule.ActiveAt(time.Now()))
+		defaultOrder = "priority, id"
+	}
+
+	bounceRules, total, err := searchBounceRules(a.DB, params, defaultOrder, mods...)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if bounceRules == nil {
+		bounceRules = models.BounceRuleSlice{}
+	}
+	rulequery.SetPageHeaders(w, r, params, total)
+
+	// Tag the listing so clients can tell whether two fetches saw the same rules
+	if release.NotModified(w, r, bounceRuleListHash(bounceRules, total)) {
+		return
+	}
+
+	render.List(w, r, bounceRules, bounceRuleListColumns)
+}
+
+// bounceRuleListHash hashes the ID and version of every listed rule and the total
+// count. Every change to a rule bumps its version, so the hash changes with
+// the listing without encoding it twice.
+func bounceRuleListHash(bounceRules models.BounceRuleSlice, total int64) string {
+	var key strings.Builder
+	fmt.Fprintf(&key, "%d", total)
+	for _, rule := range bounceRules {
+		fmt.Fprintf(&key, ",%d:%d", rule.ID, rule.Version)
+	}
+	return release.Hash([]byte(key.String()))
+}
+
+// parseBounceRuleID reads the id route variable, which must fit the SMALLINT primary key.
+func parseBounceRuleID(r *http.Request) (int16, error) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 16)
+	return int16(id), err
+}
+
+// readBounceRule reads and validates a full bounce rule from the request body.
+func readBounceRule(w http.ResponseWriter, r *http.Request) (models.BounceRule, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule request payload")
+		return models.BounceRule{}, false
+	}
+
+	bounceRule, err := decodeBounceRule(body)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return models.BounceRule{}, false
+	}
+
+	return bounceRule, true
+}
+
+// exportBounceRules downloads every bounce rule as JSON, YAML or CSV.
+func (a *App) exportBounceRules(w http.ResponseWriter, r *http.Request) {
+	format, err := bulk.ResponseFormat(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusNotAcceptable, err.Error())
+		return
+	}
+
 	bounceRules, err := getBounceRules(a.DB)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
 
+	if err := bulk.Write(w, format, "bounce_rules", bounceRules, bounceRuleExportColumns); err != nil {
+		log.Printf("Failed to write bounce rule export: %v", err)
+	}
+}
+
+// importBounceRules creates, updates or replaces bounce rules from a JSON,
+// YAML or CSV file in one transaction.
+func (a *App) importBounceRules(w http.ResponseWriter, r *http.Request) {
+	format, err := bulk.RequestFormat(r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, http.StatusUnsupportedMediaType, err.Error())
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, bounceRules)
+	options, err := bulk.ParseOptions(r)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule import")
+		return
+	}
+
+	records, err := bulk.Decode(format, body, bounceRuleExportColumns)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return
+	}
+
+	log.Printf("Importing %d bounce rules with mode %s", len(records), options.Mode)
+	result, err := importBounceRules(a.DB, records, options)
+	if err != nil {
+		if importErr, ok := err.(bulk.ImportError); ok {
+			problem.Invalid(w, r, importErr)
+			return
+		}
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
 }
 
 func (a *App) createBounceRule(w http.ResponseWriter, r *http.Request) {
-	var br BounceRule
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&br); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid bounce rule request payload")
+	bounceRule, ok := readBounceRule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := createBounceRule(a.DB, &bounceRule); err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.Header().Set("ETag", concurrency.ETag(bounceRule.Version))
+	respondWithJSON(w, http.StatusCreated, bounceRule)
+}
+
+// classifyBounce returns the bounce rule in effect that matches a bounce's
+// response_code, enhanced_code and message.
+func (a *App) classifyBounce(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	responseCode, err := strconv.ParseInt(query.Get("response_code"), 10, 16)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid response_code")
 		return
 	}
-	defer r.Body.Close()
 
-	if err := br.createBounceRule(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	bounceRules, err := getActiveBounceRules(a.DB, time.Now())
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	respondWithJSON(w, http.StatusCreated, br)
+	bounceRule := classifyBounce(bounceRules, int16(responseCode), query.Get("enhanced_code"), query.Get("message"))
+	if bounceRule == nil {
+		respondWithError(w, r, http.StatusNotFound, "No bounce rule matches the bounce")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, bounceRule)
 }
 
 func (a *App) getBounceRule(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseBounceRuleID(r)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid bounce rule ID")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule ID")
 		return
 	}
 
-	br := BounceRule{ID: id}
-	err = br.getBounceRule(a.DB)
+	bounceRule, err := getBounceRule(a.DB, id)
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
-			respondWithError(w, http.StatusNotFound, "Bounce rule not found")
+			respondWithError(w, r, http.StatusNotFound, "Bounce rule not found")
 		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		}
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, br)
+	// The ETag is the rule's version, which PUT and DELETE accept in If-Match
+	if release.NotModified(w, r, strconv.Itoa(bounceRule.Version)) {
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, bounceRule)
 }
 
 func (a *App) updateBounceRule(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseBounceRuleID(r)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid bounce rule ID")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule ID")
+		return
+	}
+
+	bounceRule, ok := readBounceRule(w, r)
+	if !ok {
+		return
+	}
+
+	bounceRule.ID = id
+	updatedBounceRule, err := updateBounceRule(a.DB, bounceRule, concurrency.IfMatch(r))
+	if err != nil {
+		if conflict, ok := err.(concurrency.ConflictError); ok {
+			concurrency.RespondWithConflict(w, r, conflict)
+			return
+		}
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Bounce rule not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
-	var br BounceRule
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&br); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid bounce rule request payload")
+	w.Header().Set("ETag", concurrency.ETag(updatedBounceRule.Version))
+	respondWithJSON(w, http.StatusOK, updatedBounceRule)
+}
+
+// patchBounceRule applies an application/merge-patch+json or
+// application/json-patch+json body to a bounce rule.
+func (a *App) patchBounceRule(w http.ResponseWriter, r *http.Request) {
+	id, err := parseBounceRuleID(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule ID")
 		return
 	}
+
+	patchDocument, err := ioutil.ReadAll(r.Body)
 	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule patch")
+		return
+	}
 
-	br.ID = id
-	if err := br.updateBounceRule(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	updatedBounceRule, err := patchBounceRule(a.DB, id, r.Header.Get("Content-Type"), patchDocument, concurrency.IfMatch(r))
+	if err != nil {
+		var conflict concurrency.ConflictError
+		var validationErr changerequest.ValidationError
+		var invalidPatch patch.InvalidPatchError
+		switch {
+		case errors.As(err, &conflict):
+			concurrency.RespondWithConflict(w, r, conflict)
+		case err == sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Bounce rule not found")
+		case err == patch.ErrUnsupportedMediaType:
+			respondWithError(w, r, http.StatusUnsupportedMediaType, err.Error())
+		case err == patch.ErrTestFailed:
+			respondWithError(w, r, http.StatusConflict, err.Error())
+		case errors.As(err, &invalidPatch):
+			respondWithError(w, r, http.StatusBadRequest, invalidPatch.Error())
+		case errors.As(err, &validationErr):
+			problem.Invalid(w, r, validationErr)
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, br)
+	w.Header().Set("ETag", concurrency.ETag(updatedBounceRule.Version))
+	respondWithJSON(w, http.StatusOK, updatedBounceRule)
 }
 
 func (a *App) deleteBounceRule(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := parseBounceRuleID(r)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid bounce rule ID")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule ID")
 		return
 	}
 
-	br := BounceRule{ID: id}
-	if err := br.deleteBounceRule(a.DB); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := deleteBounceRule(a.DB, id, concurrency.IfMatch(r)); err != nil {
+		if conflict, ok := err.(concurrency.ConflictError); ok {
+			concurrency.RespondWithConflict(w, r, conflict)
+			return
+		}
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Bounce rule not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
 		return
 	}
 
@@ -177,35 +650,93 @@ func (a *App) deleteBounceRule(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *App) getBounceRuleChanges(w http.ResponseWriter, r *http.Request) {
-	bounceRuleChanges, err := getBounceRuleChanges(a.DB)
-
+	params, err := changequery.Parse(r.URL.Query(), bounceRuleChangeFilterFields)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		problem.Invalid(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, bounceRuleChanges)
+	a.writeBounceRuleChanges(w, r, params)
 }
 
 func (a *App) getBounceRuleChangesForBounceRule(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id, err := strconv.Atoi(vars["id"])
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid bounce rule ID")
+		return
+	}
+
+	params, err := changequery.Parse(r.URL.Query(), bounceRuleChangeFilterFields)
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid bounce rule ID")
+		problem.Invalid(w, r, err)
 		return
 	}
 
-	bounceRuleChanges, err := getBounceRuleChangesForBounceRule(a.DB, id)
+	params.RuleID = &id
+	a.writeBounceRuleChanges(w, r, params)
+}
+
+// writeBounceRuleChanges streams a page of changes in the negotiated format row
+// by row, linking to the next page if there is one.
+func (a *App) writeBounceRuleChanges(w http.ResponseWriter, r *http.Request, params changequery.Params) {
+	format, ok := render.Negotiate(w, r)
+	if !ok {
+		return
+	}
 
+	last, err := getBounceRuleChangePageEnd(a.DB, params)
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			respondWithError(w, http.StatusNotFound, "Bounce rule changes not found")
-		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-		}
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rows, err := queryBounceRuleChanges(a.DB, params)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
+	defer rows.Close()
 
-	respondWithJSON(w, http.StatusOK, bounceRuleChanges)
+	if last != nil {
+		changequery.SetNextLink(w, r, params.Next(int(last.ID), last.UpdatedAt))
+	}
+	encoder := render.Stream(w, format, bounceRuleChangeListColumns)
+	err = eachBounceRuleChange(rows, func(bounceRuleChange *models.BounceRuleChange) error {
+		return encoder.Encode(bounceRuleChange)
+	})
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		log.Printf("Failed to write bounce rule changes: %v", err)
+	}
+}
+
+// Stream bounce rule changes as server-sent events, using the change IDs as the event cursor.
+func (a *App) streamBounceRuleChanges(w http.ResponseWriter, r *http.Request) {
+	stream := a.ChangeStream()
+	stream.ServeHTTP(w, r)
+}
+
+// ChangeStream polls the bounce rule change history for the server-sent event
+// and gRPC change streams.
+func (a *App) ChangeStream() *events.Stream {
+	return &events.Stream{
+		Source: func(ctx context.Context, after int, limit int) ([]events.Event, error) {
+			bounceRuleChanges, err := getBounceRuleChangesAfter(a.DB, after, limit)
+			if err != nil {
+				return nil, err
+			}
+
+			bounceRuleEvents := make([]events.Event, 0, len(bounceRuleChanges))
+			for _, brc := range bounceRuleChanges {
+				bounceRuleEvents = append(bounceRuleEvents, events.Event{ID: int(brc.ID), Type: brc.Action, Data: brc, At: brc.UpdatedAt})
+			}
+			return bounceRuleEvents, nil
+		},
+		Head: func(ctx context.Context) (int, error) {
+			return getLatestBounceRuleChangeID(a.DB)
+		},
+		Draining: a.Draining,
+	}
 }
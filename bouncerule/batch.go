@@ -0,0 +1,66 @@
+package bouncerule
+
+import (
+	"context"
+	"database/sql"
+	"gobrm/batch"
+	"gobrm/changerequest"
+	"gobrm/problem"
+	"math"
+)
+
+// BatchApplier lets batches create, update and delete bounce rules.
+func (a *App) BatchApplier() batch.Applier {
+	return bounceRuleBatchApplier{}
+}
+
+// bounceRuleBatchApplier applies batch operations on bounce rules with the
+// same functions the direct CRUD handlers use.
+type bounceRuleBatchApplier struct{}
+
+func batchBounceRuleID(op batch.Operation) (int16, error) {
+	if op.ID > math.MaxInt16 {
+		return 0, changerequest.ValidationError{Message: "Invalid bounce rule ID", Fields: []problem.FieldError{{Field: "id", Message: "is not a bounce rule ID"}}}
+	}
+	return int16(op.ID), nil
+}
+
+func (bounceRuleBatchApplier) Apply(ctx context.Context, tx *sql.Tx, op batch.Operation) (int, interface{}, error) {
+	switch op.Op {
+	case batch.OpCreate:
+		bounceRule, err := decodeBounceRule(op.Rule)
+		if err != nil {
+			return 0, nil, batch.RuleError(err)
+		}
+		bounceRule.ID = 0
+		if err := createBounceRuleTx(ctx, tx, &bounceRule); err != nil {
+			return 0, nil, err
+		}
+		return int(bounceRule.ID), &bounceRule, nil
+	case batch.OpUpdate:
+		id, err := batchBounceRuleID(op)
+		if err != nil {
+			return 0, nil, err
+		}
+		bounceRule, err := decodeBounceRule(op.Rule)
+		if err != nil {
+			return 0, nil, batch.RuleError(err)
+		}
+		bounceRule.ID = id
+		updatedBounceRule, err := updateBounceRuleTx(ctx, tx, bounceRule, op.Precondition())
+		if err != nil {
+			return 0, nil, err
+		}
+		return int(id), updatedBounceRule, nil
+	default:
+		id, err := batchBounceRuleID(op)
+		if err != nil {
+			return 0, nil, err
+		}
+		deletedBounceRule, err := deleteBounceRuleTx(ctx, tx, id, op.Precondition())
+		if err != nil {
+			return 0, nil, err
+		}
+		return int(id), deletedBounceRule, nil
+	}
+}
@@ -0,0 +1,149 @@
+package bouncerule
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"gobrm/bulk"
+	"gobrm/changerequest"
+	"gobrm/models"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// bounceRuleListColumns and bounceRuleChangeListColumns order every field
+// of bounce rule and change lists in YAML and CSV.
+var (
+	bounceRuleListColumns       = bulk.Columns(models.BounceRule{})
+	bounceRuleChangeListColumns = bulk.Columns(models.BounceRuleChange{})
+)
+
+// bounceRuleExportColumns orders bounce rule fields in YAML and CSV exports.
+var bounceRuleExportColumns = []bulk.Column{
+	{Name: "id", Numeric: true},
+	{Name: "response_code", Numeric: true},
+	{Name: "enhanced_code"},
+	{Name: "regex"},
+	{Name: "priority", Numeric: true},
+	{Name: "description"},
+	{Name: "bounce_action"},
+	{Name: "effective_at"},
+	{Name: "expires_at"},
+	{Name: "version", Numeric: true},
+}
+
+// bounceRuleKey is the natural key imports match bounce rules on: the same
+// response code, enhanced code and regex describe the same bounce.
+func bounceRuleKey(bounceRule *models.BounceRule) string {
+	return fmt.Sprintf("%d\x00%s\x00%s", bounceRule.ResponseCode, bounceRule.EnhancedCode, bounceRule.Regex)
+}
+
+func sameBounceRule(a, b *models.BounceRule) bool {
+	return a.ResponseCode == b.ResponseCode &&
+		a.EnhancedCode == b.EnhancedCode &&
+		a.Regex == b.Regex &&
+		a.Priority == b.Priority &&
+		a.Description == b.Description &&
+		a.BounceAction == b.BounceAction &&
+		bulk.SameTime(a.EffectiveAt, b.EffectiveAt) &&
+		bulk.SameTime(a.ExpiresAt, b.ExpiresAt)
+}
+
+func decodeImportedBounceRules(records []json.RawMessage) (models.BounceRuleSlice, error) {
+	bounceRules := make(models.BounceRuleSlice, len(records))
+	for i, record := range records {
+		bounceRule, err := decodeBounceRule(record)
+		if err != nil {
+			if validationErr, ok := err.(changerequest.ValidationError); ok {
+				return nil, bulk.ImportError{Record: i + 1, Message: validationErr.Message}
+			}
+			return nil, err
+		}
+		bounceRule.ID = 0
+		bounceRules[i] = &bounceRule
+	}
+	return bounceRules, nil
+}
+
+// importBounceRules applies an import in one transaction, auditing each
+// created, updated and deleted rule like the CRUD handlers. A dry run plans
+// the same changes and rolls them back.
+func importBounceRules(db *sql.DB, records []json.RawMessage, options bulk.Options) (bulk.Result, error) {
+	result := bulk.NewResult(options)
+
+	bounceRules, err := decodeImportedBounceRules(records)
+	if err != nil {
+		return result, err
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	existingBounceRules, err := models.BounceRules(qm.OrderBy("id"), qm.For("UPDATE")).All(ctx, tx)
+	if err != nil {
+		return result, err
+	}
+
+	existingKeys := make([]string, len(existingBounceRules))
+	for i, bounceRule := range existingBounceRules {
+		existingKeys[i] = bounceRuleKey(bounceRule)
+	}
+	importedKeys := make([]string, len(bounceRules))
+	for i, bounceRule := range bounceRules {
+		importedKeys[i] = bounceRuleKey(bounceRule)
+	}
+
+	steps, err := bulk.Plan(options.Mode, false, existingKeys, importedKeys)
+	if err != nil {
+		return result, err
+	}
+
+	for i, bounceRule := range bounceRules {
+		match := steps.Matches[i]
+		if match < 0 {
+			if !options.DryRun {
+				if err := createBounceRuleTx(ctx, tx, bounceRule); err != nil {
+					return result, err
+				}
+			}
+			result.Add(bulk.Change{Action: bulk.ActionCreated, Record: i + 1, RuleID: int(bounceRule.ID), Rule: bounceRule})
+			continue
+		}
+
+		existingBounceRule := existingBounceRules[match]
+		if sameBounceRule(existingBounceRule, bounceRule) {
+			result.Unchanged++
+			continue
+		}
+
+		bounceRule.ID = existingBounceRule.ID
+		updatedBounceRule := bounceRule
+		if !options.DryRun {
+			if updatedBounceRule, err = updateBounceRuleTx(ctx, tx, *bounceRule, nil); err != nil {
+				return result, err
+			}
+		}
+		result.Add(bulk.Change{Action: bulk.ActionUpdated, Record: i + 1, RuleID: int(bounceRule.ID), Rule: updatedBounceRule, Previous: existingBounceRule})
+	}
+
+	for _, match := range steps.Deletes {
+		existingBounceRule := existingBounceRules[match]
+		if !options.DryRun {
+			if _, err := deleteBounceRuleTx(ctx, tx, existingBounceRule.ID, nil); err != nil {
+				return result, err
+			}
+		}
+		result.Add(bulk.Change{Action: bulk.ActionDeleted, RuleID: int(existingBounceRule.ID), Rule: existingBounceRule})
+	}
+
+	if options.DryRun {
+		return result, nil
+	}
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,101 @@
+package bouncerule
+
+import (
+	"encoding/json"
+	"gobrm/bulk"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var importedBounceRules = []json.RawMessage{
+	json.RawMessage(`{"response_code":450,"enhanced_code":"4.7.1","regex":"regex1","priority":3,"description":"description1","bounce_action":"retry"}`),
+	json.RawMessage(`{"response_code":550,"enhanced_code":"5.1.1","regex":"regex3","priority":1,"description":"description3","bounce_action":"suppress"}`),
+}
+
+func existingBounceRuleRows() *sqlmock.Rows {
+	return sqlmock.NewRows(bounceRuleColumns).
+		AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1).
+		AddRow(2, 451, "4.7.2", "regex2", 2, "description2", "retry", nil, nil, 1)
+}
+
+func TestImportBounceRulesDryRun(t *testing.T) {
+	log.Print("Testing model's importBounceRules plans a replace_all without writing")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` ORDER BY id FOR UPDATE").WillReturnRows(existingBounceRuleRows())
+	mock.ExpectRollback()
+
+	result, importErr := importBounceRules(db, importedBounceRules, bulk.Options{Mode: bulk.ModeReplaceAll, DryRun: true})
+	assert.NoError(t, importErr, "should not receive an error when planning an import")
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 1, result.Deleted)
+	assert.Equal(t, []string{"updated", "created", "deleted"}, []string{result.Changes[0].Action, result.Changes[1].Action, result.Changes[2].Action})
+	assert.Equal(t, 1, result.Changes[0].RuleID, "should update the rule with the same natural key")
+	assert.Equal(t, 2, result.Changes[2].RuleID, "should delete the rule missing from the import")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestImportBounceRulesUpsert(t *testing.T) {
+	log.Print("Testing model's importBounceRules upserts and audits in one transaction")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` ORDER BY id FOR UPDATE").WillReturnRows(existingBounceRuleRows())
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` WHERE \\(`bounce_rule`.`id` = \\?\\) LIMIT 1 FOR UPDATE").WithArgs(int16(1)).
+		WillReturnRows(sqlmock.NewRows(bounceRuleColumns).AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1))
+	mock.ExpectExec("UPDATE `bounce_rule` SET").
+		WithArgs(450, "4.7.1", "regex1", 3, "description1", "retry", nil, nil, 2, int16(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `bounce_rule_change`").
+		WithArgs("updated", 1, 450, "4.7.1", "regex1", 3, "description1", "retry", nil, nil, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_outbox").
+		WithArgs("bounce_rule.updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `bounce_rule`").
+		WithArgs(550, "5.1.1", "regex3", 1, "description3", "suppress", nil, nil, 1).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO `bounce_rule_change`").
+		WithArgs("created", 3, 550, "5.1.1", "regex3", 1, "description3", "suppress", nil, nil, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO webhook_outbox").
+		WithArgs("bounce_rule.created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	result, importErr := importBounceRules(db, importedBounceRules, bulk.Options{Mode: bulk.ModeUpsert})
+	assert.NoError(t, importErr, "should not receive an error when importing bounce rules")
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Updated)
+	assert.Equal(t, 0, result.Deleted)
+	assert.Equal(t, 3, result.Changes[1].RuleID, "should report the created rule's ID")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestImportBounceRulesInvalidRecord(t *testing.T) {
+	log.Print("Testing model's importBounceRules names the invalid record")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	records := append(importedBounceRules, json.RawMessage(`{"response_code":550}`))
+	_, importErr := importBounceRules(db, records, bulk.Options{Mode: bulk.ModeAppend})
+	assert.Equal(t, bulk.ImportError{Record: 3, Message: "Missing required fields: enhanced_code, regex, priority, description, bounce_action"}, importErr)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
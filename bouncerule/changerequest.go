@@ -0,0 +1,90 @@
+package bouncerule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gobrm/changerequest"
+	"gobrm/models"
+	"math"
+)
+
+// bounceRuleApplier applies bounce rule change requests with the same
+// functions the direct CRUD handlers use.
+type bounceRuleApplier struct{}
+
+func decodeProposedBounceRule(cr changerequest.ChangeRequest) (models.BounceRule, error) {
+	return decodeBounceRule(cr.Rule)
+}
+
+func proposedBounceRuleID(cr changerequest.ChangeRequest) (int16, error) {
+	if *cr.RuleID < 1 || *cr.RuleID > math.MaxInt16 {
+		return 0, changerequest.ValidationError{Message: "Invalid bounce rule ID"}
+	}
+	return int16(*cr.RuleID), nil
+}
+
+func (bounceRuleApplier) Validate(ctx context.Context, db *sql.DB, cr changerequest.ChangeRequest) error {
+	if cr.Action != changerequest.ActionDelete {
+		if _, err := decodeProposedBounceRule(cr); err != nil {
+			return err
+		}
+	}
+
+	if cr.RuleID == nil {
+		return nil
+	}
+
+	id, err := proposedBounceRuleID(cr)
+	if err != nil {
+		return err
+	}
+
+	exists, err := models.BounceRuleExists(ctx, db, id)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return changerequest.ValidationError{Message: fmt.Sprintf("Bounce rule %d not found", id)}
+	}
+
+	return nil
+}
+
+func (bounceRuleApplier) Apply(ctx context.Context, tx *sql.Tx, cr changerequest.ChangeRequest) (int, error) {
+	switch cr.Action {
+	case changerequest.ActionCreate:
+		bounceRule, err := decodeProposedBounceRule(cr)
+		if err != nil {
+			return 0, err
+		}
+		bounceRule.ID = 0
+		if err := createBounceRuleTx(ctx, tx, &bounceRule); err != nil {
+			return 0, err
+		}
+		return int(bounceRule.ID), nil
+	case changerequest.ActionUpdate:
+		bounceRule, err := decodeProposedBounceRule(cr)
+		if err != nil {
+			return 0, err
+		}
+		if bounceRule.ID, err = proposedBounceRuleID(cr); err != nil {
+			return 0, err
+		}
+		if _, err := updateBounceRuleTx(ctx, tx, bounceRule, nil); err != nil {
+			return 0, err
+		}
+		return int(bounceRule.ID), nil
+	case changerequest.ActionDelete:
+		id, err := proposedBounceRuleID(cr)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := deleteBounceRuleTx(ctx, tx, id, nil); err != nil {
+			return 0, err
+		}
+		return int(id), nil
+	default:
+		return 0, changerequest.ValidationError{Message: "action must be one of create, update or delete"}
+	}
+}
@@ -0,0 +1,247 @@
+package bouncerule
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"gobrm/changerequest"
+	"gobrm/events"
+	"gobrm/grpcserver"
+	"gobrm/models"
+	"gobrm/rulespb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// bounceRuleService serves the BounceRules gRPC service with the same domain
+// logic as the REST handlers.
+type bounceRuleService struct {
+	rulespb.UnimplementedBounceRulesServer
+	app *App
+}
+
+// RegisterGRPC adds the BounceRules service to a gRPC server.
+func (a *App) RegisterGRPC(s *grpcserver.Server) {
+	rulespb.RegisterBounceRulesServer(s, &bounceRuleService{app: a})
+}
+
+const bounceRuleNotFound = "Bounce rule not found"
+
+func bounceRuleToProto(bounceRule *models.BounceRule) *rulespb.BounceRule {
+	return &rulespb.BounceRule{
+		Id:           int32(bounceRule.ID),
+		ResponseCode: int32(bounceRule.ResponseCode),
+		EnhancedCode: bounceRule.EnhancedCode,
+		Regex:        bounceRule.Regex,
+		Priority:     int32(bounceRule.Priority),
+		Description:  bounceRule.Description,
+		BounceAction: bounceRule.BounceAction,
+		EffectiveAt:  grpcserver.Timestamp(bounceRule.EffectiveAt),
+		ExpiresAt:    grpcserver.Timestamp(bounceRule.ExpiresAt),
+		Version:      int32(bounceRule.Version),
+	}
+}
+
+// bounceRuleFromProto converts and validates a bounce rule sent over gRPC.
+func bounceRuleFromProto(bounceRule *rulespb.BounceRule) (models.BounceRule, error) {
+	if bounceRule == nil {
+		return models.BounceRule{}, changerequest.ValidationError{Message: "bounce_rule is required"}
+	}
+	if bounceRule.ResponseCode < 0 || bounceRule.ResponseCode > math.MaxInt16 {
+		return models.BounceRule{}, changerequest.ValidationError{Message: "response_code is out of range"}
+	}
+	if bounceRule.Priority < math.MinInt8 || bounceRule.Priority > math.MaxInt8 {
+		return models.BounceRule{}, changerequest.ValidationError{Message: "priority is out of range"}
+	}
+
+	converted := models.BounceRule{
+		ResponseCode: int16(bounceRule.ResponseCode),
+		EnhancedCode: bounceRule.EnhancedCode,
+		Regex:        bounceRule.Regex,
+		Priority:     int8(bounceRule.Priority),
+		Description:  bounceRule.Description,
+		BounceAction: bounceRule.BounceAction,
+		EffectiveAt:  grpcserver.Time(bounceRule.EffectiveAt),
+		ExpiresAt:    grpcserver.Time(bounceRule.ExpiresAt),
+	}
+	return converted, validateBounceRule(converted)
+}
+
+func bounceRuleChangeToProto(bounceRuleChange *models.BounceRuleChange) *rulespb.BounceRuleChange {
+	return &rulespb.BounceRuleChange{
+		Id:     int32(bounceRuleChange.ID),
+		Action: bounceRuleChange.Action,
+		BounceRule: &rulespb.BounceRule{
+			Id:           int32(bounceRuleChange.BounceRuleID),
+			ResponseCode: int32(bounceRuleChange.ResponseCode),
+			EnhancedCode: bounceRuleChange.EnhancedCode,
+			Regex:        bounceRuleChange.Regex,
+			Priority:     int32(bounceRuleChange.Priority),
+			Description:  bounceRuleChange.Description,
+			BounceAction: bounceRuleChange.BounceAction,
+			EffectiveAt:  grpcserver.Timestamp(bounceRuleChange.EffectiveAt),
+			ExpiresAt:    grpcserver.Timestamp(bounceRuleChange.ExpiresAt),
+		},
+		UpdatedAt: timestamppb.New(bounceRuleChange.UpdatedAt),
+	}
+}
+
+// bounceRuleID checks an ID fits the SMALLINT primary key.
+func bounceRuleID(id int32) (int16, error) {
+	if id < 1 || id > math.MaxInt16 {
+		return 0, status.Error(codes.InvalidArgument, "Invalid bounce rule ID")
+	}
+	return int16(id), nil
+}
+
+func (s *bounceRuleService) ListBounceRules(ctx context.Context, req *rulespb.ListBounceRulesRequest) (*rulespb.ListBounceRulesResponse, error) {
+	var bounceRules models.BounceRuleSlice
+	var err error
+	if req.Active {
+		bounceRules, err = getActiveBounceRules(s.app.DB, time.Now())
+	} else {
+		bounceRules, err = getBounceRules(s.app.DB)
+	}
+	if err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+
+	response := &rulespb.ListBounceRulesResponse{BounceRules: make([]*rulespb.BounceRule, 0, len(bounceRules))}
+	for _, bounceRule := range bounceRules {
+		response.BounceRules = append(response.BounceRules, bounceRuleToProto(bounceRule))
+	}
+	return response, nil
+}
+
+func (s *bounceRuleService) GetBounceRule(ctx context.Context, req *rulespb.GetBounceRuleRequest) (*rulespb.BounceRule, error) {
+	id, err := bounceRuleID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	bounceRule, err := getBounceRule(s.app.DB, id)
+	if err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+	return bounceRuleToProto(bounceRule), nil
+}
+
+func (s *bounceRuleService) CreateBounceRule(ctx context.Context, req *rulespb.CreateBounceRuleRequest) (*rulespb.BounceRule, error) {
+	if s.app.RequireChangeRequests {
+		return nil, grpcserver.ErrDirectChangesDisabled
+	}
+
+	bounceRule, err := bounceRuleFromProto(req.BounceRule)
+	if err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+
+	if err := createBounceRule(s.app.DB, &bounceRule); err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+	return bounceRuleToProto(&bounceRule), nil
+}
+
+func (s *bounceRuleService) UpdateBounceRule(ctx context.Context, req *rulespb.UpdateBounceRuleRequest) (*rulespb.BounceRule, error) {
+	if s.app.RequireChangeRequests {
+		return nil, grpcserver.ErrDirectChangesDisabled
+	}
+
+	bounceRule, err := bounceRuleFromProto(req.BounceRule)
+	if err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+	if bounceRule.ID, err = bounceRuleID(req.BounceRule.Id); err != nil {
+		return nil, err
+	}
+
+	updatedBounceRule, err := updateBounceRule(s.app.DB, bounceRule, grpcserver.Precondition(req.IfMatchVersion))
+	if err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+	return bounceRuleToProto(updatedBounceRule), nil
+}
+
+func (s *bounceRuleService) DeleteBounceRule(ctx context.Context, req *rulespb.DeleteBounceRuleRequest) (*emptypb.Empty, error) {
+	if s.app.RequireChangeRequests {
+		return nil, grpcserver.ErrDirectChangesDisabled
+	}
+
+	id, err := bounceRuleID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := deleteBounceRule(s.app.DB, id, grpcserver.Precondition(req.IfMatchVersion)); err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *bounceRuleService) ListBounceRuleChanges(ctx context.Context, req *rulespb.ListChangesRequest) (*rulespb.ListBounceRuleChangesResponse, error) {
+	params, err := grpcserver.ChangeParams(req, bounceRuleChangeFilterFields)
+	if err != nil {
+		return nil, err
+	}
+
+	bounceRuleChanges, err := getBounceRuleChanges(s.app.DB, params)
+	if err != nil {
+		return nil, grpcserver.Error(err, "Bounce rule changes not found")
+	}
+
+	response := &rulespb.ListBounceRuleChangesResponse{}
+	if len(bounceRuleChanges) > params.Limit {
+		bounceRuleChanges = bounceRuleChanges[:params.Limit]
+		last := bounceRuleChanges[len(bounceRuleChanges)-1]
+		response.NextPageToken = params.Next(int(last.ID), last.UpdatedAt).Encode()
+	}
+
+	response.Changes = make([]*rulespb.BounceRuleChange, 0, len(bounceRuleChanges))
+	for _, bounceRuleChange := range bounceRuleChanges {
+		response.Changes = append(response.Changes, bounceRuleChangeToProto(bounceRuleChange))
+	}
+	return response, nil
+}
+
+func (s *bounceRuleService) ClassifyBounce(ctx context.Context, req *rulespb.ClassifyBounceRequest) (*rulespb.BounceRule, error) {
+	if req.ResponseCode < 0 || req.ResponseCode > math.MaxInt16 {
+		return nil, status.Error(codes.InvalidArgument, "Invalid response_code")
+	}
+
+	bounceRules, err := getActiveBounceRules(s.app.DB, time.Now())
+	if err != nil {
+		return nil, grpcserver.Error(err, bounceRuleNotFound)
+	}
+
+	bounceRule := classifyBounce(bounceRules, int16(req.ResponseCode), req.EnhancedCode, req.Message)
+	if bounceRule == nil {
+		return nil, status.Error(codes.NotFound, "No bounce rule matches the bounce")
+	}
+	return bounceRuleToProto(bounceRule), nil
+}
+
+func (s *bounceRuleService) WatchBounceRuleChanges(req *rulespb.WatchChangesRequest, stream rulespb.BounceRules_WatchBounceRuleChangesServer) error {
+	ctx := stream.Context()
+	changes := s.app.ChangeStream()
+
+	var cursor int
+	if req.AfterId != nil {
+		if *req.AfterId < 0 {
+			return status.Error(codes.InvalidArgument, "Invalid after_id")
+		}
+		cursor = int(*req.AfterId)
+	} else {
+		var err error
+		if cursor, err = changes.Head(ctx); err != nil {
+			return grpcserver.Error(err, "")
+		}
+	}
+
+	return changes.Follow(ctx, cursor, func(event events.Event) error {
+		return stream.Send(bounceRuleChangeToProto(event.Data.(*models.BounceRuleChange)))
+	})
+}
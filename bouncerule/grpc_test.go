@@ -0,0 +1,126 @@
+package bouncerule
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"gobrm/grpcserver"
+	"gobrm/rulespb"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dialBounceRules serves the BounceRules service of an App over an in-memory
+// connection and returns a client for it.
+func dialBounceRules(t *testing.T, a *App) rulespb.BounceRulesClient {
+	s := grpcserver.New()
+	a.RegisterGRPC(s)
+	listener := bufconn.Listen(1024 * 1024)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithInsecure(),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return rulespb.NewBounceRulesClient(conn)
+}
+
+func TestGRPCGetBounceRule(t *testing.T) {
+	log.Print("Testing GetBounceRule over gRPC")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	expiresAt := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	rows := sqlmock.NewRows(bounceRuleColumns).
+		AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, expiresAt, 3)
+	mock.ExpectQuery("select \\* from `bounce_rule` where `id`=\\?").WithArgs(int16(1)).WillReturnRows(rows)
+	mock.ExpectQuery("select \\* from `bounce_rule` where `id`=\\?").WithArgs(int16(2)).WillReturnError(sql.ErrNoRows)
+
+	client := dialBounceRules(t, &App{DB: db})
+
+	bounceRule, err := client.GetBounceRule(context.Background(), &rulespb.GetBounceRuleRequest{Id: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(450), bounceRule.ResponseCode)
+	assert.Equal(t, "suppress", bounceRule.BounceAction)
+	assert.Equal(t, int32(3), bounceRule.Version)
+	assert.Nil(t, bounceRule.EffectiveAt, "should leave a null effective_at unset")
+	assert.True(t, expiresAt.Equal(bounceRule.ExpiresAt.AsTime()))
+
+	_, err = client.GetBounceRule(context.Background(), &rulespb.GetBounceRuleRequest{Id: 2})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.GetBounceRule(context.Background(), &rulespb.GetBounceRuleRequest{Id: 40000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "should reject IDs that do not fit the primary key")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestGRPCCreateBounceRuleValidates(t *testing.T) {
+	log.Print("Testing CreateBounceRule over gRPC applies the REST validation")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	a := &App{DB: db}
+	client := dialBounceRules(t, a)
+
+	now := time.Now()
+	_, err = client.CreateBounceRule(context.Background(), &rulespb.CreateBounceRuleRequest{BounceRule: &rulespb.BounceRule{
+		ResponseCode: 550,
+		BounceAction: "suppress",
+		EffectiveAt:  timestamppb.New(now),
+		ExpiresAt:    timestamppb.New(now.Add(-time.Hour)),
+	}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "should reject a rule that expires before it takes effect")
+
+	_, err = client.CreateBounceRule(context.Background(), &rulespb.CreateBounceRuleRequest{BounceRule: &rulespb.BounceRule{Priority: 300}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "should reject a priority that does not fit the column")
+
+	a.RequireChangeRequests = true
+	_, err = client.CreateBounceRule(context.Background(), &rulespb.CreateBounceRuleRequest{BounceRule: &rulespb.BounceRule{ResponseCode: 550}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err), "should require change requests like the REST API")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestGRPCClassifyBounce(t *testing.T) {
+	log.Print("Testing ClassifyBounce over gRPC")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	rows := sqlmock.NewRows(bounceRuleColumns).
+		AddRow(1, 550, "5.1.1", "unknown user", 1, "unknown user", "suppress", nil, nil, 1).
+		AddRow(2, 0, "", ".*", 9, "catch all", "no_action", nil, nil, 1)
+	mock.ExpectQuery("SELECT (.+) FROM `bounce_rule`").WillReturnRows(rows)
+
+	client := dialBounceRules(t, &App{DB: db})
+
+	bounceRule, err := client.ClassifyBounce(context.Background(), &rulespb.ClassifyBounceRequest{
+		ResponseCode: 550,
+		EnhancedCode: "5.1.1",
+		Message:      "550 5.1.1 unknown user",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), bounceRule.Id)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
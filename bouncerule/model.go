@@ -1,10 +1,19 @@
 package bouncerule
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
-	"log"
-	"time"
+	"gobrm/batch"
+	"gobrm/changequery"
+	"gobrm/concurrency"
+	"gobrm/models"
+	"gobrm/rulequery"
+	"gobrm/webhook"
+	"strings"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
 // CREATE TABLE bounce_rule (
@@ -15,81 +24,225 @@ import (
 //   priority TINYINT NOT NULL DEFAULT 0,
 //   description VARCHAR(255) NOT NULL,
 //   bounce_action VARCHAR(255) NOT NULL,
+//   effective_at DATETIME NULL,
+//   expires_at DATETIME NULL,
+//   version INT NOT NULL DEFAULT 1,
 //   PRIMARY KEY(id)
 // );
 
-type BounceRule struct {
-	ID           int    `json:"id"`
-	ResponseCode int    `json:"response_code"`
-	EnhancedCode string `json:"enhanced_code"`
-	Regex        string `json:"regex"`
-	Priority     int    `json:"priority"`
-	Description  string `json:"description"`
-	BounceAction string `json:"bounce_action"`
+func getBounceRules(db *sql.DB) (models.BounceRuleSlice, error) {
+	ctx := context.Background()
+	bounceRules, err := models.BounceRules().All(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bounceRules, nil
 }
 
-const bounceRuleTable = "bounce_rule"
+// bounceRuleSearchFields are the columns bounce rule listings can be filtered and sorted on.
+var bounceRuleSearchFields = rulequery.Fields{
+	"response_code": rulequery.Number,
+	"enhanced_code": rulequery.Text,
+	"regex":         rulequery.Text,
+	"priority":      rulequery.Number,
+	"description":   rulequery.Text,
+	"bounce_action": rulequery.Text,
+	"version":       rulequery.Number,
+}
 
-func getBounceRules(db *sql.DB) ([]BounceRule, error) {
-	statement := fmt.Sprintf("SELECT id, response_code, enhanced_code, regex, priority, description, bounce_action FROM %s", bounceRuleTable)
-	log.Printf("Getting bounce rules with this query: %s", statement)
-	rows, err := db.Query(statement)
+// searchBounceRules returns a page of the bounce rules matching a search and
+// the number of matches. The mods narrow the search further, e.g. to active rules.
+func searchBounceRules(db *sql.DB, params rulequery.Params, defaultOrder string, mods ...qm.QueryMod) (models.BounceRuleSlice, int64, error) {
+	ctx := context.Background()
+	if condition, args := params.Where(); condition != "" {
+		mods = append(mods, qm.Where(condition, args...))
+	}
 
+	page := append([]qm.QueryMod{qm.OrderBy(params.OrderBy(defaultOrder))}, mods...)
+	if params.Limit > 0 {
+		page = append(page, qm.Limit(params.Limit), qm.Offset(params.Offset))
+	}
+
+	bounceRules, err := models.BounceRules(page...).All(ctx, db)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	defer rows.Close()
+	// A page that is neither the first nor short needs a separate count
+	total := int64(len(bounceRules))
+	if params.Limit > 0 && (params.Offset > 0 || total == int64(params.Limit)) {
+		total, err = models.BounceRules(mods...).Count(ctx, db)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
 
-	bounceRules := []BounceRule{}
+	return bounceRules, total, nil
+}
 
-	for rows.Next() {
-		var br BounceRule
-		if err := rows.Scan(&br.ID, &br.ResponseCode, &br.EnhancedCode, &br.Regex, &br.Priority, &br.Description, &br.BounceAction); err != nil {
-			return nil, err
-		}
-		bounceRules = append(bounceRules, br)
+func getBounceRule(db *sql.DB, id int16) (*models.BounceRule, error) {
+	ctx := context.Background()
+	bounceRule, err := models.FindBounceRule(ctx, db, id)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return bounceRules, nil
+	return bounceRule, nil
 }
 
-func (br *BounceRule) getBounceRule(db *sql.DB) error {
-	statement := fmt.Sprintf("SELECT id, response_code, enhanced_code, regex, priority, description, bounce_action FROM %s WHERE id=%d", bounceRuleTable, br.ID)
-	log.Printf("Getting bounce rule with this query: %s", statement)
-	return db.QueryRow(statement).Scan(&br.ID, &br.ResponseCode, &br.EnhancedCode, &br.Regex, &br.Priority, &br.Description, &br.BounceAction)
+// Rule changes are written in a transaction together with their audit row and
+// webhook outbox event so neither is kept for a change that was rolled back.
+func createBounceRule(db *sql.DB, bounceRule *models.BounceRule) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = createBounceRuleTx(ctx, tx, bounceRule)
+
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func (br *BounceRule) createBounceRule(db *sql.DB) error {
-	statement := fmt.Sprintf("INSERT INTO %s (response_code, enhanced_code, regex, priority, description, bounce_action) VALUES (%d, '%s', '%s', %d, '%s', '%s')", bounceRuleTable, br.ResponseCode, br.EnhancedCode, br.Regex, br.Priority, br.Description, br.BounceAction)
-	log.Printf("Creating bounce rule with this query: %s", statement)
-	_, err := db.Exec(statement)
+func createBounceRuleTx(ctx context.Context, tx *sql.Tx, bounceRule *models.BounceRule) error {
+	bounceRule.Version = 1
+	err := bounceRule.Insert(ctx, tx, boil.Infer())
+
+	if err != nil {
+		return err
+	}
+
+	err = insertBounceRuleChange(ctx, tx, "created", bounceRule)
 
 	if err != nil {
 		return err
 	}
 
-	err = db.QueryRow("SELECT LAST_INSERT_ID()").Scan(&br.ID)
+	return webhook.Enqueue(ctx, tx, bounceRuleEventType("created"), bounceRule)
+}
+
+// findBounceRuleForUpdate locks a bounce rule until the transaction ends so
+// its version cannot change between the check and the write.
+func findBounceRuleForUpdate(ctx context.Context, tx *sql.Tx, id int16) (*models.BounceRule, error) {
+	return models.BounceRules(models.BounceRuleWhere.ID.EQ(id), qm.For("UPDATE")).One(ctx, tx)
+}
+
+// updateBounceRule writes a bounce rule if its version satisfies the
+// precondition and bumps the version, returning the stored rule.
+func updateBounceRule(db *sql.DB, bounceRule models.BounceRule, precondition concurrency.Precondition) (*models.BounceRule, error) {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	updatedBounceRule, err := updateBounceRuleTx(ctx, tx, bounceRule, precondition)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedBounceRule, tx.Commit()
+}
+
+func updateBounceRuleTx(ctx context.Context, tx *sql.Tx, bounceRule models.BounceRule, precondition concurrency.Precondition) (*models.BounceRule, error) {
+	currentBounceRule, err := findBounceRuleForUpdate(ctx, tx, bounceRule.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := precondition.Check(currentBounceRule.Version, currentBounceRule); err != nil {
+		return nil, err
+	}
+
+	currentBounceRule.ResponseCode = bounceRule.ResponseCode
+	currentBounceRule.EnhancedCode = bounceRule.EnhancedCode
+	currentBounceRule.Regex = bounceRule.Regex
+	currentBounceRule.Priority = bounceRule.Priority
+	currentBounceRule.Description = bounceRule.Description
+	currentBounceRule.BounceAction = bounceRule.BounceAction
+	currentBounceRule.EffectiveAt = bounceRule.EffectiveAt
+	currentBounceRule.ExpiresAt = bounceRule.ExpiresAt
+	currentBounceRule.Version++
+
+	_, err = currentBounceRule.Update(ctx, tx, boil.Infer())
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertBounceRuleChange(ctx, tx, "updated", currentBounceRule)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = webhook.Enqueue(ctx, tx, bounceRuleEventType("updated"), currentBounceRule)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return currentBounceRule, nil
+}
+
+func deleteBounceRule(db *sql.DB, id int16, precondition concurrency.Precondition) error {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
 
+	_, err = deleteBounceRuleTx(ctx, tx, id, precondition)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-func (br *BounceRule) updateBounceRule(db *sql.DB) error {
-	statement := fmt.Sprintf("UPDATE %s SET response_code=%d, enhanced_code='%s', regex='%s', priority=%d, description='%s', bounce_action='%s' WHERE id=%d", bounceRuleTable, br.ResponseCode, br.EnhancedCode, br.Regex, br.Priority, br.Description, br.BounceAction, br.ID)
-	log.Printf("Updating bounce rule with this query: %s", statement)
-	_, err := db.Exec(statement)
-	return err
+func deleteBounceRuleTx(ctx context.Context, tx *sql.Tx, id int16, precondition concurrency.Precondition) (*models.BounceRule, error) {
+	bounceRule, err := findBounceRuleForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := precondition.Check(bounceRule.Version, bounceRule); err != nil {
+		return nil, err
+	}
+
+	_, err = bounceRule.Delete(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertBounceRuleChange(ctx, tx, "deleted", bounceRule)
+	if err != nil {
+		return nil, err
+	}
+
+	err = webhook.Enqueue(ctx, tx, bounceRuleEventType("deleted"), bounceRule)
+	if err != nil {
+		return nil, err
+	}
+
+	return bounceRule, nil
 }
 
-func (br *BounceRule) deleteBounceRule(db *sql.DB) error {
-	statement := fmt.Sprintf("DELETE FROM %s WHERE id=%d", bounceRuleTable, br.ID)
-	log.Printf("Deleting bounce rule with this query: %s", statement)
-	_, err := db.Exec(statement)
-	return err
+// bounceRuleEventType names webhook events after the change action, e.g. "bounce_rule.created".
+func bounceRuleEventType(action string) string {
+	return models.TableNames.BounceRule + "." + action
 }
 
 // CREATE TABLE bounce_rule_change (
@@ -102,69 +255,122 @@ func (br *BounceRule) deleteBounceRule(db *sql.DB) error {
 //   priority TINYINT NOT NULL DEFAULT 0,
 //   description VARCHAR(255) NOT NULL,
 //   bounce_action VARCHAR(255) NOT NULL,
+//   effective_at DATETIME NULL,
+//   expires_at DATETIME NULL,
 //   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   batch_id VARCHAR(36) NULL,
 //   PRIMARY KEY (id)
 // );
 
-type BounceRuleChange struct {
-	ID           int       `json:"id"`
-	Action       string    `json:"action"`
-	BounceRuleID int       `json:"bounce_rule_id"`
-	ResponseCode int       `json:"response_code"`
-	EnhancedCode string    `json:"enhanced_code"`
-	Regex        string    `json:"regex"`
-	Priority     int       `json:"priority"`
-	Description  string    `json:"description"`
-	BounceAction string    `json:"bounce_action"`
-	UpdatedAt    time.Time `json:"updated_at"`
-}
+func insertBounceRuleChange(ctx context.Context, exec boil.ContextExecutor, action string, bounceRule *models.BounceRule) error {
+	bounceRuleChange := models.BounceRuleChange{
+		Action:       action,
+		BounceRuleID: bounceRule.ID,
+		ResponseCode: bounceRule.ResponseCode,
+		EnhancedCode: bounceRule.EnhancedCode,
+		Regex:        bounceRule.Regex,
+		Priority:     bounceRule.Priority,
+		Description:  bounceRule.Description,
+		BounceAction: bounceRule.BounceAction,
+		EffectiveAt:  bounceRule.EffectiveAt,
+		ExpiresAt:    bounceRule.ExpiresAt,
+		BatchID:      batch.ID(ctx),
+	}
 
-const bounceRuleChangeTable = "bounce_rule_change"
+	return bounceRuleChange.Insert(ctx, exec, boil.Infer())
+}
 
-func getBounceRuleChanges(db *sql.DB) ([]BounceRuleChange, error) {
-	statement := fmt.Sprintf("SELECT id, action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action, updated_at FROM %s", bounceRuleChangeTable)
-	log.Printf("Getting bounce rule changes with this query: %s", statement)
-	rows, err := db.Query(statement)
+// bounceRuleChangeFilterFields are the change columns that can be filtered on by exact value.
+var bounceRuleChangeFilterFields = []string{"response_code", "enhanced_code", "regex", "priority", "description", "bounce_action", "batch_id"}
 
-	if err != nil {
-		return nil, err
+// bounceRuleChangeMods select the changes matching the parameters in page order.
+func bounceRuleChangeMods(params changequery.Params) []qm.QueryMod {
+	conditions, args := params.Where("bounce_rule_id")
+	mods := []qm.QueryMod{qm.OrderBy(params.OrderBy())}
+	if len(conditions) > 0 {
+		mods = append(mods, qm.Where(strings.Join(conditions, " AND "), args...))
 	}
+	return mods
+}
 
-	defer rows.Close()
+func getBounceRuleChanges(db *sql.DB, params changequery.Params) (models.BounceRuleChangeSlice, error) {
+	ctx := context.Background()
+	mods := append(bounceRuleChangeMods(params), qm.Limit(params.FetchLimit()))
 
-	bounceRuleChanges := []BounceRuleChange{}
+	bounceRuleChanges, err := models.BounceRuleChanges(mods...).All(ctx, db)
 
-	for rows.Next() {
-		var brc BounceRuleChange
-		if err := rows.Scan(&brc.ID, &brc.Action, &brc.BounceRuleID, &brc.ResponseCode, &brc.EnhancedCode, &brc.Regex, &brc.Priority, &brc.Description, &brc.BounceAction, &brc.UpdatedAt); err != nil {
-			return nil, err
-		}
-		bounceRuleChanges = append(bounceRuleChanges, brc)
+	if err != nil {
+		return nil, err
 	}
 
 	return bounceRuleChanges, nil
 }
 
-func getBounceRuleChangesForBounceRule(db *sql.DB, bounceRuleID int) ([]BounceRuleChange, error) {
-	statement := fmt.Sprintf("SELECT id, action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action, updated_at FROM %s WHERE bounce_rule_id = %d", bounceRuleChangeTable, bounceRuleID)
-	log.Printf("Getting bounce rule changes for bounce rule with this query: %s", statement)
-	rows, err := db.Query(statement)
+// getBounceRuleChangePageEnd returns the last change of the page when another page
+// follows it, or nil, so the next link can be sent before the page is streamed.
+func getBounceRuleChangePageEnd(db *sql.DB, params changequery.Params) (*models.BounceRuleChange, error) {
+	ctx := context.Background()
+	mods := append(bounceRuleChangeMods(params), qm.Limit(2), qm.Offset(params.Limit-1))
 
-	if err != nil {
+	bounceRuleChanges, err := models.BounceRuleChanges(mods...).All(ctx, db)
+
+	if err != nil || len(bounceRuleChanges) < 2 {
 		return nil, err
 	}
 
-	defer rows.Close()
+	return bounceRuleChanges[0], nil
+}
 
-	bounceRuleChanges := []BounceRuleChange{}
+// queryBounceRuleChanges starts reading a page of changes for eachBounceRuleChange.
+func queryBounceRuleChanges(db *sql.DB, params changequery.Params) (*sql.Rows, error) {
+	ctx := context.Background()
+	mods := append(bounceRuleChangeMods(params), qm.Limit(params.Limit))
+	return models.BounceRuleChanges(mods...).QueryContext(ctx, db)
+}
 
-	for rows.Next() {
-		var brc BounceRuleChange
-		if err := rows.Scan(&brc.ID, &brc.Action, &brc.BounceRuleID, &brc.ResponseCode, &brc.EnhancedCode, &brc.Regex, &brc.Priority, &brc.Description, &brc.BounceAction, &brc.UpdatedAt); err != nil {
-			return nil, err
+// eachBounceRuleChange calls fn with every change as it is read from rows, so
+// long change histories are never held in memory.
+func eachBounceRuleChange(rows *sql.Rows, fn func(*models.BounceRuleChange) error) error {
+	for {
+		var bounceRuleChange models.BounceRuleChange
+		err := queries.Bind(rows, &bounceRuleChange)
+		if err == sql.ErrNoRows {
+			return rows.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(&bounceRuleChange); err != nil {
+			return err
 		}
-		bounceRuleChanges = append(bounceRuleChanges, brc)
+	}
+}
+
+func getBounceRuleChangesAfter(db *sql.DB, afterID int, limit int) (models.BounceRuleChangeSlice, error) {
+	ctx := context.Background()
+	bounceRuleChanges, err := models.BounceRuleChanges(
+		qm.Where("id > ?", afterID),
+		qm.OrderBy("id"),
+		qm.Limit(limit),
+	).All(ctx, db)
+
+	if err != nil {
+		return nil, err
 	}
 
 	return bounceRuleChanges, nil
 }
+
+func getLatestBounceRuleChangeID(db *sql.DB) (int, error) {
+	ctx := context.Background()
+	bounceRuleChange, err := models.BounceRuleChanges(qm.OrderBy("id DESC")).One(ctx, db)
+
+	switch err {
+	case nil:
+		return int(bounceRuleChange.ID), nil
+	case sql.ErrNoRows:
+		return 0, nil
+	default:
+		return 0, err
+	}
+}
@@ -1,14 +1,30 @@
 package bouncerule
 
 import (
-	"fmt"
+	"database/sql"
+	"encoding/json"
+	"gobrm/apiversion"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/models"
+	"gobrm/patch"
+	"gobrm/problem"
+	"gobrm/rulequery"
 	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 )
 
+var bounceRuleColumns = []string{"id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action", "effective_at", "expires_at", "version"}
+
 func TestGetBounceRules(t *testing.T) {
 	log.Print("Testing model's getBounceRules")
 	// Open new mock database
@@ -16,18 +32,18 @@ func TestGetBounceRules(t *testing.T) {
 	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action"}).
-		AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress").
-		AddRow(2, 451, "4.7.2", "regex2", 2, "description2", "no_action")
+	rows := sqlmock.NewRows(bounceRuleColumns).
+		AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1).
+		AddRow(2, 451, "4.7.2", "regex2", 2, "description2", "no_action", nil, nil, 1)
 
-	query := fmt.Sprintf("SELECT (.+) FROM %s", bounceRuleTable)
-	mock.ExpectQuery(query).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT (.+) FROM `bounce_rule`").WillReturnRows(rows)
 
 	actualBounceRules, err := getBounceRules(db)
 
+	assert.NoError(t, err, "should not receive an error when getting bounce rules")
 	assert.Len(t, actualBounceRules, 2, "should have 2 bounce rules")
 
-	expectedBounceRules := []BounceRule{
+	expectedBounceRules := models.BounceRuleSlice{
 		{
 			ID:           1,
 			ResponseCode: 450,
@@ -36,6 +52,7 @@ func TestGetBounceRules(t *testing.T) {
 			Priority:     1,
 			Description:  "description1",
 			BounceAction: "suppress",
+			Version:      1,
 		},
 		{
 			ID:           2,
@@ -45,6 +62,7 @@ func TestGetBounceRules(t *testing.T) {
 			Priority:     2,
 			Description:  "description2",
 			BounceAction: "no_action",
+			Version:      1,
 		},
 	}
 
@@ -54,18 +72,105 @@ func TestGetBounceRules(t *testing.T) {
 	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
 }
 
+func TestSearchBounceRules(t *testing.T) {
+	log.Print("Testing model's searchBounceRules filters, pages and counts matches")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	params, err := rulequery.Parse(url.Values{
+		"q":      {"response_code>=500 AND bounce_action=suppress"},
+		"sort":   {"-priority"},
+		"limit":  {"1"},
+		"offset": {"1"},
+	}, bounceRuleSearchFields)
+	assert.NoError(t, err)
+
+	rows := sqlmock.NewRows(bounceRuleColumns).
+		AddRow(2, 550, "5.1.1", "regex2", 2, "description2", "suppress", nil, nil, 1)
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` WHERE \\(\\(response_code >= \\? AND bounce_action = \\?\\)\\) ORDER BY priority DESC, id LIMIT 1 OFFSET 1").
+		WithArgs(int64(500), "suppress").
+		WillReturnRows(rows)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM `bounce_rule` WHERE \\(\\(response_code >= \\? AND bounce_action = \\?\\)\\)").
+		WithArgs(int64(500), "suppress").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	bounceRules, total, err := searchBounceRules(db, params, "id")
+
+	assert.NoError(t, err)
+	assert.Len(t, bounceRules, 1)
+	assert.Equal(t, int16(2), bounceRules[0].ID)
+	assert.Equal(t, int64(3), total)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestBounceRuleListHash(t *testing.T) {
+	log.Print("Testing listings are tagged by their rules' IDs and versions")
+	bounceRules := models.BounceRuleSlice{{ID: 1, Version: 1}, {ID: 2, Version: 3}}
+	hash := bounceRuleListHash(bounceRules, 2)
+
+	assert.Equal(t, hash, bounceRuleListHash(models.BounceRuleSlice{{ID: 1, Version: 1, Regex: "unread"}, {ID: 2, Version: 3}}, 2))
+	assert.NotEqual(t, hash, bounceRuleListHash(models.BounceRuleSlice{{ID: 1, Version: 2}, {ID: 2, Version: 3}}, 2), "should change when a rule does")
+	assert.NotEqual(t, hash, bounceRuleListHash(models.BounceRuleSlice{{ID: 1, Version: 1}, {ID: 3, Version: 3}}, 2), "should change when a rule is replaced")
+	assert.NotEqual(t, hash, bounceRuleListHash(bounceRules, 3), "should change when the total does")
+}
+
+func TestGetBounceRuleChangesStreamsRows(t *testing.T) {
+	log.Print("Testing bounce rule changes stream in the accepted format and link to the next page")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	columns := []string{"id", "action", "bounce_rule_id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action", "effective_at", "expires_at", "updated_at", "batch_id"}
+	updatedAt := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule_change` WHERE \\(bounce_action = \\?\\) ORDER BY id ASC LIMIT 2 OFFSET 1").
+		WithArgs("suppress").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(2, "updated", 1, 550, "5.1.1", "regex", 1, "description", "suppress", nil, nil, updatedAt, nil).
+			AddRow(3, "deleted", 1, 550, "5.1.1", "regex", 1, "description", "suppress", nil, nil, updatedAt, nil))
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule_change` WHERE \\(bounce_action = \\?\\) ORDER BY id ASC LIMIT 2").
+		WithArgs("suppress").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "created", 1, 550, "5.1.1", "regex", 1, "description", "suppress", nil, nil, updatedAt, "batch-1").
+			AddRow(2, "updated", 1, 550, "5.1.1", "regex", 1, "description", "suppress", nil, nil, updatedAt, nil))
+
+	a := &App{DB: db}
+	req := httptest.NewRequest("GET", "/bounce_rule_changes?bounce_action=suppress&limit=2", nil)
+	req.Header.Set("Accept", "application/x-ndjson")
+	rr := httptest.NewRecorder()
+	a.getBounceRuleChanges(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Header().Get("Link"), `rel="next"`)
+	lines := strings.Split(strings.TrimSuffix(rr.Body.String(), "\n"), "\n")
+	assert.Len(t, lines, 2, "should write one change per line")
+	assert.Contains(t, lines[0], `"batch_id":"batch-1"`)
+	assert.Contains(t, lines[1], `"action":"updated"`)
+
+	req.Header.Set("Accept", "application/xml")
+	rr = httptest.NewRecorder()
+	a.getBounceRuleChanges(rr, req)
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
 func TestGetBounceRule(t *testing.T) {
 	log.Print("Testing model's getBounceRule")
 	db, mock, err := sqlmock.New()
 	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
 	defer db.Close()
 
-	rows := sqlmock.NewRows([]string{"id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action"}).
-		AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress")
+	rows := sqlmock.NewRows(bounceRuleColumns).
+		AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1)
 
-	id := 1
-	mock.ExpectQuery("SELECT").WillReturnRows(rows)
-	expectedBounceRule := BounceRule{
+	var id int16 = 1
+	mock.ExpectQuery("select \\* from `bounce_rule` where `id`=\\?").WithArgs(id).WillReturnRows(rows)
+	expectedBounceRule := &models.BounceRule{
 		ID:           id,
 		ResponseCode: 450,
 		EnhancedCode: "4.7.1",
@@ -73,9 +178,9 @@ func TestGetBounceRule(t *testing.T) {
 		Priority:     1,
 		Description:  "description1",
 		BounceAction: "suppress",
+		Version:      1,
 	}
-	bounceRule := BounceRule{ID: id}
-	bounceRuleErr := bounceRule.getBounceRule(db)
+	bounceRule, bounceRuleErr := getBounceRule(db, id)
 
 	assert.NoError(t, bounceRuleErr, "should not receive an error when getting bounce rule")
 	assert.Equalf(t, expectedBounceRule, bounceRule, "bounce rule does not match %v", expectedBounceRule)
@@ -84,7 +189,54 @@ func TestGetBounceRule(t *testing.T) {
 	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
 }
 
-// TODO: BOUNCE RULE NOT FOUND ERROR
+func TestGetBounceRuleNotFound(t *testing.T) {
+	log.Print("Testing model's getBounceRule when the bounce rule does not exist")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectQuery("select \\* from `bounce_rule`").WillReturnRows(sqlmock.NewRows(bounceRuleColumns))
+
+	_, bounceRuleErr := getBounceRule(db, 1)
+
+	assert.Equal(t, sql.ErrNoRows, bounceRuleErr, "should receive sql.ErrNoRows for a missing bounce rule")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestBounceRuleErrorsByVersion(t *testing.T) {
+	log.Print("Testing bounce rule errors keep the legacy body on v1 and are problems on v2")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	a := &App{DB: db}
+	get := func(version string) *httptest.ResponseRecorder {
+		mock.ExpectQuery("select \\* from `bounce_rule`").WillReturnRows(sqlmock.NewRows(bounceRuleColumns))
+		req := httptest.NewRequest("GET", "/"+version+"/bounce_rules/1", nil)
+		req = mux.SetURLVars(req.WithContext(apiversion.NewContext(req.Context(), version)), map[string]string{"id": "1"})
+		rr := httptest.NewRecorder()
+		a.getBounceRule(rr, req)
+		return rr
+	}
+
+	rr := get(apiversion.V1)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"Bounce rule not found"}`, rr.Body.String())
+
+	rr = get(apiversion.V2)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, problem.ContentType, rr.Header().Get("Content-Type"))
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "urn:gobrm:problem:not-found", body["type"])
+	assert.Equal(t, "Bounce rule not found", body["detail"])
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
 
 func TestCreateBounceRule(t *testing.T) {
 	log.Print("Testing model's createBounceRule")
@@ -92,43 +244,206 @@ func TestCreateBounceRule(t *testing.T) {
 	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
 	defer db.Close()
 
-	mock.ExpectExec("INSERT INTO").WillReturnResult(sqlmock.NewResult(1, 1))
-	rows := sqlmock.NewRows([]string{"id"}).AddRow(1)
-	mock.ExpectQuery("SELECT").WillReturnRows(rows)
-
-	expectedBounceRule := BounceRule{
-		ID:           1,
+	bounceRule := models.BounceRule{
 		ResponseCode: 450,
 		EnhancedCode: "4.7.1",
-		Regex:        "regex1",
+		Regex:        "it's a regex",
 		Description:  "description1",
 		Priority:     1,
 		BounceAction: "suppress",
 	}
-	bounceRule := BounceRule{
-		ResponseCode: expectedBounceRule.ResponseCode,
-		EnhancedCode: expectedBounceRule.EnhancedCode,
-		Regex:        expectedBounceRule.Regex,
-		Description:  expectedBounceRule.Description,
-		Priority:     expectedBounceRule.Priority,
-		BounceAction: expectedBounceRule.BounceAction,
-	}
 
-	bounceRuleErr := bounceRule.createBounceRule(db)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO `bounce_rule`").
+		WithArgs(bounceRule.ResponseCode, bounceRule.EnhancedCode, bounceRule.Regex, bounceRule.Priority, bounceRule.Description, bounceRule.BounceAction, bounceRule.EffectiveAt, bounceRule.ExpiresAt, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO `bounce_rule_change`").
+		WithArgs("created", 1, bounceRule.ResponseCode, bounceRule.EnhancedCode, bounceRule.Regex, bounceRule.Priority, bounceRule.Description, bounceRule.BounceAction, bounceRule.EffectiveAt, bounceRule.ExpiresAt, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_outbox").
+		WithArgs("bounce_rule.created", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	bounceRuleErr := createBounceRule(db, &bounceRule)
 	assert.NoError(t, bounceRuleErr, "should not receive an error when creating bounce rule")
-	assert.Equalf(t, expectedBounceRule, bounceRule, "bounce rule does not match %v", expectedBounceRule)
+	assert.Equal(t, int16(1), bounceRule.ID, "should set the ID of the created bounce rule")
 
 	mockErr := mock.ExpectationsWereMet()
 	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
-
 }
 
 func TestUpdateBounceRule(t *testing.T) {
 	log.Print("Testing model's updateBounceRule")
-	assert.True(t, true, "True is true!")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	bounceRule := models.BounceRule{
+		ID:           1,
+		ResponseCode: 451,
+		EnhancedCode: "4.7.2",
+		Regex:        "regex2",
+		Description:  "description2",
+		Priority:     2,
+		BounceAction: "retry",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` WHERE \\(`bounce_rule`.`id` = \\?\\) LIMIT 1 FOR UPDATE").WithArgs(bounceRule.ID).
+		WillReturnRows(sqlmock.NewRows(bounceRuleColumns).AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1))
+	mock.ExpectExec("UPDATE `bounce_rule` SET").
+		WithArgs(bounceRule.ResponseCode, bounceRule.EnhancedCode, bounceRule.Regex, bounceRule.Priority, bounceRule.Description, bounceRule.BounceAction, bounceRule.EffectiveAt, bounceRule.ExpiresAt, 2, bounceRule.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `bounce_rule_change`").
+		WithArgs("updated", bounceRule.ID, bounceRule.ResponseCode, bounceRule.EnhancedCode, bounceRule.Regex, bounceRule.Priority, bounceRule.Description, bounceRule.BounceAction, bounceRule.EffectiveAt, bounceRule.ExpiresAt, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO webhook_outbox").
+		WithArgs("bounce_rule.updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	updatedBounceRule, bounceRuleErr := updateBounceRule(db, bounceRule, concurrency.Precondition{1})
+	assert.NoError(t, bounceRuleErr, "should not receive an error when updating bounce rule")
+	assert.Equal(t, 2, updatedBounceRule.Version, "should bump the bounce rule version")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestUpdateBounceRuleNotFound(t *testing.T) {
+	log.Print("Testing model's updateBounceRule when the bounce rule does not exist")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` WHERE (.+) FOR UPDATE").WillReturnRows(sqlmock.NewRows(bounceRuleColumns))
+	mock.ExpectRollback()
+
+	_, bounceRuleErr := updateBounceRule(db, models.BounceRule{ID: 1, EnhancedCode: "4.7.1"}, nil)
+	assert.Equal(t, sql.ErrNoRows, bounceRuleErr, "should receive sql.ErrNoRows for a missing bounce rule")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
 }
 
 func TestDeleteBounceRule(t *testing.T) {
 	log.Print("Testing model's deleteBounceRule")
-	assert.True(t, true, "True is true!")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` WHERE \\(`bounce_rule`.`id` = \\?\\) LIMIT 1 FOR UPDATE").WithArgs(int16(1)).
+		WillReturnRows(sqlmock.NewRows(bounceRuleColumns).AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1))
+	mock.ExpectExec("DELETE FROM `bounce_rule` WHERE `id`=\\?").WithArgs(int16(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `bounce_rule_change`").
+		WithArgs("deleted", 1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectExec("INSERT INTO webhook_outbox").
+		WithArgs("bounce_rule.deleted", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	mock.ExpectCommit()
+
+	bounceRuleErr := deleteBounceRule(db, 1, nil)
+	assert.NoError(t, bounceRuleErr, "should not receive an error when deleting bounce rule")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestUpdateBounceRuleVersionConflict(t *testing.T) {
+	log.Print("Testing model's updateBounceRule when If-Match names an old version")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` WHERE (.+) FOR UPDATE").WithArgs(int16(1)).
+		WillReturnRows(sqlmock.NewRows(bounceRuleColumns).AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 3))
+	mock.ExpectRollback()
+
+	_, bounceRuleErr := updateBounceRule(db, models.BounceRule{ID: 1, EnhancedCode: "4.7.2"}, concurrency.Precondition{2})
+	conflict, ok := bounceRuleErr.(concurrency.ConflictError)
+	assert.True(t, ok, "should receive a version conflict")
+	assert.Equal(t, 3, conflict.CurrentVersion, "should carry the current version")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestPatchBounceRule(t *testing.T) {
+	log.Print("Testing model's patchBounceRule with a merge patch")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	lockQuery := "SELECT \\* FROM `bounce_rule` WHERE \\(`bounce_rule`.`id` = \\?\\) LIMIT 1 FOR UPDATE"
+	currentRow := sqlmock.NewRows(bounceRuleColumns).AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(lockQuery).WithArgs(int16(1)).WillReturnRows(currentRow)
+	mock.ExpectQuery(lockQuery).WithArgs(int16(1)).
+		WillReturnRows(sqlmock.NewRows(bounceRuleColumns).AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1))
+	mock.ExpectExec("UPDATE `bounce_rule` SET").
+		WithArgs(450, "4.7.1", "regex1", 5, "description1", "retry", nil, nil, 2, int16(1)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO `bounce_rule_change`").
+		WithArgs("updated", 1, 450, "4.7.1", "regex1", 5, "description1", "retry", nil, nil, sqlmock.AnyArg(), nil).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec("INSERT INTO webhook_outbox").
+		WithArgs("bounce_rule.updated", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	patchedBounceRule, bounceRuleErr := patchBounceRule(db, 1, patch.MergePatch, []byte(`{"priority":5,"bounce_action":"retry"}`), concurrency.Precondition{1})
+	assert.NoError(t, bounceRuleErr, "should not receive an error when patching bounce rule")
+	assert.Equal(t, int8(5), patchedBounceRule.Priority)
+	assert.Equal(t, "retry", patchedBounceRule.BounceAction)
+	assert.Equal(t, 2, patchedBounceRule.Version, "should bump the bounce rule version")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestPatchBounceRuleRemovesRequiredField(t *testing.T) {
+	log.Print("Testing model's patchBounceRule rejects a patch removing a required field")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT \\* FROM `bounce_rule` WHERE (.+) FOR UPDATE").
+		WillReturnRows(sqlmock.NewRows(bounceRuleColumns).AddRow(1, 450, "4.7.1", "regex1", 1, "description1", "suppress", nil, nil, 1))
+	mock.ExpectRollback()
+
+	_, bounceRuleErr := patchBounceRule(db, 1, patch.JSONPatch, []byte(`[{"op":"remove","path":"/priority"}]`), nil)
+	assert.Equal(t, changerequest.ValidationError{
+		Message: "Missing required fields: priority",
+		Fields:  []problem.FieldError{{Field: "priority", Message: "is required"}},
+	}, bounceRuleErr)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestDecodeBounceRuleRequiresFullPayload(t *testing.T) {
+	log.Print("Testing full bounce rule payloads must name every required field")
+	_, err := decodeBounceRule([]byte(`{"response_code":450,"enhanced_code":"4.7.1","regex":"regex1","description":"description1"}`))
+	assert.Equal(t, changerequest.ValidationError{
+		Message: "Missing required fields: priority, bounce_action",
+		Fields:  []problem.FieldError{{Field: "priority", Message: "is required"}, {Field: "bounce_action", Message: "is required"}},
+	}, err)
+
+	_, err = decodeBounceRule([]byte(`{"response_code":450,"enhanced_code":"4.7.1","regex":"regex1","priority":1,"description":"description1","bounce_action":"suppress","extra":true}`))
+	assert.Equal(t, changerequest.ValidationError{
+		Message: "Invalid bounce rule request payload",
+		Fields:  []problem.FieldError{{Field: "extra", Message: "is not a known field"}},
+	}, err, "should reject unknown fields")
+
+	bounceRule, err := decodeBounceRule([]byte(`{"response_code":450,"enhanced_code":"4.7.1","regex":"regex1","priority":0,"description":"description1","bounce_action":"suppress"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, int8(0), bounceRule.Priority, "should allow an explicit zero")
 }
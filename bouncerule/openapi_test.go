@@ -0,0 +1,29 @@
+package bouncerule
+
+import (
+	"gobrm/openapi"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	log.Print("Testing every bounce rule route is in the OpenAPI document")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	a := App{}
+	a.InitializeWithDB(db)
+	a.initializeSharedRoutes()
+	a.initializeOpenAPI()
+
+	routes, err := openapi.GorillaRoutes(a.Router)
+	assert.NoError(t, err)
+
+	doc, err := openapi.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, openapi.Missing(doc, routes), "add these routes to openapi/openapi.yaml")
+}
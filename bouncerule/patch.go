@@ -0,0 +1,96 @@
+package bouncerule
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/models"
+	"gobrm/patch"
+	"gobrm/problem"
+	"gobrm/schedule"
+	"strings"
+)
+
+// bounceRuleRequiredFields must be present in every full bounce rule payload,
+// otherwise a left out priority would silently become 0.
+var bounceRuleRequiredFields = []string{"response_code", "enhanced_code", "regex", "priority", "description", "bounce_action"}
+
+// decodeBounceRule decodes and validates a full bounce rule payload, as sent
+// to POST and PUT, proposed in a change request or produced by a PATCH.
+func decodeBounceRule(body []byte) (models.BounceRule, error) {
+	var bounceRule models.BounceRule
+
+	missing, err := patch.MissingFields(body, bounceRuleRequiredFields)
+	if err != nil {
+		return bounceRule, changerequest.ValidationError{Message: "Invalid bounce rule request payload"}
+	}
+	if len(missing) > 0 {
+		fields := make([]problem.FieldError, 0, len(missing))
+		for _, field := range missing {
+			fields = append(fields, problem.FieldError{Field: field, Message: "is required"})
+		}
+		return bounceRule, changerequest.ValidationError{Message: "Missing required fields: " + strings.Join(missing, ", "), Fields: fields}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&bounceRule); err != nil {
+		return bounceRule, changerequest.ValidationError{Message: "Invalid bounce rule request payload", Fields: problem.DecodeErrors(err)}
+	}
+
+	return bounceRule, validateBounceRule(bounceRule)
+}
+
+// validateBounceRule checks a decoded bounce rule, whichever API it came from.
+func validateBounceRule(bounceRule models.BounceRule) error {
+	if err := schedule.ValidateWindow(bounceRule.EffectiveAt, bounceRule.ExpiresAt); err != nil {
+		return changerequest.ValidationError{Message: err.Error(), Fields: []problem.FieldError{schedule.ErrInvalidWindow}}
+	}
+	return nil
+}
+
+// patchBounceRule applies a merge patch or JSON patch to a bounce rule and
+// writes the result like a full update.
+func patchBounceRule(db *sql.DB, id int16, contentType string, patchDocument []byte, precondition concurrency.Precondition) (*models.BounceRule, error) {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	currentBounceRule, err := findBounceRuleForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := precondition.Check(currentBounceRule.Version, currentBounceRule); err != nil {
+		return nil, err
+	}
+
+	document, err := json.Marshal(currentBounceRule)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := patch.Apply(contentType, document, patchDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	bounceRule, err := decodeBounceRule(patched)
+	if err != nil {
+		return nil, err
+	}
+
+	bounceRule.ID = id
+	updatedBounceRule, err := updateBounceRuleTx(ctx, tx, bounceRule, precondition)
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedBounceRule, tx.Commit()
+}
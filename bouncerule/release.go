@@ -0,0 +1,38 @@
+package bouncerule
+
+import (
+	"context"
+	"database/sql"
+	"gobrm/models"
+	"gobrm/release"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// snapshotBounceRules reads every bounce rule and the bounce rule changes made after afterChangeID.
+func snapshotBounceRules(ctx context.Context, tx *sql.Tx, afterChangeID int) (release.Snapshot, error) {
+	bounceRules, err := models.BounceRules(qm.OrderBy("id")).All(ctx, tx)
+	if err != nil {
+		return release.Snapshot{}, err
+	}
+
+	bounceRuleChanges, err := models.BounceRuleChanges(
+		qm.Select("id"),
+		qm.Where("id > ?", afterChangeID),
+		qm.OrderBy("id"),
+	).All(ctx, tx)
+	if err != nil {
+		return release.Snapshot{}, err
+	}
+
+	changeIDs := make([]int, 0, len(bounceRuleChanges))
+	for _, bounceRuleChange := range bounceRuleChanges {
+		changeIDs = append(changeIDs, int(bounceRuleChange.ID))
+	}
+
+	if bounceRules == nil {
+		bounceRules = models.BounceRuleSlice{}
+	}
+
+	return release.Snapshot{Rules: bounceRules, ChangeIDs: changeIDs}, nil
+}
@@ -0,0 +1,79 @@
+package bouncerule
+
+import (
+	"context"
+	"database/sql"
+	"gobrm/models"
+	"gobrm/schedule"
+	"gobrm/webhook"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// bounceRuleTransitions lists the bounce rules taking effect or expiring in (from, to].
+func bounceRuleTransitions(ctx context.Context, exec boil.ContextExecutor, from, to time.Time) ([]schedule.Transition, error) {
+	bounceRules, err := models.BounceRules(schedule.InWindow(from, to), qm.OrderBy("id")).All(ctx, exec)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := []schedule.Transition{}
+	for _, bounceRule := range bounceRules {
+		transitions = append(transitions, schedule.Transitions(int(bounceRule.ID), bounceRule, bounceRule.EffectiveAt, bounceRule.ExpiresAt, from, to)...)
+	}
+	schedule.Sort(transitions)
+
+	return transitions, nil
+}
+
+// recordBounceRuleTransition writes the activated or expired change and webhook event for a bounce rule.
+func recordBounceRuleTransition(ctx context.Context, tx *sql.Tx, t schedule.Transition) error {
+	bounceRule := t.Rule.(*models.BounceRule)
+
+	if err := insertBounceRuleChange(ctx, tx, t.Action, bounceRule); err != nil {
+		return err
+	}
+
+	return webhook.Enqueue(ctx, tx, bounceRuleEventType(t.Action), bounceRule)
+}
+
+// getActiveBounceRules reads the bounce rules that apply at now in the order they are matched.
+func getActiveBounceRules(db *sql.DB, now time.Time) (models.BounceRuleSlice, error) {
+	ctx := context.Background()
+	bounceRules, err := models.BounceRules(schedule.ActiveAt(now), qm.OrderBy("priority, id")).All(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return bounceRules, nil
+}
+
+// classifyBounce returns the first bounce rule matching a bounce, or nil.
+// Rules are tried in priority order, lowest first. A zero response code or
+// empty enhanced code on a rule matches any bounce.
+func classifyBounce(bounceRules models.BounceRuleSlice, responseCode int16, enhancedCode string, message string) *models.BounceRule {
+	for _, bounceRule := range bounceRules {
+		if bounceRule.ResponseCode != 0 && bounceRule.ResponseCode != responseCode {
+			continue
+		}
+		if bounceRule.EnhancedCode != "" && bounceRule.EnhancedCode != enhancedCode {
+			continue
+		}
+
+		regex, err := regexp.Compile(bounceRule.Regex)
+		if err != nil {
+			log.Printf("Skipping bounce rule %d with invalid regex: %s", bounceRule.ID, err)
+			continue
+		}
+		if regex.MatchString(message) {
+			return bounceRule
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package bouncerule
+
+import (
+	"gobrm/models"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyBounce(t *testing.T) {
+	log.Print("Testing bounces are classified by the first matching rule")
+	bounceRules := models.BounceRuleSlice{
+		{ID: 1, ResponseCode: 450, EnhancedCode: "4.7.0", Regex: "try again later", Priority: 0, BounceAction: "retry"},
+		{ID: 2, ResponseCode: 450, EnhancedCode: "", Regex: ".*", Priority: 1, BounceAction: "no_action"},
+		{ID: 3, ResponseCode: 0, EnhancedCode: "5.1.1", Regex: "(", Priority: 2, BounceAction: "suppress"},
+		{ID: 4, ResponseCode: 0, EnhancedCode: "5.1.1", Regex: "unknown user", Priority: 3, BounceAction: "suppress"},
+	}
+
+	assert.Equal(t, int16(1), classifyBounce(bounceRules, 450, "4.7.0", "please try again later").ID)
+	assert.Equal(t, int16(2), classifyBounce(bounceRules, 450, "4.7.1", "rate limited").ID, "an empty enhanced code should match any")
+	assert.Equal(t, int16(4), classifyBounce(bounceRules, 550, "5.1.1", "unknown user").ID, "should skip rules with invalid regexes")
+	assert.Nil(t, classifyBounce(bounceRules, 421, "4.4.2", "timeout"))
+}
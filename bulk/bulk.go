@@ -0,0 +1,278 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gobrm/problem"
+
+	"github.com/volatiletech/null/v8"
+	"gopkg.in/yaml.v2"
+)
+
+// Format is a rule file format accepted by imports and exports, and a
+// response format of every list.
+type Format string
+
+// Supported formats, selected with ?format= or the Content-Type and Accept
+// headers. NDJSON writes one JSON object per line.
+const (
+	JSON   Format = "json"
+	NDJSON Format = "ndjson"
+	YAML   Format = "yaml"
+	CSV    Format = "csv"
+)
+
+var contentTypes = map[Format]string{
+	JSON:   "application/json",
+	NDJSON: "application/x-ndjson",
+	YAML:   "application/yaml",
+	CSV:    "text/csv",
+}
+
+var mediaTypeFormats = map[string]Format{
+	"application/json":     JSON,
+	"application/x-ndjson": NDJSON,
+	"application/ndjson":   NDJSON,
+	"application/yaml":     YAML,
+	"application/x-yaml":   YAML,
+	"text/yaml":            YAML,
+	"text/csv":             CSV,
+}
+
+// ErrUnsupportedFormat is returned for a format other than JSON, NDJSON, YAML or CSV.
+var ErrUnsupportedFormat = errors.New("format must be one of json, ndjson, yaml or csv")
+
+// ContentType is the media type a format is sent as.
+func ContentType(format Format) string {
+	return contentTypes[format]
+}
+
+// Column is a rule field written as a CSV column. Numeric columns are
+// imported as JSON numbers, all others as strings.
+type Column struct {
+	Name    string
+	Numeric bool
+}
+
+// ImportError is returned for an import file that cannot be read, or a record
+// in it that is not a valid rule. Record counts from 1.
+type ImportError struct {
+	Record  int
+	Message string
+}
+
+func (e ImportError) Error() string {
+	if e.Record == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("record %d: %s", e.Record, e.Message)
+}
+
+// FieldErrors names the invalid record, if any, in a validation problem.
+func (e ImportError) FieldErrors() []problem.FieldError {
+	if e.Record == 0 {
+		return nil
+	}
+	return []problem.FieldError{{Field: fmt.Sprintf("record %d", e.Record), Message: e.Message}}
+}
+
+func parseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case JSON, NDJSON, YAML, CSV:
+		return format, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// RequestFormat picks the format of an import body from ?format= or the
+// Content-Type header, defaulting to JSON.
+func RequestFormat(r *http.Request) (Format, error) {
+	if value := r.URL.Query().Get("format"); value != "" {
+		return parseFormat(value)
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return JSON, nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", ErrUnsupportedFormat
+	}
+	if format, ok := mediaTypeFormats[mediaType]; ok {
+		return format, nil
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// ResponseFormat picks the format of an export or list from ?format= or the first
+// supported media type in the Accept header, defaulting to JSON.
+func ResponseFormat(r *http.Request) (Format, error) {
+	if value := r.URL.Query().Get("format"); value != "" {
+		return parseFormat(value)
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return JSON, nil
+	}
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if format, ok := mediaTypeFormats[mediaType]; ok {
+			return format, nil
+		}
+		if mediaType == "*/*" || mediaType == "application/*" {
+			return JSON, nil
+		}
+	}
+	return "", ErrUnsupportedFormat
+}
+
+// Decode splits an import body into one JSON object per rule, so every format
+// goes through the same validation as a single rule payload.
+func Decode(format Format, body []byte, columns []Column) ([]json.RawMessage, error) {
+	switch format {
+	case JSON:
+		var records []json.RawMessage
+		if err := json.Unmarshal(body, &records); err != nil {
+			return nil, ImportError{Message: "Import body must be a JSON array of rules"}
+		}
+		return records, nil
+	case NDJSON:
+		return decodeNDJSON(body)
+	case YAML:
+		return decodeYAML(body)
+	case CSV:
+		return decodeCSV(body, columns)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func decodeNDJSON(body []byte) ([]json.RawMessage, error) {
+	records := []json.RawMessage{}
+	for i, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		if !json.Valid(line) || line[0] != '{' {
+			return nil, ImportError{Record: i + 1, Message: "rule must be a JSON object on one line"}
+		}
+		records = append(records, json.RawMessage(line))
+	}
+	return records, nil
+}
+
+func decodeYAML(body []byte) ([]json.RawMessage, error) {
+	var documents []interface{}
+	if err := yaml.Unmarshal(body, &documents); err != nil {
+		return nil, ImportError{Message: "Import body must be a YAML list of rules"}
+	}
+
+	records := make([]json.RawMessage, len(documents))
+	for i, document := range documents {
+		record, err := json.Marshal(jsonValue(document))
+		if err != nil {
+			return nil, ImportError{Record: i + 1, Message: "rule is not representable as JSON"}
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// jsonValue converts the map[interface{}]interface{} maps the YAML decoder
+// produces into maps encoding/json can marshal.
+func jsonValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		object := make(map[string]interface{}, len(value))
+		for key, field := range value {
+			object[fmt.Sprint(key)] = jsonValue(field)
+		}
+		return object
+	case []interface{}:
+		for i, item := range value {
+			value[i] = jsonValue(item)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+func decodeCSV(body []byte, columns []Column) ([]json.RawMessage, error) {
+	rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		return nil, ImportError{Message: "Import body is not valid CSV: " + err.Error()}
+	}
+	if len(rows) == 0 {
+		return nil, ImportError{Message: "CSV import needs a header row"}
+	}
+
+	numeric := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		numeric[column.Name] = column.Numeric
+	}
+
+	header := rows[0]
+	records := make([]json.RawMessage, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		object := make(map[string]interface{}, len(header))
+		for j, name := range header {
+			// Empty cells leave the field out, so required fields are reported missing
+			if row[j] == "" {
+				continue
+			}
+			if !numeric[name] {
+				object[name] = row[j]
+				continue
+			}
+			if _, err := strconv.ParseFloat(row[j], 64); err != nil {
+				return nil, ImportError{Record: i + 1, Message: fmt.Sprintf("%s must be a number", name)}
+			}
+			object[name] = json.Number(row[j])
+		}
+
+		record, err := json.Marshal(object)
+		if err != nil {
+			return nil, ImportError{Record: i + 1, Message: err.Error()}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Write sends rules as an attachment named after the rule type, with fields
+// in column order for YAML and CSV.
+func Write(w http.ResponseWriter, format Format, name string, rules interface{}, columns []Column) error {
+	encoder, err := NewEncoder(w, format, columns)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+	w.WriteHeader(http.StatusOK)
+	return encoder.EncodeAll(rules)
+}
+
+// SameTime reports whether two optional times are both unset or equal.
+func SameTime(a, b null.Time) bool {
+	if a.Valid != b.Valid {
+		return false
+	}
+	return !a.Valid || a.Time.Equal(b.Time)
+}
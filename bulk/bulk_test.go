@@ -0,0 +1,201 @@
+package bulk
+
+import (
+	"bytes"
+	"log"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+var columns = []Column{
+	{Name: "id", Numeric: true},
+	{Name: "mx_domain"},
+	{Name: "max_connections", Numeric: true},
+	{Name: "expires_at"},
+}
+
+type rule struct {
+	ID             int       `json:"id"`
+	MXDomain       string    `json:"mx_domain"`
+	MaxConnections int       `json:"max_connections"`
+	ExpiresAt      null.Time `json:"expires_at,omitempty"`
+}
+
+func TestRequestFormat(t *testing.T) {
+	log.Print("Testing import formats come from ?format= or Content-Type")
+	req := httptest.NewRequest("POST", "/throughput_rules/import", nil)
+	format, err := RequestFormat(req)
+	assert.NoError(t, err)
+	assert.Equal(t, JSON, format, "should default to JSON")
+
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	format, err = RequestFormat(req)
+	assert.NoError(t, err)
+	assert.Equal(t, CSV, format)
+
+	req = httptest.NewRequest("POST", "/throughput_rules/import?format=YAML", nil)
+	req.Header.Set("Content-Type", "text/csv")
+	format, err = RequestFormat(req)
+	assert.NoError(t, err)
+	assert.Equal(t, YAML, format, "should prefer the query parameter")
+
+	req.Header.Set("Content-Type", "application/xml")
+	req.URL.RawQuery = ""
+	_, err = RequestFormat(req)
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestResponseFormat(t *testing.T) {
+	log.Print("Testing export formats come from ?format= or Accept")
+	req := httptest.NewRequest("GET", "/throughput_rules/export", nil)
+	req.Header.Set("Accept", "application/xml, application/yaml;q=0.9")
+	format, err := ResponseFormat(req)
+	assert.NoError(t, err)
+	assert.Equal(t, YAML, format)
+
+	req.Header.Set("Accept", "*/*")
+	format, err = ResponseFormat(req)
+	assert.NoError(t, err)
+	assert.Equal(t, JSON, format)
+
+	req.Header.Set("Accept", "application/xml")
+	_, err = ResponseFormat(req)
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestDecode(t *testing.T) {
+	log.Print("Testing every format decodes into JSON rule objects")
+	records, err := Decode(JSON, []byte(`[{"mx_domain":"gmail.com"}]`), columns)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"mx_domain":"gmail.com"}`, string(records[0]))
+
+	records, err = Decode(YAML, []byte("- mx_domain: gmail.com\n  max_connections: 10\n  expires_at: 2021-06-01T00:00:00Z\n"), columns)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"mx_domain":"gmail.com","max_connections":10,"expires_at":"2021-06-01T00:00:00Z"}`, string(records[0]))
+
+	records, err = Decode(CSV, []byte("mx_domain,max_connections,expires_at\ngmail.com,10,\n007.com,20,2021-06-01T00:00:00Z\n"), columns)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.JSONEq(t, `{"mx_domain":"gmail.com","max_connections":10}`, string(records[0]), "should leave empty cells out")
+	assert.JSONEq(t, `{"mx_domain":"007.com","max_connections":20,"expires_at":"2021-06-01T00:00:00Z"}`, string(records[1]), "should keep text columns as strings")
+
+	_, err = Decode(CSV, []byte("mx_domain,max_connections\ngmail.com,ten\n"), columns)
+	assert.Equal(t, ImportError{Record: 1, Message: "max_connections must be a number"}, err)
+
+	_, err = Decode(JSON, []byte(`{"mx_domain":"gmail.com"}`), columns)
+	assert.IsType(t, ImportError{}, err, "should require a list of rules")
+}
+
+func TestWrite(t *testing.T) {
+	log.Print("Testing exports write fields in column order")
+	rules := []rule{
+		{ID: 1, MXDomain: "gmail.com", MaxConnections: 10},
+		{ID: 2, MXDomain: "yahoo.com", MaxConnections: 20, ExpiresAt: null.TimeFrom(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))},
+	}
+
+	recorder := httptest.NewRecorder()
+	assert.NoError(t, Write(recorder, CSV, "throughput_rules", rules, columns))
+	assert.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="throughput_rules.csv"`, recorder.Header().Get("Content-Disposition"))
+	assert.Equal(t, "id,mx_domain,max_connections,expires_at\n1,gmail.com,10,\n2,yahoo.com,20,2021-06-01T00:00:00Z\n", recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	assert.NoError(t, Write(recorder, YAML, "throughput_rules", rules, columns))
+	assert.Equal(t, "- id: 1\n  mx_domain: gmail.com\n  max_connections: 10\n- id: 2\n  mx_domain: yahoo.com\n  max_connections: 20\n  expires_at: \"2021-06-01T00:00:00Z\"\n", recorder.Body.String())
+
+	records, err := Decode(YAML, recorder.Body.Bytes(), columns)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":2,"mx_domain":"yahoo.com","max_connections":20,"expires_at":"2021-06-01T00:00:00Z"}`, string(records[1]), "should read its own export")
+
+	recorder = httptest.NewRecorder()
+	assert.NoError(t, Write(recorder, JSON, "throughput_rules", rules, columns))
+	assert.JSONEq(t, `[{"id":1,"mx_domain":"gmail.com","max_connections":10,"expires_at":null},{"id":2,"mx_domain":"yahoo.com","max_connections":20,"expires_at":"2021-06-01T00:00:00Z"}]`, recorder.Body.String())
+}
+
+func TestEncoder(t *testing.T) {
+	log.Print("Testing lists encode one item at a time in every format")
+	rules := []rule{
+		{ID: 1, MXDomain: "gmail.com", MaxConnections: 10},
+		{ID: 2, MXDomain: "yahoo.com", MaxConnections: 20},
+	}
+
+	var buffer bytes.Buffer
+	encoder, err := NewEncoder(&buffer, NDJSON, columns)
+	assert.NoError(t, err)
+	assert.NoError(t, encoder.EncodeAll(rules))
+	assert.Equal(t, "{\"id\":1,\"mx_domain\":\"gmail.com\",\"max_connections\":10,\"expires_at\":null}\n{\"id\":2,\"mx_domain\":\"yahoo.com\",\"max_connections\":20,\"expires_at\":null}\n", buffer.String())
+
+	records, err := Decode(NDJSON, buffer.Bytes(), columns)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2, "should read its own output")
+
+	for format, empty := range map[Format]string{JSON: "[]", NDJSON: "", YAML: "[]\n", CSV: "id,mx_domain,max_connections,expires_at\n"} {
+		buffer.Reset()
+		encoder, err := NewEncoder(&buffer, format, columns)
+		assert.NoError(t, err)
+		assert.NoError(t, encoder.EncodeAll([]rule{}))
+		assert.Equalf(t, empty, buffer.String(), "should write an empty %s list", format)
+	}
+
+	type subscription struct {
+		URL        string   `json:"url"`
+		EventTypes []string `json:"event_types"`
+		Secret     string   `json:"-"`
+	}
+	subscriptionColumns := Columns(subscription{})
+	assert.Equal(t, []Column{{Name: "url"}, {Name: "event_types"}}, subscriptionColumns)
+
+	buffer.Reset()
+	encoder, err = NewEncoder(&buffer, CSV, subscriptionColumns)
+	assert.NoError(t, err)
+	assert.NoError(t, encoder.EncodeAll([]subscription{{URL: "https://example.com", EventTypes: []string{"bounce_rule.*"}}}))
+	assert.Equal(t, "url,event_types\nhttps://example.com,\"[\"\"bounce_rule.*\"\"]\"\n", buffer.String(), "should write lists as JSON cells")
+
+	_, err = NewEncoder(&buffer, Format("xml"), columns)
+	assert.Equal(t, ErrUnsupportedFormat, err)
+}
+
+func TestParseOptions(t *testing.T) {
+	log.Print("Testing import options default to a real append")
+	options, err := ParseOptions(httptest.NewRequest("POST", "/bounce_rules/import", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, Options{Mode: ModeAppend}, options)
+
+	options, err = ParseOptions(httptest.NewRequest("POST", "/bounce_rules/import?mode=replace_all&dry_run=true", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, Options{Mode: ModeReplaceAll, DryRun: true}, options)
+
+	_, err = ParseOptions(httptest.NewRequest("POST", "/bounce_rules/import?mode=merge", nil))
+	assert.Equal(t, ErrInvalidMode, err)
+}
+
+func TestPlan(t *testing.T) {
+	log.Print("Testing imports are matched to existing rules by key")
+	existing := []string{"gmail.com", "yahoo.com", "aol.com"}
+	imported := []string{"yahoo.com", "outlook.com"}
+
+	steps, err := Plan(ModeAppend, false, existing, imported)
+	assert.NoError(t, err)
+	assert.Equal(t, Steps{Matches: []int{-1, -1}}, steps, "should create every rule")
+
+	steps, err = Plan(ModeUpsert, false, existing, imported)
+	assert.NoError(t, err)
+	assert.Equal(t, Steps{Matches: []int{1, -1}}, steps)
+
+	steps, err = Plan(ModeReplaceAll, false, existing, imported)
+	assert.NoError(t, err)
+	assert.Equal(t, Steps{Matches: []int{1, -1}, Deletes: []int{0, 2}}, steps)
+
+	_, err = Plan(ModeAppend, true, existing, imported)
+	assert.Equal(t, ImportError{Record: 1, Message: "a rule with this key already exists, import with mode=upsert to update it"}, err)
+
+	_, err = Plan(ModeUpsert, false, existing, []string{"outlook.com", "outlook.com"})
+	assert.Equal(t, ImportError{Record: 2, Message: "duplicates the key of record 1"}, err)
+
+	_, err = Plan(ModeAppend, false, existing, []string{"outlook.com", "outlook.com"})
+	assert.NoError(t, err, "should allow appending duplicates of non-unique keys")
+}
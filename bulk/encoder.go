@@ -0,0 +1,276 @@
+package bulk
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Encoder writes a list one item at a time, so a long list can be sent while
+// it is read instead of being built in memory first. YAML and CSV items are
+// written with their fields in column order.
+type Encoder struct {
+	w       io.Writer
+	format  Format
+	columns []Column
+	csv     *csv.Writer
+	count   int
+}
+
+// NewEncoder returns an encoder writing the given format to w. Close must be
+// called after the last item to finish the list.
+func NewEncoder(w io.Writer, format Format, columns []Column) (*Encoder, error) {
+	switch format {
+	case JSON, NDJSON, YAML:
+		return &Encoder{w: w, format: format, columns: columns}, nil
+	case CSV:
+		return &Encoder{w: w, format: format, columns: columns, csv: csv.NewWriter(w)}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// Encode writes the next item of the list.
+func (e *Encoder) Encode(item interface{}) error {
+	var err error
+	switch e.format {
+	case JSON, NDJSON:
+		err = e.encodeJSON(item)
+	case YAML:
+		err = e.encodeYAML(item)
+	case CSV:
+		err = e.encodeCSV(item)
+	}
+	if err != nil {
+		return err
+	}
+	e.count++
+	return nil
+}
+
+// Close finishes the list, writing an empty one if nothing was encoded.
+func (e *Encoder) Close() error {
+	switch e.format {
+	case JSON:
+		if e.count == 0 {
+			_, err := io.WriteString(e.w, "[]")
+			return err
+		}
+		_, err := io.WriteString(e.w, "]")
+		return err
+	case YAML:
+		if e.count == 0 {
+			_, err := io.WriteString(e.w, "[]\n")
+			return err
+		}
+	case CSV:
+		if e.count == 0 {
+			if err := e.csv.Write(e.header()); err != nil {
+				return err
+			}
+		}
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return nil
+}
+
+// EncodeAll writes every item of a slice and closes the list.
+func (e *Encoder) EncodeAll(items interface{}) error {
+	list := reflect.ValueOf(items)
+	if list.Kind() != reflect.Slice {
+		return fmt.Errorf("cannot encode %T as a list", items)
+	}
+	for i := 0; i < list.Len(); i++ {
+		if err := e.Encode(list.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return e.Close()
+}
+
+func (e *Encoder) encodeJSON(item interface{}) error {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	var separator string
+	switch {
+	case e.format == NDJSON:
+		encoded = append(encoded, '\n')
+	case e.count == 0:
+		separator = "["
+	default:
+		separator = ","
+	}
+	if _, err := io.WriteString(e.w, separator); err != nil {
+		return err
+	}
+	_, err = e.w.Write(encoded)
+	return err
+}
+
+func (e *Encoder) encodeYAML(item interface{}) error {
+	object, err := fieldMap(item)
+	if err != nil {
+		return err
+	}
+
+	// A one item list per call concatenates into one YAML list
+	encoded, err := yaml.Marshal([]yaml.MapSlice{yamlDocument(object, e.columns)})
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(encoded)
+	return err
+}
+
+func (e *Encoder) encodeCSV(item interface{}) error {
+	object, err := fieldMap(item)
+	if err != nil {
+		return err
+	}
+
+	if e.count == 0 {
+		if err := e.csv.Write(e.header()); err != nil {
+			return err
+		}
+	}
+
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		if value, ok := object[column.Name]; ok && value != nil {
+			row[i] = csvCell(value)
+		}
+	}
+	if err := e.csv.Write(row); err != nil {
+		return err
+	}
+	// Flush every row so CSV streams like the other formats
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+func (e *Encoder) header() []string {
+	header := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		header[i] = column.Name
+	}
+	return header
+}
+
+// csvCell writes lists and objects, e.g. webhook event types, as JSON.
+func csvCell(value interface{}) string {
+	switch value.(type) {
+	case []interface{}, map[string]interface{}:
+		encoded, err := json.Marshal(value)
+		if err == nil {
+			return string(encoded)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// fieldMap reads an item through its JSON encoding so YAML and CSV show the
+// same field names and values as the JSON API.
+func fieldMap(item interface{}) (map[string]interface{}, error) {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		return nil, err
+	}
+	return object, nil
+}
+
+func yamlDocument(object map[string]interface{}, columns []Column) yaml.MapSlice {
+	document := yaml.MapSlice{}
+	for _, column := range columns {
+		value, ok := object[column.Name]
+		if !ok || value == nil {
+			continue
+		}
+		document = append(document, yaml.MapItem{Key: column.Name, Value: yamlValue(value)})
+	}
+	return document
+}
+
+// yamlValue keeps numbers, including those nested in lists and objects,
+// unquoted in the YAML output.
+func yamlValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			return n
+		}
+		if f, err := value.Float64(); err == nil {
+			return f
+		}
+		return value.String()
+	case []interface{}:
+		for i, item := range value {
+			value[i] = yamlValue(item)
+		}
+		return value
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = yamlValue(item)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// Columns lists the JSON fields of a struct in declaration order, for lists
+// written with every field of their items.
+func Columns(item interface{}) []Column {
+	t := reflect.TypeOf(item)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	columns := []Column{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if comma := strings.Index(tag, ","); comma >= 0 {
+				tag = tag[:comma]
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+		columns = append(columns, Column{Name: name, Numeric: isNumeric(field.Type)})
+	}
+	return columns
+}
+
+func isNumeric(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
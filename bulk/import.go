@@ -0,0 +1,155 @@
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// Mode decides what an import does with rules that already exist.
+type Mode string
+
+const (
+	// ModeAppend creates every imported rule.
+	ModeAppend Mode = "append"
+	// ModeUpsert updates rules with the same key as an imported rule and
+	// creates the rest.
+	ModeUpsert Mode = "upsert"
+	// ModeReplaceAll upserts and then deletes every rule not in the import.
+	ModeReplaceAll Mode = "replace_all"
+)
+
+// Import change actions, matching the rule change history.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// ErrInvalidMode is returned for a mode other than append, upsert or replace_all.
+var ErrInvalidMode = errors.New("mode must be one of append, upsert or replace_all")
+
+// Options are the import query parameters.
+type Options struct {
+	Mode   Mode
+	DryRun bool
+}
+
+// ParseOptions reads ?mode=, defaulting to append, and ?dry_run=.
+func ParseOptions(r *http.Request) (Options, error) {
+	options := Options{Mode: ModeAppend}
+	query := r.URL.Query()
+
+	if value := query.Get("mode"); value != "" {
+		switch mode := Mode(value); mode {
+		case ModeAppend, ModeUpsert, ModeReplaceAll:
+			options.Mode = mode
+		default:
+			return options, ErrInvalidMode
+		}
+	}
+
+	if value := query.Get("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, errors.New("dry_run must be true or false")
+		}
+		options.DryRun = dryRun
+	}
+
+	return options, nil
+}
+
+// Steps matches imported rules to existing rules by key.
+type Steps struct {
+	// Matches holds, for each imported rule, the index of the existing rule
+	// it updates or -1 to create it.
+	Matches []int
+	// Deletes holds the indexes of existing rules that replace_all removes.
+	Deletes []int
+}
+
+// Plan matches imported rule keys against existing rule keys. Upserts and
+// replace_all reject an import that names the same key twice, since it would
+// be ambiguous which rule wins. Unique keys, which the database enforces,
+// are also rejected when appending a rule that already exists.
+func Plan(mode Mode, unique bool, existingKeys []string, importedKeys []string) (Steps, error) {
+	existing := make(map[string]int, len(existingKeys))
+	for i, key := range existingKeys {
+		if _, ok := existing[key]; !ok {
+			existing[key] = i
+		}
+	}
+
+	steps := Steps{Matches: make([]int, len(importedKeys))}
+	imported := make(map[string]int, len(importedKeys))
+	for i, key := range importedKeys {
+		if first, ok := imported[key]; ok && (unique || mode != ModeAppend) {
+			return steps, ImportError{Record: i + 1, Message: fmt.Sprintf("duplicates the key of record %d", first+1)}
+		}
+		imported[key] = i
+
+		steps.Matches[i] = -1
+		match, ok := existing[key]
+		if !ok {
+			continue
+		}
+		if mode == ModeAppend {
+			if unique {
+				return steps, ImportError{Record: i + 1, Message: "a rule with this key already exists, import with mode=upsert to update it"}
+			}
+			continue
+		}
+		steps.Matches[i] = match
+	}
+
+	if mode == ModeReplaceAll {
+		for i, key := range existingKeys {
+			if _, ok := imported[key]; !ok {
+				steps.Deletes = append(steps.Deletes, i)
+			}
+		}
+	}
+
+	return steps, nil
+}
+
+// Change is one rule an import creates, updates or deletes.
+type Change struct {
+	Action   string      `json:"action"`
+	Record   int         `json:"record,omitempty"`
+	RuleID   int         `json:"rule_id,omitempty"`
+	Rule     interface{} `json:"rule"`
+	Previous interface{} `json:"previous,omitempty"`
+}
+
+// Result summarizes an import. For a dry run it lists the changes the import
+// would have made without making them.
+type Result struct {
+	Mode      Mode     `json:"mode"`
+	DryRun    bool     `json:"dry_run"`
+	Created   int      `json:"created"`
+	Updated   int      `json:"updated"`
+	Deleted   int      `json:"deleted"`
+	Unchanged int      `json:"unchanged"`
+	Changes   []Change `json:"changes"`
+}
+
+// NewResult starts an empty result for the import options.
+func NewResult(options Options) Result {
+	return Result{Mode: options.Mode, DryRun: options.DryRun, Changes: []Change{}}
+}
+
+// Add records a change and counts it by action.
+func (result *Result) Add(change Change) {
+	switch change.Action {
+	case ActionCreated:
+		result.Created++
+	case ActionUpdated:
+		result.Updated++
+	case ActionDeleted:
+		result.Deleted++
+	}
+	result.Changes = append(result.Changes, change)
+}
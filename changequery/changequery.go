@@ -0,0 +1,234 @@
+package changequery
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gobrm/apiversion"
+	"gobrm/problem"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+
+	SortID        = "id"
+	SortUpdatedAt = "updated_at"
+)
+
+// Params are the pagination, filter and sort options shared by the change
+// history endpoints of both rule managers.
+type Params struct {
+	Limit   int
+	Sort    string
+	Desc    bool
+	Cursor  *Cursor
+	Actions []string
+	RuleID  *int
+	Since   *time.Time
+	Until   *time.Time
+	Fields  map[string]string
+}
+
+// Cursor is the position of the last row of a page in the requested sort order.
+type Cursor struct {
+	Sort      string
+	UpdatedAt time.Time
+	ID        int
+}
+
+// Parse reads the query parameters of a change history request. Only the
+// given fields may be filtered on by exact value, e.g. ?bounce_action=suppress.
+func Parse(values url.Values, filterFields []string) (Params, error) {
+	p := Params{Limit: DefaultLimit, Sort: SortID, Fields: map[string]string{}}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return p, problem.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", MaxLimit)}
+		}
+		p.Limit = n
+	}
+
+	if order := values.Get("sort"); order != "" {
+		p.Desc = strings.HasPrefix(order, "-")
+		p.Sort = strings.TrimPrefix(order, "-")
+		if p.Sort != SortID && p.Sort != SortUpdatedAt {
+			return p, problem.FieldError{Field: "sort", Message: "must be one of id, -id, updated_at or -updated_at"}
+		}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		c, err := decodeCursor(cursor)
+		if err != nil || c.Sort != p.Sort {
+			return p, problem.FieldError{Field: "cursor", Message: "is invalid"}
+		}
+		p.Cursor = &c
+	}
+
+	for _, action := range values["action"] {
+		for _, a := range strings.Split(action, ",") {
+			if a = strings.TrimSpace(a); a != "" {
+				p.Actions = append(p.Actions, a)
+			}
+		}
+	}
+
+	if ruleID := values.Get("rule_id"); ruleID != "" {
+		id, err := strconv.Atoi(ruleID)
+		if err != nil {
+			return p, problem.FieldError{Field: "rule_id", Message: "must be an integer"}
+		}
+		p.RuleID = &id
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+	}{{"since", &p.Since}, {"until", &p.Until}} {
+		if value := values.Get(bound.name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return p, problem.FieldError{Field: bound.name, Message: "must be an RFC 3339 timestamp"}
+			}
+			*bound.target = &t
+		}
+	}
+
+	for _, field := range filterFields {
+		if value, ok := values[field]; ok && len(value) > 0 {
+			p.Fields[field] = value[0]
+		}
+	}
+
+	return p, nil
+}
+
+// Where returns parameterized SQL conditions and their arguments. The rule ID
+// column differs per change table, e.g. bounce_rule_id.
+func (p Params) Where(ruleIDColumn string) ([]string, []interface{}) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if len(p.Actions) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(p.Actions)), ", ")
+		conditions = append(conditions, fmt.Sprintf("action IN (%s)", placeholders))
+		for _, action := range p.Actions {
+			args = append(args, action)
+		}
+	}
+
+	if p.RuleID != nil {
+		conditions = append(conditions, ruleIDColumn+" = ?")
+		args = append(args, *p.RuleID)
+	}
+
+	if p.Since != nil {
+		conditions = append(conditions, "updated_at >= ?")
+		args = append(args, *p.Since)
+	}
+
+	if p.Until != nil {
+		conditions = append(conditions, "updated_at < ?")
+		args = append(args, *p.Until)
+	}
+
+	for _, field := range sortedKeys(p.Fields) {
+		conditions = append(conditions, field+" = ?")
+		args = append(args, p.Fields[field])
+	}
+
+	if p.Cursor != nil {
+		op := ">"
+		if p.Desc {
+			op = "<"
+		}
+
+		switch p.Sort {
+		case SortUpdatedAt:
+			conditions = append(conditions, fmt.Sprintf("(updated_at %s ? OR (updated_at = ? AND id %s ?))", op, op))
+			args = append(args, p.Cursor.UpdatedAt, p.Cursor.UpdatedAt, p.Cursor.ID)
+		default:
+			conditions = append(conditions, fmt.Sprintf("id %s ?", op))
+			args = append(args, p.Cursor.ID)
+		}
+	}
+
+	return conditions, args
+}
+
+// OrderBy returns the ORDER BY expression, using the ID to break ties.
+func (p Params) OrderBy() string {
+	direction := "ASC"
+	if p.Desc {
+		direction = "DESC"
+	}
+
+	if p.Sort == SortUpdatedAt {
+		return fmt.Sprintf("updated_at %s, id %s", direction, direction)
+	}
+	return "id " + direction
+}
+
+// FetchLimit is one more than the page size so callers can tell whether there is a next page.
+func (p Params) FetchLimit() int {
+	return p.Limit + 1
+}
+
+// Next returns the cursor pointing after the given last row of a page.
+func (p Params) Next(id int, updatedAt time.Time) Cursor {
+	return Cursor{Sort: p.Sort, UpdatedAt: updatedAt, ID: id}
+}
+
+// SetNextLink adds a Link header for the next page, keeping every other query parameter.
+func SetNextLink(w http.ResponseWriter, r *http.Request, next Cursor) {
+	values := r.URL.Query()
+	values.Set("cursor", next.Encode())
+
+	nextURL := url.URL{Path: apiversion.Path(r), RawQuery: values.Encode()}
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", nextURL.String()))
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%s|%d|%d", c.Sort, c.UpdatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return Cursor{}, fmt.Errorf("malformed cursor")
+	}
+
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	return Cursor{Sort: parts[0], UpdatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,84 @@
+package changequery
+
+import (
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDefaults(t *testing.T) {
+	log.Print("Testing change query defaults")
+	params, err := Parse(url.Values{}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultLimit, params.Limit)
+	assert.Equal(t, "id ASC", params.OrderBy())
+
+	conditions, args := params.Where("bounce_rule_id")
+	assert.Empty(t, conditions)
+	assert.Empty(t, args)
+}
+
+func TestParseFilters(t *testing.T) {
+	log.Print("Testing change query filters")
+	values := url.Values{
+		"action":        {"created,deleted"},
+		"rule_id":       {"3"},
+		"since":         {"2021-05-01T00:00:00Z"},
+		"bounce_action": {"suppress"},
+		"unknown":       {"ignored"},
+		"sort":          {"-updated_at"},
+	}
+	params, err := Parse(values, []string{"bounce_action"})
+	assert.NoError(t, err)
+
+	conditions, args := params.Where("bounce_rule_id")
+	assert.Equal(t, []string{"action IN (?, ?)", "bounce_rule_id = ?", "updated_at >= ?", "bounce_action = ?"}, conditions)
+	assert.Equal(t, []interface{}{"created", "deleted", 3, time.Date(2021, 5, 1, 0, 0, 0, 0, time.UTC), "suppress"}, args)
+	assert.Equal(t, "updated_at DESC, id DESC", params.OrderBy())
+}
+
+func TestParseRejectsInvalidParams(t *testing.T) {
+	log.Print("Testing change query validation")
+	for _, values := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"5000"}},
+		{"sort": {"priority"}},
+		{"rule_id": {"abc"}},
+		{"until": {"yesterday"}},
+		{"cursor": {"not a cursor"}},
+	} {
+		_, err := Parse(values, nil)
+		assert.Errorf(t, err, "should reject %v", values)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	log.Print("Testing change query cursors")
+	updatedAt := time.Date(2021, 5, 1, 12, 30, 0, 0, time.UTC)
+	first, _ := Parse(url.Values{"sort": {"updated_at"}, "limit": {"2"}}, nil)
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/bounce_rule_changes?sort=updated_at&limit=2", nil)
+	SetNextLink(rr, req, first.Next(7, updatedAt))
+
+	link := rr.Header().Get("Link")
+	nextURL, err := url.Parse(link[1 : len(link)-len(`>; rel="next"`)])
+	assert.NoError(t, err)
+	assert.Equal(t, "/bounce_rule_changes", nextURL.Path)
+
+	next, err := Parse(nextURL.Query(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, next.Limit, "should keep the other query parameters")
+
+	conditions, args := next.Where("bounce_rule_id")
+	assert.Equal(t, []string{"(updated_at > ? OR (updated_at = ? AND id > ?))"}, conditions)
+	assert.Equal(t, []interface{}{updatedAt, updatedAt, 7}, args)
+
+	_, err = Parse(url.Values{"cursor": nextURL.Query()["cursor"], "sort": {"id"}}, nil)
+	assert.Error(t, err, "should reject a cursor from another sort order")
+}
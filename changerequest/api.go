@@ -0,0 +1,311 @@
+package changerequest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"gobrm/auth"
+	"gobrm/bulk"
+	"gobrm/problem"
+	"gobrm/render"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ActorHeader identifies the person proposing or reviewing a change request
+// when authentication is disabled.
+const ActorHeader = "X-Actor"
+
+// changeRequestListColumns orders the fields of change request lists in YAML and CSV.
+var changeRequestListColumns = bulk.Columns(ChangeRequest{})
+
+// ErrUnauthenticatedReview refuses reviews when authentication is disabled.
+var ErrUnauthenticatedReview = errors.New("Reviewing change requests needs authentication, as " + ActorHeader + " cannot tell the reviewer from the proposer")
+
+// Actor returns who is making the request: the authenticated client or,
+// without authentication, the X-Actor header.
+func Actor(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		return identity.Subject
+	}
+	return r.Header.Get(ActorHeader)
+}
+
+// API serves the change request workflow for one rule type. URL parameters
+// are read through URLParam so each rule manager can mount it on its own router.
+type API struct {
+	DB       *sql.DB
+	RuleType string
+	Applier  Applier
+	URLParam func(r *http.Request, key string) string
+}
+
+type proposal struct {
+	Action string          `json:"action"`
+	RuleID *int            `json:"rule_id"`
+	Rule   json.RawMessage `json:"rule"`
+	Submit bool            `json:"submit"`
+}
+
+type reviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Respond(w, r, code, message)
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+// respondWithWorkflowError maps workflow and validation errors to their HTTP status.
+func respondWithWorkflowError(w http.ResponseWriter, r *http.Request, err error) {
+	var validationErr ValidationError
+	var transitionErr TransitionError
+	var duplicateErr problem.DuplicateError
+	switch {
+	case err == sql.ErrNoRows:
+		respondWithError(w, r, http.StatusNotFound, "Change request not found")
+	case errors.As(err, &validationErr):
+		problem.Invalid(w, r, validationErr)
+	case errors.As(err, &duplicateErr):
+		problem.Write(w, r, problem.Duplicate(duplicateErr, "Applying the change request would duplicate a rule: "+duplicateErr.Error()))
+	case errors.As(err, &transitionErr):
+		respondWithError(w, r, http.StatusConflict, transitionErr.Error())
+	case err == ErrSelfApproval, err == ErrNotProposer:
+		respondWithError(w, r, http.StatusForbidden, err.Error())
+	default:
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func (api *API) actorOrError(w http.ResponseWriter, r *http.Request) (string, bool) {
+	actor := Actor(r)
+	if actor == "" {
+		respondWithError(w, r, http.StatusBadRequest, ActorHeader+" header is required")
+		return "", false
+	}
+	return actor, true
+}
+
+// reviewerOrError refuses requests without an authenticated client, as anyone
+// could send a new X-Actor to approve their own change request.
+func (api *API) reviewerOrError(w http.ResponseWriter, r *http.Request) bool {
+	if _, ok := auth.FromContext(r.Context()); !ok {
+		respondWithError(w, r, http.StatusForbidden, ErrUnauthenticatedReview.Error())
+		return false
+	}
+	return true
+}
+
+func (api *API) id(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid change request ID")
+		return 0, false
+	}
+	return id, true
+}
+
+func (api *API) validate(ctx context.Context, cr ChangeRequest) error {
+	if err := validateAction(cr); err != nil {
+		return err
+	}
+	return api.Applier.Validate(ctx, api.DB, cr)
+}
+
+func (api *API) GetChangeRequests(w http.ResponseWriter, r *http.Request) {
+	changeRequests, err := getChangeRequests(r.Context(), api.DB, api.RuleType, r.URL.Query().Get("status"))
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.List(w, r, changeRequests, changeRequestListColumns)
+}
+
+func (api *API) GetChangeRequest(w http.ResponseWriter, r *http.Request) {
+	id, ok := api.id(w, r)
+	if !ok {
+		return
+	}
+
+	cr, err := getChangeRequest(r.Context(), api.DB, api.RuleType, id)
+	if err != nil {
+		respondWithWorkflowError(w, r, err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cr)
+}
+
+// CreateChangeRequest proposes a change as a draft, or straight to review when "submit" is true.
+func (api *API) CreateChangeRequest(w http.ResponseWriter, r *http.Request) {
+	actor, ok := api.actorOrError(w, r)
+	if !ok {
+		return
+	}
+
+	var p proposal
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&p); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid change request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	cr := ChangeRequest{
+		RuleType:   api.RuleType,
+		Action:     p.Action,
+		RuleID:     p.RuleID,
+		Rule:       p.Rule,
+		Status:     StatusDraft,
+		ProposedBy: actor,
+	}
+	if p.Submit {
+		cr.Status = StatusPending
+	}
+
+	if err := api.validate(r.Context(), cr); err != nil {
+		respondWithWorkflowError(w, r, err)
+		return
+	}
+
+	if err := createChangeRequest(r.Context(), api.DB, &cr); err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, cr)
+}
+
+// UpdateChangeRequest replaces the proposal of a draft.
+func (api *API) UpdateChangeRequest(w http.ResponseWriter, r *http.Request) {
+	var p proposal
+	api.transition(w, r, func(tx *sql.Tx, cr *ChangeRequest, actor string) error {
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&p); err != nil {
+			return ValidationError{Message: "Invalid change request payload"}
+		}
+		defer r.Body.Close()
+
+		if err := edit(cr, actor, ChangeRequest{Action: p.Action, RuleID: p.RuleID, Rule: p.Rule}); err != nil {
+			return err
+		}
+		return api.validate(r.Context(), *cr)
+	})
+}
+
+func (api *API) SubmitChangeRequest(w http.ResponseWriter, r *http.Request) {
+	api.transition(w, r, func(tx *sql.Tx, cr *ChangeRequest, actor string) error {
+		if err := submit(cr, actor); err != nil {
+			return err
+		}
+		return api.validate(r.Context(), *cr)
+	})
+}
+
+func (api *API) ApproveChangeRequest(w http.ResponseWriter, r *http.Request) {
+	if !api.reviewerOrError(w, r) {
+		return
+	}
+	api.transition(w, r, func(tx *sql.Tx, cr *ChangeRequest, actor string) error {
+		return review(cr, actor, true, decodeComment(r))
+	})
+}
+
+func (api *API) RejectChangeRequest(w http.ResponseWriter, r *http.Request) {
+	if !api.reviewerOrError(w, r) {
+		return
+	}
+	api.transition(w, r, func(tx *sql.Tx, cr *ChangeRequest, actor string) error {
+		return review(cr, actor, false, decodeComment(r))
+	})
+}
+
+// ApplyChangeRequest performs an approved change. The rule change, its audit
+// row and the change request status are committed together.
+func (api *API) ApplyChangeRequest(w http.ResponseWriter, r *http.Request) {
+	api.transition(w, r, func(tx *sql.Tx, cr *ChangeRequest, actor string) error {
+		if cr.Status != StatusApproved {
+			return TransitionError{"apply", cr.Status}
+		}
+
+		log.Printf("Applying %s change request %d approved by %s", api.RuleType, cr.ID, *cr.ReviewedBy)
+		ruleID, err := api.Applier.Apply(r.Context(), tx, *cr)
+		if err == sql.ErrNoRows {
+			return TransitionError{"apply", "targeting a rule that no longer exists"}
+		}
+		if err != nil {
+			return err
+		}
+
+		return markApplied(cr, ruleID, time.Now().UTC())
+	})
+}
+
+func decodeComment(r *http.Request) string {
+	var review reviewRequest
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&review)
+	}
+	return review.Comment
+}
+
+// transition locks the change request, applies change to it and saves it in
+// a single transaction that change may also write to.
+func (api *API) transition(w http.ResponseWriter, r *http.Request, change func(tx *sql.Tx, cr *ChangeRequest, actor string) error) {
+	actor, ok := api.actorOrError(w, r)
+	if !ok {
+		return
+	}
+
+	id, ok := api.id(w, r)
+	if !ok {
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := api.DB.BeginTx(ctx, nil)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	cr, err := lockChangeRequest(ctx, tx, api.RuleType, id)
+	if err != nil {
+		respondWithWorkflowError(w, r, err)
+		return
+	}
+
+	if err := change(tx, &cr, actor); err != nil {
+		respondWithWorkflowError(w, r, err)
+		return
+	}
+
+	if err := saveChangeRequest(ctx, tx, cr); err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	cr, err = getChangeRequest(ctx, api.DB, api.RuleType, id)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, cr)
+}
@@ -0,0 +1,40 @@
+package changerequest
+
+import (
+	"errors"
+	"gobrm/auth"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewsNeedAuthentication(t *testing.T) {
+	log.Print("Testing change requests cannot be reviewed as an X-Actor")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	api := &API{DB: db, RuleType: "bounce", URLParam: func(r *http.Request, key string) string { return "1" }}
+	for _, review := range []http.HandlerFunc{api.ApproveChangeRequest, api.RejectChangeRequest} {
+		req := httptest.NewRequest("POST", "/bounce_rule_change_requests/1/approve", nil)
+		req.Header.Set(ActorHeader, "bob")
+		rr := httptest.NewRecorder()
+		review(rr, req)
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+		assert.Contains(t, rr.Body.String(), "needs authentication")
+	}
+
+	mock.ExpectBegin().WillReturnError(errors.New("connection refused"))
+	req := httptest.NewRequest("POST", "/bounce_rule_change_requests/1/approve", nil)
+	req = req.WithContext(auth.WithIdentity(req.Context(), auth.Identity{Subject: "api-key:bob"}))
+	rr := httptest.NewRecorder()
+	api.ApproveChangeRequest(rr, req)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code, "authenticated reviewers should get to the change request")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
@@ -0,0 +1,167 @@
+package changerequest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// CREATE TABLE rule_change_request (
+//   id INT NOT NULL AUTO_INCREMENT,
+//   rule_type VARCHAR(32) NOT NULL,
+//   action VARCHAR(16) NOT NULL,
+//   rule_id INT NULL,
+//   payload TEXT NOT NULL,
+//   status VARCHAR(16) NOT NULL DEFAULT 'draft',
+//   proposed_by VARCHAR(255) NOT NULL,
+//   reviewed_by VARCHAR(255) NULL,
+//   review_comment VARCHAR(1024) NULL,
+//   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+//   applied_at DATETIME NULL,
+//   PRIMARY KEY (id),
+//   INDEX (rule_type, status)
+// );
+
+const (
+	StatusDraft    = "draft"
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+	StatusApplied  = "applied"
+
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// ChangeRequest is a proposed create, update or delete of a single rule. Rule
+// holds the proposed rule as JSON and is empty for deletes.
+type ChangeRequest struct {
+	ID            int             `json:"id"`
+	RuleType      string          `json:"rule_type"`
+	Action        string          `json:"action"`
+	RuleID        *int            `json:"rule_id"`
+	Rule          json.RawMessage `json:"rule,omitempty"`
+	Status        string          `json:"status"`
+	ProposedBy    string          `json:"proposed_by"`
+	ReviewedBy    *string         `json:"reviewed_by"`
+	ReviewComment *string         `json:"review_comment"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+	AppliedAt     *time.Time      `json:"applied_at"`
+}
+
+const changeRequestColumns = "id, rule_type, action, rule_id, payload, status, proposed_by, reviewed_by, review_comment, created_at, updated_at, applied_at"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanChangeRequest(row scanner) (ChangeRequest, error) {
+	var cr ChangeRequest
+	var ruleID sql.NullInt64
+	var payload string
+	var reviewedBy, reviewComment sql.NullString
+	var appliedAt sql.NullTime
+	err := row.Scan(&cr.ID, &cr.RuleType, &cr.Action, &ruleID, &payload, &cr.Status, &cr.ProposedBy,
+		&reviewedBy, &reviewComment, &cr.CreatedAt, &cr.UpdatedAt, &appliedAt)
+	if err != nil {
+		return cr, err
+	}
+
+	if ruleID.Valid {
+		id := int(ruleID.Int64)
+		cr.RuleID = &id
+	}
+	if payload != "" {
+		cr.Rule = json.RawMessage(payload)
+	}
+	if reviewedBy.Valid {
+		cr.ReviewedBy = &reviewedBy.String
+	}
+	if reviewComment.Valid {
+		cr.ReviewComment = &reviewComment.String
+	}
+	if appliedAt.Valid {
+		cr.AppliedAt = &appliedAt.Time
+	}
+	return cr, nil
+}
+
+func getChangeRequests(ctx context.Context, db *sql.DB, ruleType string, status string) ([]ChangeRequest, error) {
+	statement := "SELECT " + changeRequestColumns + " FROM rule_change_request WHERE rule_type = ?"
+	args := []interface{}{ruleType}
+	if status != "" {
+		statement += " AND status = ?"
+		args = append(args, status)
+	}
+	statement += " ORDER BY id"
+
+	rows, err := db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changeRequests := []ChangeRequest{}
+	for rows.Next() {
+		cr, err := scanChangeRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		changeRequests = append(changeRequests, cr)
+	}
+
+	return changeRequests, rows.Err()
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getChangeRequest(ctx context.Context, db queryRower, ruleType string, id int) (ChangeRequest, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+changeRequestColumns+" FROM rule_change_request WHERE id = ? AND rule_type = ?", id, ruleType)
+	return scanChangeRequest(row)
+}
+
+// lockChangeRequest reads a change request for update so concurrent reviews
+// and applies of the same request are serialized.
+func lockChangeRequest(ctx context.Context, tx *sql.Tx, ruleType string, id int) (ChangeRequest, error) {
+	row := tx.QueryRowContext(ctx, "SELECT "+changeRequestColumns+" FROM rule_change_request WHERE id = ? AND rule_type = ? FOR UPDATE", id, ruleType)
+	return scanChangeRequest(row)
+}
+
+func createChangeRequest(ctx context.Context, db *sql.DB, cr *ChangeRequest) error {
+	result, err := db.ExecContext(ctx,
+		"INSERT INTO rule_change_request (rule_type, action, rule_id, payload, status, proposed_by) VALUES (?, ?, ?, ?, ?, ?)",
+		cr.RuleType, cr.Action, cr.RuleID, string(cr.Rule), cr.Status, cr.ProposedBy)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	created, err := getChangeRequest(ctx, db, cr.RuleType, int(id))
+	if err != nil {
+		return err
+	}
+	*cr = created
+	return nil
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// saveChangeRequest writes the mutable fields of a change request.
+func saveChangeRequest(ctx context.Context, exec execer, cr ChangeRequest) error {
+	_, err := exec.ExecContext(ctx,
+		"UPDATE rule_change_request SET action = ?, rule_id = ?, payload = ?, status = ?, reviewed_by = ?, review_comment = ?, applied_at = ? WHERE id = ?",
+		cr.Action, cr.RuleID, string(cr.Rule), cr.Status, cr.ReviewedBy, cr.ReviewComment, cr.AppliedAt, cr.ID)
+	return err
+}
@@ -0,0 +1,137 @@
+package changerequest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gobrm/problem"
+	"time"
+)
+
+// Applier validates and applies change requests for one rule type. Each rule
+// manager implements it on top of the same functions its direct CRUD uses.
+type Applier interface {
+	// Validate checks the proposed rule the same way a direct change is checked.
+	Validate(ctx context.Context, db *sql.DB, cr ChangeRequest) error
+	// Apply performs the change, including its *_rule_change audit row, inside
+	// tx and returns the ID of the rule that was changed.
+	Apply(ctx context.Context, tx *sql.Tx, cr ChangeRequest) (int, error)
+}
+
+// ValidationError is returned by an Applier when the proposed change is invalid.
+// Fields lists the offending fields when they are known.
+type ValidationError struct {
+	Message string
+	Fields  []problem.FieldError
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// FieldErrors makes problem.Invalid answer with a validation problem.
+func (e ValidationError) FieldErrors() []problem.FieldError {
+	return e.Fields
+}
+
+// invalidField reports a single offending field, e.g. rule_id.
+func invalidField(field string, message string) ValidationError {
+	return ValidationError{Message: field + " " + message, Fields: []problem.FieldError{{Field: field, Message: message}}}
+}
+
+var (
+	ErrSelfApproval = errors.New("Change requests must be reviewed by someone other than their proposer")
+	ErrNotProposer  = errors.New("Only the proposer may edit or submit a change request")
+)
+
+// TransitionError is returned when a change request is not in a status that allows the action.
+type TransitionError struct {
+	Action string
+	Status string
+}
+
+func (e TransitionError) Error() string {
+	return fmt.Sprintf("Cannot %s a change request that is %s", e.Action, e.Status)
+}
+
+func validateAction(cr ChangeRequest) error {
+	switch cr.Action {
+	case ActionCreate:
+		if cr.RuleID != nil {
+			return invalidField("rule_id", "must not be set when creating a rule")
+		}
+	case ActionUpdate, ActionDelete:
+		if cr.RuleID == nil {
+			return invalidField("rule_id", fmt.Sprintf("is required to %s a rule", cr.Action))
+		}
+	default:
+		return invalidField("action", "must be one of create, update or delete")
+	}
+
+	if cr.Action != ActionDelete && len(cr.Rule) == 0 {
+		return invalidField("rule", fmt.Sprintf("is required to %s a rule", cr.Action))
+	}
+
+	return nil
+}
+
+// edit replaces the proposal of a draft. Only the proposer may edit it.
+func edit(cr *ChangeRequest, actor string, proposal ChangeRequest) error {
+	if cr.Status != StatusDraft {
+		return TransitionError{"edit", cr.Status}
+	}
+	if actor != cr.ProposedBy {
+		return ErrNotProposer
+	}
+
+	cr.Action = proposal.Action
+	cr.RuleID = proposal.RuleID
+	cr.Rule = proposal.Rule
+	return nil
+}
+
+func submit(cr *ChangeRequest, actor string) error {
+	if cr.Status != StatusDraft {
+		return TransitionError{"submit", cr.Status}
+	}
+	if actor != cr.ProposedBy {
+		return ErrNotProposer
+	}
+
+	cr.Status = StatusPending
+	return nil
+}
+
+// review approves or rejects a pending change request. The reviewer must not be the proposer.
+func review(cr *ChangeRequest, actor string, approved bool, comment string) error {
+	action, status := "reject", StatusRejected
+	if approved {
+		action, status = "approve", StatusApproved
+	}
+
+	if cr.Status != StatusPending {
+		return TransitionError{action, cr.Status}
+	}
+	if actor == cr.ProposedBy {
+		return ErrSelfApproval
+	}
+
+	cr.Status = status
+	cr.ReviewedBy = &actor
+	if comment != "" {
+		cr.ReviewComment = &comment
+	}
+	return nil
+}
+
+func markApplied(cr *ChangeRequest, ruleID int, now time.Time) error {
+	if cr.Status != StatusApproved {
+		return TransitionError{"apply", cr.Status}
+	}
+
+	cr.Status = StatusApplied
+	cr.RuleID = &ruleID
+	cr.AppliedAt = &now
+	return nil
+}
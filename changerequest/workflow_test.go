@@ -0,0 +1,61 @@
+package changerequest
+
+import (
+	"encoding/json"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAction(t *testing.T) {
+	log.Print("Testing change request action validation")
+	ruleID := 1
+	rule := json.RawMessage(`{"mx_domain": "example.com"}`)
+
+	assert.NoError(t, validateAction(ChangeRequest{Action: ActionCreate, Rule: rule}))
+	assert.NoError(t, validateAction(ChangeRequest{Action: ActionUpdate, RuleID: &ruleID, Rule: rule}))
+	assert.NoError(t, validateAction(ChangeRequest{Action: ActionDelete, RuleID: &ruleID}))
+
+	assert.Error(t, validateAction(ChangeRequest{Action: ActionCreate, RuleID: &ruleID, Rule: rule}), "creates should not target a rule")
+	assert.Error(t, validateAction(ChangeRequest{Action: ActionUpdate, Rule: rule}), "updates should target a rule")
+	assert.Error(t, validateAction(ChangeRequest{Action: ActionUpdate, RuleID: &ruleID}), "updates should propose a rule")
+	assert.Error(t, validateAction(ChangeRequest{Action: "upsert", Rule: rule}))
+}
+
+func TestWorkflow(t *testing.T) {
+	log.Print("Testing change request workflow")
+	cr := ChangeRequest{Status: StatusDraft, ProposedBy: "alice"}
+
+	assert.Equal(t, ErrNotProposer, submit(&cr, "bob"), "only the proposer should submit")
+	assert.NoError(t, submit(&cr, "alice"))
+	assert.Equal(t, StatusPending, cr.Status)
+
+	assert.Equal(t, TransitionError{"edit", StatusPending}, edit(&cr, "alice", ChangeRequest{}), "pending changes should not be edited")
+	assert.Equal(t, ErrSelfApproval, review(&cr, "alice", true, ""), "proposers should not approve their own change")
+	assert.Equal(t, StatusPending, cr.Status)
+
+	assert.NoError(t, review(&cr, "bob", true, "looks good"))
+	assert.Equal(t, StatusApproved, cr.Status)
+	assert.Equal(t, "bob", *cr.ReviewedBy)
+	assert.Equal(t, "looks good", *cr.ReviewComment)
+
+	assert.Equal(t, TransitionError{"reject", StatusApproved}, review(&cr, "carol", false, ""))
+
+	now := time.Now()
+	assert.NoError(t, markApplied(&cr, 7, now))
+	assert.Equal(t, StatusApplied, cr.Status)
+	assert.Equal(t, 7, *cr.RuleID)
+	assert.Equal(t, TransitionError{"apply", StatusApplied}, markApplied(&cr, 7, now), "changes should only be applied once")
+}
+
+func TestReject(t *testing.T) {
+	log.Print("Testing change request rejection")
+	cr := ChangeRequest{Status: StatusPending, ProposedBy: "alice"}
+
+	assert.NoError(t, review(&cr, "bob", false, ""))
+	assert.Equal(t, StatusRejected, cr.Status)
+	assert.Nil(t, cr.ReviewComment)
+	assert.Equal(t, TransitionError{"apply", StatusRejected}, markApplied(&cr, 1, time.Now()))
+}
@@ -0,0 +1,60 @@
+// Command apikey issues an API key directly in the database, e.g. the first
+// admin key of a new deployment:
+//
+//	go run ./cmd/apikey -name ops -grant admin
+//
+// It reads the MySQL settings the servers read from -config, $GOBRM_CONFIG
+// and the environment.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"gobrm/auth"
+	"gobrm/config"
+
+	"github.com/volatiletech/null/v8"
+)
+
+func main() {
+	name := flag.String("name", "", "name of the key, shown in audits as api-key:<name>")
+	grants := flag.String("grant", "", "comma separated grants, e.g. bounce:editor,throughput:viewer or admin")
+	configFile := flag.String("config", "", "config file; defaults to $"+config.FileEnv)
+	flag.Parse()
+	if *name == "" || *grants == "" {
+		flag.Usage()
+		log.Fatal("-name and -grant are required")
+	}
+
+	var keyGrants []auth.Grant
+	for _, value := range strings.Split(*grants, ",") {
+		grant, err := auth.ParseGrant(strings.TrimSpace(value))
+		if err != nil {
+			log.Fatalf("Invalid grant %q: %v", value, err)
+		}
+		keyGrants = append(keyGrants, grant)
+	}
+
+	c, err := config.Load(*configFile, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.RedactLogs(c)
+
+	db, err := c.MySQL.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	key, err := auth.CreateKey(context.Background(), db, *name, keyGrants, "cmd/apikey", null.Time{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Created API key %d for %s; it cannot be shown again", key.ID, key.Name)
+	fmt.Println(key.Key)
+}
@@ -1,23 +1,41 @@
 package main
 
 import (
-	"fmt"
-	"gobrm/bouncerule"
+	"log"
 	"os"
+
+	"gobrm/bouncerule"
+	"gobrm/config"
 )
 
-// TODO: Kelsey hightower env config
 func main() {
-	a := bouncerule.App{}
-
-	user := os.Getenv("MYSQL_USER")
-	password := os.Getenv("MYSQL_PASSWORD")
-	dbname := os.Getenv("MYSQL_DATABASE")
-	port := os.Getenv("SERVER_PORT")
-	address := fmt.Sprintf(":%s", port)
+	c, err := config.Parse(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.RedactLogs(c)
+	log.Printf("Config: %s", c)
 
-	fmt.Printf("Running server on port %s...", port)
+	if err := c.Validate(); err != nil {
+		log.Fatal(err)
+	}
 
-	a.Initialize(user, password, dbname)
-	a.Run(address)
+	a := bouncerule.App{
+		InitialRolloutPercentage: c.Server.InitialRolloutPercentage,
+		GRPCAddr:                 c.Server.GRPCAddr(),
+		IdempotencyWindow:        c.Server.IdempotencyWindow,
+		V1DeprecatedAt:           c.Server.V1DeprecatedAt,
+		V1Sunset:                 c.Server.V1Sunset,
+		Auth:                     c.Server.AuthConfig(),
+		RateLimit:                c.Server.RateLimitConfig(),
+		TLS:                      c.Server.TLSConfig(),
+		Timeouts:                 c.Server.HTTPTimeouts(),
+		ShutdownTimeout:          c.Server.Timeouts.Shutdown,
+	}
+	log.Printf("Running server on %s...", c.Server.Addr())
+	a.Initialize(c.MySQL)
+	a.RequireChangeRequests = c.Server.RequireChangeRequests
+	if err := a.Run(c.Server.Addr()); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,41 @@
+// Command config checks the config of the rule managers without starting a
+// server. It reads the config file, environment variables and flags the
+// servers read, and prints the result with its secrets redacted:
+//
+//	go run ./cmd/config validate --config /etc/gobrm/gobrm.yaml
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"gobrm/config"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "validate" {
+		fmt.Fprintf(os.Stderr, "usage: %s validate [--config file] [flags]\n\n", os.Args[0])
+		config.FlagSet("validate").PrintDefaults()
+		os.Exit(2)
+	}
+
+	c, err := config.Parse("validate", os.Args[2:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Println(c)
+
+	if err := c.Validate(); err != nil {
+		if invalid, ok := err.(*config.ValidationError); ok {
+			fmt.Fprintln(os.Stderr, "Config is invalid:")
+			for _, problem := range invalid.Problems {
+				fmt.Fprintf(os.Stderr, "  - %s\n", problem)
+			}
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("Config is valid")
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"gobrm/config"
+	"gobrm/rulemanager"
+)
+
+func main() {
+	c, err := config.Parse(os.Args[0], os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	config.RedactLogs(c)
+	log.Printf("Config: %s", c)
+
+	if err := c.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Connecting to %s...", c.MySQL.RedactedDSN())
+	db, err := c.MySQL.Open()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := rulemanager.Server{}
+	s.Initialize(db, rulemanager.Config{
+		BounceRulesEnabled:       c.Server.BounceRulesEnabled,
+		ThroughputRulesEnabled:   c.Server.ThroughputRulesEnabled,
+		RequireChangeRequests:    c.Server.RequireChangeRequests,
+		InitialRolloutPercentage: c.Server.InitialRolloutPercentage,
+		GRPCAddr:                 c.Server.GRPCAddr(),
+		IdempotencyWindow:        c.Server.IdempotencyWindow,
+		V1DeprecatedAt:           c.Server.V1DeprecatedAt,
+		V1Sunset:                 c.Server.V1Sunset,
+		Auth:                     c.Server.AuthConfig(),
+		RateLimit:                c.Server.RateLimitConfig(),
+		TLS:                      c.Server.TLSConfig(),
+		Timeouts:                 c.Server.HTTPTimeouts(),
+		ShutdownTimeout:          c.Server.Timeouts.Shutdown,
+	})
+	if err := s.Run(c.Server.Addr()); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -1,43 +1,40 @@
 package main
 
 import (
-	"fmt"
 	"log"
+	"os"
 
+	"gobrm/config"
 	"gobrm/throughputrule"
-
-	"github.com/kelseyhightower/envconfig"
 )
 
-type MySQLConfig struct {
-	User     string
-	Password string
-	Database string
-	Port     int
-}
-
-type ServerConfig struct {
-	Port int
-}
-
 func main() {
-	var mySQLConfig MySQLConfig
-	err := envconfig.Process("mysql", &mySQLConfig)
+	c, err := config.Parse(os.Args[0], os.Args[1:])
 	if err != nil {
-		log.Fatal(err.Error())
+		log.Fatal(err)
 	}
-	log.Printf("MySQL Config: %+v", mySQLConfig)
+	config.RedactLogs(c)
+	log.Printf("Config: %s", c)
 
-	var serverConfig ServerConfig
-	err = envconfig.Process("server", &serverConfig)
-	if err != nil {
-		log.Fatal(err.Error())
+	if err := c.Validate(); err != nil {
+		log.Fatal(err)
 	}
-	log.Printf("Server Config: %+v", serverConfig)
-
-	address := fmt.Sprintf(":%d", serverConfig.Port)
 
-	a := throughputrule.App{}
-	a.Initialize(mySQLConfig.User, mySQLConfig.Password, mySQLConfig.Database)
-	a.Run(address)
+	a := throughputrule.App{
+		InitialRolloutPercentage: c.Server.InitialRolloutPercentage,
+		GRPCAddr:                 c.Server.GRPCAddr(),
+		IdempotencyWindow:        c.Server.IdempotencyWindow,
+		V1DeprecatedAt:           c.Server.V1DeprecatedAt,
+		V1Sunset:                 c.Server.V1Sunset,
+		Auth:                     c.Server.AuthConfig(),
+		RateLimit:                c.Server.RateLimitConfig(),
+		TLS:                      c.Server.TLSConfig(),
+		Timeouts:                 c.Server.HTTPTimeouts(),
+		ShutdownTimeout:          c.Server.Timeouts.Shutdown,
+	}
+	a.Initialize(c.MySQL)
+	a.RequireChangeRequests = c.Server.RequireChangeRequests
+	if err := a.Run(c.Server.Addr()); err != nil {
+		log.Fatal(err)
+	}
 }
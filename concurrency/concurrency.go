@@ -0,0 +1,94 @@
+package concurrency
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gobrm/problem"
+)
+
+// Precondition is the set of rule versions a write may apply to, from the
+// request's If-Match header. A nil Precondition allows any version.
+type Precondition []int
+
+// IfMatch reads the versions listed in If-Match. Tags that are weak or not a
+// version never match, so a request carrying only those always conflicts.
+// A missing header or "*" makes the write unconditional.
+func IfMatch(r *http.Request) Precondition {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := Precondition{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			continue
+		}
+		version, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err != nil {
+			continue
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// Allows reports whether a write may apply to a rule at the given version.
+func (p Precondition) Allows(version int) bool {
+	if p == nil {
+		return true
+	}
+	for _, v := range p {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Check returns a ConflictError carrying the current rule when the
+// precondition does not allow its version.
+func (p Precondition) Check(currentVersion int, current interface{}) error {
+	if p.Allows(currentVersion) {
+		return nil
+	}
+	return ConflictError{CurrentVersion: currentVersion, Current: current}
+}
+
+// ETag formats a rule version as a strong entity tag.
+func ETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ConflictError is returned when a rule changed since the client read it.
+type ConflictError struct {
+	CurrentVersion int
+	Current        interface{}
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("version conflict, the current version is %d", e.CurrentVersion)
+}
+
+// RespondWithConflict writes a 412 Precondition Failed problem with the
+// current version and rule so the client can merge its change and retry.
+func RespondWithConflict(w http.ResponseWriter, r *http.Request, err ConflictError) {
+	w.Header().Set("ETag", ETag(err.CurrentVersion))
+	problem.Write(w, r, ConflictProblem(err, err.Error()))
+}
+
+// ConflictProblem describes a version conflict with the current version and rule.
+func ConflictProblem(err ConflictError, detail string) problem.Problem {
+	p := problem.New(http.StatusPreconditionFailed, detail)
+	p.Type = problem.TypeVersionConflict
+	p.Title = "Version Conflict"
+	p.Extensions = map[string]interface{}{
+		"current_version": err.CurrentVersion,
+		"current":         err.Current,
+	}
+	return p
+}
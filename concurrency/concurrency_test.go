@@ -0,0 +1,64 @@
+package concurrency
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gobrm/apiversion"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	log.Print("Testing If-Match headers are read as rule versions")
+	req := httptest.NewRequest("PUT", "/throughput_rules/1", nil)
+	assert.Nil(t, IfMatch(req), "should be unconditional without If-Match")
+
+	req.Header.Set("If-Match", "*")
+	assert.Nil(t, IfMatch(req), "should be unconditional for any version")
+
+	req.Header.Set("If-Match", `"3", W/"4", "abc"`)
+	precondition := IfMatch(req)
+	assert.Equal(t, Precondition{3}, precondition, "should skip weak and non-version tags")
+	assert.True(t, precondition.Allows(3))
+	assert.False(t, precondition.Allows(4))
+
+	req.Header.Set("If-Match", `W/"4"`)
+	assert.False(t, IfMatch(req).Allows(4), "weak tags should never match")
+}
+
+func TestCheck(t *testing.T) {
+	log.Print("Testing version conflicts carry the current version")
+	current := map[string]int{"id": 1, "version": 5}
+
+	assert.NoError(t, Precondition(nil).Check(5, current))
+	assert.NoError(t, Precondition{5}.Check(5, current))
+
+	err := Precondition{4}.Check(5, current)
+	assert.Equal(t, ConflictError{CurrentVersion: 5, Current: current}, err)
+
+	req := httptest.NewRequest("PUT", "/v2/throughput_rules/1", nil)
+	rr := httptest.NewRecorder()
+	RespondWithConflict(rr, req.WithContext(apiversion.NewContext(req.Context(), apiversion.V2)), err.(ConflictError))
+	assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+	assert.Equal(t, `"5"`, rr.Header().Get("ETag"))
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	var body struct {
+		Type           string         `json:"type"`
+		CurrentVersion int            `json:"current_version"`
+		Current        map[string]int `json:"current"`
+	}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, "urn:gobrm:problem:version-conflict", body.Type)
+	assert.Equal(t, 5, body.CurrentVersion)
+	assert.Equal(t, current, body.Current)
+
+	rr = httptest.NewRecorder()
+	RespondWithConflict(rr, httptest.NewRequest("PUT", "/throughput_rules/1", nil), err.(ConflictError))
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"error":"version conflict, the current version is 5","current_version":5,"current":{"id":1,"version":5}}`, rr.Body.String(), "v1 should keep the legacy body")
+}
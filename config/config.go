@@ -0,0 +1,367 @@
+// Package config reads the settings of the rule managers. Each setting has
+// a default, which a config file, then environment variables, then flags
+// override:
+//
+//	server:
+//	  port: 8443
+//	  tls:
+//	    cert_file: /etc/gobrm/tls/server.pem
+//
+// is SERVER_TLS_CERT_FILE=/etc/gobrm/tls/server.pem in the environment and
+// --server-tls-cert-file=/etc/gobrm/tls/server.pem on the command line.
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"gobrm/auth"
+	"gobrm/ratelimit"
+	"gobrm/tlsserver"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// FileEnv names the config file when --config is not given.
+const FileEnv = "GOBRM_CONFIG"
+
+// Config holds every setting of the rule managers. Each server reads the
+// settings it needs.
+type Config struct {
+	MySQL  MySQL  `mapstructure:"mysql"`
+	Server Server `mapstructure:"server"`
+}
+
+// Server holds how the servers are reached and how they behave.
+type Server struct {
+	Port     int `mapstructure:"port" default:"8000" usage:"HTTP port"`
+	GRPCPort int `mapstructure:"grpc_port" usage:"gRPC port, 0 to not serve gRPC"`
+	// Plaintext allows serving without TLS when no certificate is set.
+	Plaintext                bool          `mapstructure:"plaintext" usage:"serve plaintext HTTP and gRPC when no TLS certificate is set"`
+	BounceRulesEnabled       bool          `mapstructure:"bounce_rules_enabled" default:"true" usage:"serve bounce rules from the rule manager"`
+	ThroughputRulesEnabled   bool          `mapstructure:"throughput_rules_enabled" default:"true" usage:"serve throughput rules from the rule manager"`
+	RequireChangeRequests    bool          `mapstructure:"require_change_requests" usage:"only change rules through reviewed change requests"`
+	InitialRolloutPercentage int           `mapstructure:"initial_rollout_percentage" usage:"share of consumers a new release reaches, 0 for the default"`
+	IdempotencyWindow        time.Duration `mapstructure:"idempotency_window" default:"24h" usage:"how long Idempotency-Key requests are remembered"`
+	V1DeprecatedAt           time.Time     `mapstructure:"v1_deprecated_at" usage:"when v1 was deprecated, in RFC 3339, unset to not deprecate it"`
+	V1Sunset                 time.Time     `mapstructure:"v1_sunset" usage:"when v1 stops being served, in RFC 3339, six months after v1_deprecated_at by default"`
+	Auth                     Auth          `mapstructure:"auth"`
+	RateLimit                RateLimit     `mapstructure:"rate_limit"`
+	TLS                      TLS           `mapstructure:"tls"`
+	Timeouts                 Timeouts      `mapstructure:"timeouts"`
+}
+
+// Auth configures authentication; see auth.Config.
+type Auth struct {
+	Enabled            bool                     `mapstructure:"enabled" default:"true" usage:"require API keys, JWTs or client certificates"`
+	JWKSFile           string                   `mapstructure:"jwks_file" usage:"JWKS file JWTs are verified with"`
+	JWTIssuer          string                   `mapstructure:"jwt_issuer" usage:"required iss claim of JWTs"`
+	JWTAudience        string                   `mapstructure:"jwt_audience" usage:"required aud claim of JWTs"`
+	ClientCertificates []auth.ClientCertificate `mapstructure:"client_certificates" usage:"grants of client certificates, e.g. deployer=bounce:editor+throughput:viewer"`
+}
+
+// RateLimit sets the budgets of every client; see ratelimit.Config.
+type RateLimit struct {
+	Read            ratelimit.Limit   `mapstructure:"read" default:"1200/m" usage:"read budget of each client, e.g. 1200/m"`
+	Write           ratelimit.Limit   `mapstructure:"write" default:"120/m" usage:"write budget of each client, e.g. 120/m"`
+	Routes          []ratelimit.Route `mapstructure:"routes" usage:"route budgets, e.g. GET /bounce_rule_changes=60/m"`
+	Unauthenticated ratelimit.Limit   `mapstructure:"unauthenticated" default:"60/m" usage:"failed authentication budget of each IP address, e.g. 60/m"`
+}
+
+// TLS sets the certificates the servers are reached with; see tlsserver.Config.
+type TLS struct {
+	CertFile          string `mapstructure:"cert_file" usage:"PEM encoded server certificate chain"`
+	KeyFile           string `mapstructure:"key_file" usage:"PEM encoded server key"`
+	ClientCAFile      string `mapstructure:"client_ca_file" usage:"PEM encoded CAs client certificates are verified against"`
+	RequireClientCert bool   `mapstructure:"require_client_cert" usage:"reject clients without a verified certificate"`
+}
+
+// Timeouts bound requests and the shutdown; see tlsserver.Timeouts.
+type Timeouts struct {
+	ReadHeader time.Duration `mapstructure:"read_header" default:"10s" usage:"how long a client may take to send the request headers"`
+	Read       time.Duration `mapstructure:"read" default:"30s" usage:"how long a client may take to send the request"`
+	Write      time.Duration `mapstructure:"write" default:"60s" usage:"how long writing a response may take; event streams are exempt"`
+	Idle       time.Duration `mapstructure:"idle" default:"120s" usage:"how long a kept alive connection may wait for the next request"`
+	// Shutdown bounds finishing the requests in flight on SIGTERM or SIGINT.
+	Shutdown time.Duration `mapstructure:"shutdown" default:"30s" usage:"how long a shutdown waits for requests in flight and workers"`
+}
+
+// AuthConfig returns the config of the authenticator.
+func (s Server) AuthConfig() auth.Config {
+	return auth.Config{
+		Enabled:            s.Auth.Enabled,
+		JWKSFile:           s.Auth.JWKSFile,
+		Issuer:             s.Auth.JWTIssuer,
+		Audience:           s.Auth.JWTAudience,
+		ClientCertificates: s.Auth.ClientCertificates,
+	}
+}
+
+// RateLimitConfig returns the config of the rate limiter.
+func (s Server) RateLimitConfig() ratelimit.Config {
+	return ratelimit.Config{
+		Read:            s.RateLimit.Read,
+		Write:           s.RateLimit.Write,
+		Routes:          s.RateLimit.Routes,
+		Unauthenticated: s.RateLimit.Unauthenticated,
+	}
+}
+
+// TLSConfig returns the config of the TLS credentials.
+func (s Server) TLSConfig() tlsserver.Config {
+	return tlsserver.Config{
+		CertFile:          s.TLS.CertFile,
+		KeyFile:           s.TLS.KeyFile,
+		ClientCAFile:      s.TLS.ClientCAFile,
+		RequireClientCert: s.TLS.RequireClientCert,
+		Plaintext:         s.Plaintext,
+	}
+}
+
+// HTTPTimeouts returns the timeouts of the HTTP server.
+func (s Server) HTTPTimeouts() tlsserver.Timeouts {
+	return tlsserver.Timeouts{
+		ReadHeader: s.Timeouts.ReadHeader,
+		Read:       s.Timeouts.Read,
+		Write:      s.Timeouts.Write,
+		Idle:       s.Timeouts.Idle,
+	}
+}
+
+// Addr is the address HTTP is served on.
+func (s Server) Addr() string {
+	return fmt.Sprintf(":%d", s.Port)
+}
+
+// GRPCAddr is the address gRPC is served on, empty when it is not.
+func (s Server) GRPCAddr() string {
+	if s.GRPCPort == 0 {
+		return ""
+	}
+	return fmt.Sprintf(":%d", s.GRPCPort)
+}
+
+// setting is a leaf of Config.
+type setting struct {
+	key   string
+	field reflect.StructField
+}
+
+// flagName is the flag of a setting, e.g. --server-tls-cert-file.
+func (s setting) flagName() string {
+	return strings.NewReplacer(".", "-", "_", "-").Replace(s.key)
+}
+
+// settings lists the leaves of a struct type by their dotted keys.
+func settings(t reflect.Type, prefix string) []setting {
+	var leaves []setting
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("mapstructure")
+		if field.Type.Kind() == reflect.Struct && field.Tag.Get("usage") == "" {
+			leaves = append(leaves, settings(field.Type, key+".")...)
+			continue
+		}
+		leaves = append(leaves, setting{key: key, field: field})
+	}
+	return leaves
+}
+
+var allSettings = settings(reflect.TypeOf(Config{}), "")
+
+// FlagSet returns a flag set with --config and a flag for every setting.
+func FlagSet(name string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet(name, pflag.ContinueOnError)
+	flags.String("config", "", "config file, in any format viper reads; defaults to $"+FileEnv)
+	for _, s := range allSettings {
+		usage := s.field.Tag.Get("usage")
+		def := s.field.Tag.Get("default")
+		switch s.field.Type.Kind() {
+		case reflect.Bool:
+			flags.Bool(s.flagName(), def == "true", usage)
+		default:
+			flags.String(s.flagName(), def, usage)
+		}
+	}
+	return flags
+}
+
+// textUnmarshalerHook decodes strings into types such as time.Time and
+// ratelimit.Limit. Empty strings leave settings at their zero value.
+func textUnmarshalerHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	text, ok := data.(string)
+	if from.Kind() != reflect.String || !ok {
+		return data, nil
+	}
+	if text == "" && to.Kind() != reflect.String {
+		return reflect.Zero(to).Interface(), nil
+	}
+	unmarshaler, ok := reflect.New(to).Interface().(encoding.TextUnmarshaler)
+	if !ok {
+		return data, nil
+	}
+	if err := unmarshaler.UnmarshalText([]byte(text)); err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(unmarshaler).Elem().Interface(), nil
+}
+
+// Load reads the config from its defaults, the file, environment variables
+// and flags, each overriding the one before. file may be empty to use
+// $GOBRM_CONFIG, if set, and flags may be nil.
+func Load(file string, flags *pflag.FlagSet) (Config, error) {
+	v := viper.New()
+	for _, s := range allSettings {
+		v.SetDefault(s.key, s.field.Tag.Get("default"))
+		if flags != nil {
+			if flag := flags.Lookup(s.flagName()); flag != nil {
+				if err := v.BindPFlag(s.key, flag); err != nil {
+					return Config{}, err
+				}
+			}
+		}
+	}
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if file == "" {
+		file = os.Getenv(FileEnv)
+	}
+	if file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return Config{}, fmt.Errorf("reading config file %s: %w", file, err)
+		}
+	}
+
+	var c Config
+	err := v.UnmarshalExact(&c, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		textUnmarshalerHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)))
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config: %w", err)
+	}
+	return c, nil
+}
+
+// Parse reads the config with the flags in args, e.g. os.Args[1:].
+func Parse(name string, args []string) (Config, error) {
+	flags := FlagSet(name)
+	if err := flags.Parse(args); err != nil {
+		return Config{}, err
+	}
+	file, _ := flags.GetString("config")
+	return Load(file, flags)
+}
+
+// ValidationError lists everything wrong with a config.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the settings and the files they name, without connecting
+// to MySQL or serving anything.
+func (c Config) Validate() error {
+	problems := c.MySQL.validate()
+
+	s := c.Server
+	if s.Port < 1 || s.Port > 65535 {
+		problems = append(problems, "server.port must be between 1 and 65535")
+	}
+	if s.GRPCPort < 0 || s.GRPCPort > 65535 {
+		problems = append(problems, "server.grpc_port must be between 0 and 65535")
+	}
+	if s.GRPCPort != 0 && s.GRPCPort == s.Port {
+		problems = append(problems, "server.grpc_port must differ from server.port")
+	}
+	if !s.BounceRulesEnabled && !s.ThroughputRulesEnabled {
+		problems = append(problems, "at least one of server.bounce_rules_enabled and server.throughput_rules_enabled must be true")
+	}
+	if s.InitialRolloutPercentage < 0 || s.InitialRolloutPercentage > 100 {
+		problems = append(problems, "server.initial_rollout_percentage must be between 0 and 100")
+	}
+	if s.IdempotencyWindow < 0 {
+		problems = append(problems, "server.idempotency_window must not be negative")
+	}
+	if !s.V1Sunset.IsZero() && (s.V1DeprecatedAt.IsZero() || s.V1Sunset.Before(s.V1DeprecatedAt)) {
+		problems = append(problems, "server.v1_sunset needs server.v1_deprecated_at and must not be before it")
+	}
+	t := s.Timeouts
+	if t.ReadHeader < 0 || t.Read < 0 || t.Write < 0 || t.Idle < 0 || t.Shutdown < 0 {
+		problems = append(problems, "server.timeouts must not be negative")
+	}
+	if s.Auth.JWKSFile != "" {
+		if _, err := auth.LoadJWKS(s.Auth.JWKSFile); err != nil {
+			problems = append(problems, fmt.Sprintf("server.auth.jwks_file: %v", err))
+		}
+	}
+	if _, err := tlsserver.New(s.TLSConfig()); err != nil {
+		problems = append(problems, fmt.Sprintf("server.tls: %v", err))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Secrets returns the values of the secret settings, to redact from logs.
+func (c Config) Secrets() []string {
+	var secrets []string
+	value := reflect.ValueOf(c)
+	for _, s := range allSettings {
+		if s.field.Tag.Get("secret") != "true" {
+			continue
+		}
+		if secret := fieldByKey(value, s.key).String(); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// fieldByKey finds the value of a setting in a Config value.
+func fieldByKey(value reflect.Value, key string) reflect.Value {
+	for _, name := range strings.Split(key, ".") {
+		t := value.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).Tag.Get("mapstructure") == name {
+				value = value.Field(i)
+				break
+			}
+		}
+	}
+	return value
+}
+
+// Redacted returns a copy of the config with its secrets replaced, to log
+// or print.
+func (c Config) Redacted() Config {
+	value := reflect.ValueOf(&c).Elem()
+	for _, s := range allSettings {
+		if s.field.Tag.Get("secret") != "true" {
+			continue
+		}
+		if field := fieldByKey(value, s.key); field.String() != "" {
+			field.SetString(Redacted)
+		}
+	}
+	return c
+}
+
+func (c Config) String() string {
+	r := c.Redacted()
+	return fmt.Sprintf("mysql=%+v server=%+v", r.MySQL, r.Server)
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// tlsConfigName is the name the TLS config of MySQL.TLSCAFile is
+// registered with the driver under.
+const tlsConfigName = "gobrm"
+
+// tlsModes are the values of MySQL.TLS the driver understands.
+var tlsModes = map[string]bool{"false": true, "true": true, "skip-verify": true, "preferred": true}
+
+// MySQL is where the rule managers store rules and how they connect.
+type MySQL struct {
+	User     string `mapstructure:"user" usage:"MySQL user"`
+	Password string `mapstructure:"password" secret:"true" usage:"MySQL password"`
+	Database string `mapstructure:"database" usage:"MySQL database"`
+	Host     string `mapstructure:"host" default:"127.0.0.1" usage:"MySQL host"`
+	Port     int    `mapstructure:"port" default:"3306" usage:"MySQL port"`
+	// TLS is false, true, skip-verify or preferred, as in the driver's DSN.
+	TLS string `mapstructure:"tls" default:"false" usage:"MySQL TLS mode: false, true, skip-verify or preferred"`
+	// TLSCAFile verifies the server against these CAs instead of the system's.
+	TLSCAFile      string        `mapstructure:"tls_ca_file" usage:"PEM encoded CAs the MySQL server certificate is verified against"`
+	ConnectTimeout time.Duration `mapstructure:"connect_timeout" default:"10s" usage:"MySQL dial timeout"`
+	ReadTimeout    time.Duration `mapstructure:"read_timeout" default:"30s" usage:"MySQL I/O read timeout"`
+	WriteTimeout   time.Duration `mapstructure:"write_timeout" default:"30s" usage:"MySQL I/O write timeout"`
+	// MaxOpenConns caps the connection pool, so a busy client cannot take
+	// every connection MySQL allows; zero is unlimited.
+	MaxOpenConns    int           `mapstructure:"max_open_conns" default:"25" usage:"most open MySQL connections, 0 for unlimited"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns" default:"10" usage:"most idle MySQL connections kept open"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime" default:"5m" usage:"how long a MySQL connection is reused, 0 for ever"`
+}
+
+// driverConfig returns the driver config of the DSN.
+func (m MySQL) driverConfig() *mysql.Config {
+	config := mysql.NewConfig()
+	config.User = m.User
+	config.Passwd = m.Password
+	config.Net = "tcp"
+	config.Addr = net.JoinHostPort(m.Host, strconv.Itoa(m.Port))
+	config.DBName = m.Database
+	config.ParseTime = true
+	switch {
+	case m.TLSCAFile != "":
+		config.TLSConfig = tlsConfigName
+	case m.TLS != "false":
+		config.TLSConfig = m.TLS
+	}
+	config.Timeout = m.ConnectTimeout
+	config.ReadTimeout = m.ReadTimeout
+	config.WriteTimeout = m.WriteTimeout
+	return config
+}
+
+// DSN returns the data source name, including the password.
+func (m MySQL) DSN() string {
+	return m.driverConfig().FormatDSN()
+}
+
+// RedactedDSN returns the data source name to log.
+func (m MySQL) RedactedDSN() string {
+	config := m.driverConfig()
+	if config.Passwd != "" {
+		config.Passwd = Redacted
+	}
+	return config.FormatDSN()
+}
+
+// registerTLS registers the TLS config of TLSCAFile with the driver.
+func (m MySQL) registerTLS() error {
+	pem, err := ioutil.ReadFile(m.TLSCAFile)
+	if err != nil {
+		return fmt.Errorf("reading MySQL CA file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("%s holds no PEM encoded certificates", m.TLSCAFile)
+	}
+	return mysql.RegisterTLSConfig(tlsConfigName, &tls.Config{
+		RootCAs:    roots,
+		ServerName: m.Host,
+		MinVersion: tls.VersionTLS12,
+	})
+}
+
+// Open opens the connection pool. Like sql.Open it does not connect yet.
+func (m MySQL) Open() (*sql.DB, error) {
+	if m.TLSCAFile != "" {
+		if err := m.registerTLS(); err != nil {
+			return nil, err
+		}
+	}
+
+	db, err := sql.Open("mysql", m.DSN())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(m.MaxOpenConns)
+	db.SetMaxIdleConns(m.MaxIdleConns)
+	db.SetConnMaxLifetime(m.ConnMaxLifetime)
+	return db, nil
+}
+
+// validate returns the problems of the MySQL settings.
+func (m MySQL) validate() []string {
+	var problems []string
+	if m.User == "" {
+		problems = append(problems, "mysql.user is required")
+	}
+	if m.Database == "" {
+		problems = append(problems, "mysql.database is required")
+	}
+	if m.Host == "" {
+		problems = append(problems, "mysql.host is required")
+	}
+	if m.Port < 1 || m.Port > 65535 {
+		problems = append(problems, "mysql.port must be between 1 and 65535")
+	}
+	if !tlsModes[m.TLS] {
+		problems = append(problems, "mysql.tls must be false, true, skip-verify or preferred")
+	}
+	if m.TLSCAFile != "" {
+		if _, err := ioutil.ReadFile(m.TLSCAFile); err != nil {
+			problems = append(problems, fmt.Sprintf("mysql.tls_ca_file: %v", err))
+		}
+	}
+	if m.MaxOpenConns < 0 || m.MaxIdleConns < 0 {
+		problems = append(problems, "mysql.max_open_conns and mysql.max_idle_conns must not be negative")
+	}
+	if m.ConnectTimeout < 0 || m.ReadTimeout < 0 || m.WriteTimeout < 0 || m.ConnMaxLifetime < 0 {
+		problems = append(problems, "mysql timeouts and mysql.conn_max_lifetime must not be negative")
+	}
+	return problems
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Redacted replaces secrets in logs and printed configs.
+const Redacted = "[REDACTED]"
+
+// patterns find secrets whose values are not known up front: API keys keep
+// the prefix they are found by, bearer tokens and DSN passwords are hidden.
+var patterns = []struct {
+	re   *regexp.Regexp
+	repl []byte
+}{
+	{regexp.MustCompile(`gobrm_([0-9a-f]{8})_[0-9a-f]+`), []byte("gobrm_${1}_" + Redacted)},
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`), []byte("${1}" + Redacted)},
+	{regexp.MustCompile(`([^\s:/@]+):[^\s@]*@(tcp|unix)\(`), []byte("${1}:" + Redacted + "@${2}(")},
+}
+
+// Redactor is a writer that replaces secrets before writing to another
+// writer. The log package writes a line at a time, so a secret is never
+// split across writes.
+type Redactor struct {
+	w       io.Writer
+	mu      sync.RWMutex
+	secrets [][]byte
+}
+
+// NewRedactor redacts secrets, and the patterns of secrets, from what is
+// written to w.
+func NewRedactor(w io.Writer, secrets ...string) *Redactor {
+	r := &Redactor{w: w}
+	r.Add(secrets...)
+	return r
+}
+
+// Add redacts more secrets. Empty secrets are ignored.
+func (r *Redactor) Add(secrets ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			r.secrets = append(r.secrets, []byte(secret))
+		}
+	}
+}
+
+// Redact returns p with its secrets replaced.
+func (r *Redactor) Redact(p []byte) []byte {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, secret := range r.secrets {
+		p = bytes.ReplaceAll(p, secret, []byte(Redacted))
+	}
+	for _, pattern := range patterns {
+		p = pattern.re.ReplaceAll(p, pattern.repl)
+	}
+	return p
+}
+
+func (r *Redactor) Write(p []byte) (int, error) {
+	if _, err := r.w.Write(r.Redact(p)); err != nil {
+		return 0, err
+	}
+	// Callers check the length they wrote, not what reached w
+	return len(p), nil
+}
+
+// RedactLogs redacts the secrets of a config from every log line, both the
+// standard logger's and the request log's.
+func RedactLogs(c Config) {
+	secrets := c.Secrets()
+	log.SetOutput(NewRedactor(os.Stderr, secrets...))
+	middleware.DefaultLogger = middleware.RequestLogger(&middleware.DefaultLogFormatter{
+		Logger:  log.New(NewRedactor(os.Stdout, secrets...), "", log.LstdFlags),
+		NoColor: true,
+	})
+}
@@ -17,6 +17,9 @@ CREATE TABLE bounce_rule (
   priority TINYINT NOT NULL DEFAULT 0,
   description VARCHAR(255) NOT NULL,
   bounce_action VARCHAR(255) NOT NULL,
+  effective_at DATETIME NULL,
+  expires_at DATETIME NULL,
+  version INT NOT NULL DEFAULT 1,
   PRIMARY KEY(id)
 );
 
@@ -30,57 +33,56 @@ CREATE TABLE bounce_rule_change (
   priority TINYINT NOT NULL DEFAULT 0,
   description VARCHAR(255) NOT NULL,
   bounce_action VARCHAR(255) NOT NULL,
+  effective_at DATETIME NULL,
+  expires_at DATETIME NULL,
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  batch_id VARCHAR(36) NULL,
   PRIMARY KEY (id)
 );
 
 -- FOREIGN KEY (bounce_rule_id) REFERENCES bounce_rule(id) ON DELETE CASCADE
 
+-- Support filtering and keyset pagination of the change history
+CREATE INDEX bounce_rule_change_rule_idx ON bounce_rule_change (bounce_rule_id, id);
+CREATE INDEX bounce_rule_change_updated_at_idx ON bounce_rule_change (updated_at, id);
+CREATE INDEX bounce_rule_change_batch_idx ON bounce_rule_change (batch_id);
+
 -- SHOW TABLES;
 -- DESCRIBE bounce_rule;
 -- DESCRIBE bounce_rule_change;
 
--- Use triggers to update the bounce_rule_change table
-
-DELIMITER //
-CREATE TRIGGER add_bounce_rule_created_change AFTER INSERT ON bounce_rule
-FOR EACH ROW
-BEGIN
-  INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
-  VALUES('created', NEW.id, NEW.response_code, NEW.enhanced_code, NEW.regex, NEW.priority, NEW.description, NEW.bounce_action);
-END//
-DELIMITER ;
-
-DELIMITER //
-CREATE TRIGGER add_bounce_rule_updated_change AFTER UPDATE ON bounce_rule
-FOR EACH ROW
-BEGIN
-  INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
-  VALUES('updated', NEW.id, NEW.response_code, NEW.enhanced_code, NEW.regex, NEW.priority, NEW.description, NEW.bounce_action);
-END//
-DELIMITER ;
-
-DELIMITER //
-CREATE TRIGGER add_bounce_rule_deleted_change AFTER DELETE ON bounce_rule
-FOR EACH ROW
-BEGIN
-  INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
-  VALUES('deleted', OLD.id, OLD.response_code, OLD.enhanced_code, OLD.regex, OLD.priority, OLD.description, OLD.bounce_action);
-END//
-DELIMITER ;
-
--- SHOW TRIGGERS;
+-- Ensure both bounce_rule and bounce_rule_change writes happen together through transactions
 
+START TRANSACTION;
 INSERT INTO bounce_rule (response_code, enhanced_code, regex, priority, description, bounce_action)
-VALUES (500, '4.7.1', 'some 500 4.7.1 regex', 0, 'some description about 500 4.7.1', 'suppress');
+  VALUES (500, '4.7.1', 'some 500 4.7.1 regex', 0, 'some description about 500 4.7.1', 'suppress');
+INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
+  VALUES ('created', LAST_INSERT_ID(), 500, '4.7.1', 'some 500 4.7.1 regex', 0, 'some description about 500 4.7.1', 'suppress');
+COMMIT;
+
+START TRANSACTION;
 INSERT INTO bounce_rule (response_code, enhanced_code, regex, priority, description, bounce_action)
-VALUES (450, '4.7.2', 'some 450 4.7.2 regex', 0, 'some description about 450 4.7.2', 'retry');
+  VALUES (450, '4.7.2', 'some 450 4.7.2 regex', 0, 'some description about 450 4.7.2', 'retry');
+INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
+  VALUES ('created', LAST_INSERT_ID(), 450, '4.7.2', 'some 450 4.7.2 regex', 0, 'some description about 450 4.7.2', 'retry');
+COMMIT;
+
+START TRANSACTION;
 INSERT INTO bounce_rule (response_code, enhanced_code, regex, priority, description, bounce_action)
-VALUES (501, '4.7.3', 'some 501 4.7.3 regex', 0, 'some description about 501 4.7.3', 'no_action');
+  VALUES (501, '4.7.3', 'some 501 4.7.3 regex', 0, 'some description about 501 4.7.3', 'no_action');
+INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
+  VALUES ('created', LAST_INSERT_ID(), 501, '4.7.3', 'some 501 4.7.3 regex', 0, 'some description about 501 4.7.3', 'no_action');
+COMMIT;
+
+START TRANSACTION;
 INSERT INTO bounce_rule (response_code, enhanced_code, regex, priority, description, bounce_action)
-VALUES (475, '5.0.1', 'some 475 5.0.1 regex', 0, 'some description about 475 5.0.1', 'suppress');
+  VALUES (475, '5.0.1', 'some 475 5.0.1 regex', 0, 'some description about 475 5.0.1', 'suppress');
+INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
+  VALUES ('created', LAST_INSERT_ID(), 475, '5.0.1', 'some 475 5.0.1 regex', 0, 'some description about 475 5.0.1', 'suppress');
+COMMIT;
 
-UPDATE bounce_rule 
+START TRANSACTION;
+UPDATE bounce_rule
 SET response_code = 502,
     enhanced_code = '4.7.3',
     regex = 'some 502 4.7.3 regex',
@@ -88,9 +90,16 @@ SET response_code = 502,
     description = 'some description about 502 4.7.3',
     bounce_action = 'suppress'
 WHERE id = 3;
+INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
+  VALUES ('updated', 3, 502, '4.7.3', 'some 502 4.7.3 regex', 1, 'some description about 502 4.7.3', 'suppress');
+COMMIT;
 
 -- SELECT * FROM bounce_rule;
+START TRANSACTION;
 DELETE FROM bounce_rule WHERE id = 4;
+INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
+  VALUES ('deleted', 4, 475, '5.0.1', 'some 475 5.0.1 regex', 0, 'some description about 475 5.0.1', 'suppress');
+COMMIT;
 
 -- INSERT INTO bounce_rule_change (action, bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
 -- VALUES ('created', 1, 500, '4.7.1', 'some 500 4.7.1 regex', 0, 'some description about 500 4.7.1', 'suppress');
@@ -101,13 +110,15 @@ DELETE FROM bounce_rule WHERE id = 4;
 -- INSERT INTO bounce_rule_change (bounce_rule_id, response_code, enhanced_code, regex, priority, description, bounce_action)
 -- VALUES ('created', 4, 475, '5.0.1', 'some 475 5.0.1 regex', 0, 'some description about 475 5.0.1', 'suppress');
 
-
 CREATE TABLE throughput_rule (
   id INT(10) NOT NULL AUTO_INCREMENT,
   mx_domain VARCHAR(255) NOT NULL UNIQUE,
   max_connections INT(11) NOT NULL,
   messages_per_connection INT(11) NOT NULL,
   connection_ttl_millis INT(11) NOT NULL,
+  effective_at DATETIME NULL,
+  expires_at DATETIME NULL,
+  version INT NOT NULL DEFAULT 1,
   PRIMARY KEY(id)
 );
 
@@ -119,11 +130,20 @@ CREATE TABLE throughput_rule_change (
   max_connections INT(11) NOT NULL,
   messages_per_connection INT(11) NOT NULL,
   connection_ttl_millis INT(11) NOT NULL,
+  effective_at DATETIME NULL,
+  expires_at DATETIME NULL,
   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-  PRIMARY KEY (id),
-  FOREIGN KEY (throughput_rule_id) REFERENCES throughput_rule(id) ON DELETE CASCADE
+  batch_id VARCHAR(36) NULL,
+  PRIMARY KEY (id)
 );
 
+-- FOREIGN KEY (throughput_rule_id) REFERENCES throughput_rule(id) ON DELETE CASCADE
+
+-- Support filtering and keyset pagination of the change history
+CREATE INDEX throughput_rule_change_rule_idx ON throughput_rule_change (throughput_rule_id, id);
+CREATE INDEX throughput_rule_change_updated_at_idx ON throughput_rule_change (updated_at, id);
+CREATE INDEX throughput_rule_change_batch_idx ON throughput_rule_change (batch_id);
+
 -- SHOW TABLES;
 -- DESCRIBE throughput_rule;
 -- DESCRIBE throughput_rule_change;
@@ -143,3 +163,143 @@ INSERT INTO throughput_rule (mx_domain, max_connections, messages_per_connection
 INSERT INTO throughput_rule_change (action, throughput_rule_id, mx_domain, max_connections, messages_per_connection, connection_ttl_millis) 
   VALUES('created', LAST_INSERT_ID(), 'somemx.net', 100, 100, 15);
 COMMIT;
+
+-- Webhook subscriptions receive rule change events. Events are written to the outbox in the same
+-- transaction as the rule change and fanned out to one delivery per matching subscription.
+
+CREATE TABLE webhook_subscription (
+  id INT NOT NULL AUTO_INCREMENT,
+  url VARCHAR(2048) NOT NULL,
+  secret VARCHAR(255) NOT NULL,
+  event_types VARCHAR(1024) NOT NULL DEFAULT '',
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE webhook_outbox (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  event_type VARCHAR(64) NOT NULL,
+  payload TEXT NOT NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  dispatched_at DATETIME NULL,
+  PRIMARY KEY (id),
+  INDEX (dispatched_at)
+);
+
+CREATE TABLE webhook_delivery (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  subscription_id INT NOT NULL,
+  outbox_id BIGINT NOT NULL,
+  event_type VARCHAR(64) NOT NULL,
+  payload TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'pending',
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  last_response_code INT NULL,
+  last_error VARCHAR(1024) NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  delivered_at DATETIME NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY (subscription_id, outbox_id),
+  INDEX (status, next_attempt_at),
+  FOREIGN KEY (subscription_id) REFERENCES webhook_subscription(id) ON DELETE CASCADE
+);
+
+-- Change requests propose bounce or throughput rule changes that must be approved by someone
+-- other than the proposer before they are applied.
+
+CREATE TABLE rule_change_request (
+  id INT NOT NULL AUTO_INCREMENT,
+  rule_type VARCHAR(32) NOT NULL,
+  action VARCHAR(16) NOT NULL,
+  rule_id INT NULL,
+  payload TEXT NOT NULL,
+  status VARCHAR(16) NOT NULL DEFAULT 'draft',
+  proposed_by VARCHAR(255) NOT NULL,
+  reviewed_by VARCHAR(255) NULL,
+  review_comment VARCHAR(1024) NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  applied_at DATETIME NULL,
+  PRIMARY KEY (id),
+  INDEX (rule_type, status)
+);
+
+-- Releases freeze a rule set into an immutable, numbered snapshot with a content hash.
+
+CREATE TABLE rule_set_release (
+  id INT NOT NULL AUTO_INCREMENT,
+  rule_type VARCHAR(32) NOT NULL,
+  version INT NOT NULL,
+  content_hash CHAR(64) NOT NULL,
+  rules MEDIUMTEXT NOT NULL,
+  change_ids TEXT NOT NULL,
+  last_change_id INT NOT NULL DEFAULT 0,
+  published_by VARCHAR(255) NULL,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY (rule_type, version)
+);
+
+CREATE TABLE rule_set_rollout (
+  rule_type VARCHAR(32) NOT NULL,
+  release_version INT NOT NULL,
+  previous_version INT NULL,
+  percentage TINYINT NOT NULL,
+  updated_by VARCHAR(255) NULL,
+  updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+  PRIMARY KEY (rule_type)
+);
+
+CREATE TABLE rule_transition_watermark (
+  rule_type VARCHAR(32) NOT NULL,
+  checked_until DATETIME(6) NOT NULL,
+  PRIMARY KEY (rule_type)
+);
+
+CREATE TABLE idempotency_key (
+  idempotency_key VARCHAR(255) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  status INT NULL,
+  headers TEXT NULL,
+  body MEDIUMTEXT NULL,
+  redacted BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (idempotency_key),
+  INDEX (created_at)
+);
+
+-- API keys authenticate clients. Only a SHA-256 hash of each key is stored; the prefix finds it.
+-- Grants are comma separated, e.g. bounce:editor,throughput:viewer. Every request made with a
+-- key is recorded in its usage audit.
+
+CREATE TABLE api_key (
+  id INT NOT NULL AUTO_INCREMENT,
+  name VARCHAR(255) NOT NULL,
+  key_prefix CHAR(8) NOT NULL,
+  key_hash CHAR(64) NOT NULL,
+  grants VARCHAR(1024) NOT NULL,
+  created_by VARCHAR(255) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  expires_at DATETIME NULL,
+  revoked_at DATETIME NULL,
+  last_used_at DATETIME NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY (key_prefix)
+);
+
+CREATE TABLE api_key_usage (
+  id BIGINT NOT NULL AUTO_INCREMENT,
+  api_key_id INT NOT NULL,
+  method VARCHAR(16) NOT NULL,
+  path VARCHAR(2048) NOT NULL,
+  status INT NOT NULL,
+  request_id VARCHAR(255) NOT NULL DEFAULT '',
+  remote_addr VARCHAR(255) NOT NULL DEFAULT '',
+  used_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX (api_key_id, used_at),
+  FOREIGN KEY (api_key_id) REFERENCES api_key(id) ON DELETE CASCADE
+);
@@ -0,0 +1,74 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gobrm/problem"
+)
+
+// Merged serves several streams as one, e.g. the changes of both rule types.
+// Event types are prefixed with the name of their stream, as in
+// bounce_rule.created, and event IDs join the cursors of all streams with
+// dots, as in 12.7, so a client resumes each stream where it left off.
+// Polling, heartbeats and draining follow the first stream.
+type Merged struct {
+	Names   []string
+	Streams []*Stream
+}
+
+func (m *Merged) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		problem.Respond(w, r, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	ctx := r.Context()
+	cursors := make([]int, len(m.Streams))
+	if value := lastEventID(r); value != "" {
+		parts := strings.Split(value, ".")
+		invalid := len(parts) != len(m.Streams)
+		for i := 0; !invalid && i < len(parts); i++ {
+			var err error
+			cursors[i], err = parseCursor(parts[i])
+			invalid = err != nil
+		}
+		if invalid {
+			problem.Invalid(w, r, problem.FieldError{Field: "Last-Event-ID", Message: fmt.Sprintf("must be %d change IDs joined by dots, not %q", len(m.Streams), value)})
+			return
+		}
+	} else {
+		for i, s := range m.Streams {
+			if s.Head == nil {
+				continue
+			}
+			var err error
+			if cursors[i], err = s.Head(ctx); err != nil {
+				problem.Respond(w, r, http.StatusInternalServerError, "Failed to read latest "+m.Names[i]+" event: "+err.Error())
+				return
+			}
+		}
+	}
+
+	m.Streams[0].serve(w, r, flusher, func() error {
+		for i, s := range m.Streams {
+			i := i
+			var err error
+			cursors[i], err = s.drain(ctx, cursors[i], func(event Event) error {
+				ids := make([]string, len(cursors))
+				for j, cursor := range cursors {
+					ids[j] = strconv.Itoa(cursor)
+				}
+				ids[i] = strconv.Itoa(event.ID)
+				return writeEvent(w, strings.Join(ids, "."), m.Names[i]+"."+event.Type, event.Data)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to write %s event after %d: %w", m.Names[i], cursors[i], err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,76 @@
+package events
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergedJoinsCursors(t *testing.T) {
+	log.Print("Testing merged streams prefix event types and join cursors")
+	bounceAfter := make(chan int, 10)
+	throughputAfter := make(chan int, 10)
+	merged := Merged{
+		Names: []string{"bounce_rule", "throughput_rule"},
+		Streams: []*Stream{
+			{
+				Source: func(ctx context.Context, after int, limit int) ([]Event, error) {
+					bounceAfter <- after
+					if after >= 13 {
+						return nil, nil
+					}
+					return []Event{{ID: 13, Type: "created", Data: map[string]int{"id": 1}}}, nil
+				},
+				PollInterval:      10 * time.Millisecond,
+				HeartbeatInterval: time.Hour,
+			},
+			{
+				Source: func(ctx context.Context, after int, limit int) ([]Event, error) {
+					throughputAfter <- after
+					if after >= 8 {
+						return nil, nil
+					}
+					return []Event{{ID: 8, Type: "deleted", Data: map[string]int{"id": 2}}}, nil
+				},
+				Head: func(ctx context.Context) (int, error) {
+					t.Error("head should not be read when resuming")
+					return 0, nil
+				},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "12.7")
+	rr := httptest.NewRecorder()
+
+	merged.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 12, <-bounceAfter, "should resume the first stream from the first cursor")
+	assert.Equal(t, 7, <-throughputAfter, "should resume the second stream from the second cursor")
+	assert.Contains(t, rr.Body.String(), "id: 13.7\nevent: bounce_rule.created\ndata: {\"id\":1}\n\n")
+	assert.Contains(t, rr.Body.String(), "id: 13.8\nevent: throughput_rule.deleted\ndata: {\"id\":2}\n\n")
+}
+
+func TestMergedRejectsPartialLastEventID(t *testing.T) {
+	log.Print("Testing merged streams need a cursor for every stream")
+	merged := Merged{Names: []string{"bounce_rule", "throughput_rule"}, Streams: []*Stream{{}, {}}}
+
+	for _, lastEventID := range []string{"12", "12.x", "12.7.3", "12.-1"} {
+		req := httptest.NewRequest("GET", "/events", nil)
+		req.Header.Set("Last-Event-ID", lastEventID)
+		rr := httptest.NewRecorder()
+
+		merged.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code, lastEventID)
+	}
+}
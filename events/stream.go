@@ -0,0 +1,247 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gobrm/problem"
+)
+
+// Event is a single server-sent event. ID is the cursor clients resume from
+// and is taken from the change table the event was read from. At is when the
+// change was written; events without it are sent as soon as they are read.
+type Event struct {
+	ID   int
+	Type string
+	Data interface{}
+	At   time.Time
+}
+
+// Source returns up to limit events with an ID greater than after, ordered by ID.
+type Source func(ctx context.Context, after int, limit int) ([]Event, error)
+
+// Head returns the ID of the most recent event so new clients only see what
+// happens after they connect.
+type Head func(ctx context.Context) (int, error)
+
+const (
+	defaultPollInterval      = time.Second
+	defaultHeartbeatInterval = 15 * time.Second
+	defaultBatchSize         = 100
+	defaultSettleWindow      = 5 * time.Second
+)
+
+// Stream serves a server-sent event stream by polling a Source.
+type Stream struct {
+	Source            Source
+	Head              Head
+	PollInterval      time.Duration
+	HeartbeatInterval time.Duration
+	BatchSize         int
+	// SettleWindow holds back events younger than it, five seconds by
+	// default. Change IDs are taken when a change is inserted, not when it
+	// commits, so a change may become visible after one with a higher ID and
+	// would be skipped by a cursor already past it. Waiting gives such
+	// transactions time to commit first.
+	SettleWindow time.Duration
+	// Draining ends the stream when it is closed, e.g. when the server shuts
+	// down, so clients resume from their last event on another server.
+	Draining <-chan struct{}
+}
+
+func (s *Stream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		problem.Respond(w, r, http.StatusInternalServerError, "Streaming unsupported")
+		return
+	}
+
+	ctx := r.Context()
+	var cursor int
+	if value := lastEventID(r); value != "" {
+		var err error
+		if cursor, err = parseCursor(value); err != nil {
+			problem.Invalid(w, r, problem.FieldError{Field: "Last-Event-ID", Message: fmt.Sprintf("must be a change ID, not %q", value)})
+			return
+		}
+	} else if s.Head != nil {
+		var err error
+		if cursor, err = s.Head(ctx); err != nil {
+			problem.Respond(w, r, http.StatusInternalServerError, "Failed to read latest event: "+err.Error())
+			return
+		}
+	}
+
+	s.serve(w, r, flusher, func() error {
+		var err error
+		cursor, err = s.drain(ctx, cursor, func(event Event) error {
+			return writeEvent(w, strconv.Itoa(event.ID), event.Type, event.Data)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write event after %d: %w", cursor, err)
+		}
+		return nil
+	})
+}
+
+// serve opens the event stream and calls poll every poll interval, with
+// heartbeats in between, until the client leaves, the stream drains or poll
+// fails.
+func (s *Stream) serve(w http.ResponseWriter, r *http.Request, flusher http.Flusher, poll func() error) {
+	// The stream stays open for as long as the client listens, so the
+	// server's write timeout must not cut it off
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("Failed to lift the write deadline of an event stream: %s", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", s.pollInterval().Milliseconds())
+	flusher.Flush()
+
+	pollTicker := time.NewTicker(s.pollInterval())
+	defer pollTicker.Stop()
+	heartbeat := time.NewTicker(s.heartbeatInterval())
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.Draining:
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		case <-pollTicker.C:
+			err := poll()
+			flusher.Flush()
+			if err != nil {
+				log.Printf("Closing event stream: %s", err)
+				return
+			}
+		}
+	}
+}
+
+// Follow sends every event after cursor, and then each new event as it is
+// read, until ctx is done, the stream drains or send fails. It returns
+// send's error.
+func (s *Stream) Follow(ctx context.Context, cursor int, send func(Event) error) error {
+	poll := time.NewTicker(s.pollInterval())
+	defer poll.Stop()
+
+	for {
+		var err error
+		if cursor, err = s.drain(ctx, cursor, send); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.Draining:
+			return nil
+		case <-poll.C:
+		}
+	}
+}
+
+// drain sends the events after cursor in batches until the Source has no
+// more, or until the first event still inside the settle window, and returns
+// the ID of the last event sent. Source errors are logged and retried on the
+// next poll; only send errors are returned.
+func (s *Stream) drain(ctx context.Context, cursor int, send func(Event) error) (int, error) {
+	for {
+		events, err := s.Source(ctx, cursor, s.batchSize())
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to read events after %d: %s", cursor, err)
+			}
+			return cursor, nil
+		}
+
+		settled := time.Now().Add(-s.settleWindow())
+		for _, event := range events {
+			if event.At.After(settled) {
+				return cursor, nil
+			}
+			if err := send(event); err != nil {
+				return cursor, err
+			}
+			cursor = event.ID
+		}
+
+		if len(events) < s.batchSize() {
+			return cursor, nil
+		}
+	}
+}
+
+// lastEventID reads the cursor a client is resuming from. Clients that cannot
+// set the Last-Event-ID header may pass it as the last_event_id query parameter.
+func lastEventID(r *http.Request) string {
+	if value := r.Header.Get("Last-Event-ID"); value != "" {
+		return value
+	}
+	return r.URL.Query().Get("last_event_id")
+}
+
+func parseCursor(value string) (int, error) {
+	cursor, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+	if cursor < 0 {
+		return 0, fmt.Errorf("negative change ID %d", cursor)
+	}
+	return cursor, nil
+}
+
+func writeEvent(w io.Writer, id string, eventType string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, eventType, body)
+	return err
+}
+
+func (s *Stream) pollInterval() time.Duration {
+	if s.PollInterval > 0 {
+		return s.PollInterval
+	}
+	return defaultPollInterval
+}
+
+func (s *Stream) heartbeatInterval() time.Duration {
+	if s.HeartbeatInterval > 0 {
+		return s.HeartbeatInterval
+	}
+	return defaultHeartbeatInterval
+}
+
+func (s *Stream) settleWindow() time.Duration {
+	if s.SettleWindow > 0 {
+		return s.SettleWindow
+	}
+	return defaultSettleWindow
+}
+
+func (s *Stream) batchSize() int {
+	if s.BatchSize > 0 {
+		return s.BatchSize
+	}
+	return defaultBatchSize
+}
@@ -0,0 +1,201 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamResumesFromLastEventID(t *testing.T) {
+	log.Print("Testing stream resumes from Last-Event-ID")
+	afterIDs := make(chan int, 10)
+	stream := Stream{
+		Source: func(ctx context.Context, after int, limit int) ([]Event, error) {
+			afterIDs <- after
+			if after >= 3 {
+				return nil, nil
+			}
+			return []Event{
+				{ID: 3, Type: "updated", Data: map[string]int{"id": 3}},
+			}, nil
+		},
+		Head: func(ctx context.Context) (int, error) {
+			t.Error("head should not be read when resuming")
+			return 0, nil
+		},
+		PollInterval:      10 * time.Millisecond,
+		HeartbeatInterval: time.Hour,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	req.Header.Set("Last-Event-ID", "2")
+	rr := httptest.NewRecorder()
+
+	stream.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+	assert.Equal(t, 2, <-afterIDs, "should resume after the Last-Event-ID")
+	assert.Equal(t, 3, <-afterIDs, "should advance the cursor past sent events")
+	assert.Equal(t, 1, strings.Count(rr.Body.String(), "id: 3\nevent: updated\ndata: {\"id\":3}\n\n"), "should send each event once")
+}
+
+func TestStreamStartsFromHead(t *testing.T) {
+	log.Print("Testing stream starts from head and sends heartbeats")
+	afterIDs := make(chan int, 10)
+	stream := Stream{
+		Source: func(ctx context.Context, after int, limit int) ([]Event, error) {
+			afterIDs <- after
+			return nil, nil
+		},
+		Head: func(ctx context.Context) (int, error) {
+			return 42, nil
+		},
+		PollInterval:      10 * time.Millisecond,
+		HeartbeatInterval: 10 * time.Millisecond,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("GET", "/events", nil).WithContext(ctx)
+	rr := httptest.NewRecorder()
+
+	stream.ServeHTTP(rr, req)
+
+	assert.Equal(t, 42, <-afterIDs, "should start after the latest event")
+	assert.Contains(t, rr.Body.String(), ": heartbeat\n\n")
+}
+
+func TestStreamRejectsInvalidLastEventID(t *testing.T) {
+	log.Print("Testing stream rejects an invalid Last-Event-ID")
+	stream := Stream{}
+	req := httptest.NewRequest("GET", "/events?last_event_id=abc", nil)
+	rr := httptest.NewRecorder()
+
+	stream.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestFollowSendsEventsUntilCancelled(t *testing.T) {
+	log.Print("Testing Follow sends events after the cursor until the context is done")
+	stream := Stream{
+		Source: func(ctx context.Context, after int, limit int) ([]Event, error) {
+			if after >= 5 {
+				return nil, nil
+			}
+			return []Event{{ID: after + 1, Type: "created"}}, nil
+		},
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    1,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	sent := []int{}
+	err := stream.Follow(ctx, 2, func(event Event) error {
+		sent = append(sent, event.ID)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 4, 5}, sent, "should send every event after the cursor once")
+}
+
+func TestFollowStopsWhenSendFails(t *testing.T) {
+	log.Print("Testing Follow returns the error of a failed send")
+	stream := Stream{
+		Source: func(ctx context.Context, after int, limit int) ([]Event, error) {
+			return []Event{{ID: after + 1}}, nil
+		},
+	}
+
+	sendErr := errors.New("client went away")
+	err := stream.Follow(context.Background(), 0, func(event Event) error {
+		return sendErr
+	})
+
+	assert.Equal(t, sendErr, err)
+}
+
+func TestDrainWaitsForOutOfOrderCommits(t *testing.T) {
+	log.Print("Testing a change committed after one with a higher ID is not skipped")
+	inserted := time.Now()
+	changes := []Event{{ID: 10, At: inserted}, {ID: 11, At: inserted.Add(time.Millisecond)}}
+	committed := map[int]bool{11: true}
+	stream := Stream{
+		Source: func(ctx context.Context, after int, limit int) ([]Event, error) {
+			events := []Event{}
+			for _, event := range changes {
+				if event.ID > after && committed[event.ID] {
+					events = append(events, event)
+				}
+			}
+			return events, nil
+		},
+		SettleWindow: 50 * time.Millisecond,
+	}
+	sent := []int{}
+	send := func(event Event) error {
+		sent = append(sent, event.ID)
+		return nil
+	}
+
+	cursor, err := stream.drain(context.Background(), 9, send)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, cursor, "should not move past changes that may still have gaps before them")
+	assert.Empty(t, sent)
+
+	committed[10] = true
+	time.Sleep(60 * time.Millisecond)
+	cursor, err = stream.drain(context.Background(), cursor, send)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, cursor)
+	assert.Equal(t, []int{10, 11}, sent, "should send both changes in ID order once they settle")
+}
+
+func TestStreamOutlivesWriteTimeoutUntilDraining(t *testing.T) {
+	log.Print("Testing streams outlive the write timeout and end when draining")
+	draining := make(chan struct{})
+	stream := &Stream{
+		Source:            func(ctx context.Context, after int, limit int) ([]Event, error) { return nil, nil },
+		PollInterval:      10 * time.Millisecond,
+		HeartbeatInterval: 10 * time.Millisecond,
+		Draining:          draining,
+	}
+	server := httptest.NewUnstartedServer(stream)
+	server.Config.WriteTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	time.AfterFunc(150*time.Millisecond, func() { close(draining) })
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err, "should end the stream cleanly when draining")
+	assert.Greater(t, strings.Count(string(body), ": heartbeat"), 5, "should keep sending past the write timeout")
+}
+
+func TestFollowStopsWhenDraining(t *testing.T) {
+	log.Print("Testing Follow returns when the stream drains")
+	draining := make(chan struct{})
+	close(draining)
+	stream := Stream{
+		Source:   func(ctx context.Context, after int, limit int) ([]Event, error) { return nil, nil },
+		Draining: draining,
+	}
+
+	assert.NoError(t, stream.Follow(context.Background(), 0, func(event Event) error { return nil }))
+}
@@ -0,0 +1,188 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	"gobrm/changequery"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/problem"
+	"gobrm/rulespb"
+
+	"github.com/volatiletech/null/v8"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Server is a gRPC server with health checking and reflection. The rule
+// managers register their services on it before ListenAndServe.
+type Server struct {
+	*grpc.Server
+	health *health.Server
+}
+
+// New creates a server that answers health checks and reflection requests.
+func New(opts ...grpc.ServerOption) *Server {
+	s := &Server{Server: grpc.NewServer(opts...), health: health.NewServer()}
+	healthpb.RegisterHealthServer(s.Server, s.health)
+	reflection.Register(s.Server)
+	return s
+}
+
+// ListenAndServe reports every registered service as serving and serves
+// gRPC on addr.
+func (s *Server) ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	s.markServing()
+	log.Printf("Serving gRPC on %s", listener.Addr())
+	return s.Serve(listener)
+}
+
+// Shutdown reports every service as not serving, so clients stop sending
+// calls, and stops the server once the calls in flight finish. Calls still
+// running when ctx ends are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.Stop()
+		return ctx.Err()
+	}
+}
+
+// markServing reports every registered service, and the server as a whole,
+// as serving.
+func (s *Server) markServing() {
+	for service := range s.GetServiceInfo() {
+		s.health.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+}
+
+// ErrDirectChangesDisabled rejects direct rule writes when they must go
+// through change requests.
+var ErrDirectChangesDisabled = status.Error(codes.PermissionDenied, "Direct rule changes are disabled, propose a change request instead")
+
+// Error converts the errors of the rule managers' domain logic to gRPC
+// statuses, like the REST handlers do to HTTP status codes. notFound is the
+// message for a missing rule. Internal errors are logged, not returned.
+func Error(err error, notFound string) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var conflict concurrency.ConflictError
+	var validationErr changerequest.ValidationError
+	var duplicateErr problem.DuplicateError
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Error(codes.NotFound, notFound)
+	case errors.As(err, &conflict):
+		return status.Error(codes.FailedPrecondition, conflict.Error())
+	case errors.As(err, &validationErr):
+		return status.Error(codes.InvalidArgument, validationErr.Message)
+	case errors.As(err, &duplicateErr):
+		return status.Error(codes.AlreadyExists, duplicateErr.Error())
+	default:
+		// Like the REST API, keep database errors in the logs
+		log.Printf("Failed to handle gRPC request: %v", err)
+		return status.Error(codes.Internal, "The server failed to handle the request")
+	}
+}
+
+// Precondition reads an optional if_match_version like an If-Match header.
+func Precondition(version *int32) concurrency.Precondition {
+	if version == nil {
+		return nil
+	}
+	return concurrency.Precondition{int(*version)}
+}
+
+// ChangeParams reads a change history request with the same rules as the
+// query parameters of the REST endpoints.
+func ChangeParams(req *rulespb.ListChangesRequest, filterFields []string) (changequery.Params, error) {
+	values := url.Values{}
+	if req.PageSize != 0 {
+		values.Set("limit", strconv.Itoa(int(req.PageSize)))
+	}
+	if req.PageToken != "" {
+		values.Set("cursor", req.PageToken)
+	}
+	if req.Sort != "" {
+		values.Set("sort", req.Sort)
+	}
+	values["action"] = req.Actions
+	if req.RuleId != nil {
+		values.Set("rule_id", strconv.Itoa(int(*req.RuleId)))
+	}
+	if req.Since != nil {
+		values.Set("since", req.Since.AsTime().Format(time.RFC3339Nano))
+	}
+	if req.Until != nil {
+		values.Set("until", req.Until.AsTime().Format(time.RFC3339Nano))
+	}
+	for field, value := range req.Filters {
+		if !contains(filterFields, field) {
+			return changequery.Params{}, status.Errorf(codes.InvalidArgument, "Unknown filter %q", field)
+		}
+		values.Set(field, value)
+	}
+
+	params, err := changequery.Parse(values, filterFields)
+	if err != nil {
+		return params, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return params, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Timestamp converts an optional rule timestamp, leaving it unset when null.
+func Timestamp(t null.Time) *timestamppb.Timestamp {
+	if !t.Valid {
+		return nil
+	}
+	return timestamppb.New(t.Time)
+}
+
+// Time converts an optional timestamp back to a nullable rule timestamp.
+func Time(t *timestamppb.Timestamp) null.Time {
+	if t == nil {
+		return null.Time{}
+	}
+	return null.TimeFrom(t.AsTime())
+}
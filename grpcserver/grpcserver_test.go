@@ -0,0 +1,133 @@
+package grpcserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/problem"
+	"gobrm/rulespb"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestError(t *testing.T) {
+	log.Print("Testing domain errors become gRPC statuses")
+	assert.NoError(t, Error(nil, "Bounce rule not found"))
+	assert.Equal(t, codes.NotFound, status.Code(Error(sql.ErrNoRows, "Bounce rule not found")))
+	assert.Equal(t, "Bounce rule not found", status.Convert(Error(sql.ErrNoRows, "Bounce rule not found")).Message())
+	assert.Equal(t, codes.FailedPrecondition, status.Code(Error(concurrency.ConflictError{CurrentVersion: 2}, "")))
+	assert.Equal(t, codes.InvalidArgument, status.Code(Error(changerequest.ValidationError{Message: "bad"}, "")))
+	assert.Equal(t, codes.AlreadyExists, status.Code(Error(problem.DuplicateError{Field: "mx_domain", Value: "example.com"}, "")))
+	assert.Equal(t, codes.Internal, status.Code(Error(errors.New("connection refused"), "")))
+	assert.Equal(t, codes.PermissionDenied, status.Code(Error(ErrDirectChangesDisabled, "")), "should keep statuses as they are")
+}
+
+func TestChangeParams(t *testing.T) {
+	log.Print("Testing change history requests are read like the REST query parameters")
+	ruleID := int32(7)
+	since := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	params, err := ChangeParams(&rulespb.ListChangesRequest{
+		PageSize: 10,
+		Sort:     "-updated_at",
+		Actions:  []string{"created", "deleted"},
+		RuleId:   &ruleID,
+		Since:    timestamppb.New(since),
+		Filters:  map[string]string{"bounce_action": "suppress"},
+	}, []string{"bounce_action"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 10, params.Limit)
+	assert.Equal(t, "updated_at", params.Sort)
+	assert.True(t, params.Desc)
+	assert.Equal(t, []string{"created", "deleted"}, params.Actions)
+	assert.Equal(t, 7, *params.RuleID)
+	assert.True(t, since.Equal(*params.Since))
+	assert.Equal(t, map[string]string{"bounce_action": "suppress"}, params.Fields)
+
+	_, err = ChangeParams(&rulespb.ListChangesRequest{PageSize: 5000}, nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = ChangeParams(&rulespb.ListChangesRequest{Filters: map[string]string{"limit": "1"}}, []string{"bounce_action"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "should reject filters on unknown fields")
+}
+
+func TestServerAnswersHealthChecks(t *testing.T) {
+	log.Print("Testing the server reports registered services as serving")
+	s := New()
+	rulespb.RegisterBounceRulesServer(s, &rulespb.UnimplementedBounceRulesServer{})
+
+	listener := bufconn.Listen(1024 * 1024)
+	s.markServing()
+	go s.Serve(listener)
+	defer s.Stop()
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithInsecure(),
+	)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	health := healthpb.NewHealthClient(conn)
+	response, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "gobrm.rules.v1.BounceRules"})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.Status)
+
+	_, err = health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "gobrm.rules.v1.ThroughputRules"})
+	assert.Equal(t, codes.NotFound, status.Code(err), "should not report services that are not registered")
+
+	_, ok := s.GetServiceInfo()["grpc.reflection.v1alpha.ServerReflection"]
+	assert.True(t, ok, "should serve reflection")
+}
+
+type blockingBounceRules struct {
+	rulespb.UnimplementedBounceRulesServer
+	watching chan struct{}
+}
+
+func (b *blockingBounceRules) WatchBounceRuleChanges(req *rulespb.WatchChangesRequest, stream rulespb.BounceRules_WatchBounceRuleChangesServer) error {
+	close(b.watching)
+	<-stream.Context().Done()
+	return stream.Context().Err()
+}
+
+func TestShutdownCancelsCallsAtTheDeadline(t *testing.T) {
+	log.Print("Testing shutdown waits for calls in flight until its deadline")
+	s := New()
+	service := &blockingBounceRules{watching: make(chan struct{})}
+	rulespb.RegisterBounceRulesServer(s, service)
+
+	listener := bufconn.Listen(1024 * 1024)
+	s.markServing()
+	go s.Serve(listener)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithInsecure(),
+	)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	stream, err := rulespb.NewBounceRulesClient(conn).WatchBounceRuleChanges(context.Background(), &rulespb.WatchChangesRequest{})
+	assert.NoError(t, err)
+	<-service.watching
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, s.Shutdown(ctx))
+	_, err = stream.Recv()
+	assert.Error(t, err, "should end the call still running at the deadline")
+}
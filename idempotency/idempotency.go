@@ -0,0 +1,321 @@
+// Package idempotency lets clients retry mutating requests safely. A request
+// with an Idempotency-Key header is processed once; repeating the key replays
+// the stored response instead of applying the request again. Responses that
+// hold secrets are not stored; repeating their key gets a 409 instead.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"gobrm/auth"
+	"gobrm/problem"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// CREATE TABLE idempotency_key (
+//   idempotency_key VARCHAR(255) NOT NULL,
+//   request_hash CHAR(64) NOT NULL,
+//   status INT NULL,
+//   headers TEXT NULL,
+//   body MEDIUMTEXT NULL,
+//   redacted BOOLEAN NOT NULL DEFAULT FALSE,
+//   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   PRIMARY KEY (idempotency_key),
+//   INDEX (created_at)
+// );
+
+// Header is the request header that carries the client's key.
+const Header = "Idempotency-Key"
+
+// ReplayedHeader marks a response replayed from an earlier request.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxKeyLength is the longest key the idempotency_key table holds.
+const MaxKeyLength = 255
+
+const (
+	defaultWindow        = 24 * time.Hour
+	defaultPurgeInterval = 10 * time.Minute
+	// lockTimeout is how long a request may hold its key before it is
+	// presumed lost, e.g. to a crash, and the key may be claimed again.
+	lockTimeout = time.Minute
+)
+
+// Store keeps processed keys and their response snapshots.
+type Store struct {
+	DB *sql.DB
+	// Window is how long a key is remembered; it defaults to 24 hours.
+	Window time.Duration
+	// PurgeInterval is how often Run deletes expired keys; it defaults to 10 minutes.
+	PurgeInterval time.Duration
+}
+
+func (s *Store) window() time.Duration {
+	if s.Window <= 0 {
+		return defaultWindow
+	}
+	return s.Window
+}
+
+// snapshot is a stored response. A nil Status means the first request with
+// the key is still being processed. A Redacted response kept only its status.
+type snapshot struct {
+	RequestHash string
+	Status      sql.NullInt64
+	Headers     http.Header
+	Body        []byte
+	Redacted    bool
+}
+
+// requestHash fingerprints what a key was first used for, so reusing it for
+// another request can be told apart from a retry.
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// clientKey scopes a key to the authenticated client, so clients cannot
+// replay each other's responses by guessing keys.
+func clientKey(r *http.Request, key string) string {
+	identity, ok := auth.FromContext(r.Context())
+	if !ok {
+		return key
+	}
+	sum := sha256.Sum256([]byte(identity.Subject + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+// claim records the key as in flight. It reports false when the key is
+// already taken by an earlier request that has not expired.
+func (s *Store) claim(ctx context.Context, key string, hash string) (bool, error) {
+	_, err := s.DB.ExecContext(ctx,
+		"DELETE FROM idempotency_key WHERE idempotency_key = ? AND (created_at < NOW() - INTERVAL ? SECOND OR (status IS NULL AND created_at < NOW() - INTERVAL ? SECOND))",
+		key, int64(s.window().Seconds()), int64(lockTimeout.Seconds()))
+	if err != nil {
+		return false, err
+	}
+
+	result, err := s.DB.ExecContext(ctx, "INSERT IGNORE INTO idempotency_key (idempotency_key, request_hash) VALUES (?, ?)", key, hash)
+	if err != nil {
+		return false, err
+	}
+	claimed, err := result.RowsAffected()
+	return claimed == 1, err
+}
+
+func (s *Store) get(ctx context.Context, key string) (snapshot, error) {
+	var snap snapshot
+	var headers, body sql.NullString
+	err := s.DB.QueryRowContext(ctx, "SELECT request_hash, status, headers, body, redacted FROM idempotency_key WHERE idempotency_key = ?", key).
+		Scan(&snap.RequestHash, &snap.Status, &headers, &body, &snap.Redacted)
+	if err != nil {
+		return snap, err
+	}
+	if headers.Valid {
+		if err := json.Unmarshal([]byte(headers.String), &snap.Headers); err != nil {
+			return snap, err
+		}
+	}
+	snap.Body = []byte(body.String)
+	return snap, nil
+}
+
+func (s *Store) save(ctx context.Context, key string, rec *recorder) error {
+	if rec.redacted {
+		_, err := s.DB.ExecContext(ctx, "UPDATE idempotency_key SET status = ?, redacted = TRUE WHERE idempotency_key = ?", rec.status, key)
+		return err
+	}
+
+	headers, err := json.Marshal(rec.Header())
+	if err != nil {
+		return err
+	}
+	_, err = s.DB.ExecContext(ctx, "UPDATE idempotency_key SET status = ?, headers = ?, body = ? WHERE idempotency_key = ?",
+		rec.status, string(headers), rec.body.String(), key)
+	return err
+}
+
+func (s *Store) release(ctx context.Context, key string) error {
+	_, err := s.DB.ExecContext(ctx, "DELETE FROM idempotency_key WHERE idempotency_key = ?", key)
+	return err
+}
+
+// Purge deletes the keys older than the window.
+func (s *Store) Purge(ctx context.Context) (int64, error) {
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM idempotency_key WHERE created_at < NOW() - INTERVAL ? SECOND", int64(s.window().Seconds()))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Run purges expired keys until the context is cancelled.
+func (s *Store) Run(ctx context.Context) {
+	interval := s.PurgeInterval
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.Purge(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to purge idempotency keys: %s", err)
+			}
+		}
+	}
+}
+
+// recorder passes a response through while keeping a copy to store.
+type recorder struct {
+	http.ResponseWriter
+	status   int
+	body     bytes.Buffer
+	redacted bool
+}
+
+type recorderKey struct{}
+
+// Redacted marks a handler's responses as holding secrets, e.g. a new API key
+// or webhook secret. Only their status is stored, so a retry with the same
+// key gets a 409 rather than a replay of the secret.
+func Redacted(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if rec, ok := r.Context().Value(recorderKey{}).(*recorder); ok {
+			rec.redacted = true
+		}
+		next(w, r)
+	}
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// isMutating reports whether the method changes state and so honours keys.
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// Middleware processes each mutating request with an Idempotency-Key once.
+// A retry with the same key and payload gets the original response replayed;
+// the same key with a different method, path or body gets a 422. Server
+// errors are not stored, so a request that failed that way can be retried.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(Header)
+		if key == "" || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > MaxKeyLength {
+			problem.Write(w, r, problem.Validation("Invalid Idempotency-Key header", []problem.FieldError{{Field: Header, Message: "must be at most 255 characters"}}))
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			problem.Respond(w, r, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		hash := requestHash(r, body)
+		key = clientKey(r, key)
+
+		claimed, err := s.claim(r.Context(), key, hash)
+		if err != nil {
+			problem.Respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if !claimed {
+			s.replay(w, r, key, hash)
+			return
+		}
+
+		rec := &recorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), recorderKey{}, rec)))
+
+		// The response is already sent, so failing to store it only costs the
+		// client its replay; the key is released rather than left in flight.
+		ctx := context.Background()
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			err = s.release(ctx, key)
+		} else if err = s.save(ctx, key, rec); err != nil {
+			s.release(ctx, key)
+		}
+		if err != nil {
+			log.Printf("Failed to store the response for idempotency key %q: %s", key, err)
+		}
+	})
+}
+
+// replay answers a repeated key with the response stored for it.
+func (s *Store) replay(w http.ResponseWriter, r *http.Request, key string, hash string) {
+	snap, err := s.get(r.Context(), key)
+	if errors.Is(err, sql.ErrNoRows) {
+		problem.Respond(w, r, http.StatusConflict, "A request with this Idempotency-Key just expired, retry it")
+		return
+	}
+	if err != nil {
+		problem.Respond(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if snap.RequestHash != hash {
+		problem.Respond(w, r, http.StatusUnprocessableEntity, "The Idempotency-Key was already used for a different request")
+		return
+	}
+	if !snap.Status.Valid {
+		problem.Respond(w, r, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+		return
+	}
+	if snap.Redacted {
+		problem.Respond(w, r, http.StatusConflict, fmt.Sprintf("A request with this Idempotency-Key was already answered with status %d; its response held a secret and is not replayed", snap.Status.Int64))
+		return
+	}
+
+	for name, values := range snap.Headers {
+		// The replay is answered under the retry's own request ID.
+		if name != middleware.RequestIDHeader {
+			w.Header()[name] = values
+		}
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(int(snap.Status.Int64))
+	w.Write(snap.Body)
+}
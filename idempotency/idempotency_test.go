@@ -0,0 +1,211 @@
+package idempotency
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const createBody = `{"mx_domain": "yahoo.com"}`
+
+func newCreateRequest(key string, body string) *http.Request {
+	req, _ := http.NewRequest("POST", "/throughput_rules", strings.NewReader(body))
+	req.Header.Set(Header, key)
+	return req
+}
+
+// countingHandler creates a rule per call and reports how often it ran.
+func countingHandler(calls *int, code int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"1"`)
+		w.WriteHeader(code)
+		w.Write([]byte(`{"id":7}`))
+	})
+}
+
+func expectClaim(mock sqlmock.Sqlmock, key string, claimed bool) {
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM idempotency_key WHERE idempotency_key = ? AND")).
+		WithArgs(key, int64(86400), int64(60)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rowsAffected := int64(0)
+	if claimed {
+		rowsAffected = 1
+	}
+	mock.ExpectExec(regexp.QuoteMeta("INSERT IGNORE INTO idempotency_key (idempotency_key, request_hash) VALUES (?, ?)")).
+		WithArgs(key, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func TestFirstRequestIsStored(t *testing.T) {
+	log.Print("Testing the first request with a key is processed and its response stored")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	expectClaim(mock, "retry-1", true)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE idempotency_key SET status = ?, headers = ?, body = ? WHERE idempotency_key = ?")).
+		WithArgs(http.StatusCreated, `{"Content-Type":["application/json"],"Etag":["\"1\""]}`, `{"id":7}`, "retry-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	calls := 0
+	store := &Store{DB: db}
+	rr := httptest.NewRecorder()
+	store.Middleware(countingHandler(&calls, http.StatusCreated)).ServeHTTP(rr, newCreateRequest("retry-1", createBody))
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, `{"id":7}`, rr.Body.String())
+	assert.Empty(t, rr.Header().Get(ReplayedHeader))
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestRepeatedKeyReplaysResponse(t *testing.T) {
+	log.Print("Testing a repeated key replays the stored response without reprocessing")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	req := newCreateRequest("retry-1", createBody)
+	hash := requestHash(req, []byte(createBody))
+
+	expectClaim(mock, "retry-1", false)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT request_hash, status, headers, body, redacted FROM idempotency_key WHERE idempotency_key = ?")).
+		WithArgs("retry-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status", "headers", "body", "redacted"}).
+			AddRow(hash, http.StatusCreated, `{"Content-Type":["application/json"],"Etag":["\"1\""],"X-Request-Id":["first"]}`, `{"id":7}`, false))
+
+	calls := 0
+	store := &Store{DB: db}
+	rr := httptest.NewRecorder()
+	store.Middleware(countingHandler(&calls, http.StatusCreated)).ServeHTTP(rr, req)
+
+	assert.Equal(t, 0, calls, "should not create the rule again")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, `{"id":7}`, rr.Body.String())
+	assert.Equal(t, `"1"`, rr.Header().Get("ETag"))
+	assert.Equal(t, "true", rr.Header().Get(ReplayedHeader))
+	assert.Empty(t, rr.Header().Get("X-Request-Id"), "should not replay the first request ID")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestRepeatedKeyWithDifferentPayload(t *testing.T) {
+	log.Print("Testing a key reused for a different payload or a request in flight is rejected")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	first := requestHash(newCreateRequest("retry-1", createBody), []byte(createBody))
+	columns := []string{"request_hash", "status", "headers", "body", "redacted"}
+	store := &Store{DB: db}
+	calls := 0
+
+	expectClaim(mock, "retry-1", false)
+	mock.ExpectQuery("SELECT request_hash").WithArgs("retry-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(first, http.StatusCreated, `{}`, `{"id":7}`, false))
+	rr := httptest.NewRecorder()
+	store.Middleware(countingHandler(&calls, http.StatusCreated)).ServeHTTP(rr, newCreateRequest("retry-1", `{"mx_domain": "gmail.com"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	expectClaim(mock, "retry-1", false)
+	mock.ExpectQuery("SELECT request_hash").WithArgs("retry-1").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow(first, nil, nil, nil, false))
+	rr = httptest.NewRecorder()
+	store.Middleware(countingHandler(&calls, http.StatusCreated)).ServeHTTP(rr, newCreateRequest("retry-1", createBody))
+	assert.Equal(t, http.StatusConflict, rr.Code, "should not run a request twice at once")
+
+	assert.Equal(t, 0, calls)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestRedactedResponsesAreNotReplayed(t *testing.T) {
+	log.Print("Testing responses holding secrets keep only their status and are not replayed")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	calls := 0
+	handler := Redacted(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":7,"key":"gobrm_secret"}`))
+	})
+	store := &Store{DB: db}
+
+	expectClaim(mock, "retry-1", true)
+	mock.ExpectExec(regexp.QuoteMeta("UPDATE idempotency_key SET status = ?, redacted = TRUE WHERE idempotency_key = ?")).
+		WithArgs(http.StatusCreated, "retry-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rr := httptest.NewRecorder()
+	store.Middleware(handler).ServeHTTP(rr, newCreateRequest("retry-1", createBody))
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Contains(t, rr.Body.String(), "gobrm_secret", "should answer the first request in full")
+
+	expectClaim(mock, "retry-1", false)
+	mock.ExpectQuery("SELECT request_hash").WithArgs("retry-1").
+		WillReturnRows(sqlmock.NewRows([]string{"request_hash", "status", "headers", "body", "redacted"}).
+			AddRow(requestHash(newCreateRequest("retry-1", createBody), []byte(createBody)), http.StatusCreated, nil, nil, true))
+	rr = httptest.NewRecorder()
+	store.Middleware(handler).ServeHTTP(rr, newCreateRequest("retry-1", createBody))
+	assert.Equal(t, http.StatusConflict, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "gobrm_secret")
+	assert.Equal(t, 1, calls, "should not run the request again")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestServerErrorsAreNotStored(t *testing.T) {
+	log.Print("Testing a key is released when the request fails with a server error")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	expectClaim(mock, "retry-1", true)
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM idempotency_key WHERE idempotency_key = ?")).
+		WithArgs("retry-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	calls := 0
+	rr := httptest.NewRecorder()
+	(&Store{DB: db}).Middleware(countingHandler(&calls, http.StatusInternalServerError)).ServeHTTP(rr, newCreateRequest("retry-1", createBody))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestRequestsWithoutKeysPassThrough(t *testing.T) {
+	log.Print("Testing reads and requests without a key skip the key store")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	calls := 0
+	handler := (&Store{DB: db}).Middleware(countingHandler(&calls, http.StatusOK))
+	handler.ServeHTTP(httptest.NewRecorder(), newCreateRequest("", createBody))
+	get, _ := http.NewRequest("GET", "/throughput_rules", nil)
+	get.Header.Set(Header, "retry-1")
+	handler.ServeHTTP(httptest.NewRecorder(), get)
+	assert.Equal(t, 2, calls)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, newCreateRequest(strings.Repeat("k", MaxKeyLength+1), createBody))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
@@ -101,9 +101,7 @@ func TestInsert(t *testing.T) {
 
 // TestToOne tests cannot be run in parallel
 // or deadlocks can occur.
-func TestToOne(t *testing.T) {
-	t.Run("ThroughputRuleChangeToThroughputRuleUsingThroughputRule", testThroughputRuleChangeToOneThroughputRuleUsingThroughputRule)
-}
+func TestToOne(t *testing.T) {}
 
 // TestOneToOne tests cannot be run in parallel
 // or deadlocks can occur.
@@ -111,15 +109,11 @@ func TestOneToOne(t *testing.T) {}
 
 // TestToMany tests cannot be run in parallel
 // or deadlocks can occur.
-func TestToMany(t *testing.T) {
-	t.Run("ThroughputRuleToThroughputRuleChanges", testThroughputRuleToManyThroughputRuleChanges)
-}
+func TestToMany(t *testing.T) {}
 
 // TestToOneSet tests cannot be run in parallel
 // or deadlocks can occur.
-func TestToOneSet(t *testing.T) {
-	t.Run("ThroughputRuleChangeToThroughputRuleUsingThroughputRuleChanges", testThroughputRuleChangeToOneSetOpThroughputRuleUsingThroughputRule)
-}
+func TestToOneSet(t *testing.T) {}
 
 // TestToOneRemove tests cannot be run in parallel
 // or deadlocks can occur.
@@ -135,9 +129,7 @@ func TestOneToOneRemove(t *testing.T) {}
 
 // TestToManyAdd tests cannot be run in parallel
 // or deadlocks can occur.
-func TestToManyAdd(t *testing.T) {
-	t.Run("ThroughputRuleToThroughputRuleChanges", testThroughputRuleToManyAddOpThroughputRuleChanges)
-}
+func TestToManyAdd(t *testing.T) {}
 
 // TestToManySet tests cannot be run in parallel
 // or deadlocks can occur.
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
@@ -23,13 +24,16 @@ import (
 
 // BounceRule is an object representing the database table.
 type BounceRule struct {
-	ID           int16  `boil:"id" json:"id" toml:"id" yaml:"id"`
-	ResponseCode int16  `boil:"response_code" json:"response_code" toml:"response_code" yaml:"response_code"`
-	EnhancedCode string `boil:"enhanced_code" json:"enhanced_code" toml:"enhanced_code" yaml:"enhanced_code"`
-	Regex        string `boil:"regex" json:"regex" toml:"regex" yaml:"regex"`
-	Priority     int8   `boil:"priority" json:"priority" toml:"priority" yaml:"priority"`
-	Description  string `boil:"description" json:"description" toml:"description" yaml:"description"`
-	BounceAction string `boil:"bounce_action" json:"bounce_action" toml:"bounce_action" yaml:"bounce_action"`
+	ID           int16     `boil:"id" json:"id" toml:"id" yaml:"id"`
+	ResponseCode int16     `boil:"response_code" json:"response_code" toml:"response_code" yaml:"response_code"`
+	EnhancedCode string    `boil:"enhanced_code" json:"enhanced_code" toml:"enhanced_code" yaml:"enhanced_code"`
+	Regex        string    `boil:"regex" json:"regex" toml:"regex" yaml:"regex"`
+	Priority     int8      `boil:"priority" json:"priority" toml:"priority" yaml:"priority"`
+	Description  string    `boil:"description" json:"description" toml:"description" yaml:"description"`
+	BounceAction string    `boil:"bounce_action" json:"bounce_action" toml:"bounce_action" yaml:"bounce_action"`
+	EffectiveAt  null.Time `boil:"effective_at" json:"effective_at,omitempty" toml:"effective_at" yaml:"effective_at,omitempty"`
+	ExpiresAt    null.Time `boil:"expires_at" json:"expires_at,omitempty" toml:"expires_at" yaml:"expires_at,omitempty"`
+	Version      int       `boil:"version" json:"version" toml:"version" yaml:"version"`
 
 	R *bounceRuleR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L bounceRuleL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -43,6 +47,9 @@ var BounceRuleColumns = struct {
 	Priority     string
 	Description  string
 	BounceAction string
+	EffectiveAt  string
+	ExpiresAt    string
+	Version      string
 }{
 	ID:           "id",
 	ResponseCode: "response_code",
@@ -51,6 +58,9 @@ var BounceRuleColumns = struct {
 	Priority:     "priority",
 	Description:  "description",
 	BounceAction: "bounce_action",
+	EffectiveAt:  "effective_at",
+	ExpiresAt:    "expires_at",
+	Version:      "version",
 }
 
 var BounceRuleTableColumns = struct {
@@ -61,6 +71,9 @@ var BounceRuleTableColumns = struct {
 	Priority     string
 	Description  string
 	BounceAction string
+	EffectiveAt  string
+	ExpiresAt    string
+	Version      string
 }{
 	ID:           "bounce_rule.id",
 	ResponseCode: "bounce_rule.response_code",
@@ -69,6 +82,9 @@ var BounceRuleTableColumns = struct {
 	Priority:     "bounce_rule.priority",
 	Description:  "bounce_rule.description",
 	BounceAction: "bounce_rule.bounce_action",
+	EffectiveAt:  "bounce_rule.effective_at",
+	ExpiresAt:    "bounce_rule.expires_at",
+	Version:      "bounce_rule.version",
 }
 
 // Generated where
@@ -142,6 +158,52 @@ func (w whereHelperint8) NIN(slice []int8) qm.QueryMod {
 	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
 }
 
+type whereHelpernull_Time struct{ field string }
+
+func (w whereHelpernull_Time) EQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_Time) NEQ(x null.Time) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_Time) IsNull() qm.QueryMod    { return qmhelper.WhereIsNull(w.field) }
+func (w whereHelpernull_Time) IsNotNull() qm.QueryMod { return qmhelper.WhereIsNotNull(w.field) }
+func (w whereHelpernull_Time) LT(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_Time) LTE(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_Time) GT(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_Time) GTE(x null.Time) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
+type whereHelperint struct{ field string }
+
+func (w whereHelperint) EQ(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
+func (w whereHelperint) NEQ(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
+func (w whereHelperint) LT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
+func (w whereHelperint) LTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
+func (w whereHelperint) GT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
+func (w whereHelperint) GTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
+func (w whereHelperint) IN(slice []int) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
+}
+func (w whereHelperint) NIN(slice []int) qm.QueryMod {
+	values := make([]interface{}, 0, len(slice))
+	for _, value := range slice {
+		values = append(values, value)
+	}
+	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
+}
+
 var BounceRuleWhere = struct {
 	ID           whereHelperint16
 	ResponseCode whereHelperint16
@@ -150,6 +212,9 @@ var BounceRuleWhere = struct {
 	Priority     whereHelperint8
 	Description  whereHelperstring
 	BounceAction whereHelperstring
+	EffectiveAt  whereHelpernull_Time
+	ExpiresAt    whereHelpernull_Time
+	Version      whereHelperint
 }{
 	ID:           whereHelperint16{field: "`bounce_rule`.`id`"},
 	ResponseCode: whereHelperint16{field: "`bounce_rule`.`response_code`"},
@@ -158,6 +223,9 @@ var BounceRuleWhere = struct {
 	Priority:     whereHelperint8{field: "`bounce_rule`.`priority`"},
 	Description:  whereHelperstring{field: "`bounce_rule`.`description`"},
 	BounceAction: whereHelperstring{field: "`bounce_rule`.`bounce_action`"},
+	EffectiveAt:  whereHelpernull_Time{field: "`bounce_rule`.`effective_at`"},
+	ExpiresAt:    whereHelpernull_Time{field: "`bounce_rule`.`expires_at`"},
+	Version:      whereHelperint{field: "`bounce_rule`.`version`"},
 }
 
 // BounceRuleRels is where relationship names are stored.
@@ -177,9 +245,9 @@ func (*bounceRuleR) NewStruct() *bounceRuleR {
 type bounceRuleL struct{}
 
 var (
-	bounceRuleAllColumns            = []string{"id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action"}
-	bounceRuleColumnsWithoutDefault = []string{"enhanced_code", "regex", "description", "bounce_action"}
-	bounceRuleColumnsWithDefault    = []string{"id", "response_code", "priority"}
+	bounceRuleAllColumns            = []string{"id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action", "effective_at", "expires_at", "version"}
+	bounceRuleColumnsWithoutDefault = []string{"enhanced_code", "regex", "description", "bounce_action", "effective_at", "expires_at"}
+	bounceRuleColumnsWithDefault    = []string{"id", "response_code", "priority", "version"}
 	bounceRulePrimaryKeyColumns     = []string{"id"}
 )
 
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
@@ -23,16 +24,19 @@ import (
 
 // BounceRuleChange is an object representing the database table.
 type BounceRuleChange struct {
-	ID           int16     `boil:"id" json:"id" toml:"id" yaml:"id"`
-	Action       string    `boil:"action" json:"action" toml:"action" yaml:"action"`
-	BounceRuleID int16     `boil:"bounce_rule_id" json:"bounce_rule_id" toml:"bounce_rule_id" yaml:"bounce_rule_id"`
-	ResponseCode int16     `boil:"response_code" json:"response_code" toml:"response_code" yaml:"response_code"`
-	EnhancedCode string    `boil:"enhanced_code" json:"enhanced_code" toml:"enhanced_code" yaml:"enhanced_code"`
-	Regex        string    `boil:"regex" json:"regex" toml:"regex" yaml:"regex"`
-	Priority     int8      `boil:"priority" json:"priority" toml:"priority" yaml:"priority"`
-	Description  string    `boil:"description" json:"description" toml:"description" yaml:"description"`
-	BounceAction string    `boil:"bounce_action" json:"bounce_action" toml:"bounce_action" yaml:"bounce_action"`
-	UpdatedAt    time.Time `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+	ID           int16       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	Action       string      `boil:"action" json:"action" toml:"action" yaml:"action"`
+	BounceRuleID int16       `boil:"bounce_rule_id" json:"bounce_rule_id" toml:"bounce_rule_id" yaml:"bounce_rule_id"`
+	ResponseCode int16       `boil:"response_code" json:"response_code" toml:"response_code" yaml:"response_code"`
+	EnhancedCode string      `boil:"enhanced_code" json:"enhanced_code" toml:"enhanced_code" yaml:"enhanced_code"`
+	Regex        string      `boil:"regex" json:"regex" toml:"regex" yaml:"regex"`
+	Priority     int8        `boil:"priority" json:"priority" toml:"priority" yaml:"priority"`
+	Description  string      `boil:"description" json:"description" toml:"description" yaml:"description"`
+	BounceAction string      `boil:"bounce_action" json:"bounce_action" toml:"bounce_action" yaml:"bounce_action"`
+	EffectiveAt  null.Time   `boil:"effective_at" json:"effective_at,omitempty" toml:"effective_at" yaml:"effective_at,omitempty"`
+	ExpiresAt    null.Time   `boil:"expires_at" json:"expires_at,omitempty" toml:"expires_at" yaml:"expires_at,omitempty"`
+	UpdatedAt    time.Time   `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+	BatchID      null.String `boil:"batch_id" json:"batch_id,omitempty" toml:"batch_id" yaml:"batch_id,omitempty"`
 
 	R *bounceRuleChangeR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L bounceRuleChangeL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -48,7 +52,10 @@ var BounceRuleChangeColumns = struct {
 	Priority     string
 	Description  string
 	BounceAction string
+	EffectiveAt  string
+	ExpiresAt    string
 	UpdatedAt    string
+	BatchID      string
 }{
 	ID:           "id",
 	Action:       "action",
@@ -59,7 +66,10 @@ var BounceRuleChangeColumns = struct {
 	Priority:     "priority",
 	Description:  "description",
 	BounceAction: "bounce_action",
+	EffectiveAt:  "effective_at",
+	ExpiresAt:    "expires_at",
 	UpdatedAt:    "updated_at",
+	BatchID:      "batch_id",
 }
 
 var BounceRuleChangeTableColumns = struct {
@@ -72,7 +82,10 @@ var BounceRuleChangeTableColumns = struct {
 	Priority     string
 	Description  string
 	BounceAction string
+	EffectiveAt  string
+	ExpiresAt    string
 	UpdatedAt    string
+	BatchID      string
 }{
 	ID:           "bounce_rule_change.id",
 	Action:       "bounce_rule_change.action",
@@ -83,7 +96,10 @@ var BounceRuleChangeTableColumns = struct {
 	Priority:     "bounce_rule_change.priority",
 	Description:  "bounce_rule_change.description",
 	BounceAction: "bounce_rule_change.bounce_action",
+	EffectiveAt:  "bounce_rule_change.effective_at",
+	ExpiresAt:    "bounce_rule_change.expires_at",
 	UpdatedAt:    "bounce_rule_change.updated_at",
+	BatchID:      "bounce_rule_change.batch_id",
 }
 
 // Generated where
@@ -109,6 +125,27 @@ func (w whereHelpertime_Time) GTE(x time.Time) qm.QueryMod {
 	return qmhelper.Where(w.field, qmhelper.GTE, x)
 }
 
+type whereHelpernull_String struct{ field string }
+
+func (w whereHelpernull_String) EQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, false, x)
+}
+func (w whereHelpernull_String) NEQ(x null.String) qm.QueryMod {
+	return qmhelper.WhereNullEQ(w.field, true, x)
+}
+func (w whereHelpernull_String) LT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LT, x)
+}
+func (w whereHelpernull_String) LTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.LTE, x)
+}
+func (w whereHelpernull_String) GT(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GT, x)
+}
+func (w whereHelpernull_String) GTE(x null.String) qm.QueryMod {
+	return qmhelper.Where(w.field, qmhelper.GTE, x)
+}
+
 var BounceRuleChangeWhere = struct {
 	ID           whereHelperint16
 	Action       whereHelperstring
@@ -119,7 +156,10 @@ var BounceRuleChangeWhere = struct {
 	Priority     whereHelperint8
 	Description  whereHelperstring
 	BounceAction whereHelperstring
+	EffectiveAt  whereHelpernull_Time
+	ExpiresAt    whereHelpernull_Time
 	UpdatedAt    whereHelpertime_Time
+	BatchID      whereHelpernull_String
 }{
 	ID:           whereHelperint16{field: "`bounce_rule_change`.`id`"},
 	Action:       whereHelperstring{field: "`bounce_rule_change`.`action`"},
@@ -130,7 +170,10 @@ var BounceRuleChangeWhere = struct {
 	Priority:     whereHelperint8{field: "`bounce_rule_change`.`priority`"},
 	Description:  whereHelperstring{field: "`bounce_rule_change`.`description`"},
 	BounceAction: whereHelperstring{field: "`bounce_rule_change`.`bounce_action`"},
+	EffectiveAt:  whereHelpernull_Time{field: "`bounce_rule_change`.`effective_at`"},
+	ExpiresAt:    whereHelpernull_Time{field: "`bounce_rule_change`.`expires_at`"},
 	UpdatedAt:    whereHelpertime_Time{field: "`bounce_rule_change`.`updated_at`"},
+	BatchID:      whereHelpernull_String{field: "`bounce_rule_change`.`batch_id`"},
 }
 
 // BounceRuleChangeRels is where relationship names are stored.
@@ -150,8 +193,8 @@ func (*bounceRuleChangeR) NewStruct() *bounceRuleChangeR {
 type bounceRuleChangeL struct{}
 
 var (
-	bounceRuleChangeAllColumns            = []string{"id", "action", "bounce_rule_id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action", "updated_at"}
-	bounceRuleChangeColumnsWithoutDefault = []string{"action", "bounce_rule_id", "enhanced_code", "regex", "description", "bounce_action"}
+	bounceRuleChangeAllColumns            = []string{"id", "action", "bounce_rule_id", "response_code", "enhanced_code", "regex", "priority", "description", "bounce_action", "effective_at", "expires_at", "updated_at", "batch_id"}
+	bounceRuleChangeColumnsWithoutDefault = []string{"action", "bounce_rule_id", "enhanced_code", "regex", "description", "bounce_action", "effective_at", "expires_at", "batch_id"}
 	bounceRuleChangeColumnsWithDefault    = []string{"id", "response_code", "priority", "updated_at"}
 	bounceRuleChangePrimaryKeyColumns     = []string{"id"}
 )
@@ -568,7 +568,7 @@ func testBounceRuleChangesSelect(t *testing.T) {
 }
 
 var (
-	bounceRuleChangeDBTypes = map[string]string{`ID`: `smallint`, `Action`: `varchar`, `BounceRuleID`: `smallint`, `ResponseCode`: `smallint`, `EnhancedCode`: `varchar`, `Regex`: `varchar`, `Priority`: `tinyint`, `Description`: `varchar`, `BounceAction`: `varchar`, `UpdatedAt`: `datetime`}
+	bounceRuleChangeDBTypes = map[string]string{`ID`: `smallint`, `Action`: `varchar`, `BounceRuleID`: `smallint`, `ResponseCode`: `smallint`, `EnhancedCode`: `varchar`, `Regex`: `varchar`, `Priority`: `tinyint`, `Description`: `varchar`, `BounceAction`: `varchar`, `EffectiveAt`: `datetime`, `ExpiresAt`: `datetime`, `UpdatedAt`: `datetime`, `BatchID`: `varchar`}
 	_                       = bytes.MinRead
 )
 
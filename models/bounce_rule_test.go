@@ -568,7 +568,7 @@ func testBounceRulesSelect(t *testing.T) {
 }
 
 var (
-	bounceRuleDBTypes = map[string]string{`ID`: `smallint`, `ResponseCode`: `smallint`, `EnhancedCode`: `varchar`, `Regex`: `varchar`, `Priority`: `tinyint`, `Description`: `varchar`, `BounceAction`: `varchar`}
+	bounceRuleDBTypes = map[string]string{`ID`: `smallint`, `ResponseCode`: `smallint`, `EnhancedCode`: `varchar`, `Regex`: `varchar`, `Priority`: `tinyint`, `Description`: `varchar`, `BounceAction`: `varchar`, `EffectiveAt`: `datetime`, `ExpiresAt`: `datetime`, `Version`: `int`}
 	_                 = bytes.MinRead
 )
 
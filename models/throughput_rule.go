@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
@@ -23,11 +24,14 @@ import (
 
 // ThroughputRule is an object representing the database table.
 type ThroughputRule struct {
-	ID                    int    `boil:"id" json:"id" toml:"id" yaml:"id"`
-	MXDomain              string `boil:"mx_domain" json:"mx_domain" toml:"mx_domain" yaml:"mx_domain"`
-	MaxConnections        int    `boil:"max_connections" json:"max_connections" toml:"max_connections" yaml:"max_connections"`
-	MessagesPerConnection int    `boil:"messages_per_connection" json:"messages_per_connection" toml:"messages_per_connection" yaml:"messages_per_connection"`
-	ConnectionTTLMillis   int    `boil:"connection_ttl_millis" json:"connection_ttl_millis" toml:"connection_ttl_millis" yaml:"connection_ttl_millis"`
+	ID                    int       `boil:"id" json:"id" toml:"id" yaml:"id"`
+	MXDomain              string    `boil:"mx_domain" json:"mx_domain" toml:"mx_domain" yaml:"mx_domain"`
+	MaxConnections        int       `boil:"max_connections" json:"max_connections" toml:"max_connections" yaml:"max_connections"`
+	MessagesPerConnection int       `boil:"messages_per_connection" json:"messages_per_connection" toml:"messages_per_connection" yaml:"messages_per_connection"`
+	ConnectionTTLMillis   int       `boil:"connection_ttl_millis" json:"connection_ttl_millis" toml:"connection_ttl_millis" yaml:"connection_ttl_millis"`
+	EffectiveAt           null.Time `boil:"effective_at" json:"effective_at,omitempty" toml:"effective_at" yaml:"effective_at,omitempty"`
+	ExpiresAt             null.Time `boil:"expires_at" json:"expires_at,omitempty" toml:"expires_at" yaml:"expires_at,omitempty"`
+	Version               int       `boil:"version" json:"version" toml:"version" yaml:"version"`
 
 	R *throughputRuleR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L throughputRuleL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -39,12 +43,18 @@ var ThroughputRuleColumns = struct {
 	MaxConnections        string
 	MessagesPerConnection string
 	ConnectionTTLMillis   string
+	EffectiveAt           string
+	ExpiresAt             string
+	Version               string
 }{
 	ID:                    "id",
 	MXDomain:              "mx_domain",
 	MaxConnections:        "max_connections",
 	MessagesPerConnection: "messages_per_connection",
 	ConnectionTTLMillis:   "connection_ttl_millis",
+	EffectiveAt:           "effective_at",
+	ExpiresAt:             "expires_at",
+	Version:               "version",
 }
 
 var ThroughputRuleTableColumns = struct {
@@ -53,63 +63,48 @@ var ThroughputRuleTableColumns = struct {
 	MaxConnections        string
 	MessagesPerConnection string
 	ConnectionTTLMillis   string
+	EffectiveAt           string
+	ExpiresAt             string
+	Version               string
 }{
 	ID:                    "throughput_rule.id",
 	MXDomain:              "throughput_rule.mx_domain",
 	MaxConnections:        "throughput_rule.max_connections",
 	MessagesPerConnection: "throughput_rule.messages_per_connection",
 	ConnectionTTLMillis:   "throughput_rule.connection_ttl_millis",
+	EffectiveAt:           "throughput_rule.effective_at",
+	ExpiresAt:             "throughput_rule.expires_at",
+	Version:               "throughput_rule.version",
 }
 
 // Generated where
 
-type whereHelperint struct{ field string }
-
-func (w whereHelperint) EQ(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.EQ, x) }
-func (w whereHelperint) NEQ(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.NEQ, x) }
-func (w whereHelperint) LT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.LT, x) }
-func (w whereHelperint) LTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.LTE, x) }
-func (w whereHelperint) GT(x int) qm.QueryMod  { return qmhelper.Where(w.field, qmhelper.GT, x) }
-func (w whereHelperint) GTE(x int) qm.QueryMod { return qmhelper.Where(w.field, qmhelper.GTE, x) }
-func (w whereHelperint) IN(slice []int) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereIn(fmt.Sprintf("%s IN ?", w.field), values...)
-}
-func (w whereHelperint) NIN(slice []int) qm.QueryMod {
-	values := make([]interface{}, 0, len(slice))
-	for _, value := range slice {
-		values = append(values, value)
-	}
-	return qm.WhereNotIn(fmt.Sprintf("%s NOT IN ?", w.field), values...)
-}
-
 var ThroughputRuleWhere = struct {
 	ID                    whereHelperint
 	MXDomain              whereHelperstring
 	MaxConnections        whereHelperint
 	MessagesPerConnection whereHelperint
 	ConnectionTTLMillis   whereHelperint
+	EffectiveAt           whereHelpernull_Time
+	ExpiresAt             whereHelpernull_Time
+	Version               whereHelperint
 }{
 	ID:                    whereHelperint{field: "`throughput_rule`.`id`"},
 	MXDomain:              whereHelperstring{field: "`throughput_rule`.`mx_domain`"},
 	MaxConnections:        whereHelperint{field: "`throughput_rule`.`max_connections`"},
 	MessagesPerConnection: whereHelperint{field: "`throughput_rule`.`messages_per_connection`"},
 	ConnectionTTLMillis:   whereHelperint{field: "`throughput_rule`.`connection_ttl_millis`"},
+	EffectiveAt:           whereHelpernull_Time{field: "`throughput_rule`.`effective_at`"},
+	ExpiresAt:             whereHelpernull_Time{field: "`throughput_rule`.`expires_at`"},
+	Version:               whereHelperint{field: "`throughput_rule`.`version`"},
 }
 
 // ThroughputRuleRels is where relationship names are stored.
 var ThroughputRuleRels = struct {
-	ThroughputRuleChanges string
-}{
-	ThroughputRuleChanges: "ThroughputRuleChanges",
-}
+}{}
 
 // throughputRuleR is where relationships are stored.
 type throughputRuleR struct {
-	ThroughputRuleChanges ThroughputRuleChangeSlice `boil:"ThroughputRuleChanges" json:"ThroughputRuleChanges" toml:"ThroughputRuleChanges" yaml:"ThroughputRuleChanges"`
 }
 
 // NewStruct creates a new relationship struct
@@ -121,9 +116,9 @@ func (*throughputRuleR) NewStruct() *throughputRuleR {
 type throughputRuleL struct{}
 
 var (
-	throughputRuleAllColumns            = []string{"id", "mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis"}
-	throughputRuleColumnsWithoutDefault = []string{"mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis"}
-	throughputRuleColumnsWithDefault    = []string{"id"}
+	throughputRuleAllColumns            = []string{"id", "mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis", "effective_at", "expires_at", "version"}
+	throughputRuleColumnsWithoutDefault = []string{"mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis", "effective_at", "expires_at"}
+	throughputRuleColumnsWithDefault    = []string{"id", "version"}
 	throughputRulePrimaryKeyColumns     = []string{"id"}
 )
 
@@ -402,178 +397,6 @@ func (q throughputRuleQuery) Exists(ctx context.Context, exec boil.ContextExecut
 	return count > 0, nil
 }
 
-// ThroughputRuleChanges retrieves all the throughput_rule_change's ThroughputRuleChanges with an executor.
-func (o *ThroughputRule) ThroughputRuleChanges(mods ...qm.QueryMod) throughputRuleChangeQuery {
-	var queryMods []qm.QueryMod
-	if len(mods) != 0 {
-		queryMods = append(queryMods, mods...)
-	}
-
-	queryMods = append(queryMods,
-		qm.Where("`throughput_rule_change`.`throughput_rule_id`=?", o.ID),
-	)
-
-	query := ThroughputRuleChanges(queryMods...)
-	queries.SetFrom(query.Query, "`throughput_rule_change`")
-
-	if len(queries.GetSelect(query.Query)) == 0 {
-		queries.SetSelect(query.Query, []string{"`throughput_rule_change`.*"})
-	}
-
-	return query
-}
-
-// LoadThroughputRuleChanges allows an eager lookup of values, cached into the
-// loaded structs of the objects. This is for a 1-M or N-M relationship.
-func (throughputRuleL) LoadThroughputRuleChanges(ctx context.Context, e boil.ContextExecutor, singular bool, maybeThroughputRule interface{}, mods queries.Applicator) error {
-	var slice []*ThroughputRule
-	var object *ThroughputRule
-
-	if singular {
-		object = maybeThroughputRule.(*ThroughputRule)
-	} else {
-		slice = *maybeThroughputRule.(*[]*ThroughputRule)
-	}
-
-	args := make([]interface{}, 0, 1)
-	if singular {
-		if object.R == nil {
-			object.R = &throughputRuleR{}
-		}
-		args = append(args, object.ID)
-	} else {
-	Outer:
-		for _, obj := range slice {
-			if obj.R == nil {
-				obj.R = &throughputRuleR{}
-			}
-
-			for _, a := range args {
-				if a == obj.ID {
-					continue Outer
-				}
-			}
-
-			args = append(args, obj.ID)
-		}
-	}
-
-	if len(args) == 0 {
-		return nil
-	}
-
-	query := NewQuery(
-		qm.From(`throughput_rule_change`),
-		qm.WhereIn(`throughput_rule_change.throughput_rule_id in ?`, args...),
-	)
-	if mods != nil {
-		mods.Apply(query)
-	}
-
-	results, err := query.QueryContext(ctx, e)
-	if err != nil {
-		return errors.Wrap(err, "failed to eager load throughput_rule_change")
-	}
-
-	var resultSlice []*ThroughputRuleChange
-	if err = queries.Bind(results, &resultSlice); err != nil {
-		return errors.Wrap(err, "failed to bind eager loaded slice throughput_rule_change")
-	}
-
-	if err = results.Close(); err != nil {
-		return errors.Wrap(err, "failed to close results in eager load on throughput_rule_change")
-	}
-	if err = results.Err(); err != nil {
-		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for throughput_rule_change")
-	}
-
-	if len(throughputRuleChangeAfterSelectHooks) != 0 {
-		for _, obj := range resultSlice {
-			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
-				return err
-			}
-		}
-	}
-	if singular {
-		object.R.ThroughputRuleChanges = resultSlice
-		for _, foreign := range resultSlice {
-			if foreign.R == nil {
-				foreign.R = &throughputRuleChangeR{}
-			}
-			foreign.R.ThroughputRule = object
-		}
-		return nil
-	}
-
-	for _, foreign := range resultSlice {
-		for _, local := range slice {
-			if local.ID == foreign.ThroughputRuleID {
-				local.R.ThroughputRuleChanges = append(local.R.ThroughputRuleChanges, foreign)
-				if foreign.R == nil {
-					foreign.R = &throughputRuleChangeR{}
-				}
-				foreign.R.ThroughputRule = local
-				break
-			}
-		}
-	}
-
-	return nil
-}
-
-// AddThroughputRuleChanges adds the given related objects to the existing relationships
-// of the throughput_rule, optionally inserting them as new records.
-// Appends related to o.R.ThroughputRuleChanges.
-// Sets related.R.ThroughputRule appropriately.
-func (o *ThroughputRule) AddThroughputRuleChanges(ctx context.Context, exec boil.ContextExecutor, insert bool, related ...*ThroughputRuleChange) error {
-	var err error
-	for _, rel := range related {
-		if insert {
-			rel.ThroughputRuleID = o.ID
-			if err = rel.Insert(ctx, exec, boil.Infer()); err != nil {
-				return errors.Wrap(err, "failed to insert into foreign table")
-			}
-		} else {
-			updateQuery := fmt.Sprintf(
-				"UPDATE `throughput_rule_change` SET %s WHERE %s",
-				strmangle.SetParamNames("`", "`", 0, []string{"throughput_rule_id"}),
-				strmangle.WhereClause("`", "`", 0, throughputRuleChangePrimaryKeyColumns),
-			)
-			values := []interface{}{o.ID, rel.ID}
-
-			if boil.IsDebug(ctx) {
-				writer := boil.DebugWriterFrom(ctx)
-				fmt.Fprintln(writer, updateQuery)
-				fmt.Fprintln(writer, values)
-			}
-			if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
-				return errors.Wrap(err, "failed to update foreign table")
-			}
-
-			rel.ThroughputRuleID = o.ID
-		}
-	}
-
-	if o.R == nil {
-		o.R = &throughputRuleR{
-			ThroughputRuleChanges: related,
-		}
-	} else {
-		o.R.ThroughputRuleChanges = append(o.R.ThroughputRuleChanges, related...)
-	}
-
-	for _, rel := range related {
-		if rel.R == nil {
-			rel.R = &throughputRuleChangeR{
-				ThroughputRule: o,
-			}
-		} else {
-			rel.R.ThroughputRule = o
-		}
-	}
-	return nil
-}
-
 // ThroughputRules retrieves all the records using an executor.
 func ThroughputRules(mods ...qm.QueryMod) throughputRuleQuery {
 	mods = append(mods, qm.From("`throughput_rule`"))
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/friendsofgo/errors"
+	"github.com/volatiletech/null/v8"
 	"github.com/volatiletech/sqlboiler/v4/boil"
 	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
@@ -23,14 +24,17 @@ import (
 
 // ThroughputRuleChange is an object representing the database table.
 type ThroughputRuleChange struct {
-	ID                    int       `boil:"id" json:"id" toml:"id" yaml:"id"`
-	Action                string    `boil:"action" json:"action" toml:"action" yaml:"action"`
-	ThroughputRuleID      int       `boil:"throughput_rule_id" json:"throughput_rule_id" toml:"throughput_rule_id" yaml:"throughput_rule_id"`
-	MXDomain              string    `boil:"mx_domain" json:"mx_domain" toml:"mx_domain" yaml:"mx_domain"`
-	MaxConnections        int       `boil:"max_connections" json:"max_connections" toml:"max_connections" yaml:"max_connections"`
-	MessagesPerConnection int       `boil:"messages_per_connection" json:"messages_per_connection" toml:"messages_per_connection" yaml:"messages_per_connection"`
-	ConnectionTTLMillis   int       `boil:"connection_ttl_millis" json:"connection_ttl_millis" toml:"connection_ttl_millis" yaml:"connection_ttl_millis"`
-	UpdatedAt             time.Time `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+	ID                    int         `boil:"id" json:"id" toml:"id" yaml:"id"`
+	Action                string      `boil:"action" json:"action" toml:"action" yaml:"action"`
+	ThroughputRuleID      int         `boil:"throughput_rule_id" json:"throughput_rule_id" toml:"throughput_rule_id" yaml:"throughput_rule_id"`
+	MXDomain              string      `boil:"mx_domain" json:"mx_domain" toml:"mx_domain" yaml:"mx_domain"`
+	MaxConnections        int         `boil:"max_connections" json:"max_connections" toml:"max_connections" yaml:"max_connections"`
+	MessagesPerConnection int         `boil:"messages_per_connection" json:"messages_per_connection" toml:"messages_per_connection" yaml:"messages_per_connection"`
+	ConnectionTTLMillis   int         `boil:"connection_ttl_millis" json:"connection_ttl_millis" toml:"connection_ttl_millis" yaml:"connection_ttl_millis"`
+	EffectiveAt           null.Time   `boil:"effective_at" json:"effective_at,omitempty" toml:"effective_at" yaml:"effective_at,omitempty"`
+	ExpiresAt             null.Time   `boil:"expires_at" json:"expires_at,omitempty" toml:"expires_at" yaml:"expires_at,omitempty"`
+	UpdatedAt             time.Time   `boil:"updated_at" json:"updated_at" toml:"updated_at" yaml:"updated_at"`
+	BatchID               null.String `boil:"batch_id" json:"batch_id,omitempty" toml:"batch_id" yaml:"batch_id,omitempty"`
 
 	R *throughputRuleChangeR `boil:"-" json:"-" toml:"-" yaml:"-"`
 	L throughputRuleChangeL  `boil:"-" json:"-" toml:"-" yaml:"-"`
@@ -44,7 +48,10 @@ var ThroughputRuleChangeColumns = struct {
 	MaxConnections        string
 	MessagesPerConnection string
 	ConnectionTTLMillis   string
+	EffectiveAt           string
+	ExpiresAt             string
 	UpdatedAt             string
+	BatchID               string
 }{
 	ID:                    "id",
 	Action:                "action",
@@ -53,7 +60,10 @@ var ThroughputRuleChangeColumns = struct {
 	MaxConnections:        "max_connections",
 	MessagesPerConnection: "messages_per_connection",
 	ConnectionTTLMillis:   "connection_ttl_millis",
+	EffectiveAt:           "effective_at",
+	ExpiresAt:             "expires_at",
 	UpdatedAt:             "updated_at",
+	BatchID:               "batch_id",
 }
 
 var ThroughputRuleChangeTableColumns = struct {
@@ -64,7 +74,10 @@ var ThroughputRuleChangeTableColumns = struct {
 	MaxConnections        string
 	MessagesPerConnection string
 	ConnectionTTLMillis   string
+	EffectiveAt           string
+	ExpiresAt             string
 	UpdatedAt             string
+	BatchID               string
 }{
 	ID:                    "throughput_rule_change.id",
 	Action:                "throughput_rule_change.action",
@@ -73,7 +86,10 @@ var ThroughputRuleChangeTableColumns = struct {
 	MaxConnections:        "throughput_rule_change.max_connections",
 	MessagesPerConnection: "throughput_rule_change.messages_per_connection",
 	ConnectionTTLMillis:   "throughput_rule_change.connection_ttl_millis",
+	EffectiveAt:           "throughput_rule_change.effective_at",
+	ExpiresAt:             "throughput_rule_change.expires_at",
 	UpdatedAt:             "throughput_rule_change.updated_at",
+	BatchID:               "throughput_rule_change.batch_id",
 }
 
 // Generated where
@@ -86,7 +102,10 @@ var ThroughputRuleChangeWhere = struct {
 	MaxConnections        whereHelperint
 	MessagesPerConnection whereHelperint
 	ConnectionTTLMillis   whereHelperint
+	EffectiveAt           whereHelpernull_Time
+	ExpiresAt             whereHelpernull_Time
 	UpdatedAt             whereHelpertime_Time
+	BatchID               whereHelpernull_String
 }{
 	ID:                    whereHelperint{field: "`throughput_rule_change`.`id`"},
 	Action:                whereHelperstring{field: "`throughput_rule_change`.`action`"},
@@ -95,19 +114,18 @@ var ThroughputRuleChangeWhere = struct {
 	MaxConnections:        whereHelperint{field: "`throughput_rule_change`.`max_connections`"},
 	MessagesPerConnection: whereHelperint{field: "`throughput_rule_change`.`messages_per_connection`"},
 	ConnectionTTLMillis:   whereHelperint{field: "`throughput_rule_change`.`connection_ttl_millis`"},
+	EffectiveAt:           whereHelpernull_Time{field: "`throughput_rule_change`.`effective_at`"},
+	ExpiresAt:             whereHelpernull_Time{field: "`throughput_rule_change`.`expires_at`"},
 	UpdatedAt:             whereHelpertime_Time{field: "`throughput_rule_change`.`updated_at`"},
+	BatchID:               whereHelpernull_String{field: "`throughput_rule_change`.`batch_id`"},
 }
 
 // ThroughputRuleChangeRels is where relationship names are stored.
 var ThroughputRuleChangeRels = struct {
-	ThroughputRule string
-}{
-	ThroughputRule: "ThroughputRule",
-}
+}{}
 
 // throughputRuleChangeR is where relationships are stored.
 type throughputRuleChangeR struct {
-	ThroughputRule *ThroughputRule `boil:"ThroughputRule" json:"ThroughputRule" toml:"ThroughputRule" yaml:"ThroughputRule"`
 }
 
 // NewStruct creates a new relationship struct
@@ -119,8 +137,8 @@ func (*throughputRuleChangeR) NewStruct() *throughputRuleChangeR {
 type throughputRuleChangeL struct{}
 
 var (
-	throughputRuleChangeAllColumns            = []string{"id", "action", "throughput_rule_id", "mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis", "updated_at"}
-	throughputRuleChangeColumnsWithoutDefault = []string{"action", "throughput_rule_id", "mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis"}
+	throughputRuleChangeAllColumns            = []string{"id", "action", "throughput_rule_id", "mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis", "effective_at", "expires_at", "updated_at", "batch_id"}
+	throughputRuleChangeColumnsWithoutDefault = []string{"action", "throughput_rule_id", "mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis", "effective_at", "expires_at", "batch_id"}
 	throughputRuleChangeColumnsWithDefault    = []string{"id", "updated_at"}
 	throughputRuleChangePrimaryKeyColumns     = []string{"id"}
 )
@@ -400,171 +418,6 @@ func (q throughputRuleChangeQuery) Exists(ctx context.Context, exec boil.Context
 	return count > 0, nil
 }
 
-// ThroughputRule pointed to by the foreign key.
-func (o *ThroughputRuleChange) ThroughputRule(mods ...qm.QueryMod) throughputRuleQuery {
-	queryMods := []qm.QueryMod{
-		qm.Where("`id` = ?", o.ThroughputRuleID),
-	}
-
-	queryMods = append(queryMods, mods...)
-
-	query := ThroughputRules(queryMods...)
-	queries.SetFrom(query.Query, "`throughput_rule`")
-
-	return query
-}
-
-// LoadThroughputRule allows an eager lookup of values, cached into the
-// loaded structs of the objects. This is for an N-1 relationship.
-func (throughputRuleChangeL) LoadThroughputRule(ctx context.Context, e boil.ContextExecutor, singular bool, maybeThroughputRuleChange interface{}, mods queries.Applicator) error {
-	var slice []*ThroughputRuleChange
-	var object *ThroughputRuleChange
-
-	if singular {
-		object = maybeThroughputRuleChange.(*ThroughputRuleChange)
-	} else {
-		slice = *maybeThroughputRuleChange.(*[]*ThroughputRuleChange)
-	}
-
-	args := make([]interface{}, 0, 1)
-	if singular {
-		if object.R == nil {
-			object.R = &throughputRuleChangeR{}
-		}
-		args = append(args, object.ThroughputRuleID)
-
-	} else {
-	Outer:
-		for _, obj := range slice {
-			if obj.R == nil {
-				obj.R = &throughputRuleChangeR{}
-			}
-
-			for _, a := range args {
-				if a == obj.ThroughputRuleID {
-					continue Outer
-				}
-			}
-
-			args = append(args, obj.ThroughputRuleID)
-
-		}
-	}
-
-	if len(args) == 0 {
-		return nil
-	}
-
-	query := NewQuery(
-		qm.From(`throughput_rule`),
-		qm.WhereIn(`throughput_rule.id in ?`, args...),
-	)
-	if mods != nil {
-		mods.Apply(query)
-	}
-
-	results, err := query.QueryContext(ctx, e)
-	if err != nil {
-		return errors.Wrap(err, "failed to eager load ThroughputRule")
-	}
-
-	var resultSlice []*ThroughputRule
-	if err = queries.Bind(results, &resultSlice); err != nil {
-		return errors.Wrap(err, "failed to bind eager loaded slice ThroughputRule")
-	}
-
-	if err = results.Close(); err != nil {
-		return errors.Wrap(err, "failed to close results of eager load for throughput_rule")
-	}
-	if err = results.Err(); err != nil {
-		return errors.Wrap(err, "error occurred during iteration of eager loaded relations for throughput_rule")
-	}
-
-	if len(throughputRuleChangeAfterSelectHooks) != 0 {
-		for _, obj := range resultSlice {
-			if err := obj.doAfterSelectHooks(ctx, e); err != nil {
-				return err
-			}
-		}
-	}
-
-	if len(resultSlice) == 0 {
-		return nil
-	}
-
-	if singular {
-		foreign := resultSlice[0]
-		object.R.ThroughputRule = foreign
-		if foreign.R == nil {
-			foreign.R = &throughputRuleR{}
-		}
-		foreign.R.ThroughputRuleChanges = append(foreign.R.ThroughputRuleChanges, object)
-		return nil
-	}
-
-	for _, local := range slice {
-		for _, foreign := range resultSlice {
-			if local.ThroughputRuleID == foreign.ID {
-				local.R.ThroughputRule = foreign
-				if foreign.R == nil {
-					foreign.R = &throughputRuleR{}
-				}
-				foreign.R.ThroughputRuleChanges = append(foreign.R.ThroughputRuleChanges, local)
-				break
-			}
-		}
-	}
-
-	return nil
-}
-
-// SetThroughputRule of the throughputRuleChange to the related item.
-// Sets o.R.ThroughputRule to related.
-// Adds o to related.R.ThroughputRuleChanges.
-func (o *ThroughputRuleChange) SetThroughputRule(ctx context.Context, exec boil.ContextExecutor, insert bool, related *ThroughputRule) error {
-	var err error
-	if insert {
-		if err = related.Insert(ctx, exec, boil.Infer()); err != nil {
-			return errors.Wrap(err, "failed to insert into foreign table")
-		}
-	}
-
-	updateQuery := fmt.Sprintf(
-		"UPDATE `throughput_rule_change` SET %s WHERE %s",
-		strmangle.SetParamNames("`", "`", 0, []string{"throughput_rule_id"}),
-		strmangle.WhereClause("`", "`", 0, throughputRuleChangePrimaryKeyColumns),
-	)
-	values := []interface{}{related.ID, o.ID}
-
-	if boil.IsDebug(ctx) {
-		writer := boil.DebugWriterFrom(ctx)
-		fmt.Fprintln(writer, updateQuery)
-		fmt.Fprintln(writer, values)
-	}
-	if _, err = exec.ExecContext(ctx, updateQuery, values...); err != nil {
-		return errors.Wrap(err, "failed to update local table")
-	}
-
-	o.ThroughputRuleID = related.ID
-	if o.R == nil {
-		o.R = &throughputRuleChangeR{
-			ThroughputRule: related,
-		}
-	} else {
-		o.R.ThroughputRule = related
-	}
-
-	if related.R == nil {
-		related.R = &throughputRuleR{
-			ThroughputRuleChanges: ThroughputRuleChangeSlice{o},
-		}
-	} else {
-		related.R.ThroughputRuleChanges = append(related.R.ThroughputRuleChanges, o)
-	}
-
-	return nil
-}
-
 // ThroughputRuleChanges retrieves all the records using an executor.
 func ThroughputRuleChanges(mods ...qm.QueryMod) throughputRuleChangeQuery {
 	mods = append(mods, qm.From("`throughput_rule_change`"))
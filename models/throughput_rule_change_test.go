@@ -494,115 +494,6 @@ func testThroughputRuleChangesInsertWhitelist(t *testing.T) {
 	}
 }
 
-func testThroughputRuleChangeToOneThroughputRuleUsingThroughputRule(t *testing.T) {
-	ctx := context.Background()
-	tx := MustTx(boil.BeginTx(ctx, nil))
-	defer func() { _ = tx.Rollback() }()
-
-	var local ThroughputRuleChange
-	var foreign ThroughputRule
-
-	seed := randomize.NewSeed()
-	if err := randomize.Struct(seed, &local, throughputRuleChangeDBTypes, false, throughputRuleChangeColumnsWithDefault...); err != nil {
-		t.Errorf("Unable to randomize ThroughputRuleChange struct: %s", err)
-	}
-	if err := randomize.Struct(seed, &foreign, throughputRuleDBTypes, false, throughputRuleColumnsWithDefault...); err != nil {
-		t.Errorf("Unable to randomize ThroughputRule struct: %s", err)
-	}
-
-	if err := foreign.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-
-	local.ThroughputRuleID = foreign.ID
-	if err := local.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-
-	check, err := local.ThroughputRule().One(ctx, tx)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	if check.ID != foreign.ID {
-		t.Errorf("want: %v, got %v", foreign.ID, check.ID)
-	}
-
-	slice := ThroughputRuleChangeSlice{&local}
-	if err = local.L.LoadThroughputRule(ctx, tx, false, (*[]*ThroughputRuleChange)(&slice), nil); err != nil {
-		t.Fatal(err)
-	}
-	if local.R.ThroughputRule == nil {
-		t.Error("struct should have been eager loaded")
-	}
-
-	local.R.ThroughputRule = nil
-	if err = local.L.LoadThroughputRule(ctx, tx, true, &local, nil); err != nil {
-		t.Fatal(err)
-	}
-	if local.R.ThroughputRule == nil {
-		t.Error("struct should have been eager loaded")
-	}
-}
-
-func testThroughputRuleChangeToOneSetOpThroughputRuleUsingThroughputRule(t *testing.T) {
-	var err error
-
-	ctx := context.Background()
-	tx := MustTx(boil.BeginTx(ctx, nil))
-	defer func() { _ = tx.Rollback() }()
-
-	var a ThroughputRuleChange
-	var b, c ThroughputRule
-
-	seed := randomize.NewSeed()
-	if err = randomize.Struct(seed, &a, throughputRuleChangeDBTypes, false, strmangle.SetComplement(throughputRuleChangePrimaryKeyColumns, throughputRuleChangeColumnsWithoutDefault)...); err != nil {
-		t.Fatal(err)
-	}
-	if err = randomize.Struct(seed, &b, throughputRuleDBTypes, false, strmangle.SetComplement(throughputRulePrimaryKeyColumns, throughputRuleColumnsWithoutDefault)...); err != nil {
-		t.Fatal(err)
-	}
-	if err = randomize.Struct(seed, &c, throughputRuleDBTypes, false, strmangle.SetComplement(throughputRulePrimaryKeyColumns, throughputRuleColumnsWithoutDefault)...); err != nil {
-		t.Fatal(err)
-	}
-
-	if err := a.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-	if err = b.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-
-	for i, x := range []*ThroughputRule{&b, &c} {
-		err = a.SetThroughputRule(ctx, tx, i != 0, x)
-		if err != nil {
-			t.Fatal(err)
-		}
-
-		if a.R.ThroughputRule != x {
-			t.Error("relationship struct not set to correct value")
-		}
-
-		if x.R.ThroughputRuleChanges[0] != &a {
-			t.Error("failed to append to foreign relationship struct")
-		}
-		if a.ThroughputRuleID != x.ID {
-			t.Error("foreign key was wrong value", a.ThroughputRuleID)
-		}
-
-		zero := reflect.Zero(reflect.TypeOf(a.ThroughputRuleID))
-		reflect.Indirect(reflect.ValueOf(&a.ThroughputRuleID)).Set(zero)
-
-		if err = a.Reload(ctx, tx); err != nil {
-			t.Fatal("failed to reload", err)
-		}
-
-		if a.ThroughputRuleID != x.ID {
-			t.Error("foreign key was wrong value", a.ThroughputRuleID, x.ID)
-		}
-	}
-}
-
 func testThroughputRuleChangesReload(t *testing.T) {
 	t.Parallel()
 
@@ -677,7 +568,7 @@ func testThroughputRuleChangesSelect(t *testing.T) {
 }
 
 var (
-	throughputRuleChangeDBTypes = map[string]string{`ID`: `int`, `Action`: `varchar`, `ThroughputRuleID`: `int`, `MXDomain`: `varchar`, `MaxConnections`: `int`, `MessagesPerConnection`: `int`, `ConnectionTTLMillis`: `int`, `UpdatedAt`: `datetime`}
+	throughputRuleChangeDBTypes = map[string]string{`ID`: `int`, `Action`: `varchar`, `ThroughputRuleID`: `int`, `MXDomain`: `varchar`, `MaxConnections`: `int`, `MessagesPerConnection`: `int`, `ConnectionTTLMillis`: `int`, `EffectiveAt`: `datetime`, `ExpiresAt`: `datetime`, `UpdatedAt`: `datetime`, `BatchID`: `varchar`}
 	_                           = bytes.MinRead
 )
 
@@ -494,160 +494,6 @@ func testThroughputRulesInsertWhitelist(t *testing.T) {
 	}
 }
 
-func testThroughputRuleToManyThroughputRuleChanges(t *testing.T) {
-	var err error
-	ctx := context.Background()
-	tx := MustTx(boil.BeginTx(ctx, nil))
-	defer func() { _ = tx.Rollback() }()
-
-	var a ThroughputRule
-	var b, c ThroughputRuleChange
-
-	seed := randomize.NewSeed()
-	if err = randomize.Struct(seed, &a, throughputRuleDBTypes, true, throughputRuleColumnsWithDefault...); err != nil {
-		t.Errorf("Unable to randomize ThroughputRule struct: %s", err)
-	}
-
-	if err := a.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-
-	if err = randomize.Struct(seed, &b, throughputRuleChangeDBTypes, false, throughputRuleChangeColumnsWithDefault...); err != nil {
-		t.Fatal(err)
-	}
-	if err = randomize.Struct(seed, &c, throughputRuleChangeDBTypes, false, throughputRuleChangeColumnsWithDefault...); err != nil {
-		t.Fatal(err)
-	}
-
-	b.ThroughputRuleID = a.ID
-	c.ThroughputRuleID = a.ID
-
-	if err = b.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-	if err = c.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-
-	check, err := a.ThroughputRuleChanges().All(ctx, tx)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	bFound, cFound := false, false
-	for _, v := range check {
-		if v.ThroughputRuleID == b.ThroughputRuleID {
-			bFound = true
-		}
-		if v.ThroughputRuleID == c.ThroughputRuleID {
-			cFound = true
-		}
-	}
-
-	if !bFound {
-		t.Error("expected to find b")
-	}
-	if !cFound {
-		t.Error("expected to find c")
-	}
-
-	slice := ThroughputRuleSlice{&a}
-	if err = a.L.LoadThroughputRuleChanges(ctx, tx, false, (*[]*ThroughputRule)(&slice), nil); err != nil {
-		t.Fatal(err)
-	}
-	if got := len(a.R.ThroughputRuleChanges); got != 2 {
-		t.Error("number of eager loaded records wrong, got:", got)
-	}
-
-	a.R.ThroughputRuleChanges = nil
-	if err = a.L.LoadThroughputRuleChanges(ctx, tx, true, &a, nil); err != nil {
-		t.Fatal(err)
-	}
-	if got := len(a.R.ThroughputRuleChanges); got != 2 {
-		t.Error("number of eager loaded records wrong, got:", got)
-	}
-
-	if t.Failed() {
-		t.Logf("%#v", check)
-	}
-}
-
-func testThroughputRuleToManyAddOpThroughputRuleChanges(t *testing.T) {
-	var err error
-
-	ctx := context.Background()
-	tx := MustTx(boil.BeginTx(ctx, nil))
-	defer func() { _ = tx.Rollback() }()
-
-	var a ThroughputRule
-	var b, c, d, e ThroughputRuleChange
-
-	seed := randomize.NewSeed()
-	if err = randomize.Struct(seed, &a, throughputRuleDBTypes, false, strmangle.SetComplement(throughputRulePrimaryKeyColumns, throughputRuleColumnsWithoutDefault)...); err != nil {
-		t.Fatal(err)
-	}
-	foreigners := []*ThroughputRuleChange{&b, &c, &d, &e}
-	for _, x := range foreigners {
-		if err = randomize.Struct(seed, x, throughputRuleChangeDBTypes, false, strmangle.SetComplement(throughputRuleChangePrimaryKeyColumns, throughputRuleChangeColumnsWithoutDefault)...); err != nil {
-			t.Fatal(err)
-		}
-	}
-
-	if err := a.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-	if err = b.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-	if err = c.Insert(ctx, tx, boil.Infer()); err != nil {
-		t.Fatal(err)
-	}
-
-	foreignersSplitByInsertion := [][]*ThroughputRuleChange{
-		{&b, &c},
-		{&d, &e},
-	}
-
-	for i, x := range foreignersSplitByInsertion {
-		err = a.AddThroughputRuleChanges(ctx, tx, i != 0, x...)
-		if err != nil {
-			t.Fatal(err)
-		}
-
-		first := x[0]
-		second := x[1]
-
-		if a.ID != first.ThroughputRuleID {
-			t.Error("foreign key was wrong value", a.ID, first.ThroughputRuleID)
-		}
-		if a.ID != second.ThroughputRuleID {
-			t.Error("foreign key was wrong value", a.ID, second.ThroughputRuleID)
-		}
-
-		if first.R.ThroughputRule != &a {
-			t.Error("relationship was not added properly to the foreign slice")
-		}
-		if second.R.ThroughputRule != &a {
-			t.Error("relationship was not added properly to the foreign slice")
-		}
-
-		if a.R.ThroughputRuleChanges[i*2] != first {
-			t.Error("relationship struct slice not set to correct value")
-		}
-		if a.R.ThroughputRuleChanges[i*2+1] != second {
-			t.Error("relationship struct slice not set to correct value")
-		}
-
-		count, err := a.ThroughputRuleChanges().Count(ctx, tx)
-		if err != nil {
-			t.Fatal(err)
-		}
-		if want := int64((i + 1) * 2); count != want {
-			t.Error("want", want, "got", count)
-		}
-	}
-}
-
 func testThroughputRulesReload(t *testing.T) {
 	t.Parallel()
 
@@ -722,7 +568,7 @@ func testThroughputRulesSelect(t *testing.T) {
 }
 
 var (
-	throughputRuleDBTypes = map[string]string{`ID`: `int`, `MXDomain`: `varchar`, `MaxConnections`: `int`, `MessagesPerConnection`: `int`, `ConnectionTTLMillis`: `int`}
+	throughputRuleDBTypes = map[string]string{`ID`: `int`, `MXDomain`: `varchar`, `MaxConnections`: `int`, `MessagesPerConnection`: `int`, `ConnectionTTLMillis`: `int`, `EffectiveAt`: `datetime`, `ExpiresAt`: `datetime`, `Version`: `int`}
 	_                     = bytes.MinRead
 )
 
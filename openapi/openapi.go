@@ -0,0 +1,185 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+
+	"gobrm/patch"
+	"gobrm/problem"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// specification documents every route of the bounce and throughput rule
+// servers and the combined rule manager.
+//
+//go:embed openapi.yaml
+var specification []byte
+
+func init() {
+	// Merge patches are plain JSON documents to the validator
+	openapi3filter.RegisterBodyDecoder(patch.MergePatch, openapi3filter.RegisteredBodyDecoder("application/json"))
+}
+
+// Load parses and checks the specification.
+func Load() (*openapi3.T, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(specification)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Subset copies the document with only the paths that start with one of the
+// prefixes, so each server describes just the routes it serves.
+func Subset(doc *openapi3.T, prefixes ...string) *openapi3.T {
+	subset := *doc
+	subset.Paths = openapi3.Paths{}
+	for path, item := range doc.Paths {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(path, prefix) {
+				subset.Paths[path] = item
+				break
+			}
+		}
+	}
+	return &subset
+}
+
+// Handler serves the document as JSON.
+func Handler(doc *openapi3.T) http.HandlerFunc {
+	body, err := json.Marshal(doc)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err != nil {
+			problem.Respond(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
+
+// Validator checks requests against the parameters and request bodies in
+// the document before they reach a handler.
+type Validator struct {
+	router routers.Router
+}
+
+// NewValidator builds a validator for the paths in the document.
+func NewValidator(doc *openapi3.T) (*Validator, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &Validator{router: router}, nil
+}
+
+// Middleware rejects requests that do not match the document with a 400
+// validation problem naming the offending parameter or body field.
+// Requests for routes the document does not know are passed on, so the
+// router answers them with its usual 404 or 405.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		request, excludeBody := validationRequest(r, route.Operation)
+		input := &openapi3filter.RequestValidationInput{
+			Request:    request,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				ExcludeRequestBody:  excludeBody,
+				SkipSettingDefaults: true,
+				AuthenticationFunc:  openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			log.Printf("Rejecting %s %s: %v", r.Method, r.URL.Path, err)
+			problem.Write(w, r, validationProblem(err))
+			return
+		}
+
+		// The validator read the body and left a copy to read again
+		r.Body = input.Request.Body
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validationRequest picks the media type a body is validated as. Rule
+// handlers have always decoded JSON whatever the Content-Type, e.g. curl's
+// default form type, so those bodies are validated as JSON. Bodies of other
+// types the operation does not accept are not validated here, and the
+// handler rejects them with 415.
+func validationRequest(r *http.Request, operation *openapi3.Operation) (*http.Request, bool) {
+	if operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return r, false
+	}
+
+	content := operation.RequestBody.Value.Content
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "" && content.Get(mediaType) != nil {
+		return r, false
+	}
+
+	if content.Get("application/json") == nil || (mediaType != "" && mediaType != "application/x-www-form-urlencoded") {
+		return r, true
+	}
+
+	validated := r.Clone(r.Context())
+	validated.Body = r.Body
+	validated.Header.Set("Content-Type", "application/json")
+	return validated, false
+}
+
+func validationProblem(err error) problem.Problem {
+	if err, ok := err.(*openapi3filter.RequestError); ok {
+		if err.Parameter != nil {
+			field, reason := errorReason(err)
+			if field == "" {
+				field = err.Parameter.Name
+			} else {
+				field = err.Parameter.Name + "." + field
+			}
+			return problem.Validation("Invalid "+err.Parameter.In+" parameter "+err.Parameter.Name+": "+reason,
+				[]problem.FieldError{{Field: field, Message: reason}})
+		}
+		if err.RequestBody != nil {
+			field, reason := errorReason(err)
+			if field == "" {
+				return problem.Validation("Invalid request body: "+reason, nil)
+			}
+			return problem.Validation("Invalid request body: "+field+" "+reason, []problem.FieldError{{Field: field, Message: reason}})
+		}
+	}
+	return problem.Validation(err.Error(), nil)
+}
+
+// errorReason returns the path of the offending value inside a parameter or
+// body, if any, and why it was rejected.
+func errorReason(err *openapi3filter.RequestError) (string, string) {
+	if schemaErr, ok := err.Err.(*openapi3.SchemaError); ok {
+		return strings.Join(schemaErr.JSONPointer(), "."), schemaErr.Reason
+	}
+	if err.Err != nil {
+		return "", err.Err.Error()
+	}
+	return "", err.Reason
+}
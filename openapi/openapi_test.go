@@ -0,0 +1,109 @@
+package openapi
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubset(t *testing.T) {
+	log.Print("Testing a subset keeps only the paths under the prefixes")
+	doc, err := Load()
+	assert.NoError(t, err, "the specification should be valid")
+
+	subset := Subset(doc, "/throughput_rule", "/openapi.json")
+	assert.NotNil(t, subset.Paths.Find("/throughput_rules/{id}"))
+	assert.NotNil(t, subset.Paths.Find("/throughput_rule_changes"))
+	assert.NotNil(t, subset.Paths.Find("/openapi.json"))
+	assert.Nil(t, subset.Paths.Find("/bounce_rules"))
+	assert.Nil(t, subset.Paths.Find("/webhooks"))
+	assert.NotNil(t, doc.Paths.Find("/bounce_rules"), "should leave the document alone")
+}
+
+func TestMissing(t *testing.T) {
+	log.Print("Testing routes are matched to documented operations")
+	doc, err := Load()
+	assert.NoError(t, err)
+
+	router := chi.NewRouter()
+	router.Route("/throughput_rules", func(r chi.Router) {
+		r.Get("/", http.NotFound)
+		r.Get("/{id:[0-9]+}", http.NotFound)
+		r.Post("/{id:[0-9]+}/archive", http.NotFound)
+	})
+	router.Handle("/metrics", http.NotFoundHandler())
+	router.Handle("/bounce_rules/*", http.NotFoundHandler())
+
+	assert.Equal(t, []string{"POST /throughput_rules/{id}/archive"}, Missing(doc, ChiRoutes(router)))
+
+	gorilla := mux.NewRouter()
+	gorilla.HandleFunc("/bounce_rules/{id:[0-9]+}", http.NotFound).Methods("GET", "OPTIONS")
+	gorilla.Path("/metrics").Handler(http.NotFoundHandler())
+	routes, err := GorillaRoutes(gorilla)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"OPTIONS /bounce_rules/{id}"}, Missing(doc, routes))
+}
+
+func validated(t *testing.T, req *http.Request) (*httptest.ResponseRecorder, string) {
+	doc, err := Load()
+	assert.NoError(t, err)
+	validator, err := NewValidator(doc)
+	assert.NoError(t, err)
+
+	var body string
+	handler := validator.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		read, _ := ioutil.ReadAll(r.Body)
+		body = string(read)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr, body
+}
+
+func TestValidatorRejectsInvalidRequests(t *testing.T) {
+	log.Print("Testing invalid parameters and bodies are rejected before the handler")
+	rr, _ := validated(t, httptest.NewRequest("GET", "/bounce_rules/abc", nil))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "Invalid path parameter id")
+
+	req := httptest.NewRequest("POST", "/throughput_rules", strings.NewReader(`{"mx_domain":"gmail.com","max_connections":"ten","messages_per_connection":1,"connection_ttl_millis":1}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr, _ = validated(t, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "max_connections")
+
+	rr, _ = validated(t, httptest.NewRequest("PUT", "/throughput_rule_releases/rollout", strings.NewReader(`{"percentage":101}`)))
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "should validate bodies sent without a Content-Type as JSON")
+}
+
+func TestValidatorPassesValidRequests(t *testing.T) {
+	log.Print("Testing valid requests reach the handler with their body")
+	payload := `{"mx_domain":"gmail.com","max_connections":10,"messages_per_connection":1,"connection_ttl_millis":1}`
+	req := httptest.NewRequest("POST", "/throughput_rules", strings.NewReader(payload))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rr, body := validated(t, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code, "should accept curl's default Content-Type")
+	assert.Equal(t, payload, body)
+
+	req = httptest.NewRequest("PATCH", "/bounce_rules/1", strings.NewReader(`{"priority":2}`))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	rr, _ = validated(t, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req = httptest.NewRequest("PATCH", "/bounce_rules/1", strings.NewReader(`{"priority":2}`))
+	req.Header.Set("Content-Type", "application/json")
+	rr, _ = validated(t, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code, "should leave unsupported media types to the handler")
+
+	rr, _ = validated(t, httptest.NewRequest("GET", "/unknown", nil))
+	assert.Equal(t, http.StatusNoContent, rr.Code, "should leave unknown routes to the router")
+}
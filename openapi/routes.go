@@ -0,0 +1,100 @@
+package openapi
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
+)
+
+// Route is a method and path template such as GET /bounce_rules/{id}.
+type Route struct {
+	Method string
+	Path   string
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Path
+}
+
+// variablePattern matches the regular expression of a path variable, e.g.
+// the :[0-9]+ in {id:[0-9]+}.
+var variablePattern = regexp.MustCompile(`\{([^{}:]+):[^{}]*\}`)
+
+func normalizePath(path string) string {
+	path = variablePattern.ReplaceAllString(path, "{$1}")
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	return path
+}
+
+// GorillaRoutes lists the routes registered on a gorilla/mux router. Routes
+// without a method matcher, like /metrics, are listed as GET.
+func GorillaRoutes(router *mux.Router) ([]Route, error) {
+	routes := []Route{}
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+		for _, method := range methods {
+			routes = append(routes, Route{Method: method, Path: normalizePath(path)})
+		}
+		return nil
+	})
+	return routes, err
+}
+
+// ChiRoutes lists the routes registered on a chi router. Routes for any
+// method, like /metrics, are listed as GET, and catch-all routes that hand
+// a prefix to another router are skipped.
+func ChiRoutes(router chi.Routes) []Route {
+	routes := []Route{}
+	walkChi(router, "", &routes)
+	return routes
+}
+
+func walkChi(router chi.Routes, prefix string, routes *[]Route) {
+	for _, route := range router.Routes() {
+		if route.SubRoutes != nil {
+			walkChi(route.SubRoutes, prefix+strings.TrimSuffix(route.Pattern, "/*"), routes)
+			continue
+		}
+
+		if strings.HasSuffix(route.Pattern, "/*") {
+			continue
+		}
+
+		path := normalizePath(prefix + route.Pattern)
+		if _, ok := route.Handlers["*"]; ok {
+			*routes = append(*routes, Route{Method: http.MethodGet, Path: path})
+			continue
+		}
+		for method := range route.Handlers {
+			*routes = append(*routes, Route{Method: method, Path: path})
+		}
+	}
+}
+
+// Missing lists the routes the document does not describe, sorted.
+func Missing(doc *openapi3.T, routes []Route) []string {
+	missing := []string{}
+	for _, route := range routes {
+		item := doc.Paths.Find(route.Path)
+		if item == nil || item.GetOperation(route.Method) == nil {
+			missing = append(missing, route.String())
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,84 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+
+	jsonpatch "github.com/evanphx/json-patch/v5"
+)
+
+// Media types accepted by PATCH.
+const (
+	MergePatch = "application/merge-patch+json"
+	JSONPatch  = "application/json-patch+json"
+)
+
+var (
+	// ErrUnsupportedMediaType is returned for a PATCH body that is neither kind of patch.
+	ErrUnsupportedMediaType = errors.New("PATCH requires " + MergePatch + " or " + JSONPatch)
+	// ErrTestFailed is returned when a JSON Patch test operation does not hold.
+	ErrTestFailed = errors.New("JSON Patch test operation failed")
+)
+
+// InvalidPatchError is returned for a patch that cannot be parsed or applied.
+type InvalidPatchError struct {
+	Err error
+}
+
+func (e InvalidPatchError) Error() string {
+	return "Invalid patch: " + e.Err.Error()
+}
+
+// Apply patches a JSON document with a JSON Merge Patch (RFC 7396) or a JSON
+// Patch (RFC 6902), chosen by the request's Content-Type.
+func Apply(contentType string, document []byte, patchDocument []byte) ([]byte, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	switch mediaType {
+	case MergePatch:
+		if !json.Valid(patchDocument) {
+			return nil, InvalidPatchError{Err: errors.New("body is not JSON")}
+		}
+		patched, err := jsonpatch.MergePatch(document, patchDocument)
+		if err != nil {
+			return nil, InvalidPatchError{Err: err}
+		}
+		return patched, nil
+	case JSONPatch:
+		operations, err := jsonpatch.DecodePatch(patchDocument)
+		if err != nil {
+			return nil, InvalidPatchError{Err: err}
+		}
+		patched, err := operations.Apply(document)
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			return nil, ErrTestFailed
+		}
+		if err != nil {
+			return nil, InvalidPatchError{Err: err}
+		}
+		return patched, nil
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+}
+
+// MissingFields lists the required top-level fields that are absent from a
+// JSON object or null.
+func MissingFields(body []byte, required []string) ([]string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+
+	missing := []string{}
+	for _, field := range required {
+		if value, ok := fields[field]; !ok || string(value) == "null" {
+			missing = append(missing, field)
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,66 @@
+package patch
+
+import (
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var document = []byte(`{"id":1,"mx_domain":"gmail.com","max_connections":10,"effective_at":"2021-05-01T00:00:00Z"}`)
+
+func TestApplyMergePatch(t *testing.T) {
+	log.Print("Testing merge patches replace fields and null removes them")
+	patched, err := Apply(MergePatch, document, []byte(`{"max_connections":20,"effective_at":null}`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"mx_domain":"gmail.com","max_connections":20}`, string(patched))
+
+	patched, err = Apply(MergePatch+"; charset=utf-8", document, []byte(`{}`))
+	assert.NoError(t, err, "should accept media type parameters")
+	assert.JSONEq(t, string(document), string(patched))
+
+	_, err = Apply(MergePatch, document, []byte(`{"max_connections":`))
+	assert.IsType(t, InvalidPatchError{}, err)
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	log.Print("Testing JSON patches apply operations and enforce tests")
+	patched, err := Apply(JSONPatch, document, []byte(`[
+		{"op":"test","path":"/max_connections","value":10},
+		{"op":"replace","path":"/max_connections","value":20},
+		{"op":"remove","path":"/effective_at"}
+	]`))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"mx_domain":"gmail.com","max_connections":20}`, string(patched))
+
+	_, err = Apply(JSONPatch, document, []byte(`[{"op":"test","path":"/max_connections","value":11}]`))
+	assert.Equal(t, ErrTestFailed, err)
+
+	_, err = Apply(JSONPatch, document, []byte(`[{"op":"remove","path":"/missing"}]`))
+	assert.IsType(t, InvalidPatchError{}, err)
+
+	_, err = Apply(JSONPatch, document, []byte(`{"op":"remove"}`))
+	assert.IsType(t, InvalidPatchError{}, err, "should require an array of operations")
+}
+
+func TestApplyUnsupportedMediaType(t *testing.T) {
+	log.Print("Testing other content types are rejected")
+	for _, contentType := range []string{"", "application/json", "text/plain"} {
+		_, err := Apply(contentType, document, []byte(`{}`))
+		assert.Equal(t, ErrUnsupportedMediaType, err, contentType)
+	}
+}
+
+func TestMissingFields(t *testing.T) {
+	log.Print("Testing required fields that are absent or null are reported")
+	missing, err := MissingFields([]byte(`{"mx_domain":"gmail.com","max_connections":null}`), []string{"mx_domain", "max_connections", "connection_ttl_millis"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"max_connections", "connection_ttl_millis"}, missing)
+
+	missing, err = MissingFields(document, []string{"mx_domain"})
+	assert.NoError(t, err)
+	assert.Empty(t, missing)
+
+	_, err = MissingFields([]byte(`[]`), []string{"mx_domain"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,228 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"gobrm/apiversion"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// ContentType is the media type of RFC 7807 problem details.
+const ContentType = "application/problem+json"
+
+// TypePrefix starts every problem type URI, e.g. urn:gobrm:problem:not-found.
+// The URIs are stable, so clients may switch on them instead of the title.
+const TypePrefix = "urn:gobrm:problem:"
+
+// Problem types with a meaning beyond their status code.
+const (
+	TypeValidation      = TypePrefix + "validation-error"
+	TypeVersionConflict = TypePrefix + "version-conflict"
+	TypeDuplicate       = TypePrefix + "duplicate"
+)
+
+// internalDetail is all clients see of a server error; the full error is
+// logged with the request ID they can quote.
+const internalDetail = "The server failed to handle the request, quote the request_id when reporting it"
+
+// Problem is an RFC 7807 problem details object. Extensions are added to
+// the top level of the JSON object next to the standard members.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	RequestID  string
+	Errors     []FieldError
+	Extensions map[string]interface{}
+}
+
+// FieldError names a request field that failed validation and why, e.g.
+// {"field": "priority", "message": "is required"}.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// FieldErrors lets a FieldError be reported on its own by Invalid.
+func (e FieldError) FieldErrors() []FieldError {
+	return []FieldError{e}
+}
+
+// DuplicateError reports a field whose value must be unique and is already
+// taken by another rule, e.g. the mx_domain of a throughput rule.
+type DuplicateError struct {
+	Field string
+	Value string
+}
+
+func (e DuplicateError) Error() string {
+	return fmt.Sprintf("another rule already has %s %q", e.Field, e.Value)
+}
+
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := map[string]interface{}{}
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+	members["type"] = p.Type
+	members["title"] = p.Title
+	members["status"] = p.Status
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	if p.RequestID != "" {
+		members["request_id"] = p.RequestID
+	}
+	if len(p.Errors) > 0 {
+		members["errors"] = p.Errors
+	}
+	return json.Marshal(members)
+}
+
+// New describes a failed request by its status code. The type is derived
+// from the status text, e.g. urn:gobrm:problem:not-found for 404.
+func New(status int, detail string) Problem {
+	title := http.StatusText(status)
+	return Problem{
+		Type:   TypePrefix + strings.ReplaceAll(strings.ToLower(title), " ", "-"),
+		Title:  title,
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Validation describes a request whose fields failed validation.
+func Validation(detail string, fields []FieldError) Problem {
+	p := New(http.StatusBadRequest, detail)
+	p.Type = TypeValidation
+	p.Title = "Validation Failed"
+	p.Errors = fields
+	return p
+}
+
+// Duplicate describes a write that would give two rules the same value of a
+// unique field, naming the field in errors.
+func Duplicate(err DuplicateError, detail string) Problem {
+	p := New(http.StatusConflict, detail)
+	p.Type = TypeDuplicate
+	p.Errors = []FieldError{{Field: err.Field, Message: "is already taken"}}
+	return p
+}
+
+// DecodeErrors names the field a JSON decoding error is about, such as a
+// string sent for a number or, with DisallowUnknownFields, an unknown field.
+func DecodeErrors(err error) []FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		switch typeErr.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Float32, reflect.Float64:
+			return []FieldError{{Field: typeErr.Field, Message: "must be a number"}}
+		case reflect.String:
+			return []FieldError{{Field: typeErr.Field, Message: "must be a string"}}
+		default:
+			return []FieldError{{Field: typeErr.Field, Message: "has the wrong type"}}
+		}
+	}
+
+	if field := strings.TrimPrefix(err.Error(), "json: unknown field "); field != err.Error() {
+		return []FieldError{{Field: strings.Trim(field, `"`), Message: "is not a known field"}}
+	}
+
+	return nil
+}
+
+// Invalid writes a 400 problem for a rejected request. Errors that list the
+// offending fields, like a FieldError, are written as validation problems.
+func Invalid(w http.ResponseWriter, r *http.Request, err error) {
+	var invalid interface {
+		error
+		FieldErrors() []FieldError
+	}
+	if errors.As(err, &invalid) {
+		Write(w, r, Validation(err.Error(), invalid.FieldErrors()))
+		return
+	}
+	Respond(w, r, http.StatusBadRequest, err.Error())
+}
+
+// Respond writes a problem for the status code. Server errors are logged
+// with their detail, which is replaced by a generic message so database and
+// other internal errors never reach clients.
+func Respond(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	Write(w, r, New(status, detail))
+}
+
+// Write writes a problem, filling in the request path and ID. v1 requests,
+// which include unprefixed ones, get the legacy error body instead.
+func Write(w http.ResponseWriter, r *http.Request, p Problem) {
+	p.Instance = apiversion.Path(r)
+	p.RequestID = middleware.GetReqID(r.Context())
+
+	if p.Status >= http.StatusInternalServerError {
+		log.Printf("Request %s %s %s failed with %d: %s", p.RequestID, r.Method, r.URL.Path, p.Status, p.Detail)
+		p.Detail = internalDetail
+	}
+
+	if apiversion.FromContext(r.Context()) == apiversion.V1 {
+		writeLegacy(w, p)
+		return
+	}
+
+	response, _ := json.Marshal(p)
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	w.Write(response)
+}
+
+// writeLegacy writes a problem the way v1 always answered errors, as
+// {"error": "..."}, keeping extensions such as current_version next to it.
+func writeLegacy(w http.ResponseWriter, p Problem) {
+	body := map[string]interface{}{}
+	for name, value := range p.Extensions {
+		body[name] = value
+	}
+	body["error"] = p.Detail
+	if p.Detail == "" {
+		body["error"] = p.Title
+	}
+
+	response, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(p.Status)
+	w.Write(response)
+}
+
+// NotFound answers requests for paths no route matches.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	Respond(w, r, http.StatusNotFound, "No route matches "+apiversion.Path(r))
+}
+
+// MethodNotAllowed answers requests with a method the matched path does not support.
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	Respond(w, r, http.StatusMethodNotAllowed, r.Method+" is not supported on "+apiversion.Path(r))
+}
+
+// RequestID reads the request's X-Request-Id header or generates an ID,
+// and echoes it in the response so problems can be matched to the logs.
+func RequestID(next http.Handler) http.Handler {
+	return middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	}))
+}
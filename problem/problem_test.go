@@ -0,0 +1,132 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gobrm/apiversion"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+// newV2Request makes a request of API version v2, which answers with problems.
+func newV2Request(method string, target string) *http.Request {
+	req, _ := http.NewRequest(method, target, nil)
+	return req.WithContext(apiversion.NewContext(req.Context(), apiversion.V2))
+}
+
+func decode(t *testing.T, rr *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	return body
+}
+
+func TestRespond(t *testing.T) {
+	log.Print("Testing Respond writes a problem for the status code")
+	req := newV2Request("GET", "/bounce_rule/7")
+	rr := httptest.NewRecorder()
+	RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Respond(w, r, http.StatusNotFound, "Bounce rule not found")
+	})).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, ContentType, rr.Header().Get("Content-Type"))
+	body := decode(t, rr)
+	assert.Equal(t, "urn:gobrm:problem:not-found", body["type"])
+	assert.Equal(t, "Not Found", body["title"])
+	assert.Equal(t, float64(http.StatusNotFound), body["status"])
+	assert.Equal(t, "Bounce rule not found", body["detail"])
+	assert.Equal(t, "/bounce_rule/7", body["instance"])
+	assert.NotEmpty(t, body["request_id"])
+	assert.Equal(t, body["request_id"], rr.Header().Get(middleware.RequestIDHeader), "should echo the request ID")
+}
+
+func TestRespondHidesServerErrors(t *testing.T) {
+	log.Print("Testing Respond does not leak the detail of server errors")
+	req := newV2Request("GET", "/bounce_rule")
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	rr := httptest.NewRecorder()
+	RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Respond(w, r, http.StatusInternalServerError, "dial tcp 10.0.0.1:3306: connection refused")
+	})).ServeHTTP(rr, req)
+
+	body := decode(t, rr)
+	assert.Equal(t, internalDetail, body["detail"])
+	assert.Equal(t, "req-1", body["request_id"], "should keep the request ID sent by the client")
+}
+
+func TestWriteKeepsLegacyErrorsForV1(t *testing.T) {
+	log.Print("Testing v1 and unprefixed requests get the legacy error body")
+	p := New(http.StatusPreconditionFailed, "Version mismatch")
+	p.Extensions = map[string]interface{}{"current_version": 3}
+
+	for _, version := range []string{"", apiversion.V1} {
+		req, _ := http.NewRequest("PUT", "/bounce_rule/7", nil)
+		if version != "" {
+			req = req.WithContext(apiversion.NewContext(req.Context(), version))
+		}
+		rr := httptest.NewRecorder()
+		Write(rr, req, p)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		assert.JSONEq(t, `{"error":"Version mismatch","current_version":3}`, rr.Body.String())
+	}
+
+	rr := httptest.NewRecorder()
+	Write(rr, newV2Request("PUT", "/bounce_rule/7"), p)
+	assert.Equal(t, ContentType, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "urn:gobrm:problem:precondition-failed", decode(t, rr)["type"])
+
+	rr = httptest.NewRecorder()
+	Respond(rr, httptest.NewRequest("GET", "/bounce_rule", nil), http.StatusInternalServerError, "dial tcp 10.0.0.1:3306: connection refused")
+	assert.JSONEq(t, `{"error":"`+internalDetail+`"}`, rr.Body.String(), "should not leak server errors to v1 either")
+}
+
+func TestInvalid(t *testing.T) {
+	log.Print("Testing Invalid lists the fields that failed validation")
+	req := newV2Request("POST", "/bounce_rule")
+
+	rr := httptest.NewRecorder()
+	Invalid(rr, req, FieldError{Field: "expires_at", Message: "must be after effective_at"})
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	body := decode(t, rr)
+	assert.Equal(t, TypeValidation, body["type"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"field": "expires_at", "message": "must be after effective_at"}}, body["errors"])
+
+	rr = httptest.NewRecorder()
+	Invalid(rr, req, errors.New("Invalid Content-Type"))
+	body = decode(t, rr)
+	assert.Equal(t, "urn:gobrm:problem:bad-request", body["type"])
+	assert.Nil(t, body["errors"], "should not list fields for other errors")
+}
+
+func TestDecodeErrors(t *testing.T) {
+	log.Print("Testing DecodeErrors names the field a JSON error is about")
+	var rule struct {
+		Priority int    `json:"priority"`
+		Regex    string `json:"regex"`
+	}
+	err := json.Unmarshal([]byte(`{"priority":"high"}`), &rule)
+	assert.Equal(t, []FieldError{{Field: "priority", Message: "must be a number"}}, DecodeErrors(err))
+
+	err = json.Unmarshal([]byte(`{"regex":1}`), &rule)
+	assert.Equal(t, []FieldError{{Field: "regex", Message: "must be a string"}}, DecodeErrors(err))
+
+	assert.Equal(t, []FieldError{{Field: "extra", Message: "is not a known field"}}, DecodeErrors(errors.New(`json: unknown field "extra"`)))
+	assert.Nil(t, DecodeErrors(errors.New("unexpected EOF")))
+}
+
+func TestProblemExtensions(t *testing.T) {
+	log.Print("Testing extensions are added to the top level of a problem")
+	p := New(http.StatusPreconditionFailed, "Version mismatch")
+	p.Extensions = map[string]interface{}{"current_version": 3}
+	encoded, err := json.Marshal(p)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"type":"urn:gobrm:problem:precondition-failed","title":"Precondition Failed","status":412,"detail":"Version mismatch","current_version":3}`, string(encoded))
+}
@@ -0,0 +1,386 @@
+// Package ratelimit keeps one client from starving the others of the
+// database. Every client gets a token bucket for reads and one for writes,
+// and routes can be given budgets of their own, e.g. for a poller hammering
+// GET /bounce_rule_changes.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gobrm/auth"
+	"gobrm/problem"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// Response headers telling clients their budget.
+const (
+	LimitHeader     = "X-RateLimit-Limit"
+	RemainingHeader = "X-RateLimit-Remaining"
+)
+
+const defaultPruneInterval = time.Minute
+
+// exempt paths are never limited, e.g. so Prometheus scrapes keep working.
+var exempt = map[string]bool{"/metrics": true, "/openapi.json": true}
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limit_requests_total",
+		Help: "Requests checked against a rate limit by budget. result is allowed or limited.",
+	}, []string{"budget", "result"})
+	clients = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "rate_limit_buckets",
+		Help: "Token buckets of clients that used their budget recently.",
+	})
+)
+
+// units are the periods a Limit may be given per.
+var units = map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+
+// Limit allows Requests per Per. A client may spend the whole budget at
+// once; it then refills evenly over Per. The zero Limit is unlimited.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+}
+
+// ParseLimit reads a limit such as "600/m"; the unit is s, m or h. "0" or
+// an empty string is unlimited.
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+
+	parts := strings.Split(value, "/")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("rate limit %q is not of the form <requests>/<s|m|h>", value)
+	}
+	requests, err := strconv.Atoi(parts[0])
+	if err != nil || requests < 0 {
+		return Limit{}, fmt.Errorf("rate limit %q does not start with a number of requests", value)
+	}
+	per, ok := units[parts[1]]
+	if !ok {
+		return Limit{}, fmt.Errorf("rate limit %q is not per s, m or h", value)
+	}
+	if requests == 0 {
+		return Limit{}, nil
+	}
+	return Limit{Requests: requests, Per: per}, nil
+}
+
+func (l Limit) String() string {
+	for unit, per := range units {
+		if per == l.Per {
+			return fmt.Sprintf("%d/%s", l.Requests, unit)
+		}
+	}
+	return fmt.Sprintf("%d per %s", l.Requests, l.Per)
+}
+
+// Unlimited reports whether the limit lets every request through.
+func (l Limit) Unlimited() bool {
+	return l.Requests <= 0 || l.Per <= 0
+}
+
+// UnmarshalText lets config files and the environment set limits.
+func (l *Limit) UnmarshalText(text []byte) error {
+	limit, err := ParseLimit(string(text))
+	if err != nil {
+		return err
+	}
+	*l = limit
+	return nil
+}
+
+// Route gives the requests to a path, and the paths below it, a budget of
+// their own instead of the read or write budget. An empty Method matches
+// every method.
+type Route struct {
+	Method string
+	Path   string
+	Limit  Limit
+}
+
+// ParseRoute reads a route limit such as "GET /bounce_rule_changes=60/m".
+// The method may be left out.
+func ParseRoute(value string) (Route, error) {
+	i := strings.LastIndex(value, "=")
+	if i < 0 {
+		return Route{}, fmt.Errorf("route limit %q is not of the form [METHOD ]/path=<limit>", value)
+	}
+	limit, err := ParseLimit(value[i+1:])
+	if err != nil {
+		return Route{}, err
+	}
+
+	route := Route{Path: strings.TrimSpace(value[:i]), Limit: limit}
+	if fields := strings.Fields(route.Path); len(fields) == 2 {
+		route.Method, route.Path = strings.ToUpper(fields[0]), fields[1]
+	}
+	if !strings.HasPrefix(route.Path, "/") || strings.ContainsAny(route.Path, " \t") {
+		return Route{}, fmt.Errorf("route limit %q does not name a path", value)
+	}
+	route.Path = strings.TrimSuffix(route.Path, "/")
+	return route, nil
+}
+
+func (r Route) String() string {
+	if r.Method == "" {
+		return r.Path
+	}
+	return r.Method + " " + r.Path
+}
+
+// UnmarshalText lets config files and the environment set route limits.
+func (r *Route) UnmarshalText(text []byte) error {
+	route, err := ParseRoute(string(text))
+	if err != nil {
+		return err
+	}
+	*r = route
+	return nil
+}
+
+func (r Route) matches(method string, path string) bool {
+	if r.Method != "" && r.Method != method {
+		return false
+	}
+	return path == r.Path || strings.HasPrefix(path, r.Path+"/")
+}
+
+// Config sets the budgets of every client. The zero Config limits nothing.
+type Config struct {
+	// Read is the budget for GET, HEAD and OPTIONS requests.
+	Read Limit
+	// Write is the budget for every other request.
+	Write Limit
+	// Routes override the read and write budgets; the first match is used.
+	Routes []Route
+	// Unauthenticated is the budget of failed authentications of every IP
+	// address.
+	Unauthenticated Limit
+}
+
+// bucket holds the tokens a client has left of one budget.
+type bucket struct {
+	limit   Limit
+	tokens  float64
+	updated time.Time
+}
+
+// refill adds the tokens earned since the bucket was last updated.
+func (b *bucket) refill(now time.Time) {
+	rate := float64(b.limit.Requests) / b.limit.Per.Seconds()
+	b.tokens = math.Min(float64(b.limit.Requests), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+}
+
+// wait returns how long until the bucket has a whole token again.
+func (b *bucket) wait() time.Duration {
+	perToken := float64(b.limit.Per) / float64(b.limit.Requests)
+	return time.Duration((1 - b.tokens) * perToken)
+}
+
+// Limiter keeps the token buckets of every client in memory, so each server
+// enforces its budgets on its own.
+type Limiter struct {
+	Config Config
+	// PruneInterval is how often Run forgets clients with full buckets; it
+	// defaults to a minute.
+	PruneInterval time.Duration
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// New returns a limiter enforcing config.
+func New(config Config) *Limiter {
+	return &Limiter{Config: config, buckets: map[string]*bucket{}}
+}
+
+// budget returns the limit of a request and the name of its budget.
+func (l *Limiter) budget(r *http.Request) (Limit, string) {
+	for _, route := range l.Config.Routes {
+		if route.matches(r.Method, r.URL.Path) {
+			return route.Limit, route.String()
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return l.Config.Read, "read"
+	default:
+		return l.Config.Write, "write"
+	}
+}
+
+// Client names who a request is from: its API key or JWT subject when it
+// is authenticated, its IP address otherwise. X-Forwarded-For is not
+// trusted, as clients could send a new one with every request.
+func Client(r *http.Request) string {
+	if identity, ok := auth.FromContext(r.Context()); ok {
+		if identity.KeyID != 0 {
+			return "api-key:" + strconv.Itoa(identity.KeyID)
+		}
+		return "subject:" + identity.Subject
+	}
+	return address(r)
+}
+
+// address names the IP address a request is from.
+func address(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// take spends a token of a client's budget. It returns whether the request
+// may go ahead, the whole tokens left and, when it may not, how long until
+// the next token.
+func (l *Limiter) take(key string, limit Limit, now time.Time) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buckets == nil {
+		l.buckets = map[string]*bucket{}
+	}
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{limit: limit, tokens: float64(limit.Requests), updated: now}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	if b.tokens < 1 {
+		return false, 0, b.wait()
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// peek reports whether a client has a token of a budget left without
+// spending it and, when it has not, how long until the next token.
+func (l *Limiter) peek(key string, limit Limit, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		return true, 0
+	}
+	b.refill(now)
+	if b.tokens < 1 {
+		return false, b.wait()
+	}
+	return true, 0
+}
+
+// Middleware rejects requests over their client's budget with 429 Too Many
+// Requests and a Retry-After header. It reads the client from the context,
+// so it must run after authentication.
+func (l *Limiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if exempt[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+		limit, name := l.budget(r)
+		if limit.Unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ok, remaining, wait := l.take(name+"|"+Client(r), limit, time.Now())
+		w.Header().Set(LimitHeader, strconv.Itoa(limit.Requests))
+		w.Header().Set(RemainingHeader, strconv.Itoa(remaining))
+		if !ok {
+			requests.WithLabelValues(name, "limited").Inc()
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			problem.Respond(w, r, http.StatusTooManyRequests,
+				fmt.Sprintf("Rate limit of %s for %s requests exceeded; retry in %d seconds", limit, name, retryAfter))
+			return
+		}
+		requests.WithLabelValues(name, "allowed").Inc()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Unauthenticated rejects requests from IP addresses that failed to
+// authenticate too often with 429 Too Many Requests, so credentials cannot
+// be guessed as fast as the network allows. It must run before
+// authentication; only 401 Unauthorized responses spend the budget.
+func (l *Limiter) Unauthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := l.Config.Unauthenticated
+		if exempt[r.URL.Path] || limit.Unlimited() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := "unauthenticated|" + address(r)
+		if ok, wait := l.peek(key, limit, time.Now()); !ok {
+			requests.WithLabelValues("unauthenticated", "limited").Inc()
+			retryAfter := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			problem.Respond(w, r, http.StatusTooManyRequests,
+				fmt.Sprintf("Rate limit of %s for failed authentications exceeded; retry in %d seconds", limit, retryAfter))
+			return
+		}
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+		if ww.Status() == http.StatusUnauthorized {
+			requests.WithLabelValues("unauthenticated", "allowed").Inc()
+			l.take(key, limit, time.Now())
+		}
+	})
+}
+
+// Prune forgets the clients whose buckets have refilled, as they are no
+// different from new clients, and returns how many buckets are left.
+func (l *Limiter) Prune(now time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, b := range l.buckets {
+		if now.Sub(b.updated) >= b.limit.Per {
+			delete(l.buckets, key)
+		}
+	}
+	return len(l.buckets)
+}
+
+// Run prunes the buckets every PruneInterval until ctx is canceled.
+func (l *Limiter) Run(ctx context.Context) {
+	interval := l.PruneInterval
+	if interval <= 0 {
+		interval = defaultPruneInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			clients.Set(float64(l.Prune(time.Now())))
+		}
+	}
+}
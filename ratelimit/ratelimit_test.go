@@ -0,0 +1,146 @@
+package ratelimit
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gobrm/auth"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	log.Print("Testing limits are read as requests per unit")
+	limit, err := ParseLimit("600/m")
+	assert.NoError(t, err)
+	assert.Equal(t, Limit{Requests: 600, Per: time.Minute}, limit)
+	assert.Equal(t, "600/m", limit.String())
+
+	limit, err = ParseLimit("0")
+	assert.NoError(t, err)
+	assert.True(t, limit.Unlimited())
+
+	for _, value := range []string{"600", "ten/s", "5/d", "-1/s"} {
+		_, err := ParseLimit(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseRoute(t *testing.T) {
+	log.Print("Testing route limits are read with and without a method")
+	route, err := ParseRoute("get /bounce_rule_changes=60/m")
+	assert.NoError(t, err)
+	assert.Equal(t, Route{Method: "GET", Path: "/bounce_rule_changes", Limit: Limit{Requests: 60, Per: time.Minute}}, route)
+	assert.True(t, route.matches("GET", "/bounce_rule_changes"))
+	assert.False(t, route.matches("GET", "/bounce_rule_changes_archive"))
+	assert.False(t, route.matches("POST", "/bounce_rule_changes"))
+
+	route, err = ParseRoute("/batch/=10/s")
+	assert.NoError(t, err)
+	assert.Equal(t, "/batch", route.String())
+
+	_, err = ParseRoute("GET /batch")
+	assert.Error(t, err)
+	_, err = ParseRoute("batch=10/s")
+	assert.Error(t, err)
+}
+
+func serve(l *Limiter, method string, path string, remoteAddr string, identity *auth.Identity) *httptest.ResponseRecorder {
+	handler := l.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = remoteAddr
+	if identity != nil {
+		req = req.WithContext(auth.WithIdentity(req.Context(), *identity))
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestMiddlewareLimitsEachClient(t *testing.T) {
+	log.Print("Testing clients get their own read, write and route budgets")
+	l := New(Config{
+		Read:   Limit{Requests: 2, Per: time.Minute},
+		Write:  Limit{Requests: 1, Per: time.Minute},
+		Routes: []Route{{Method: "GET", Path: "/bounce_rule_changes", Limit: Limit{Requests: 1, Per: time.Hour}}},
+	})
+
+	rr := serve(l, "GET", "/bounce_rules", "192.0.2.1:1234", nil)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get(LimitHeader))
+	assert.Equal(t, "1", rr.Header().Get(RemainingHeader))
+	assert.Equal(t, http.StatusOK, serve(l, "GET", "/bounce_rules", "192.0.2.1:5678", nil).Code)
+
+	rr = serve(l, "GET", "/bounce_rules", "192.0.2.1:1234", nil)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "should share the budget across connections from one IP")
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rr.Header().Get(RemainingHeader))
+	assert.Contains(t, rr.Body.String(), "Rate limit of 2/m for read requests exceeded")
+
+	assert.Equal(t, http.StatusOK, serve(l, "POST", "/bounce_rules", "192.0.2.1:1234", nil).Code, "writes should have their own budget")
+	assert.Equal(t, http.StatusOK, serve(l, "GET", "/bounce_rules", "192.0.2.2:1234", nil).Code, "other clients should not be limited")
+	assert.Equal(t, http.StatusOK, serve(l, "GET", "/metrics", "192.0.2.1:1234", nil).Code, "metrics should not be limited")
+
+	poller := &auth.Identity{Subject: "api-key:poller", KeyID: 3}
+	assert.Equal(t, http.StatusOK, serve(l, "GET", "/bounce_rule_changes", "192.0.2.1:1234", poller).Code)
+	rr = serve(l, "GET", "/bounce_rule_changes", "192.0.2.9:1234", poller)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "should follow an API key across addresses")
+	assert.Equal(t, "3600", rr.Header().Get("Retry-After"))
+	assert.Equal(t, http.StatusOK, serve(l, "GET", "/bounce_rules", "192.0.2.9:1234", poller).Code, "routes should not spend the read budget")
+}
+
+func TestUnauthenticatedLimitsFailedAuthentications(t *testing.T) {
+	log.Print("Testing only failed authentications spend an address's budget")
+	l := New(Config{Unauthenticated: Limit{Requests: 2, Per: time.Minute}})
+	status := http.StatusOK
+	handler := l.Unauthenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/bounce_rules", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, http.StatusOK, serve("192.0.2.1:1234").Code, "authenticated requests should not spend the budget")
+	}
+	status = http.StatusUnauthorized
+	assert.Equal(t, http.StatusUnauthorized, serve("192.0.2.1:1234").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve("192.0.2.1:5678").Code)
+
+	rr := serve("192.0.2.1:1234")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+	assert.Contains(t, rr.Body.String(), "Rate limit of 2/m for failed authentications exceeded")
+	assert.Equal(t, http.StatusUnauthorized, serve("192.0.2.2:1234").Code, "other addresses should not be limited")
+}
+
+func TestBucketsRefill(t *testing.T) {
+	log.Print("Testing buckets refill evenly and full buckets are pruned")
+	l := New(Config{})
+	limit := Limit{Requests: 60, Per: time.Minute}
+	now := time.Now()
+
+	for i := 0; i < 60; i++ {
+		ok, _, _ := l.take("client", limit, now)
+		assert.True(t, ok)
+	}
+	ok, _, wait := l.take("client", limit, now)
+	assert.False(t, ok)
+	assert.Equal(t, time.Second, wait)
+
+	ok, remaining, _ := l.take("client", limit, now.Add(1500*time.Millisecond))
+	assert.True(t, ok)
+	assert.Equal(t, 0, remaining)
+
+	assert.Equal(t, 1, l.Prune(now.Add(30*time.Second)))
+	assert.Equal(t, 0, l.Prune(now.Add(2*time.Minute)))
+}
@@ -0,0 +1,289 @@
+package release
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gobrm/bulk"
+	"gobrm/changerequest"
+	"gobrm/problem"
+	"gobrm/render"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// API serves publishing and fetching releases for one rule type. URL
+// parameters are read through URLParam so each rule manager can mount it on
+// its own router.
+type API struct {
+	DB       *sql.DB
+	RuleType string
+	Snapshot Snapshotter
+	URLParam func(r *http.Request, key string) string
+	// InitialPercentage is the share of consumers a new release reaches when
+	// published; DefaultInitialPercentage is used when it is zero.
+	InitialPercentage int
+}
+
+// releaseListColumns orders the fields of release lists in YAML and CSV.
+var releaseListColumns = bulk.Columns(Release{})
+
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Respond(w, r, code, message)
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+// Publish freezes the current rule set into the next release.
+func (api *API) Publish(w http.ResponseWriter, r *http.Request) {
+	initialPercentage := api.InitialPercentage
+	if initialPercentage <= 0 || initialPercentage > 100 {
+		initialPercentage = DefaultInitialPercentage
+	}
+
+	release, rollout, err := publish(r.Context(), api.DB, api.RuleType, api.Snapshot, changerequest.Actor(r), initialPercentage)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	log.Printf("Published %s release %d with hash %s to %d%% of consumers", api.RuleType, release.Version, release.ContentHash, rollout.Percentage)
+	w.Header().Set("ETag", `"`+release.ContentHash+`"`)
+	setRolloutHeaders(w, rollout)
+	respondWithJSON(w, http.StatusCreated, release)
+}
+
+// GetReleases lists releases without their rules, newest first.
+func (api *API) GetReleases(w http.ResponseWriter, r *http.Request) {
+	releases, err := getReleases(r.Context(), api.DB, api.RuleType)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.List(w, r, releases, releaseListColumns)
+}
+
+// GetRelease returns a release by version. Releases never change, so they may be cached forever.
+func (api *API) GetRelease(w http.ResponseWriter, r *http.Request) {
+	version, err := strconv.Atoi(api.URLParam(r, "version"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid release version")
+		return
+	}
+
+	release, err := getRelease(r.Context(), api.DB, api.RuleType, version)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Release not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if NotModified(w, r, release.ContentHash) {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, release)
+}
+
+// GetLatestRelease returns the newest release. Clients should revalidate it with If-None-Match.
+func (api *API) GetLatestRelease(w http.ResponseWriter, r *http.Request) {
+	release, err := getLatestRelease(r.Context(), api.DB, api.RuleType)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "No release has been published")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	if NotModified(w, r, release.ContentHash) {
+		return
+	}
+	respondWithJSON(w, http.StatusOK, release)
+}
+
+// AssignedRelease is the release a consumer should use and the rollout that chose it.
+type AssignedRelease struct {
+	Release Release         `json:"release"`
+	Rollout ConsumerRollout `json:"rollout"`
+}
+
+// ConsumerRollout reports the rollout state as seen by one consumer.
+type ConsumerRollout struct {
+	ReleaseVersion  int  `json:"release_version"`
+	PreviousVersion *int `json:"previous_version"`
+	Percentage      int  `json:"percentage"`
+	Bucket          int  `json:"bucket"`
+	Canary          bool `json:"canary"`
+}
+
+func setRolloutHeaders(w http.ResponseWriter, rollout Rollout) {
+	w.Header().Set("X-Rollout-Release", strconv.Itoa(rollout.ReleaseVersion))
+	w.Header().Set("X-Rollout-Percentage", strconv.Itoa(rollout.Percentage))
+}
+
+// consumerID identifies the caller for bucketing, from the consumer_id query
+// parameter or the X-Consumer-ID header.
+func consumerID(r *http.Request) string {
+	if id := r.URL.Query().Get("consumer_id"); id != "" {
+		return id
+	}
+	return r.Header.Get("X-Consumer-ID")
+}
+
+// GetRollout returns the rollout state of the rule type.
+func (api *API) GetRollout(w http.ResponseWriter, r *http.Request) {
+	rollout, err := getRollout(r.Context(), api.DB, api.RuleType)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "No release has been published")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, rollout)
+}
+
+// UpdateRollout changes the share of consumers that get the newest release.
+func (api *API) UpdateRollout(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Percentage *int `json:"percentage"`
+	}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&body); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if body.Percentage == nil || *body.Percentage < 0 || *body.Percentage > 100 {
+		respondWithError(w, r, http.StatusBadRequest, "percentage must be between 0 and 100")
+		return
+	}
+
+	rollout, err := updateRollout(r.Context(), api.DB, api.RuleType, changerequest.Actor(r), func(rollout *Rollout) error {
+		if rollout.ReleaseVersion == 0 {
+			return sql.ErrNoRows
+		}
+		if rollout.PreviousVersion == nil && *body.Percentage < 100 {
+			return errNoPreviousRelease
+		}
+		rollout.Percentage = *body.Percentage
+		return nil
+	})
+	if err != nil {
+		api.respondWithRolloutError(w, r, err)
+		return
+	}
+
+	log.Printf("Rolled out %s release %d to %d%% of consumers", api.RuleType, rollout.ReleaseVersion, rollout.Percentage)
+	respondWithJSON(w, http.StatusOK, rollout)
+}
+
+// RollbackRollout returns every consumer to the previous release.
+func (api *API) RollbackRollout(w http.ResponseWriter, r *http.Request) {
+	var from int
+	rollout, err := updateRollout(r.Context(), api.DB, api.RuleType, changerequest.Actor(r), func(rollout *Rollout) error {
+		if rollout.ReleaseVersion == 0 {
+			return sql.ErrNoRows
+		}
+		from = rollout.ReleaseVersion
+
+		previous, ok := rollout.rollback()
+		if !ok {
+			return errNoPreviousRelease
+		}
+		*rollout = previous
+		return nil
+	})
+	if err != nil {
+		api.respondWithRolloutError(w, r, err)
+		return
+	}
+
+	log.Printf("Rolled back %s release %d to release %d", api.RuleType, from, rollout.ReleaseVersion)
+	respondWithJSON(w, http.StatusOK, rollout)
+}
+
+func (api *API) respondWithRolloutError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err {
+	case sql.ErrNoRows:
+		respondWithError(w, r, http.StatusNotFound, "No release has been published")
+	case errNoPreviousRelease:
+		respondWithError(w, r, http.StatusConflict, err.Error())
+	default:
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetAssignedRelease returns the release a consumer is assigned by the
+// rollout. Consumers identify themselves with consumer_id or X-Consumer-ID
+// and should revalidate with If-None-Match.
+func (api *API) GetAssignedRelease(w http.ResponseWriter, r *http.Request) {
+	id := consumerID(r)
+	if id == "" {
+		respondWithError(w, r, http.StatusBadRequest, "consumer_id or X-Consumer-ID is required")
+		return
+	}
+
+	rollout, err := getRollout(r.Context(), api.DB, api.RuleType)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "No release has been published")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	bucket := Bucket(api.RuleType, id)
+	version := rollout.AssignedVersion(bucket)
+	release, err := getRelease(r.Context(), api.DB, api.RuleType, version)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	assigned := AssignedRelease{
+		Release: release,
+		Rollout: ConsumerRollout{
+			ReleaseVersion:  rollout.ReleaseVersion,
+			PreviousVersion: rollout.PreviousVersion,
+			Percentage:      rollout.Percentage,
+			Bucket:          bucket,
+			Canary:          version == rollout.ReleaseVersion && rollout.Percentage < 100,
+		},
+	}
+	response, err := json.Marshal(assigned)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	setRolloutHeaders(w, rollout)
+	w.Header().Set("X-Rule-Set-Version", strconv.Itoa(version))
+	w.Header().Set("Cache-Control", "no-cache")
+	if NotModified(w, r, Hash(response)) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(response)
+}
@@ -0,0 +1,122 @@
+package release
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// CREATE TABLE rule_set_release (
+//   id INT NOT NULL AUTO_INCREMENT,
+//   rule_type VARCHAR(32) NOT NULL,
+//   version INT NOT NULL,
+//   content_hash CHAR(64) NOT NULL,
+//   rules MEDIUMTEXT NOT NULL,
+//   change_ids TEXT NOT NULL,
+//   last_change_id INT NOT NULL DEFAULT 0,
+//   published_by VARCHAR(255) NULL,
+//   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   PRIMARY KEY (id),
+//   UNIQUE KEY (rule_type, version)
+// );
+
+// Release is an immutable, numbered snapshot of a rule set. ChangeIDs are the
+// change rows made since the previous release.
+type Release struct {
+	ID           int             `json:"id"`
+	RuleType     string          `json:"rule_type"`
+	Version      int             `json:"version"`
+	ContentHash  string          `json:"content_hash"`
+	Rules        json.RawMessage `json:"rules,omitempty"`
+	ChangeIDs    []int           `json:"change_ids"`
+	LastChangeID int             `json:"last_change_id"`
+	PublishedBy  *string         `json:"published_by"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+const releaseColumns = "id, rule_type, version, content_hash, rules, change_ids, last_change_id, published_by, created_at"
+const releaseSummaryColumns = "id, rule_type, version, content_hash, '', change_ids, last_change_id, published_by, created_at"
+
+func scanRelease(row interface{ Scan(...interface{}) error }) (Release, error) {
+	var r Release
+	var rules, changeIDs string
+	var publishedBy sql.NullString
+	err := row.Scan(&r.ID, &r.RuleType, &r.Version, &r.ContentHash, &rules, &changeIDs, &r.LastChangeID, &publishedBy, &r.CreatedAt)
+	if err != nil {
+		return r, err
+	}
+
+	if rules != "" {
+		r.Rules = json.RawMessage(rules)
+	}
+	if err := json.Unmarshal([]byte(changeIDs), &r.ChangeIDs); err != nil {
+		return r, err
+	}
+	if publishedBy.Valid {
+		r.PublishedBy = &publishedBy.String
+	}
+	return r, nil
+}
+
+// getReleases lists release metadata without the rules, newest first.
+func getReleases(ctx context.Context, db queryer, ruleType string) ([]Release, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+releaseSummaryColumns+" FROM rule_set_release WHERE rule_type = ? ORDER BY version DESC", ruleType)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	releases := []Release{}
+	for rows.Next() {
+		r, err := scanRelease(rows)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+
+	return releases, rows.Err()
+}
+
+func getRelease(ctx context.Context, db queryer, ruleType string, version int) (Release, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+releaseColumns+" FROM rule_set_release WHERE rule_type = ? AND version = ?", ruleType, version)
+	return scanRelease(row)
+}
+
+func getLatestRelease(ctx context.Context, db queryer, ruleType string) (Release, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+releaseColumns+" FROM rule_set_release WHERE rule_type = ? ORDER BY version DESC LIMIT 1", ruleType)
+	return scanRelease(row)
+}
+
+// lockLatestRelease reads the latest release for update so concurrent publishes get consecutive versions.
+func lockLatestRelease(ctx context.Context, tx *sql.Tx, ruleType string) (Release, error) {
+	row := tx.QueryRowContext(ctx, "SELECT "+releaseColumns+" FROM rule_set_release WHERE rule_type = ? ORDER BY version DESC LIMIT 1 FOR UPDATE", ruleType)
+	return scanRelease(row)
+}
+
+func insertRelease(ctx context.Context, tx *sql.Tx, r *Release) error {
+	changeIDs, err := json.Marshal(r.ChangeIDs)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO rule_set_release (rule_type, version, content_hash, rules, change_ids, last_change_id, published_by) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		r.RuleType, r.Version, r.ContentHash, string(r.Rules), string(changeIDs), r.LastChangeID, r.PublishedBy)
+	if err != nil {
+		return err
+	}
+
+	created, err := getRelease(ctx, tx, r.RuleType, r.Version)
+	if err != nil {
+		return err
+	}
+	*r = created
+	return nil
+}
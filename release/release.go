@@ -0,0 +1,108 @@
+package release
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Snapshot is the current rule set and the change IDs written after a given change.
+type Snapshot struct {
+	Rules     interface{}
+	ChangeIDs []int
+}
+
+// Snapshotter reads the current rule set inside the publishing transaction so
+// the rules and change IDs are consistent with each other.
+type Snapshotter func(ctx context.Context, tx *sql.Tx, afterChangeID int) (Snapshot, error)
+
+// Hash returns the hex SHA-256 of a rule set's JSON encoding, which is also its ETag.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// publish freezes the current rule set into the next release version and
+// starts rolling it out to initialPercentage of consumers.
+func publish(ctx context.Context, db *sql.DB, ruleType string, snapshot Snapshotter, publishedBy string, initialPercentage int) (Release, Rollout, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Release{}, Rollout{}, err
+	}
+	defer tx.Rollback()
+
+	previous, err := lockLatestRelease(ctx, tx, ruleType)
+	if err != nil && err != sql.ErrNoRows {
+		return Release{}, Rollout{}, err
+	}
+
+	current, err := snapshot(ctx, tx, previous.LastChangeID)
+	if err != nil {
+		return Release{}, Rollout{}, err
+	}
+
+	rules, err := json.Marshal(current.Rules)
+	if err != nil {
+		return Release{}, Rollout{}, err
+	}
+
+	r := Release{
+		RuleType:     ruleType,
+		Version:      previous.Version + 1,
+		ContentHash:  Hash(rules),
+		Rules:        rules,
+		ChangeIDs:    current.ChangeIDs,
+		LastChangeID: previous.LastChangeID,
+	}
+	if r.ChangeIDs == nil {
+		r.ChangeIDs = []int{}
+	}
+	if len(r.ChangeIDs) > 0 {
+		r.LastChangeID = r.ChangeIDs[len(r.ChangeIDs)-1]
+	}
+	if publishedBy != "" {
+		r.PublishedBy = &publishedBy
+	}
+
+	if err := insertRelease(ctx, tx, &r); err != nil {
+		return Release{}, Rollout{}, err
+	}
+
+	rollout, err := lockRollout(ctx, tx, ruleType)
+	if err != nil {
+		return Release{}, Rollout{}, err
+	}
+	if rollout.ReleaseVersion == 0 && previous.Version > 0 {
+		// Releases published before rollouts existed were served to everyone.
+		rollout = Rollout{RuleType: ruleType, ReleaseVersion: previous.Version, Percentage: 100}
+	}
+
+	rollout = rollout.next(r.Version, initialPercentage)
+	if err := saveRollout(ctx, tx, &rollout, publishedBy); err != nil {
+		return Release{}, Rollout{}, err
+	}
+
+	return r, rollout, tx.Commit()
+}
+
+// NotModified sets the ETag for content with the given hash and reports
+// whether the request's If-None-Match already matches it, in which case a
+// 304 Not Modified has been written.
+func NotModified(w http.ResponseWriter, r *http.Request, hash string) bool {
+	etag := `"` + hash + `"`
+	w.Header().Set("ETag", etag)
+
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			w.WriteHeader(http.StatusNotModified)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package release
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var rolloutColumnNames = []string{"rule_type", "release_version", "previous_version", "percentage", "updated_by", "updated_at"}
+var releaseColumnNames = []string{"id", "rule_type", "version", "content_hash", "rules", "change_ids", "last_change_id", "published_by", "created_at"}
+
+func TestPublish(t *testing.T) {
+	log.Print("Testing publishing the next release")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	rules := `[{"id":1}]`
+	hash := Hash([]byte(rules))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT (.+) FROM rule_set_release WHERE rule_type = \\? ORDER BY version DESC LIMIT 1 FOR UPDATE").
+		WithArgs("bounce_rule").
+		WillReturnRows(sqlmock.NewRows(releaseColumnNames).AddRow(4, "bounce_rule", 2, "oldhash", "[]", "[3,4]", 4, nil, time.Now()))
+	mock.ExpectExec("INSERT INTO rule_set_release").
+		WithArgs("bounce_rule", 3, hash, rules, "[5,6]", 6, "alice").
+		WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectQuery("SELECT (.+) FROM rule_set_release WHERE rule_type = \\? AND version = \\?").
+		WithArgs("bounce_rule", 3).
+		WillReturnRows(sqlmock.NewRows(releaseColumnNames).AddRow(5, "bounce_rule", 3, hash, rules, "[5,6]", 6, "alice", time.Now()))
+	mock.ExpectQuery("SELECT (.+) FROM rule_set_rollout WHERE rule_type = \\? FOR UPDATE").
+		WithArgs("bounce_rule").
+		WillReturnRows(sqlmock.NewRows(rolloutColumnNames))
+	mock.ExpectExec("INSERT INTO rule_set_rollout").
+		WithArgs("bounce_rule", 3, 2, 10, "alice").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT (.+) FROM rule_set_rollout WHERE rule_type = \\?").
+		WithArgs("bounce_rule").
+		WillReturnRows(sqlmock.NewRows(rolloutColumnNames).AddRow("bounce_rule", 3, 2, 10, "alice", time.Now()))
+	mock.ExpectCommit()
+
+	var snapshotAfter int
+	snapshot := func(ctx context.Context, tx *sql.Tx, afterChangeID int) (Snapshot, error) {
+		snapshotAfter = afterChangeID
+		return Snapshot{Rules: []map[string]int{{"id": 1}}, ChangeIDs: []int{5, 6}}, nil
+	}
+
+	release, rollout, err := publish(context.Background(), db, "bounce_rule", snapshot, "alice", 10)
+
+	assert.NoError(t, err, "should not receive an error when publishing")
+	assert.Equal(t, 4, snapshotAfter, "should snapshot changes after the previous release")
+	assert.Equal(t, 3, release.Version)
+	assert.Equal(t, hash, release.ContentHash)
+	assert.Equal(t, []int{5, 6}, release.ChangeIDs)
+	assert.Equal(t, 3, rollout.ReleaseVersion, "should canary the new release")
+	assert.Equal(t, 2, *rollout.PreviousVersion, "should fall back to the release served before rollouts")
+	assert.Equal(t, 10, rollout.Percentage)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
+
+func TestNotModified(t *testing.T) {
+	log.Print("Testing conditional GETs with If-None-Match")
+	req := httptest.NewRequest("GET", "/bounce_rule_releases/latest", nil)
+	req.Header.Set("If-None-Match", `"other", W/"abc"`)
+	rr := httptest.NewRecorder()
+
+	assert.True(t, NotModified(rr, req, "abc"))
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Equal(t, `"abc"`, rr.Header().Get("ETag"))
+
+	rr = httptest.NewRecorder()
+	assert.False(t, NotModified(rr, req, "def"))
+	assert.Equal(t, `"def"`, rr.Header().Get("ETag"))
+}
@@ -0,0 +1,161 @@
+package release
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"time"
+)
+
+// CREATE TABLE rule_set_rollout (
+//   rule_type VARCHAR(32) NOT NULL,
+//   release_version INT NOT NULL,
+//   previous_version INT NULL,
+//   percentage TINYINT NOT NULL,
+//   updated_by VARCHAR(255) NULL,
+//   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+//   PRIMARY KEY (rule_type)
+// );
+
+// DefaultInitialPercentage is the share of consumers a newly published release reaches first.
+const DefaultInitialPercentage = 10
+
+var errNoPreviousRelease = errors.New("No previous release to fall back to")
+
+// Rollout is the canary state of a rule type: Percentage of consumers get
+// ReleaseVersion and the rest stay on PreviousVersion.
+type Rollout struct {
+	RuleType        string    `json:"rule_type"`
+	ReleaseVersion  int       `json:"release_version"`
+	PreviousVersion *int      `json:"previous_version"`
+	Percentage      int       `json:"percentage"`
+	UpdatedBy       *string   `json:"updated_by"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// Bucket places a consumer in one of 100 stable buckets for a rule type.
+func Bucket(ruleType string, consumerID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(ruleType))
+	h.Write([]byte{':'})
+	h.Write([]byte(consumerID))
+	return int(h.Sum32() % 100)
+}
+
+// AssignedVersion returns the release version a consumer in the given bucket should use.
+func (r Rollout) AssignedVersion(bucket int) int {
+	if bucket < r.Percentage || r.PreviousVersion == nil {
+		return r.ReleaseVersion
+	}
+	return *r.PreviousVersion
+}
+
+// next returns the rollout after publishing version. The fully rolled out
+// release, if any, stays as the fallback for consumers outside the canary.
+func (r Rollout) next(version int, initialPercentage int) Rollout {
+	next := Rollout{RuleType: r.RuleType, ReleaseVersion: version, Percentage: initialPercentage}
+
+	switch {
+	case r.ReleaseVersion == 0:
+		// The first release has nothing to fall back to.
+		next.Percentage = 100
+	case r.Percentage >= 100 || r.PreviousVersion == nil:
+		previous := r.ReleaseVersion
+		next.PreviousVersion = &previous
+	default:
+		next.PreviousVersion = r.PreviousVersion
+	}
+
+	return next
+}
+
+// rollback returns everyone to the previous release.
+func (r Rollout) rollback() (Rollout, bool) {
+	if r.PreviousVersion == nil {
+		return r, false
+	}
+
+	return Rollout{RuleType: r.RuleType, ReleaseVersion: *r.PreviousVersion, Percentage: 100}, true
+}
+
+const rolloutColumns = "rule_type, release_version, previous_version, percentage, updated_by, updated_at"
+
+func scanRollout(row interface{ Scan(...interface{}) error }) (Rollout, error) {
+	var r Rollout
+	var previousVersion sql.NullInt64
+	var updatedBy sql.NullString
+	err := row.Scan(&r.RuleType, &r.ReleaseVersion, &previousVersion, &r.Percentage, &updatedBy, &r.UpdatedAt)
+	if err != nil {
+		return r, err
+	}
+
+	if previousVersion.Valid {
+		version := int(previousVersion.Int64)
+		r.PreviousVersion = &version
+	}
+	if updatedBy.Valid {
+		r.UpdatedBy = &updatedBy.String
+	}
+	return r, nil
+}
+
+func getRollout(ctx context.Context, db queryer, ruleType string) (Rollout, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+rolloutColumns+" FROM rule_set_rollout WHERE rule_type = ?", ruleType)
+	return scanRollout(row)
+}
+
+func lockRollout(ctx context.Context, tx *sql.Tx, ruleType string) (Rollout, error) {
+	row := tx.QueryRowContext(ctx, "SELECT "+rolloutColumns+" FROM rule_set_rollout WHERE rule_type = ? FOR UPDATE", ruleType)
+	rollout, err := scanRollout(row)
+	if err == sql.ErrNoRows {
+		return Rollout{RuleType: ruleType}, nil
+	}
+	return rollout, err
+}
+
+func saveRollout(ctx context.Context, tx *sql.Tx, r *Rollout, updatedBy string) error {
+	var by interface{}
+	if updatedBy != "" {
+		by = updatedBy
+	}
+
+	_, err := tx.ExecContext(ctx,
+		"INSERT INTO rule_set_rollout (rule_type, release_version, previous_version, percentage, updated_by) VALUES (?, ?, ?, ?, ?) "+
+			"ON DUPLICATE KEY UPDATE release_version = VALUES(release_version), previous_version = VALUES(previous_version), percentage = VALUES(percentage), updated_by = VALUES(updated_by)",
+		r.RuleType, r.ReleaseVersion, r.PreviousVersion, r.Percentage, by)
+	if err != nil {
+		return err
+	}
+
+	saved, err := getRollout(ctx, tx, r.RuleType)
+	if err != nil {
+		return err
+	}
+	*r = saved
+	return nil
+}
+
+// updateRollout locks the rollout of a rule type, changes it and saves it.
+func updateRollout(ctx context.Context, db *sql.DB, ruleType string, updatedBy string, change func(r *Rollout) error) (Rollout, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Rollout{}, err
+	}
+	defer tx.Rollback()
+
+	rollout, err := lockRollout(ctx, tx, ruleType)
+	if err != nil {
+		return Rollout{}, err
+	}
+
+	if err := change(&rollout); err != nil {
+		return Rollout{}, err
+	}
+
+	if err := saveRollout(ctx, tx, &rollout, updatedBy); err != nil {
+		return Rollout{}, err
+	}
+
+	return rollout, tx.Commit()
+}
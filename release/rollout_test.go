@@ -0,0 +1,55 @@
+package release
+
+import (
+	"log"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket(t *testing.T) {
+	log.Print("Testing consumers are bucketed stably and evenly")
+	assert.Equal(t, Bucket("bounce_rule", "mta-1"), Bucket("bounce_rule", "mta-1"), "should bucket a consumer the same way every time")
+
+	counts := make([]int, 100)
+	for i := 0; i < 10000; i++ {
+		bucket := Bucket("bounce_rule", "mta-"+strconv.Itoa(i))
+		assert.True(t, bucket >= 0 && bucket < 100)
+		counts[bucket]++
+	}
+	for bucket, count := range counts {
+		assert.Truef(t, count > 50 && count < 150, "bucket %d has %d consumers", bucket, count)
+	}
+}
+
+func TestRolloutLifecycle(t *testing.T) {
+	log.Print("Testing publishing, raising and rolling back a rollout")
+	rollout := Rollout{RuleType: "bounce_rule"}
+
+	rollout = rollout.next(1, 10)
+	assert.Equal(t, 1, rollout.ReleaseVersion)
+	assert.Nil(t, rollout.PreviousVersion, "the first release has nothing to fall back to")
+	assert.Equal(t, 100, rollout.Percentage)
+	assert.Equal(t, 1, rollout.AssignedVersion(99))
+
+	rollout = rollout.next(2, 10)
+	assert.Equal(t, 2, rollout.ReleaseVersion)
+	assert.Equal(t, 1, *rollout.PreviousVersion)
+	assert.Equal(t, 2, rollout.AssignedVersion(9), "buckets below the percentage get the canary")
+	assert.Equal(t, 1, rollout.AssignedVersion(10), "other buckets stay on the previous release")
+
+	rollout = rollout.next(3, 10)
+	assert.Equal(t, 1, *rollout.PreviousVersion, "should keep falling back to the last fully rolled out release")
+
+	rollout.Percentage = 100
+	assert.Equal(t, 3, rollout.AssignedVersion(99))
+
+	rollout, ok := rollout.rollback()
+	assert.True(t, ok)
+	assert.Equal(t, 1, rollout.ReleaseVersion)
+	assert.Equal(t, 100, rollout.Percentage)
+
+	_, ok = rollout.rollback()
+	assert.False(t, ok, "should not roll back past the previous release")
+}
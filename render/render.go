@@ -0,0 +1,50 @@
+// Package render answers list requests in the format the client asks for
+// with ?format= or the Accept header: JSON, NDJSON, YAML or CSV.
+package render
+
+import (
+	"log"
+	"net/http"
+
+	"gobrm/bulk"
+	"gobrm/problem"
+)
+
+// Negotiate picks the format of a list response. When no supported format is
+// acceptable it answers 406 itself and reports false.
+func Negotiate(w http.ResponseWriter, r *http.Request) (bulk.Format, bool) {
+	w.Header().Add("Vary", "Accept")
+	format, err := bulk.ResponseFormat(r)
+	if err != nil {
+		problem.Respond(w, r, http.StatusNotAcceptable, err.Error())
+		return "", false
+	}
+	return format, true
+}
+
+// Stream starts a list response in the given format. The caller encodes each
+// item as it is read and closes the encoder after the last one; headers such
+// as Link must be set before.
+func Stream(w http.ResponseWriter, format bulk.Format, columns []bulk.Column) *bulk.Encoder {
+	encoder, err := bulk.NewEncoder(w, format, columns)
+	if err != nil {
+		// Negotiate only returns supported formats
+		panic(err)
+	}
+	w.Header().Set("Content-Type", bulk.ContentType(format))
+	w.WriteHeader(http.StatusOK)
+	return encoder
+}
+
+// List answers with every item of a slice in the negotiated format. YAML and
+// CSV show the given columns in order.
+func List(w http.ResponseWriter, r *http.Request, items interface{}, columns []bulk.Column) {
+	format, ok := Negotiate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := Stream(w, format, columns).EncodeAll(items); err != nil {
+		log.Printf("Failed to write %s list %s: %v", format, r.URL.Path, err)
+	}
+}
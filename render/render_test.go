@@ -0,0 +1,68 @@
+package render
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gobrm/apiversion"
+	"gobrm/bulk"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type release struct {
+	Version int    `json:"version"`
+	Hash    string `json:"content_hash"`
+}
+
+var releases = []release{{Version: 2, Hash: "b"}, {Version: 1, Hash: "a"}}
+
+func list(accept string, target string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rr := httptest.NewRecorder()
+	List(rr, req, releases, bulk.Columns(release{}))
+	return rr
+}
+
+func TestListNegotiatesFormat(t *testing.T) {
+	log.Print("Testing lists are written in the format the Accept header asks for")
+	rr := list("", "/bounce_rule_releases")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `[{"version":2,"content_hash":"b"},{"version":1,"content_hash":"a"}]`, rr.Body.String())
+	assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+
+	rr = list("application/x-ndjson", "/bounce_rule_releases")
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"version\":2,\"content_hash\":\"b\"}\n{\"version\":1,\"content_hash\":\"a\"}\n", rr.Body.String())
+
+	rr = list("application/yaml", "/bounce_rule_releases")
+	assert.Equal(t, "application/yaml", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "- version: 2\n  content_hash: b\n- version: 1\n  content_hash: a\n", rr.Body.String())
+
+	rr = list("text/csv", "/bounce_rule_releases")
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "version,content_hash\n2,b\n1,a\n", rr.Body.String())
+
+	rr = list("application/json", "/bounce_rule_releases?format=csv")
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"), "should prefer the query parameter")
+}
+
+func TestListRejectsUnsupportedFormat(t *testing.T) {
+	log.Print("Testing lists answer 406 when no supported format is acceptable")
+	rr := list("application/xml", "/bounce_rule_releases")
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"), "v1 should keep the legacy error body")
+
+	req := httptest.NewRequest("GET", "/v2/bounce_rule_releases", nil)
+	req.Header.Set("Accept", "application/xml")
+	rr = httptest.NewRecorder()
+	List(rr, req.WithContext(apiversion.NewContext(req.Context(), apiversion.V2)), releases, bulk.Columns(release{}))
+	assert.Equal(t, http.StatusNotAcceptable, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+}
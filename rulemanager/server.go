@@ -0,0 +1,300 @@
+package rulemanager
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gobrm/apiversion"
+	"gobrm/auth"
+	"gobrm/batch"
+	"gobrm/bouncerule"
+	"gobrm/events"
+	"gobrm/grpcserver"
+	"gobrm/idempotency"
+	"gobrm/models"
+	"gobrm/openapi"
+	"gobrm/problem"
+	"gobrm/ratelimit"
+	"gobrm/shutdown"
+	"gobrm/throughputrule"
+	"gobrm/tlsserver"
+	"gobrm/webhook"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+)
+
+// Config selects the subsystems the rule manager serves and how they behave.
+type Config struct {
+	BounceRulesEnabled     bool
+	ThroughputRulesEnabled bool
+	// RequireChangeRequests disables direct rule writes so every change goes through review.
+	RequireChangeRequests bool
+	// InitialRolloutPercentage is the share of consumers a newly published release reaches.
+	InitialRolloutPercentage int
+	// GRPCAddr is where Run serves the gRPC API, e.g. ":9090"; empty disables it.
+	GRPCAddr string
+	// IdempotencyWindow is how long Idempotency-Key requests are remembered;
+	// it defaults to 24 hours.
+	IdempotencyWindow time.Duration
+	// V1DeprecatedAt is when v1 was deprecated, announced in the Deprecation
+	// header of v1 responses; zero does not deprecate v1.
+	V1DeprecatedAt time.Time
+	// V1Sunset is when v1 stops being served, announced in the Sunset header
+	// of v1 responses; zero is six months after V1DeprecatedAt.
+	V1Sunset time.Time
+	// Auth enables authentication with API keys and JWTs.
+	Auth auth.Config
+	// RateLimit sets the read, write and route budgets of every client.
+	RateLimit ratelimit.Config
+	// TLS sets the certificates HTTP and gRPC are served with; plaintext
+	// must be allowed explicitly.
+	TLS tlsserver.Config
+	// Timeouts bound each request.
+	Timeouts tlsserver.Timeouts
+	// ShutdownTimeout bounds the graceful shutdown; it defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+}
+
+// Server hosts the bounce and throughput rule managers on one router and one
+// connection pool. Webhooks and metrics are shared; each subsystem keeps its
+// own routes, change history and transition scheduler. Batches span both.
+type Server struct {
+	Router          *chi.Mux
+	DB              *sql.DB
+	Dispatcher      *webhook.Dispatcher
+	BounceRules     *bouncerule.App
+	ThroughputRules *throughputrule.App
+	// GRPC serves the gRPC services of the enabled subsystems.
+	GRPC *grpcserver.Server
+	// Idempotency replays retried writes of every subsystem.
+	Idempotency *idempotency.Store
+	// Auth authenticates HTTP and gRPC requests; nil when authentication is disabled.
+	Auth *auth.Authenticator
+	// RateLimiter enforces the client budgets of HTTP requests.
+	RateLimiter *ratelimit.Limiter
+	// TLS holds the certificates HTTP and gRPC are served with; nil when
+	// serving plaintext.
+	TLS *tlsserver.Credentials
+	// Shutdown stops the servers, workers and connection pool on SIGTERM or
+	// SIGINT, and ends the change streams of the subsystems.
+	Shutdown  *shutdown.Manager
+	tlsConfig tlsserver.Config
+	timeouts  tlsserver.Timeouts
+	grpcAddr  string
+	webhooks  *webhook.API
+	apiKeys   *auth.API
+	batches   *batch.API
+}
+
+var (
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "rulemanager_http_duration_seconds",
+		Help: "Duration of HTTP requests.",
+	}, []string{"method", "path", "code"})
+)
+
+// prometheusMiddleware times requests by their route pattern and status code.
+func prometheusMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		path := chi.RouteContext(r.Context()).RoutePattern()
+		if path == "" {
+			path = "unmatched"
+		}
+		httpDuration.WithLabelValues(r.Method, path, strconv.Itoa(ww.Status())).Observe(time.Since(start).Seconds())
+	})
+}
+
+// Initialize wires up the enabled subsystems on an existing connection pool.
+func (s *Server) Initialize(db *sql.DB, config Config) {
+	s.DB = db
+	s.Dispatcher = &webhook.Dispatcher{DB: db}
+	s.webhooks = &webhook.API{DB: db, URLParam: chi.URLParam}
+	s.batches = &batch.API{DB: db, Appliers: map[string]batch.Applier{}, RequireChangeRequests: config.RequireChangeRequests}
+	s.Idempotency = &idempotency.Store{DB: db, Window: config.IdempotencyWindow}
+	s.apiKeys = &auth.API{DB: db, URLParam: chi.URLParam}
+	var grpcOptions []grpc.ServerOption
+	if config.Auth.Enabled {
+		authenticator, err := auth.New(db, config.Auth)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// /events streams only the enabled subsystem when the other is off
+		switch {
+		case config.BounceRulesEnabled && !config.ThroughputRulesEnabled:
+			authenticator.Events = auth.Bounce
+		case config.ThroughputRulesEnabled && !config.BounceRulesEnabled:
+			authenticator.Events = auth.Throughput
+		}
+		s.Auth = authenticator
+		grpcOptions = authenticator.ServerOptions()
+	}
+	// The gRPC server takes its credentials now; Run refuses plaintext
+	// unless it is allowed
+	s.tlsConfig = config.TLS
+	if config.TLS.CertFile != "" {
+		credentials, err := tlsserver.New(config.TLS)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.TLS = credentials
+		grpcOptions = append(grpcOptions, credentials.GRPCOption())
+	}
+	s.GRPC = grpcserver.New(grpcOptions...)
+	s.RateLimiter = ratelimit.New(config.RateLimit)
+	s.grpcAddr = config.GRPCAddr
+	s.timeouts = config.Timeouts
+	s.Shutdown = shutdown.New(config.ShutdownTimeout)
+
+	s.Router = chi.NewRouter()
+	s.Router.Use(problem.RequestID)
+	s.Router.Use(apiversion.DefaultPolicy(config.V1DeprecatedAt, config.V1Sunset).Middleware)
+	s.Router.Use(middleware.Logger)
+	s.Router.Use(middleware.Recoverer)
+	s.Router.Use(prometheusMiddleware)
+	s.Router.Use(s.RateLimiter.Unauthenticated)
+	if s.Auth != nil {
+		s.Router.Use(s.Auth.Middleware)
+	}
+	s.Router.Use(s.RateLimiter.Middleware)
+	s.Router.NotFound(problem.NotFound)
+	s.Router.MethodNotAllowed(problem.MethodNotAllowed)
+
+	doc, err := openapi.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	prefixes := []string{"/webhooks", "/api_keys", "/metrics", "/openapi.json", "/batch", "/events"}
+	if config.BounceRulesEnabled {
+		prefixes = append(prefixes, "/bounce_rule")
+	}
+	if config.ThroughputRulesEnabled {
+		prefixes = append(prefixes, "/throughput_rule")
+	}
+	doc = openapi.Subset(doc, prefixes...)
+	validator, err := openapi.NewValidator(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.Router.Use(validator.Middleware)
+	s.Router.Use(s.Idempotency.Middleware)
+
+	s.Router.Handle("/metrics", promhttp.Handler())
+	s.Router.Get("/openapi.json", openapi.Handler(doc))
+	s.Router.Post("/batch", s.batches.Execute)
+	s.Router.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", s.webhooks.GetSubscriptions)
+		r.Post("/", idempotency.Redacted(s.webhooks.CreateSubscription))
+		r.Get("/{id:[0-9]+}", s.webhooks.GetSubscription)
+		r.Put("/{id:[0-9]+}", idempotency.Redacted(s.webhooks.UpdateSubscription))
+		r.Delete("/{id:[0-9]+}", s.webhooks.DeleteSubscription)
+		r.Get("/{id:[0-9]+}/deliveries", s.webhooks.GetDeliveries)
+		r.Post("/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}/redeliver", s.webhooks.Redeliver)
+	})
+	s.Router.Route("/api_keys", func(r chi.Router) {
+		r.Get("/", s.apiKeys.GetKeys)
+		r.Post("/", idempotency.Redacted(s.apiKeys.CreateKey))
+		r.Get("/{id:[0-9]+}", s.apiKeys.GetKey)
+		r.Delete("/{id:[0-9]+}", s.apiKeys.RevokeKey)
+		r.Get("/{id:[0-9]+}/usage", s.apiKeys.GetKeyUsage)
+	})
+
+	if config.BounceRulesEnabled {
+		s.BounceRules = &bouncerule.App{
+			RequireChangeRequests:    config.RequireChangeRequests,
+			InitialRolloutPercentage: config.InitialRolloutPercentage,
+			Draining:                 s.Shutdown.Draining(),
+		}
+		s.BounceRules.InitializeWithDB(db)
+		s.BounceRules.Mount(s.Router)
+		s.BounceRules.RegisterGRPC(s.GRPC)
+		s.batches.Appliers[models.TableNames.BounceRule] = s.BounceRules.BatchApplier()
+	}
+
+	if config.ThroughputRulesEnabled {
+		s.ThroughputRules = &throughputrule.App{
+			RequireChangeRequests:    config.RequireChangeRequests,
+			InitialRolloutPercentage: config.InitialRolloutPercentage,
+			Draining:                 s.Shutdown.Draining(),
+		}
+		s.ThroughputRules.InitializeWithDB(db)
+		s.ThroughputRules.Mount(s.Router)
+		s.ThroughputRules.RegisterGRPC(s.GRPC)
+		s.batches.Appliers[models.TableNames.ThroughputRule] = s.ThroughputRules.BatchApplier()
+	}
+
+	s.Router.Get("/events", s.streamChanges)
+}
+
+// streamChanges streams the changes of the enabled subsystems. With one
+// subsystem it serves the same stream as the standalone server; with both it
+// merges them, so event types name the rule type and event IDs carry both
+// cursors.
+func (s *Server) streamChanges(w http.ResponseWriter, r *http.Request) {
+	merged := &events.Merged{}
+	if s.BounceRules != nil {
+		merged.Names = append(merged.Names, models.TableNames.BounceRule)
+		merged.Streams = append(merged.Streams, s.BounceRules.ChangeStream())
+	}
+	if s.ThroughputRules != nil {
+		merged.Names = append(merged.Names, models.TableNames.ThroughputRule)
+		merged.Streams = append(merged.Streams, s.ThroughputRules.ChangeStream())
+	}
+
+	switch len(merged.Streams) {
+	case 0:
+		problem.NotFound(w, r)
+	case 1:
+		merged.Streams[0].ServeHTTP(w, r)
+	default:
+		merged.ServeHTTP(w, r)
+	}
+}
+
+// Run starts the webhook dispatcher, the schedulers of the enabled subsystems,
+// the idempotency key purge, the rate limit pruning, the TLS file watcher,
+// the server and, with a GRPCAddr, the gRPC API. On SIGTERM or SIGINT it
+// stops accepting requests, finishes those in flight, ends the change
+// streams, stops the workers and closes the connection pool before returning.
+func (s *Server) Run(addr string) error {
+	log.Printf("Starting up rule manager with addr %s", addr)
+	if err := s.tlsConfig.Validate(); err != nil {
+		return err
+	}
+	m := s.Shutdown
+	m.Go(s.Dispatcher.Run)
+	m.Go(s.Idempotency.Run)
+	m.Go(s.RateLimiter.Run)
+	if s.TLS != nil {
+		m.Go(func(ctx context.Context) {
+			if err := s.TLS.Watch(ctx); err != nil {
+				log.Printf("Failed to watch TLS files, they will not be reloaded: %v", err)
+			}
+		})
+	}
+	if s.BounceRules != nil {
+		m.Go(s.BounceRules.Scheduler.Run)
+	}
+	if s.ThroughputRules != nil {
+		m.Go(s.ThroughputRules.Scheduler.Run)
+	}
+	if s.grpcAddr != "" {
+		m.Serve("gRPC", func() error { return s.GRPC.ListenAndServe(s.grpcAddr) }, s.GRPC.Shutdown)
+	}
+	server := tlsserver.NewServer(addr, s.Router, s.TLS, s.timeouts)
+	m.Serve("HTTP", func() error { return tlsserver.Serve(server) }, server.Shutdown)
+	m.Close(s.DB)
+	return m.Run(shutdown.Signals...)
+}
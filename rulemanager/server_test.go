@@ -0,0 +1,341 @@
+package rulemanager
+
+import (
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"gobrm/auth"
+	"gobrm/openapi"
+	"gobrm/ratelimit"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+)
+
+func serve(s *Server, method string, path string) int {
+	req := httptest.NewRequest(method, path, nil)
+	rr := httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func serveRequest(s *Server, req *http.Request) int {
+	rr := httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func TestServerMountsEnabledSubsystems(t *testing.T) {
+	log.Print("Testing the rule manager serves both subsystems on one router")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true})
+
+	// Both lookups reject a missing parameter before touching the database
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "GET", "/bounce_rules/classify"))
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "GET", "/throughput_rules/effective"))
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "GET", "/bounce_rule_transitions?within=soon"))
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "GET", "/throughput_rule_transitions?within=soon"))
+	assert.Equal(t, http.StatusOK, serve(&s, "GET", "/metrics"))
+	assert.Same(t, db, s.BounceRules.DB, "subsystems should share the connection pool")
+	assert.Same(t, db, s.ThroughputRules.DB, "subsystems should share the connection pool")
+}
+
+func TestServerDisablesSubsystems(t *testing.T) {
+	log.Print("Testing a disabled subsystem is not served")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{ThroughputRulesEnabled: true})
+
+	assert.Nil(t, s.BounceRules)
+	assert.Equal(t, http.StatusNotFound, serve(&s, "GET", "/bounce_rules/classify"))
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "GET", "/throughput_rules/effective"))
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	log.Print("Testing every rule manager route is in the OpenAPI document it serves")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true})
+
+	// Bounce rule routes are served by their own router behind catch-all routes
+	routes := openapi.ChiRoutes(s.Router)
+	bounceRoutes, err := openapi.GorillaRoutes(s.BounceRules.Router)
+	assert.NoError(t, err)
+	routes = append(routes, bounceRoutes...)
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	rr := httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	doc, err := openapi3.NewLoader().LoadFromData(rr.Body.Bytes())
+	assert.NoError(t, err, "should serve a readable document")
+	assert.Empty(t, openapi.Missing(doc, routes), "add these routes to openapi/openapi.yaml")
+	assert.NotNil(t, doc.Paths.Find("/events"), "should document the merged change stream")
+}
+
+func TestServerValidatesRequests(t *testing.T) {
+	log.Print("Testing the rule manager rejects requests that do not match the OpenAPI document")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true})
+
+	req := httptest.NewRequest("POST", "/bounce_rules", strings.NewReader(`{"response_code":"450"}`))
+	rr := httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), "response_code")
+
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "GET", "/throughput_rule_changes?sort=mx_domain"))
+}
+
+func TestServerRegistersGRPCServices(t *testing.T) {
+	log.Print("Testing the rule manager serves the gRPC services of enabled subsystems")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{ThroughputRulesEnabled: true})
+
+	services := s.GRPC.GetServiceInfo()
+	assert.Contains(t, services, "gobrm.rules.v1.ThroughputRules")
+	assert.NotContains(t, services, "gobrm.rules.v1.BounceRules")
+	assert.Contains(t, services, "grpc.health.v1.Health")
+}
+
+func TestServerServesVersionedRoutes(t *testing.T) {
+	log.Print("Testing the rule manager serves every route under /v1 and /v2 and deprecates v1")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true, V1DeprecatedAt: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)})
+
+	req := httptest.NewRequest("GET", "/v2/bounce_rules/classify", nil)
+	rr := httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"instance":"/v2/bounce_rules/classify"`, "problems should name the requested path")
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+
+	for _, path := range []string{"/v1/throughput_rules/effective", "/throughput_rules/effective"} {
+		req = httptest.NewRequest("GET", path, nil)
+		rr = httptest.NewRecorder()
+		s.Router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, path)
+		assert.Contains(t, rr.Body.String(), `"error":`, "v1 should keep the legacy error body")
+		assert.NotEmpty(t, rr.Header().Get("Deprecation"), path)
+		assert.NotEmpty(t, rr.Header().Get("Sunset"), path)
+	}
+
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "GET", "/v2/throughput_rule_transitions?within=soon"))
+	assert.Equal(t, http.StatusNotFound, serve(&s, "GET", "/v3/bounce_rules/classify"))
+
+	s = Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true})
+	rr = httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, httptest.NewRequest("GET", "/v1/throughput_rules/effective", nil))
+	assert.Empty(t, rr.Header().Get("Deprecation"), "should not deprecate v1 unless configured to")
+}
+
+func TestServerRequiresAuthentication(t *testing.T) {
+	log.Print("Testing the rule manager rejects anonymous requests when authentication is enabled")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true, Auth: auth.Config{Enabled: true}})
+
+	assert.Equal(t, http.StatusUnauthorized, serve(&s, "DELETE", "/bounce_rules/1"))
+	assert.Equal(t, http.StatusUnauthorized, serve(&s, "GET", "/v2/throughput_rules"))
+	assert.Equal(t, http.StatusUnauthorized, serve(&s, "GET", "/api_keys"))
+	assert.Equal(t, http.StatusOK, serve(&s, "GET", "/metrics"))
+	assert.Equal(t, http.StatusOK, serve(&s, "GET", "/openapi.json"))
+}
+
+func TestServerLimitsRequestRates(t *testing.T) {
+	log.Print("Testing the rule manager rejects requests over the client's budget")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true, RateLimit: ratelimit.Config{
+		Write: ratelimit.Limit{Requests: 1, Per: time.Minute},
+	}})
+
+	assert.Equal(t, http.StatusBadRequest, serve(&s, "POST", "/bounce_rules"))
+	assert.Equal(t, http.StatusTooManyRequests, serve(&s, "POST", "/v2/throughput_rules"), "versions should share the budget")
+	assert.Equal(t, http.StatusOK, serve(&s, "GET", "/metrics"))
+}
+
+func TestServerLimitsFailedAuthentications(t *testing.T) {
+	log.Print("Testing the rule manager rejects addresses that keep failing to authenticate")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true, Auth: auth.Config{Enabled: true}, RateLimit: ratelimit.Config{
+		Unauthenticated: ratelimit.Limit{Requests: 3, Per: time.Minute},
+	}})
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, serve(&s, "GET", "/bounce_rules"))
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve(&s, "GET", "/v2/throughput_rules"), "should stop guessing once the budget is spent")
+	assert.Equal(t, http.StatusOK, serve(&s, "GET", "/metrics"))
+}
+
+func TestIdempotencyDoesNotStoreSecrets(t *testing.T) {
+	log.Print("Testing responses carrying API keys or webhook secrets are never stored for replay")
+	keyColumns := []string{"id", "name", "key_prefix", "grants", "created_by", "created_at", "expires_at", "revoked_at", "last_used_at"}
+	webhookColumns := []string{"id", "url", "secret", "event_types", "active", "created_at", "updated_at"}
+	now := time.Now()
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		expect func(mock sqlmock.Sqlmock)
+		secret string
+	}{
+		{"POST", "/api_keys", `{"name":"deploys","grants":["admin"]}`, func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("INSERT INTO api_key").WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery("SELECT (.+) FROM api_key WHERE id = \\?").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(1, "deploys", "gobrm_ab", "admin", "", now, nil, nil, nil))
+		}, "gobrm_"},
+		{"POST", "/webhooks", `{"url":"https://example.com/hook","secret":"whsec-create"}`, func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("INSERT INTO webhook_subscription").WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery("SELECT (.+) FROM webhook_subscription WHERE id = \\?").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows(webhookColumns).AddRow(1, "https://example.com/hook", "whsec-create", "", true, now, now))
+		}, "whsec-create"},
+		{"PUT", "/webhooks/1", `{"url":"https://example.com/hook","secret":"whsec-rotate"}`, func(mock sqlmock.Sqlmock) {
+			rows := func() *sqlmock.Rows {
+				return sqlmock.NewRows(webhookColumns).AddRow(1, "https://example.com/hook", "whsec-rotate", "", true, now, now)
+			}
+			mock.ExpectQuery("SELECT (.+) FROM webhook_subscription WHERE id = \\?").WithArgs(1).WillReturnRows(rows())
+			mock.ExpectExec("UPDATE webhook_subscription SET").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery("SELECT (.+) FROM webhook_subscription WHERE id = \\?").WithArgs(1).WillReturnRows(rows())
+		}, "whsec-rotate"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.method+" "+test.path, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+			defer db.Close()
+
+			mock.ExpectExec("DELETE FROM idempotency_key").WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec("INSERT IGNORE INTO idempotency_key").WillReturnResult(sqlmock.NewResult(0, 1))
+			test.expect(mock)
+			// Storing the body would be a different UPDATE and fail the expectations.
+			mock.ExpectExec("UPDATE idempotency_key SET status = \\?, redacted = TRUE WHERE idempotency_key = \\?").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+
+			s := Server{}
+			s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true})
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", "retry-1")
+			rr := httptest.NewRecorder()
+			s.Router.ServeHTTP(rr, req)
+
+			assert.Less(t, rr.Code, 300)
+			assert.Contains(t, rr.Body.String(), test.secret, "should answer the first request in full")
+			mockErr := mock.ExpectationsWereMet()
+			assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+		})
+	}
+}
+
+func TestShutdownEndsEventStreams(t *testing.T) {
+	log.Print("Testing shutting down ends the change streams of every subsystem")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	s := Server{}
+	s.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true, ShutdownTimeout: time.Second})
+
+	streams := map[string]string{"/bounce_rule_events": "5", "/throughput_rule_events": "5", "/events": "5.5"}
+	ended := make(chan *httptest.ResponseRecorder, len(streams))
+	for path, lastEventID := range streams {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Last-Event-ID", lastEventID)
+		go func() {
+			rr := httptest.NewRecorder()
+			s.Router.ServeHTTP(rr, req)
+			ended <- rr
+		}()
+	}
+
+	assert.NoError(t, s.Shutdown.Shutdown())
+	for range streams {
+		select {
+		case rr := <-ended:
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "text/event-stream", rr.Header().Get("Content-Type"))
+		case <-time.After(time.Second):
+			t.Fatal("should end the streams when shutting down")
+		}
+	}
+}
+
+func TestServerEventsFollowEnabledSubsystems(t *testing.T) {
+	log.Print("Testing /events merges the streams of both subsystems and serves a single one as is")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	both := Server{}
+	both.Initialize(db, Config{BounceRulesEnabled: true, ThroughputRulesEnabled: true})
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	rr := httptest.NewRecorder()
+	both.Router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "should need a cursor for each subsystem")
+	assert.Contains(t, rr.Body.String(), "Last-Event-ID")
+
+	one := Server{}
+	one.Initialize(db, Config{ThroughputRulesEnabled: true, ShutdownTimeout: time.Second})
+	req = httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "5.5")
+	assert.Equal(t, http.StatusBadRequest, serveRequest(&one, req), "should take the standalone cursor")
+
+	ended := make(chan *httptest.ResponseRecorder, 1)
+	req = httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Last-Event-ID", "5")
+	go func() {
+		rr := httptest.NewRecorder()
+		one.Router.ServeHTTP(rr, req)
+		ended <- rr
+	}()
+	assert.NoError(t, one.Shutdown.Shutdown())
+	select {
+	case rr := <-ended:
+		assert.Equal(t, http.StatusOK, rr.Code)
+	case <-time.After(time.Second):
+		t.Fatal("should end the stream when shutting down")
+	}
+}
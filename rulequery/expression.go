@@ -0,0 +1,228 @@
+package rulequery
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// A filter expression compares fields to values and combines the comparisons
+// with AND, OR, NOT and parentheses, e.g.
+//
+//	response_code>=500 AND (bounce_action=suppress OR description~"mailbox full")
+//
+// Numbers support = != < <= > >=, text supports = != and ^= (prefix),
+// $= (suffix) and ~ (substring). Values with spaces, parentheses or
+// operator characters are quoted with " or '.
+//
+//	expression = term { "OR" term }
+//	term       = factor { "AND" factor }
+//	factor     = "NOT" factor | "(" expression ")" | field operator value
+
+// maxExpressionLength keeps a single query from growing into an expensive statement.
+const maxExpressionLength = 1024
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+	tokenEnd
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	start int
+}
+
+const operatorChars = "=!<>~^$"
+
+var operators = []string{"<=", ">=", "!=", "^=", "$=", "=", "<", ">", "~"}
+
+// tokenize splits an expression into words, quoted strings, operators and parentheses.
+func tokenize(expression string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenOpen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenClose, ")", i})
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("has an unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, token{tokenString, string(runes[i+1 : end]), i})
+			i = end + 1
+		case strings.ContainsRune(operatorChars, r):
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("has an unknown operator at position %d", i+1)
+			}
+			tokens = append(tokens, token{tokenOperator, op, i})
+			i += len([]rune(op))
+		default:
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune(operatorChars+`()"'`, runes[end]) {
+				end++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[i:end]), i})
+			i = end
+		}
+	}
+	return append(tokens, token{tokenEnd, "", len(runes)}), nil
+}
+
+// parser builds parameterized SQL from the tokens of an expression.
+type parser struct {
+	tokens []token
+	pos    int
+	fields Fields
+	args   []interface{}
+}
+
+// parseExpression translates a filter expression to a SQL condition and its arguments.
+func parseExpression(expression string, fields Fields) (string, []interface{}, error) {
+	if len(expression) > maxExpressionLength {
+		return "", nil, fmt.Errorf("must be at most %d characters", maxExpressionLength)
+	}
+
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return "", nil, err
+	}
+
+	p := &parser{tokens: tokens, fields: fields}
+	condition, err := p.expression()
+	if err != nil {
+		return "", nil, err
+	}
+	if next := p.peek(); next.kind != tokenEnd {
+		return "", nil, fmt.Errorf("has unexpected %q at position %d", next.text, next.start+1)
+	}
+	return condition, p.args, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEnd {
+		p.pos++
+	}
+	return t
+}
+
+// keyword consumes the next token if it is the given keyword, in any case.
+func (p *parser) keyword(word string) bool {
+	if t := p.peek(); t.kind == tokenWord && strings.EqualFold(t.text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expression() (string, error) {
+	return p.join("OR", p.term)
+}
+
+func (p *parser) term() (string, error) {
+	return p.join("AND", p.factor)
+}
+
+// join parses operands separated by a keyword and joins them in parentheses.
+func (p *parser) join(keyword string, operand func() (string, error)) (string, error) {
+	first, err := operand()
+	if err != nil {
+		return "", err
+	}
+
+	conditions := []string{first}
+	for p.keyword(keyword) {
+		condition, err := operand()
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, condition)
+	}
+
+	if len(conditions) == 1 {
+		return first, nil
+	}
+	return "(" + strings.Join(conditions, " "+keyword+" ") + ")", nil
+}
+
+func (p *parser) factor() (string, error) {
+	if p.keyword("NOT") {
+		condition, err := p.factor()
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + condition, nil
+	}
+
+	if p.peek().kind == tokenOpen {
+		p.next()
+		condition, err := p.expression()
+		if err != nil {
+			return "", err
+		}
+		if t := p.next(); t.kind != tokenClose {
+			return "", fmt.Errorf("is missing a closing parenthesis at position %d", t.start+1)
+		}
+		// join already wrapped anything that needs parentheses
+		return condition, nil
+	}
+
+	return p.comparison()
+}
+
+func (p *parser) comparison() (string, error) {
+	field := p.next()
+	if field.kind != tokenWord {
+		return "", fmt.Errorf("expects a field name at position %d", field.start+1)
+	}
+	kind, ok := p.fields[field.text]
+	if !ok {
+		return "", fmt.Errorf("has an unknown field %q at position %d", field.text, field.start+1)
+	}
+
+	op := p.next()
+	if op.kind != tokenOperator {
+		return "", fmt.Errorf("expects an operator after %s at position %d", field.text, op.start+1)
+	}
+
+	value := p.next()
+	if value.kind != tokenWord && value.kind != tokenString {
+		return "", fmt.Errorf("expects a value after %s%s at position %d", field.text, op.text, value.start+1)
+	}
+
+	condition, arg, err := compare(field.text, kind, op.text, value.text)
+	if err != nil {
+		return "", fmt.Errorf("has an invalid comparison at position %d: %s %s", field.start+1, field.text, err)
+	}
+	p.args = append(p.args, arg)
+	return condition, nil
+}
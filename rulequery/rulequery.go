@@ -0,0 +1,199 @@
+package rulequery
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gobrm/apiversion"
+	"gobrm/problem"
+)
+
+const MaxLimit = 1000
+
+// Kind decides how a field may be searched. Numbers can be compared and
+// ranged, text can be matched by prefix, suffix or substring.
+type Kind int
+
+const (
+	Number Kind = iota
+	Text
+)
+
+// Fields are the searchable columns of a rule table and their kinds. Only
+// these names ever reach the SQL, every value is passed as an argument.
+type Fields map[string]Kind
+
+// Params are the filter, sort and pagination options of a rule listing.
+type Params struct {
+	// Limit is the page size; zero lists every matching rule.
+	Limit      int
+	Offset     int
+	Sort       []string
+	conditions []string
+	args       []interface{}
+}
+
+// Parse reads the query parameters of a rule listing. Every field can be
+// matched exactly, e.g. ?bounce_action=suppress. Number fields also take
+// _min and _max bounds, text fields _prefix, _suffix and _contains, and ?q=
+// takes a filter expression like response_code>=500 AND bounce_action=suppress.
+// ?sort= lists fields to order by, each with an optional - for descending.
+func Parse(values url.Values, fields Fields) (Params, error) {
+	p := Params{}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return p, problem.FieldError{Field: "limit", Message: fmt.Sprintf("must be between 1 and %d", MaxLimit)}
+		}
+		p.Limit = n
+	}
+
+	if offset := values.Get("offset"); offset != "" {
+		n, err := strconv.Atoi(offset)
+		if err != nil || n < 0 {
+			return p, problem.FieldError{Field: "offset", Message: "must be a non-negative integer"}
+		}
+		// Without a limit every rule is listed, so there is no page to skip to
+		if n > 0 && p.Limit == 0 {
+			return p, problem.FieldError{Field: "offset", Message: "needs a limit"}
+		}
+		p.Offset = n
+	}
+
+	if order := values.Get("sort"); order != "" {
+		for _, key := range strings.Split(order, ",") {
+			key = strings.TrimSpace(key)
+			direction := "ASC"
+			if strings.HasPrefix(key, "-") {
+				direction = "DESC"
+				key = key[1:]
+			}
+			if _, ok := fields[key]; !ok && key != "id" {
+				return p, problem.FieldError{Field: "sort", Message: fmt.Sprintf("cannot sort by %q", key)}
+			}
+			p.Sort = append(p.Sort, key+" "+direction)
+		}
+	}
+
+	for _, name := range sortedNames(fields) {
+		if err := p.parseField(values, name, fields[name]); err != nil {
+			return p, err
+		}
+	}
+
+	if q := values.Get("q"); q != "" {
+		condition, args, err := parseExpression(q, fields)
+		if err != nil {
+			return p, problem.FieldError{Field: "q", Message: err.Error()}
+		}
+		p.conditions = append(p.conditions, condition)
+		p.args = append(p.args, args...)
+	}
+
+	return p, nil
+}
+
+// parseField adds the conditions given for one field by its query parameters.
+func (p *Params) parseField(values url.Values, name string, kind Kind) error {
+	type filter struct {
+		param, op string
+	}
+	filters := []filter{{name, "="}}
+	if kind == Number {
+		filters = append(filters, filter{name + "_min", ">="}, filter{name + "_max", "<="})
+	} else {
+		filters = append(filters, filter{name + "_prefix", "^="}, filter{name + "_suffix", "$="}, filter{name + "_contains", "~"})
+	}
+
+	for _, f := range filters {
+		value, ok := values[f.param]
+		if !ok || len(value) == 0 {
+			continue
+		}
+		condition, arg, err := compare(name, kind, f.op, value[0])
+		if err != nil {
+			return problem.FieldError{Field: f.param, Message: err.Error()}
+		}
+		p.conditions = append(p.conditions, condition)
+		p.args = append(p.args, arg)
+	}
+	return nil
+}
+
+// Where returns the parameterized SQL condition and its arguments, or an
+// empty condition when nothing is filtered.
+func (p Params) Where() (string, []interface{}) {
+	return strings.Join(p.conditions, " AND "), p.args
+}
+
+// OrderBy returns the ORDER BY expression, falling back to the given default
+// and using the ID to break ties.
+func (p Params) OrderBy(defaultOrder string) string {
+	if len(p.Sort) == 0 {
+		return defaultOrder
+	}
+	return strings.Join(append(p.Sort, "id"), ", ")
+}
+
+// SetPageHeaders reports the number of matching rules in X-Total-Count and,
+// when there are more, links to the next page keeping every other parameter.
+func SetPageHeaders(w http.ResponseWriter, r *http.Request, p Params, total int64) {
+	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	if p.Limit == 0 || int64(p.Offset+p.Limit) >= total {
+		return
+	}
+
+	values := r.URL.Query()
+	values.Set("offset", strconv.Itoa(p.Offset+p.Limit))
+
+	nextURL := url.URL{Path: apiversion.Path(r), RawQuery: values.Encode()}
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", nextURL.String()))
+}
+
+// compare translates one comparison to SQL. Text matches use LIKE with the
+// wildcards in the value escaped.
+func compare(name string, kind Kind, op string, value string) (string, interface{}, error) {
+	if kind == Number {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("must be an integer")
+		}
+		switch op {
+		case "=", "!=", "<", "<=", ">", ">=":
+			return fmt.Sprintf("%s %s ?", name, op), n, nil
+		}
+		return "", nil, fmt.Errorf("cannot use %s on a number field", op)
+	}
+
+	switch op {
+	case "=", "!=":
+		return fmt.Sprintf("%s %s ?", name, op), value, nil
+	case "^=":
+		return name + " LIKE ?", escapeLike(value) + "%", nil
+	case "$=":
+		return name + " LIKE ?", "%" + escapeLike(value), nil
+	case "~":
+		return name + " LIKE ?", "%" + escapeLike(value) + "%", nil
+	}
+	return "", nil, fmt.Errorf("cannot use %s on a text field", op)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
+
+func sortedNames(fields Fields) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,110 @@
+package rulequery
+
+import (
+	"log"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"gobrm/problem"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testFields = Fields{
+	"response_code": Number,
+	"enhanced_code": Text,
+	"priority":      Number,
+	"description":   Text,
+	"bounce_action": Text,
+}
+
+func TestParseDefaults(t *testing.T) {
+	log.Print("Testing rule query defaults")
+	params, err := Parse(url.Values{}, testFields)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, params.Limit, "should list every rule without a limit")
+	assert.Equal(t, "priority, id", params.OrderBy("priority, id"))
+
+	condition, args := params.Where()
+	assert.Empty(t, condition)
+	assert.Empty(t, args)
+}
+
+func TestParseFilters(t *testing.T) {
+	log.Print("Testing rule query filters")
+	values := url.Values{
+		"bounce_action":        {"suppress"},
+		"enhanced_code_prefix": {"5.1"},
+		"priority_min":         {"2"},
+		"priority_max":         {"8"},
+		"description_contains": {"100%_full"},
+		"active":               {"true"},
+		"sort":                 {"priority,-response_code"},
+		"limit":                {"20"},
+		"offset":               {"40"},
+	}
+	params, err := Parse(values, testFields)
+	assert.NoError(t, err)
+
+	condition, args := params.Where()
+	assert.Equal(t, "bounce_action = ? AND description LIKE ? AND enhanced_code LIKE ? AND priority >= ? AND priority <= ?", condition)
+	assert.Equal(t, []interface{}{"suppress", `%100\%\_full%`, "5.1%", int64(2), int64(8)}, args)
+	assert.Equal(t, "priority ASC, response_code DESC, id", params.OrderBy("id"))
+	assert.Equal(t, 20, params.Limit)
+	assert.Equal(t, 40, params.Offset)
+}
+
+func TestParseExpression(t *testing.T) {
+	log.Print("Testing filter expressions become parameterized SQL")
+	params, err := Parse(url.Values{"q": {`response_code>=500 and (bounce_action=suppress OR description~"mailbox full") AND NOT enhanced_code^='5.7'`}}, testFields)
+	assert.NoError(t, err)
+
+	condition, args := params.Where()
+	assert.Equal(t, "(response_code >= ? AND (bounce_action = ? OR description LIKE ?) AND NOT enhanced_code LIKE ?)", condition)
+	assert.Equal(t, []interface{}{int64(500), "suppress", "%mailbox full%", "5.7%"}, args)
+}
+
+func TestParseRejectsInvalidParams(t *testing.T) {
+	log.Print("Testing rule query validation")
+	for _, values := range []url.Values{
+		{"limit": {"0"}},
+		{"offset": {"-1"}},
+		{"offset": {"20"}},
+		{"sort": {"regex"}},
+		{"priority_min": {"high"}},
+		{"q": {"regex~abc"}},
+		{"q": {"response_code>=abc"}},
+		{"q": {"response_code~5"}},
+		{"q": {"bounce_action<suppress"}},
+		{"q": {"response_code=500 AND"}},
+		{"q": {"(response_code=500"}},
+		{"q": {"response_code=500)"}},
+		{"q": {`description="unterminated`}},
+		{"q": {"response_code 500"}},
+		{"q": {"response_code=500; DROP TABLE bounce_rule"}},
+	} {
+		_, err := Parse(values, testFields)
+		assert.Errorf(t, err, "should reject %v", values)
+	}
+
+	_, err := Parse(url.Values{"q": {"regex~abc"}}, testFields)
+	assert.Equal(t, problem.FieldError{Field: "q", Message: `has an unknown field "regex" at position 1`}, err)
+}
+
+func TestSetPageHeaders(t *testing.T) {
+	log.Print("Testing rule listings report totals and link to the next page")
+	r := httptest.NewRequest("GET", "/bounce_rules?bounce_action=suppress&limit=10&offset=10", nil)
+	params, err := Parse(r.URL.Query(), testFields)
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	SetPageHeaders(w, r, params, 25)
+	assert.Equal(t, "25", w.Header().Get("X-Total-Count"))
+	assert.Equal(t, `</bounce_rules?bounce_action=suppress&limit=10&offset=20>; rel="next"`, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	SetPageHeaders(w, r, params, 20)
+	assert.Empty(t, w.Header().Get("Link"), "should not link past the last page")
+}
@@ -0,0 +1,5 @@
+// Package rulespb holds the gRPC service definitions of the rule managers
+// and the code generated from them.
+package rulespb
+
+//go:generate buf generate
@@ -0,0 +1,772 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package rulespb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// BounceRulesClient is the client API for BounceRules service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BounceRulesClient interface {
+	// ListBounceRules lists every bounce rule, or only those in effect now, in match order.
+	ListBounceRules(ctx context.Context, in *ListBounceRulesRequest, opts ...grpc.CallOption) (*ListBounceRulesResponse, error)
+	GetBounceRule(ctx context.Context, in *GetBounceRuleRequest, opts ...grpc.CallOption) (*BounceRule, error)
+	CreateBounceRule(ctx context.Context, in *CreateBounceRuleRequest, opts ...grpc.CallOption) (*BounceRule, error)
+	// UpdateBounceRule replaces a bounce rule. It fails with FAILED_PRECONDITION
+	// when if_match_version is set and the rule has moved on.
+	UpdateBounceRule(ctx context.Context, in *UpdateBounceRuleRequest, opts ...grpc.CallOption) (*BounceRule, error)
+	DeleteBounceRule(ctx context.Context, in *DeleteBounceRuleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListBounceRuleChanges pages through the bounce rule change history.
+	ListBounceRuleChanges(ctx context.Context, in *ListChangesRequest, opts ...grpc.CallOption) (*ListBounceRuleChangesResponse, error)
+	// ClassifyBounce returns the bounce rule in effect that matches a bounce.
+	ClassifyBounce(ctx context.Context, in *ClassifyBounceRequest, opts ...grpc.CallOption) (*BounceRule, error)
+	// WatchBounceRuleChanges streams bounce rule changes as they are made.
+	WatchBounceRuleChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (BounceRules_WatchBounceRuleChangesClient, error)
+}
+
+type bounceRulesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBounceRulesClient(cc grpc.ClientConnInterface) BounceRulesClient {
+	return &bounceRulesClient{cc}
+}
+
+func (c *bounceRulesClient) ListBounceRules(ctx context.Context, in *ListBounceRulesRequest, opts ...grpc.CallOption) (*ListBounceRulesResponse, error) {
+	out := new(ListBounceRulesResponse)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.BounceRules/ListBounceRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bounceRulesClient) GetBounceRule(ctx context.Context, in *GetBounceRuleRequest, opts ...grpc.CallOption) (*BounceRule, error) {
+	out := new(BounceRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.BounceRules/GetBounceRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bounceRulesClient) CreateBounceRule(ctx context.Context, in *CreateBounceRuleRequest, opts ...grpc.CallOption) (*BounceRule, error) {
+	out := new(BounceRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.BounceRules/CreateBounceRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bounceRulesClient) UpdateBounceRule(ctx context.Context, in *UpdateBounceRuleRequest, opts ...grpc.CallOption) (*BounceRule, error) {
+	out := new(BounceRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.BounceRules/UpdateBounceRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bounceRulesClient) DeleteBounceRule(ctx context.Context, in *DeleteBounceRuleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.BounceRules/DeleteBounceRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bounceRulesClient) ListBounceRuleChanges(ctx context.Context, in *ListChangesRequest, opts ...grpc.CallOption) (*ListBounceRuleChangesResponse, error) {
+	out := new(ListBounceRuleChangesResponse)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.BounceRules/ListBounceRuleChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bounceRulesClient) ClassifyBounce(ctx context.Context, in *ClassifyBounceRequest, opts ...grpc.CallOption) (*BounceRule, error) {
+	out := new(BounceRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.BounceRules/ClassifyBounce", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bounceRulesClient) WatchBounceRuleChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (BounceRules_WatchBounceRuleChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &BounceRules_ServiceDesc.Streams[0], "/gobrm.rules.v1.BounceRules/WatchBounceRuleChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &bounceRulesWatchBounceRuleChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type BounceRules_WatchBounceRuleChangesClient interface {
+	Recv() (*BounceRuleChange, error)
+	grpc.ClientStream
+}
+
+type bounceRulesWatchBounceRuleChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *bounceRulesWatchBounceRuleChangesClient) Recv() (*BounceRuleChange, error) {
+	m := new(BounceRuleChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// BounceRulesServer is the server API for BounceRules service.
+// All implementations must embed UnimplementedBounceRulesServer
+// for forward compatibility
+type BounceRulesServer interface {
+	// ListBounceRules lists every bounce rule, or only those in effect now, in match order.
+	ListBounceRules(context.Context, *ListBounceRulesRequest) (*ListBounceRulesResponse, error)
+	GetBounceRule(context.Context, *GetBounceRuleRequest) (*BounceRule, error)
+	CreateBounceRule(context.Context, *CreateBounceRuleRequest) (*BounceRule, error)
+	// UpdateBounceRule replaces a bounce rule. It fails with FAILED_PRECONDITION
+	// when if_match_version is set and the rule has moved on.
+	UpdateBounceRule(context.Context, *UpdateBounceRuleRequest) (*BounceRule, error)
+	DeleteBounceRule(context.Context, *DeleteBounceRuleRequest) (*emptypb.Empty, error)
+	// ListBounceRuleChanges pages through the bounce rule change history.
+	ListBounceRuleChanges(context.Context, *ListChangesRequest) (*ListBounceRuleChangesResponse, error)
+	// ClassifyBounce returns the bounce rule in effect that matches a bounce.
+	ClassifyBounce(context.Context, *ClassifyBounceRequest) (*BounceRule, error)
+	// WatchBounceRuleChanges streams bounce rule changes as they are made.
+	WatchBounceRuleChanges(*WatchChangesRequest, BounceRules_WatchBounceRuleChangesServer) error
+	mustEmbedUnimplementedBounceRulesServer()
+}
+
+// UnimplementedBounceRulesServer must be embedded to have forward compatible implementations.
+type UnimplementedBounceRulesServer struct {
+}
+
+func (UnimplementedBounceRulesServer) ListBounceRules(context.Context, *ListBounceRulesRequest) (*ListBounceRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBounceRules not implemented")
+}
+func (UnimplementedBounceRulesServer) GetBounceRule(context.Context, *GetBounceRuleRequest) (*BounceRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBounceRule not implemented")
+}
+func (UnimplementedBounceRulesServer) CreateBounceRule(context.Context, *CreateBounceRuleRequest) (*BounceRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateBounceRule not implemented")
+}
+func (UnimplementedBounceRulesServer) UpdateBounceRule(context.Context, *UpdateBounceRuleRequest) (*BounceRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateBounceRule not implemented")
+}
+func (UnimplementedBounceRulesServer) DeleteBounceRule(context.Context, *DeleteBounceRuleRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteBounceRule not implemented")
+}
+func (UnimplementedBounceRulesServer) ListBounceRuleChanges(context.Context, *ListChangesRequest) (*ListBounceRuleChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBounceRuleChanges not implemented")
+}
+func (UnimplementedBounceRulesServer) ClassifyBounce(context.Context, *ClassifyBounceRequest) (*BounceRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ClassifyBounce not implemented")
+}
+func (UnimplementedBounceRulesServer) WatchBounceRuleChanges(*WatchChangesRequest, BounceRules_WatchBounceRuleChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchBounceRuleChanges not implemented")
+}
+func (UnimplementedBounceRulesServer) mustEmbedUnimplementedBounceRulesServer() {}
+
+// UnsafeBounceRulesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BounceRulesServer will
+// result in compilation errors.
+type UnsafeBounceRulesServer interface {
+	mustEmbedUnimplementedBounceRulesServer()
+}
+
+func RegisterBounceRulesServer(s grpc.ServiceRegistrar, srv BounceRulesServer) {
+	s.RegisterService(&BounceRules_ServiceDesc, srv)
+}
+
+func _BounceRules_ListBounceRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBounceRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BounceRulesServer).ListBounceRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.BounceRules/ListBounceRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BounceRulesServer).ListBounceRules(ctx, req.(*ListBounceRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BounceRules_GetBounceRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBounceRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BounceRulesServer).GetBounceRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.BounceRules/GetBounceRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BounceRulesServer).GetBounceRule(ctx, req.(*GetBounceRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BounceRules_CreateBounceRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateBounceRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BounceRulesServer).CreateBounceRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.BounceRules/CreateBounceRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BounceRulesServer).CreateBounceRule(ctx, req.(*CreateBounceRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BounceRules_UpdateBounceRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateBounceRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BounceRulesServer).UpdateBounceRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.BounceRules/UpdateBounceRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BounceRulesServer).UpdateBounceRule(ctx, req.(*UpdateBounceRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BounceRules_DeleteBounceRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteBounceRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BounceRulesServer).DeleteBounceRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.BounceRules/DeleteBounceRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BounceRulesServer).DeleteBounceRule(ctx, req.(*DeleteBounceRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BounceRules_ListBounceRuleChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BounceRulesServer).ListBounceRuleChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.BounceRules/ListBounceRuleChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BounceRulesServer).ListBounceRuleChanges(ctx, req.(*ListChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BounceRules_ClassifyBounce_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ClassifyBounceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BounceRulesServer).ClassifyBounce(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.BounceRules/ClassifyBounce",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BounceRulesServer).ClassifyBounce(ctx, req.(*ClassifyBounceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _BounceRules_WatchBounceRuleChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(BounceRulesServer).WatchBounceRuleChanges(m, &bounceRulesWatchBounceRuleChangesServer{stream})
+}
+
+type BounceRules_WatchBounceRuleChangesServer interface {
+	Send(*BounceRuleChange) error
+	grpc.ServerStream
+}
+
+type bounceRulesWatchBounceRuleChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *bounceRulesWatchBounceRuleChangesServer) Send(m *BounceRuleChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// BounceRules_ServiceDesc is the grpc.ServiceDesc for BounceRules service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var BounceRules_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gobrm.rules.v1.BounceRules",
+	HandlerType: (*BounceRulesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListBounceRules",
+			Handler:    _BounceRules_ListBounceRules_Handler,
+		},
+		{
+			MethodName: "GetBounceRule",
+			Handler:    _BounceRules_GetBounceRule_Handler,
+		},
+		{
+			MethodName: "CreateBounceRule",
+			Handler:    _BounceRules_CreateBounceRule_Handler,
+		},
+		{
+			MethodName: "UpdateBounceRule",
+			Handler:    _BounceRules_UpdateBounceRule_Handler,
+		},
+		{
+			MethodName: "DeleteBounceRule",
+			Handler:    _BounceRules_DeleteBounceRule_Handler,
+		},
+		{
+			MethodName: "ListBounceRuleChanges",
+			Handler:    _BounceRules_ListBounceRuleChanges_Handler,
+		},
+		{
+			MethodName: "ClassifyBounce",
+			Handler:    _BounceRules_ClassifyBounce_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchBounceRuleChanges",
+			Handler:       _BounceRules_WatchBounceRuleChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rules.proto",
+}
+
+// ThroughputRulesClient is the client API for ThroughputRules service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ThroughputRulesClient interface {
+	// ListThroughputRules lists every throughput rule, or only those in effect now.
+	ListThroughputRules(ctx context.Context, in *ListThroughputRulesRequest, opts ...grpc.CallOption) (*ListThroughputRulesResponse, error)
+	GetThroughputRule(ctx context.Context, in *GetThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error)
+	CreateThroughputRule(ctx context.Context, in *CreateThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error)
+	// UpdateThroughputRule replaces a throughput rule. It fails with
+	// FAILED_PRECONDITION when if_match_version is set and the rule has moved on.
+	UpdateThroughputRule(ctx context.Context, in *UpdateThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error)
+	DeleteThroughputRule(ctx context.Context, in *DeleteThroughputRuleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListThroughputRuleChanges pages through the throughput rule change history.
+	ListThroughputRuleChanges(ctx context.Context, in *ListChangesRequest, opts ...grpc.CallOption) (*ListThroughputRuleChangesResponse, error)
+	// GetEffectiveThroughputRule returns the throughput rule in effect for an MX domain.
+	GetEffectiveThroughputRule(ctx context.Context, in *GetEffectiveThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error)
+	// WatchThroughputRuleChanges streams throughput rule changes as they are made.
+	WatchThroughputRuleChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (ThroughputRules_WatchThroughputRuleChangesClient, error)
+}
+
+type throughputRulesClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewThroughputRulesClient(cc grpc.ClientConnInterface) ThroughputRulesClient {
+	return &throughputRulesClient{cc}
+}
+
+func (c *throughputRulesClient) ListThroughputRules(ctx context.Context, in *ListThroughputRulesRequest, opts ...grpc.CallOption) (*ListThroughputRulesResponse, error) {
+	out := new(ListThroughputRulesResponse)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.ThroughputRules/ListThroughputRules", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *throughputRulesClient) GetThroughputRule(ctx context.Context, in *GetThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error) {
+	out := new(ThroughputRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.ThroughputRules/GetThroughputRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *throughputRulesClient) CreateThroughputRule(ctx context.Context, in *CreateThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error) {
+	out := new(ThroughputRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.ThroughputRules/CreateThroughputRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *throughputRulesClient) UpdateThroughputRule(ctx context.Context, in *UpdateThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error) {
+	out := new(ThroughputRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.ThroughputRules/UpdateThroughputRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *throughputRulesClient) DeleteThroughputRule(ctx context.Context, in *DeleteThroughputRuleRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.ThroughputRules/DeleteThroughputRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *throughputRulesClient) ListThroughputRuleChanges(ctx context.Context, in *ListChangesRequest, opts ...grpc.CallOption) (*ListThroughputRuleChangesResponse, error) {
+	out := new(ListThroughputRuleChangesResponse)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.ThroughputRules/ListThroughputRuleChanges", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *throughputRulesClient) GetEffectiveThroughputRule(ctx context.Context, in *GetEffectiveThroughputRuleRequest, opts ...grpc.CallOption) (*ThroughputRule, error) {
+	out := new(ThroughputRule)
+	err := c.cc.Invoke(ctx, "/gobrm.rules.v1.ThroughputRules/GetEffectiveThroughputRule", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *throughputRulesClient) WatchThroughputRuleChanges(ctx context.Context, in *WatchChangesRequest, opts ...grpc.CallOption) (ThroughputRules_WatchThroughputRuleChangesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ThroughputRules_ServiceDesc.Streams[0], "/gobrm.rules.v1.ThroughputRules/WatchThroughputRuleChanges", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &throughputRulesWatchThroughputRuleChangesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type ThroughputRules_WatchThroughputRuleChangesClient interface {
+	Recv() (*ThroughputRuleChange, error)
+	grpc.ClientStream
+}
+
+type throughputRulesWatchThroughputRuleChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *throughputRulesWatchThroughputRuleChangesClient) Recv() (*ThroughputRuleChange, error) {
+	m := new(ThroughputRuleChange)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ThroughputRulesServer is the server API for ThroughputRules service.
+// All implementations must embed UnimplementedThroughputRulesServer
+// for forward compatibility
+type ThroughputRulesServer interface {
+	// ListThroughputRules lists every throughput rule, or only those in effect now.
+	ListThroughputRules(context.Context, *ListThroughputRulesRequest) (*ListThroughputRulesResponse, error)
+	GetThroughputRule(context.Context, *GetThroughputRuleRequest) (*ThroughputRule, error)
+	CreateThroughputRule(context.Context, *CreateThroughputRuleRequest) (*ThroughputRule, error)
+	// UpdateThroughputRule replaces a throughput rule. It fails with
+	// FAILED_PRECONDITION when if_match_version is set and the rule has moved on.
+	UpdateThroughputRule(context.Context, *UpdateThroughputRuleRequest) (*ThroughputRule, error)
+	DeleteThroughputRule(context.Context, *DeleteThroughputRuleRequest) (*emptypb.Empty, error)
+	// ListThroughputRuleChanges pages through the throughput rule change history.
+	ListThroughputRuleChanges(context.Context, *ListChangesRequest) (*ListThroughputRuleChangesResponse, error)
+	// GetEffectiveThroughputRule returns the throughput rule in effect for an MX domain.
+	GetEffectiveThroughputRule(context.Context, *GetEffectiveThroughputRuleRequest) (*ThroughputRule, error)
+	// WatchThroughputRuleChanges streams throughput rule changes as they are made.
+	WatchThroughputRuleChanges(*WatchChangesRequest, ThroughputRules_WatchThroughputRuleChangesServer) error
+	mustEmbedUnimplementedThroughputRulesServer()
+}
+
+// UnimplementedThroughputRulesServer must be embedded to have forward compatible implementations.
+type UnimplementedThroughputRulesServer struct {
+}
+
+func (UnimplementedThroughputRulesServer) ListThroughputRules(context.Context, *ListThroughputRulesRequest) (*ListThroughputRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListThroughputRules not implemented")
+}
+func (UnimplementedThroughputRulesServer) GetThroughputRule(context.Context, *GetThroughputRuleRequest) (*ThroughputRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetThroughputRule not implemented")
+}
+func (UnimplementedThroughputRulesServer) CreateThroughputRule(context.Context, *CreateThroughputRuleRequest) (*ThroughputRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateThroughputRule not implemented")
+}
+func (UnimplementedThroughputRulesServer) UpdateThroughputRule(context.Context, *UpdateThroughputRuleRequest) (*ThroughputRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateThroughputRule not implemented")
+}
+func (UnimplementedThroughputRulesServer) DeleteThroughputRule(context.Context, *DeleteThroughputRuleRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteThroughputRule not implemented")
+}
+func (UnimplementedThroughputRulesServer) ListThroughputRuleChanges(context.Context, *ListChangesRequest) (*ListThroughputRuleChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListThroughputRuleChanges not implemented")
+}
+func (UnimplementedThroughputRulesServer) GetEffectiveThroughputRule(context.Context, *GetEffectiveThroughputRuleRequest) (*ThroughputRule, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetEffectiveThroughputRule not implemented")
+}
+func (UnimplementedThroughputRulesServer) WatchThroughputRuleChanges(*WatchChangesRequest, ThroughputRules_WatchThroughputRuleChangesServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchThroughputRuleChanges not implemented")
+}
+func (UnimplementedThroughputRulesServer) mustEmbedUnimplementedThroughputRulesServer() {}
+
+// UnsafeThroughputRulesServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ThroughputRulesServer will
+// result in compilation errors.
+type UnsafeThroughputRulesServer interface {
+	mustEmbedUnimplementedThroughputRulesServer()
+}
+
+func RegisterThroughputRulesServer(s grpc.ServiceRegistrar, srv ThroughputRulesServer) {
+	s.RegisterService(&ThroughputRules_ServiceDesc, srv)
+}
+
+func _ThroughputRules_ListThroughputRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListThroughputRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThroughputRulesServer).ListThroughputRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.ThroughputRules/ListThroughputRules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThroughputRulesServer).ListThroughputRules(ctx, req.(*ListThroughputRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThroughputRules_GetThroughputRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetThroughputRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThroughputRulesServer).GetThroughputRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.ThroughputRules/GetThroughputRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThroughputRulesServer).GetThroughputRule(ctx, req.(*GetThroughputRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThroughputRules_CreateThroughputRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateThroughputRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThroughputRulesServer).CreateThroughputRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.ThroughputRules/CreateThroughputRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThroughputRulesServer).CreateThroughputRule(ctx, req.(*CreateThroughputRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThroughputRules_UpdateThroughputRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateThroughputRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThroughputRulesServer).UpdateThroughputRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.ThroughputRules/UpdateThroughputRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThroughputRulesServer).UpdateThroughputRule(ctx, req.(*UpdateThroughputRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThroughputRules_DeleteThroughputRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteThroughputRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThroughputRulesServer).DeleteThroughputRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.ThroughputRules/DeleteThroughputRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThroughputRulesServer).DeleteThroughputRule(ctx, req.(*DeleteThroughputRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThroughputRules_ListThroughputRuleChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThroughputRulesServer).ListThroughputRuleChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.ThroughputRules/ListThroughputRuleChanges",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThroughputRulesServer).ListThroughputRuleChanges(ctx, req.(*ListChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThroughputRules_GetEffectiveThroughputRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEffectiveThroughputRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ThroughputRulesServer).GetEffectiveThroughputRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/gobrm.rules.v1.ThroughputRules/GetEffectiveThroughputRule",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ThroughputRulesServer).GetEffectiveThroughputRule(ctx, req.(*GetEffectiveThroughputRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ThroughputRules_WatchThroughputRuleChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ThroughputRulesServer).WatchThroughputRuleChanges(m, &throughputRulesWatchThroughputRuleChangesServer{stream})
+}
+
+type ThroughputRules_WatchThroughputRuleChangesServer interface {
+	Send(*ThroughputRuleChange) error
+	grpc.ServerStream
+}
+
+type throughputRulesWatchThroughputRuleChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *throughputRulesWatchThroughputRuleChangesServer) Send(m *ThroughputRuleChange) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// ThroughputRules_ServiceDesc is the grpc.ServiceDesc for ThroughputRules service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ThroughputRules_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gobrm.rules.v1.ThroughputRules",
+	HandlerType: (*ThroughputRulesServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListThroughputRules",
+			Handler:    _ThroughputRules_ListThroughputRules_Handler,
+		},
+		{
+			MethodName: "GetThroughputRule",
+			Handler:    _ThroughputRules_GetThroughputRule_Handler,
+		},
+		{
+			MethodName: "CreateThroughputRule",
+			Handler:    _ThroughputRules_CreateThroughputRule_Handler,
+		},
+		{
+			MethodName: "UpdateThroughputRule",
+			Handler:    _ThroughputRules_UpdateThroughputRule_Handler,
+		},
+		{
+			MethodName: "DeleteThroughputRule",
+			Handler:    _ThroughputRules_DeleteThroughputRule_Handler,
+		},
+		{
+			MethodName: "ListThroughputRuleChanges",
+			Handler:    _ThroughputRules_ListThroughputRuleChanges_Handler,
+		},
+		{
+			MethodName: "GetEffectiveThroughputRule",
+			Handler:    _ThroughputRules_GetEffectiveThroughputRule_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchThroughputRuleChanges",
+			Handler:       _ThroughputRules_WatchThroughputRuleChanges_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "rules.proto",
+}
@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gobrm/bulk"
+	"gobrm/problem"
+	"gobrm/render"
+	"net/http"
+	"time"
+)
+
+const defaultUpcomingWindow = 7 * 24 * time.Hour
+
+// API serves the upcoming transitions of one rule type.
+type API struct {
+	DB     *sql.DB
+	Source Source
+}
+
+// transitionListColumns orders the fields of transition lists in YAML and CSV.
+var transitionListColumns = bulk.Columns(Transition{})
+
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Respond(w, r, code, message)
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+// GetUpcomingTransitions lists the rules that take effect or expire within
+// the given window, e.g. ?within=24h, a week by default.
+func (api *API) GetUpcomingTransitions(w http.ResponseWriter, r *http.Request) {
+	within := defaultUpcomingWindow
+	if value := r.URL.Query().Get("within"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			respondWithError(w, r, http.StatusBadRequest, "within must be a positive duration such as 24h")
+			return
+		}
+		within = parsed
+	}
+
+	now := time.Now()
+	transitions, err := api.Source(r.Context(), api.DB, now, now.Add(within))
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.List(w, r, transitions, transitionListColumns)
+}
@@ -0,0 +1,86 @@
+package schedule
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gobrm/problem"
+
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// Transition actions, recorded as change actions when they happen.
+const (
+	ActionActivated = "activated"
+	ActionExpired   = "expired"
+)
+
+// ErrInvalidWindow is returned when a rule expires before it takes effect.
+var ErrInvalidWindow = problem.FieldError{Field: "expires_at", Message: "must be after effective_at"}
+
+// Transition is the moment a rule takes effect or expires.
+type Transition struct {
+	RuleID int         `json:"rule_id"`
+	Action string      `json:"action"`
+	At     time.Time   `json:"at"`
+	Rule   interface{} `json:"rule"`
+}
+
+// Source lists the transitions with from < at <= to, ordered by time.
+type Source func(ctx context.Context, exec boil.ContextExecutor, from, to time.Time) ([]Transition, error)
+
+// ValidateWindow checks that a rule with both timestamps expires after it takes effect.
+func ValidateWindow(effectiveAt, expiresAt null.Time) error {
+	if effectiveAt.Valid && expiresAt.Valid && !expiresAt.Time.After(effectiveAt.Time) {
+		return ErrInvalidWindow
+	}
+	return nil
+}
+
+// Active reports whether a rule with the given window applies at now. Rules
+// without an effective_at apply from creation and rules without an
+// expires_at never expire.
+func Active(effectiveAt, expiresAt null.Time, now time.Time) bool {
+	if effectiveAt.Valid && effectiveAt.Time.After(now) {
+		return false
+	}
+	if expiresAt.Valid && !expiresAt.Time.After(now) {
+		return false
+	}
+	return true
+}
+
+// ActiveAt restricts a rule query to the rules that apply at now.
+func ActiveAt(now time.Time) qm.QueryMod {
+	return qm.Where("(effective_at IS NULL OR effective_at <= ?) AND (expires_at IS NULL OR expires_at > ?)", now, now)
+}
+
+// InWindow restricts a rule query to the rules with a transition in (from, to].
+func InWindow(from, to time.Time) qm.QueryMod {
+	return qm.Where("(effective_at > ? AND effective_at <= ?) OR (expires_at > ? AND expires_at <= ?)", from, to, from, to)
+}
+
+// Transitions returns the transitions of one rule that fall in (from, to].
+func Transitions(ruleID int, rule interface{}, effectiveAt, expiresAt null.Time, from, to time.Time) []Transition {
+	transitions := []Transition{}
+	if effectiveAt.Valid && effectiveAt.Time.After(from) && !effectiveAt.Time.After(to) {
+		transitions = append(transitions, Transition{RuleID: ruleID, Action: ActionActivated, At: effectiveAt.Time, Rule: rule})
+	}
+	if expiresAt.Valid && expiresAt.Time.After(from) && !expiresAt.Time.After(to) {
+		transitions = append(transitions, Transition{RuleID: ruleID, Action: ActionExpired, At: expiresAt.Time, Rule: rule})
+	}
+	return transitions
+}
+
+// Sort orders transitions by time, then by rule.
+func Sort(transitions []Transition) {
+	sort.SliceStable(transitions, func(i, j int) bool {
+		if !transitions[i].At.Equal(transitions[j].At) {
+			return transitions[i].At.Before(transitions[j].At)
+		}
+		return transitions[i].RuleID < transitions[j].RuleID
+	})
+}
@@ -0,0 +1,94 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"github.com/volatiletech/sqlboiler/v4/boil"
+)
+
+func TestActive(t *testing.T) {
+	log.Print("Testing whether rules apply inside their window")
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	hour := time.Hour
+
+	assert.True(t, Active(null.Time{}, null.Time{}, now), "rules without a window always apply")
+	assert.False(t, Active(null.TimeFrom(now.Add(hour)), null.Time{}, now), "should not apply before effective_at")
+	assert.True(t, Active(null.TimeFrom(now), null.Time{}, now), "should apply from effective_at")
+	assert.True(t, Active(null.Time{}, null.TimeFrom(now.Add(hour)), now), "should apply until expires_at")
+	assert.False(t, Active(null.Time{}, null.TimeFrom(now), now), "should not apply from expires_at")
+}
+
+func TestValidateWindow(t *testing.T) {
+	log.Print("Testing rule windows must not expire before they take effect")
+	now := time.Now()
+
+	assert.NoError(t, ValidateWindow(null.TimeFrom(now), null.TimeFrom(now.Add(time.Minute))))
+	assert.NoError(t, ValidateWindow(null.Time{}, null.TimeFrom(now)))
+	assert.Equal(t, ErrInvalidWindow, ValidateWindow(null.TimeFrom(now), null.TimeFrom(now)))
+}
+
+func TestTransitions(t *testing.T) {
+	log.Print("Testing transitions are found inside (from, to]")
+	from := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+
+	transitions := Transitions(7, nil, null.TimeFrom(from.Add(time.Minute)), null.TimeFrom(to), from, to)
+	assert.Equal(t, []Transition{
+		{RuleID: 7, Action: ActionActivated, At: from.Add(time.Minute)},
+		{RuleID: 7, Action: ActionExpired, At: to},
+	}, transitions)
+
+	assert.Empty(t, Transitions(7, nil, null.TimeFrom(from), null.TimeFrom(to.Add(time.Second)), from, to), "should skip transitions on from and after to")
+}
+
+func TestRecordDue(t *testing.T) {
+	log.Print("Testing the scheduler records transitions since its watermark")
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	checkedUntil := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	now := checkedUntil.Add(time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT checked_until FROM rule_transition_watermark WHERE rule_type = \\? FOR UPDATE").
+		WithArgs("bounce_rule").
+		WillReturnRows(sqlmock.NewRows([]string{"checked_until"}).AddRow(checkedUntil))
+	mock.ExpectExec("UPDATE rule_transition_watermark SET checked_until = \\? WHERE rule_type = \\?").
+		WithArgs(now, "bounce_rule").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var sourceFrom, sourceTo time.Time
+	recorded := []Transition{}
+	s := &Scheduler{
+		DB:       db,
+		RuleType: "bounce_rule",
+		Source: func(ctx context.Context, exec boil.ContextExecutor, from, to time.Time) ([]Transition, error) {
+			sourceFrom, sourceTo = from, to
+			return []Transition{{RuleID: 3, Action: ActionExpired, At: now}}, nil
+		},
+		Record: func(ctx context.Context, tx *sql.Tx, t Transition) error {
+			recorded = append(recorded, t)
+			return nil
+		},
+	}
+
+	count, err := s.recordDue(context.Background(), now)
+
+	assert.NoError(t, err, "should not receive an error when recording transitions")
+	assert.Equal(t, 1, count)
+	assert.Equal(t, checkedUntil, sourceFrom, "should look for transitions after the watermark")
+	assert.Equal(t, now, sourceTo)
+	assert.Equal(t, []Transition{{RuleID: 3, Action: ActionExpired, At: now}}, recorded)
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}
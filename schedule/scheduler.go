@@ -0,0 +1,100 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// CREATE TABLE rule_transition_watermark (
+//   rule_type VARCHAR(32) NOT NULL,
+//   checked_until DATETIME(6) NOT NULL,
+//   PRIMARY KEY (rule_type)
+// );
+
+const defaultInterval = 15 * time.Second
+
+// Recorder writes the change record for a transition inside the scheduler's transaction.
+type Recorder func(ctx context.Context, tx *sql.Tx, t Transition) error
+
+// Scheduler records activated and expired changes as rules cross their
+// effective_at and expires_at. It remembers how far it has checked per rule
+// type, so each transition is recorded once even with several schedulers
+// sharing one database.
+type Scheduler struct {
+	DB       *sql.DB
+	RuleType string
+	Source   Source
+	Record   Recorder
+	Interval time.Duration
+}
+
+// Run records transitions until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.recordDue(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to record %s transitions: %s", s.RuleType, err)
+			}
+		}
+	}
+}
+
+// recordDue records the transitions between the last check and now. The
+// first check only sets the watermark; rules that were already active or
+// expired by then are covered by their created and updated changes.
+func (s *Scheduler) recordDue(ctx context.Context, now time.Time) (int, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var checkedUntil time.Time
+	err = tx.QueryRowContext(ctx, "SELECT checked_until FROM rule_transition_watermark WHERE rule_type = ? FOR UPDATE", s.RuleType).Scan(&checkedUntil)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx, "INSERT INTO rule_transition_watermark (rule_type, checked_until) VALUES (?, ?)", s.RuleType, now)
+		if err != nil {
+			return 0, err
+		}
+		return 0, tx.Commit()
+	case err != nil:
+		return 0, err
+	}
+
+	if !now.After(checkedUntil) {
+		return 0, nil
+	}
+
+	transitions, err := s.Source(ctx, tx, checkedUntil, now)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range transitions {
+		if err := s.Record(ctx, tx, t); err != nil {
+			return 0, err
+		}
+		log.Printf("Recorded %s %d %s at %s", s.RuleType, t.RuleID, t.Action, t.At)
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE rule_transition_watermark SET checked_until = ? WHERE rule_type = ?", now, s.RuleType)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(transitions), tx.Commit()
+}
@@ -0,0 +1,174 @@
+// Package shutdown runs the servers and background workers of a process
+// until SIGTERM or SIGINT, and then stops them in order: the servers stop
+// accepting connections and finish the requests in flight, event streams
+// end so clients resume elsewhere, the workers stop and the connection pool
+// closes. Requests still running at the deadline are cut off.
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Signals start a graceful shutdown.
+var Signals = []os.Signal{syscall.SIGTERM, os.Interrupt}
+
+// server is served by Run and stopped by Shutdown.
+type server struct {
+	name     string
+	serve    func() error
+	shutdown func(ctx context.Context) error
+}
+
+// Manager runs servers and workers and shuts them down. Register everything
+// before Run.
+type Manager struct {
+	// Timeout bounds the whole shutdown; it defaults to 30 seconds.
+	Timeout time.Duration
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	draining chan struct{}
+	once     sync.Once
+	servers  []server
+	workers  sync.WaitGroup
+	closers  []io.Closer
+}
+
+// New returns a manager with nothing to run yet.
+func New(timeout time.Duration) *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{Timeout: timeout, ctx: ctx, cancel: cancel, draining: make(chan struct{})}
+}
+
+// Draining is closed when the shutdown starts, for long-lived streams to end.
+func (m *Manager) Draining() <-chan struct{} {
+	return m.draining
+}
+
+// Go runs a background worker until the servers have shut down. The worker
+// must return once its context is cancelled.
+func (m *Manager) Go(worker func(ctx context.Context)) {
+	m.workers.Add(1)
+	go func() {
+		defer m.workers.Done()
+		worker(m.ctx)
+	}()
+}
+
+// Serve has Run start a server with serve and stop it with shutdown, which
+// must return by the time its context ends.
+func (m *Manager) Serve(name string, serve func() error, shutdown func(ctx context.Context) error) {
+	m.servers = append(m.servers, server{name: name, serve: serve, shutdown: shutdown})
+}
+
+// Close has the shutdown close c once the servers and workers have stopped,
+// e.g. the connection pool they share.
+func (m *Manager) Close(c io.Closer) {
+	m.closers = append(m.closers, c)
+}
+
+// Run starts the servers and blocks until one of signals arrives, or until
+// a server fails, and then shuts down. It returns the error of the failed
+// server, or else the first error of the shutdown.
+func (m *Manager) Run(signals ...os.Signal) error {
+	failed := make(chan error, len(m.servers))
+	for _, s := range m.servers {
+		s := s
+		go func() {
+			if err := s.serve(); err != nil {
+				failed <- fmt.Errorf("%s server failed: %w", s.name, err)
+			}
+		}()
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, signals...)
+	defer signal.Stop(stop)
+
+	var err error
+	select {
+	case sig := <-stop:
+		log.Printf("Received %s, shutting down", sig)
+	case err = <-failed:
+		log.Printf("%s, shutting down", err)
+	}
+
+	if shutdownErr := m.Shutdown(); err == nil {
+		err = shutdownErr
+	}
+	return err
+}
+
+// Shutdown stops the servers, waiting for the requests in flight, then the
+// workers, and then closes what was registered with Close. It gives up on
+// servers and workers still running after Timeout.
+func (m *Manager) Shutdown() error {
+	timeout := m.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var err error
+	m.once.Do(func() {
+		start := time.Now()
+		close(m.draining)
+
+		errs := make(chan error, len(m.servers))
+		for _, s := range m.servers {
+			s := s
+			go func() {
+				if err := s.shutdown(ctx); err != nil {
+					errs <- fmt.Errorf("shutting down %s server: %w", s.name, err)
+					return
+				}
+				errs <- nil
+			}()
+		}
+		for range m.servers {
+			if serverErr := <-errs; serverErr != nil {
+				log.Print(serverErr)
+				if err == nil {
+					err = serverErr
+				}
+			}
+		}
+
+		m.cancel()
+		stopped := make(chan struct{})
+		go func() {
+			m.workers.Wait()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			log.Print("Background workers did not stop in time")
+			if err == nil {
+				err = ctx.Err()
+			}
+		}
+
+		for _, c := range m.closers {
+			if closeErr := c.Close(); closeErr != nil {
+				log.Printf("Failed to close: %s", closeErr)
+				if err == nil {
+					err = closeErr
+				}
+			}
+		}
+		log.Printf("Shut down in %s", time.Since(start).Round(time.Millisecond))
+	})
+	return err
+}
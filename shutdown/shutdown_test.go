@@ -0,0 +1,121 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder notes the order things happen in.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) Close() error {
+	r.record("closed")
+	return nil
+}
+
+func TestShutdownFinishesRequestsBeforeStoppingWorkers(t *testing.T) {
+	log.Print("Testing shutdown finishes requests in flight, then stops workers and closes the pool")
+	events := &recorder{}
+	m := New(time.Second)
+
+	started := make(chan struct{})
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(100 * time.Millisecond)
+		events.record("request finished")
+		w.Write([]byte("written"))
+	})}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	m.Serve("HTTP", func() error { return server.Serve(listener) }, server.Shutdown)
+	go server.Serve(listener)
+
+	m.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		events.record("worker stopped")
+	})
+	m.Close(events)
+
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		response <- result{string(body), err}
+	}()
+
+	<-started
+	assert.NoError(t, m.Shutdown())
+	select {
+	case <-m.Draining():
+	default:
+		t.Error("should close Draining")
+	}
+	assert.Equal(t, []string{"request finished", "worker stopped", "closed"}, events.events)
+	r := <-response
+	assert.NoError(t, r.err)
+	assert.Equal(t, "written", r.body, "should finish the request in flight")
+
+	_, err = http.Get("http://" + listener.Addr().String())
+	assert.Error(t, err, "should stop accepting connections")
+	assert.NoError(t, m.Shutdown(), "should shut down once")
+}
+
+func TestShutdownGivesUpAtTheDeadline(t *testing.T) {
+	log.Print("Testing shutdown reports servers and workers that outlive the deadline")
+	m := New(50 * time.Millisecond)
+	m.Serve("stuck", func() error { select {} }, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	m.Go(func(ctx context.Context) { time.Sleep(time.Second) })
+
+	start := time.Now()
+	err := m.Shutdown()
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Contains(t, err.Error(), "stuck server")
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+}
+
+func TestRunShutsDownWhenAServerFails(t *testing.T) {
+	log.Print("Testing Run shuts down and returns the error of a failed server")
+	m := New(time.Second)
+	listenErr := errors.New("address already in use")
+	stopped := make(chan struct{})
+	m.Serve("gRPC", func() error { return listenErr }, func(ctx context.Context) error { return nil })
+	m.Serve("HTTP", func() error { <-stopped; return http.ErrServerClosed }, func(ctx context.Context) error {
+		close(stopped)
+		return nil
+	})
+
+	err := m.Run(Signals...)
+	assert.True(t, errors.Is(err, listenErr))
+	assert.Equal(t, "gRPC server failed: address already in use", err.Error())
+	<-stopped
+}
@@ -1,62 +1,313 @@
 package throughputrule
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"gobrm/apiversion"
+	"gobrm/auth"
+	"gobrm/bulk"
+	"gobrm/changequery"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/config"
+	"gobrm/events"
+	"gobrm/grpcserver"
+	"gobrm/idempotency"
 	"gobrm/models"
+	"gobrm/openapi"
+	"gobrm/patch"
+	"gobrm/problem"
+	"gobrm/ratelimit"
+	"gobrm/release"
+	"gobrm/render"
+	"gobrm/rulequery"
+	"gobrm/schedule"
+	"gobrm/shutdown"
+	"gobrm/tlsserver"
+	"gobrm/webhook"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+	"google.golang.org/grpc"
 )
 
 type App struct {
-	Router *chi.Mux
-	DB     *sql.DB
+	Router     *chi.Mux
+	DB         *sql.DB
+	Dispatcher *webhook.Dispatcher
+	Scheduler  *schedule.Scheduler
+	// RequireChangeRequests disables direct throughput rule writes so every change goes through review.
+	RequireChangeRequests bool
+	// InitialRolloutPercentage is the share of consumers a newly published
+	// release reaches; set it before Initialize.
+	InitialRolloutPercentage int
+	// GRPCAddr is where Run serves the gRPC API, e.g. ":9090"; empty disables it.
+	GRPCAddr string
+	// IdempotencyWindow is how long a standalone server remembers
+	// Idempotency-Key requests; set it before Initialize.
+	IdempotencyWindow time.Duration
+	// Idempotency replays retried writes of a standalone server.
+	Idempotency *idempotency.Store
+	// V1DeprecatedAt is when a standalone server deprecated v1, announced in
+	// the Deprecation header of v1 responses; zero does not deprecate v1.
+	V1DeprecatedAt time.Time
+	// V1Sunset is when a standalone server stops serving v1, announced in the
+	// Sunset header of v1 responses; zero is six months after V1DeprecatedAt.
+	V1Sunset time.Time
+	// Auth enables authentication of a standalone server; set it before Initialize.
+	Auth          auth.Config
+	authenticator *auth.Authenticator
+	// TLS sets how a standalone server is reached; set it before Run.
+	TLS tlsserver.Config
+	// Timeouts bound the requests of a standalone server; set it before Run.
+	Timeouts tlsserver.Timeouts
+	// ShutdownTimeout bounds the graceful shutdown of a standalone server;
+	// it defaults to 30 seconds.
+	ShutdownTimeout time.Duration
+	// Draining ends the change streams when it is closed, as the server
+	// shuts down. Run sets it for a standalone server.
+	Draining <-chan struct{}
+	// RateLimit sets the client budgets of a standalone server; set it before Initialize.
+	RateLimit      ratelimit.Config
+	rateLimiter    *ratelimit.Limiter
+	apiKeys        *auth.API
+	webhooks       *webhook.API
+	changeRequests *changerequest.API
+	releases       *release.API
+	transitions    *schedule.API
 }
 
-func (a *App) Initialize(user, password, dbname string) {
-	connectionString := fmt.Sprintf("%s:%s@/%s?parseTime=true", user, password, dbname)
+func (a *App) Initialize(mysql config.MySQL) {
+	log.Printf("Connecting to %s...", mysql.RedactedDSN())
 
-	log.Printf("Connecting to %s...", connectionString)
-
-	db, err := sql.Open("mysql", connectionString)
+	db, err := mysql.Open()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	a.DB = db
+	a.InitializeWithDB(db)
 	a.Router = chi.NewRouter()
+	a.Router.Use(problem.RequestID)
+	a.Router.Use(apiversion.DefaultPolicy(a.V1DeprecatedAt, a.V1Sunset).Middleware)
 	a.Router.Use(middleware.Logger)
-	a.initializeRoutes()
+	a.Router.NotFound(problem.NotFound)
+	a.Router.MethodNotAllowed(problem.MethodNotAllowed)
+	a.rateLimiter = ratelimit.New(a.RateLimit)
+	a.Router.Use(a.rateLimiter.Unauthenticated)
+	a.initializeAuth(db)
+	a.Router.Use(a.rateLimiter.Middleware)
+	a.initializeOpenAPI()
+	a.Idempotency = &idempotency.Store{DB: db, Window: a.IdempotencyWindow}
+	a.Router.Use(a.Idempotency.Middleware)
+	a.Mount(a.Router)
+	a.initializeSharedRoutes()
+}
+
+// initializeOpenAPI serves the part of the OpenAPI document a standalone
+// throughput rule server implements and validates requests against it. It
+// adds middleware, so it must run before any other route is added.
+func (a *App) initializeOpenAPI() {
+	doc, err := openapi.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	doc = openapi.Subset(doc, "/throughput_rule", "/events", "/webhooks", "/api_keys", "/openapi.json")
+
+	validator, err := openapi.NewValidator(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	a.Router.Use(validator.Middleware)
+	a.Router.Get("/openapi.json", openapi.Handler(doc))
 }
 
-func (a *App) initializeRoutes() {
-	a.Router.Route("/throughput_rules", func(r chi.Router) {
+// InitializeWithDB prepares the throughput rule handlers on an existing
+// connection pool. Mount adds their routes to a router.
+func (a *App) InitializeWithDB(db *sql.DB) {
+	a.DB = db
+	a.Dispatcher = &webhook.Dispatcher{DB: db}
+	a.Scheduler = &schedule.Scheduler{
+		DB:       db,
+		RuleType: models.TableNames.ThroughputRule,
+		Source:   throughputRuleTransitions,
+		Record:   recordThroughputRuleTransition,
+	}
+	a.transitions = &schedule.API{DB: db, Source: throughputRuleTransitions}
+	a.webhooks = &webhook.API{DB: db, URLParam: chi.URLParam}
+	a.changeRequests = &changerequest.API{
+		DB:       db,
+		RuleType: models.TableNames.ThroughputRule,
+		Applier:  throughputRuleApplier{},
+		URLParam: chi.URLParam,
+	}
+	a.releases = &release.API{
+		DB:                db,
+		RuleType:          models.TableNames.ThroughputRule,
+		Snapshot:          snapshotThroughputRules,
+		InitialPercentage: a.InitialRolloutPercentage,
+		URLParam:          chi.URLParam,
+	}
+}
+
+// Mount adds the throughput rule routes to a router. Metrics, webhooks and
+// /events are left to the caller so the rule manager can serve them once for
+// both subsystems.
+func (a *App) Mount(router chi.Router) {
+	router.Route("/throughput_rules", func(r chi.Router) {
 		r.Get("/", a.getThroughputRules)
-		r.Post("/", a.createThroughputRule)
+		r.Get("/effective", a.getEffectiveThroughputRule)
+		r.Get("/export", a.exportThroughputRules)
 		r.Get("/{id:[0-9]+}", a.getThroughputRule)
-		r.Put("/{id:[0-9]+}", a.updateThroughputRule)
-		r.Delete("/{id:[0-9]+}", a.deleteThroughputRule)
+
+		r.Group(func(r chi.Router) {
+			r.Use(a.directChanges)
+			r.Post("/", a.createThroughputRule)
+			r.Post("/import", a.importThroughputRules)
+			r.Put("/{id:[0-9]+}", a.updateThroughputRule)
+			r.Patch("/{id:[0-9]+}", a.patchThroughputRule)
+			r.Delete("/{id:[0-9]+}", a.deleteThroughputRule)
+		})
 	})
 
-	a.Router.Route("/throughput_rule_changes", func(r chi.Router) {
+	router.Get("/throughput_rule_transitions", a.transitions.GetUpcomingTransitions)
+
+	router.Route("/throughput_rule_changes", func(r chi.Router) {
 		r.Get("/", a.getThroughputRuleChanges)
 		r.Get("/{id:[0-9]+}", a.getThroughputRuleChangesForThroughputRule)
 	})
+
+	router.Route("/throughput_rule_change_requests", func(r chi.Router) {
+		r.Get("/", a.changeRequests.GetChangeRequests)
+		r.Post("/", a.changeRequests.CreateChangeRequest)
+		r.Get("/{id:[0-9]+}", a.changeRequests.GetChangeRequest)
+		r.Put("/{id:[0-9]+}", a.changeRequests.UpdateChangeRequest)
+		r.Post("/{id:[0-9]+}/submit", a.changeRequests.SubmitChangeRequest)
+		r.Post("/{id:[0-9]+}/approve", a.changeRequests.ApproveChangeRequest)
+		r.Post("/{id:[0-9]+}/reject", a.changeRequests.RejectChangeRequest)
+		r.Post("/{id:[0-9]+}/apply", a.changeRequests.ApplyChangeRequest)
+	})
+
+	router.Route("/throughput_rule_releases", func(r chi.Router) {
+		r.Get("/", a.releases.GetReleases)
+		r.Post("/", a.releases.Publish)
+		r.Get("/latest", a.releases.GetLatestRelease)
+		r.Get("/current", a.releases.GetAssignedRelease)
+		r.Get("/rollout", a.releases.GetRollout)
+		r.Put("/rollout", a.releases.UpdateRollout)
+		r.Post("/rollout/rollback", a.releases.RollbackRollout)
+		r.Get("/{version:[0-9]+}", a.releases.GetRelease)
+	})
+
+	router.Get("/throughput_rule_events", a.streamThroughputRuleChanges)
+}
+
+// initializeAuth authenticates every request when Auth is enabled and
+// serves API key management either way. It adds middleware, so it must run
+// before any route is added.
+func (a *App) initializeAuth(db *sql.DB) {
+	a.apiKeys = &auth.API{DB: db, URLParam: chi.URLParam}
+	if !a.Auth.Enabled {
+		return
+	}
+
+	authenticator, err := auth.New(db, a.Auth)
+	if err != nil {
+		log.Fatal(err)
+	}
+	authenticator.Events = auth.Throughput
+	a.authenticator = authenticator
+	a.Router.Use(authenticator.Middleware)
 }
 
-func (a *App) Run(addr string) {
+// initializeSharedRoutes adds the routes a standalone throughput rule server
+// serves for itself.
+func (a *App) initializeSharedRoutes() {
+	a.Router.Get("/events", a.streamThroughputRuleChanges)
+
+	a.Router.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", a.webhooks.GetSubscriptions)
+		r.Post("/", idempotency.Redacted(a.webhooks.CreateSubscription))
+		r.Get("/{id:[0-9]+}", a.webhooks.GetSubscription)
+		r.Put("/{id:[0-9]+}", idempotency.Redacted(a.webhooks.UpdateSubscription))
+		r.Delete("/{id:[0-9]+}", a.webhooks.DeleteSubscription)
+		r.Get("/{id:[0-9]+}/deliveries", a.webhooks.GetDeliveries)
+		r.Post("/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}/redeliver", a.webhooks.Redeliver)
+	})
+
+	a.Router.Route("/api_keys", func(r chi.Router) {
+		r.Get("/", a.apiKeys.GetKeys)
+		r.Post("/", idempotency.Redacted(a.apiKeys.CreateKey))
+		r.Get("/{id:[0-9]+}", a.apiKeys.GetKey)
+		r.Delete("/{id:[0-9]+}", a.apiKeys.RevokeKey)
+		r.Get("/{id:[0-9]+}/usage", a.apiKeys.GetKeyUsage)
+	})
+}
+
+// Run starts the webhook dispatcher, the transition scheduler, the
+// idempotency key purge, the rate limit pruning, the TLS file watcher, the
+// server and, with a GRPCAddr, the gRPC API. It serves TLS unless TLS
+// explicitly allows plaintext. On SIGTERM or SIGINT it stops accepting
+// requests, finishes those in flight, ends the change streams, stops the
+// workers and closes the connection pool before returning.
+func (a *App) Run(addr string) error {
 	log.Printf("Starting up server with addr %s", addr)
-	log.Fatal(http.ListenAndServe(addr, a.Router))
+	credentials, err := tlsserver.New(a.TLS)
+	if err != nil {
+		return err
+	}
+	m := shutdown.New(a.ShutdownTimeout)
+	a.Draining = m.Draining()
+	m.Go(a.Dispatcher.Run)
+	m.Go(a.Scheduler.Run)
+	m.Go(a.Idempotency.Run)
+	m.Go(a.rateLimiter.Run)
+	if credentials != nil {
+		m.Go(func(ctx context.Context) { watchTLS(ctx, credentials) })
+	}
+	if a.GRPCAddr != "" {
+		var opts []grpc.ServerOption
+		if a.authenticator != nil {
+			opts = a.authenticator.ServerOptions()
+		}
+		if credentials != nil {
+			opts = append(opts, credentials.GRPCOption())
+		}
+		grpcServer := grpcserver.New(opts...)
+		a.RegisterGRPC(grpcServer)
+		m.Serve("gRPC", func() error { return grpcServer.ListenAndServe(a.GRPCAddr) }, grpcServer.Shutdown)
+	}
+	server := tlsserver.NewServer(addr, a.Router, credentials, a.Timeouts)
+	m.Serve("HTTP", func() error { return tlsserver.Serve(server) }, server.Shutdown)
+	m.Close(a.DB)
+	return m.Run(shutdown.Signals...)
+}
+
+// watchTLS reloads the TLS files of a standalone server when they change.
+func watchTLS(ctx context.Context, credentials *tlsserver.Credentials) {
+	if err := credentials.Watch(ctx); err != nil {
+		log.Printf("Failed to watch TLS files, they will not be reloaded: %v", err)
+	}
 }
 
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Respond(w, r, code, message)
+}
+
+// respondWithDuplicate answers a write that would give two throughput rules
+// the same mx_domain with a 409.
+func respondWithDuplicate(w http.ResponseWriter, r *http.Request, err problem.DuplicateError) {
+	problem.Write(w, r, problem.Duplicate(err, fmt.Sprintf("Another throughput rule already has %s %q", err.Field, err.Value)))
 }
 
 func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
@@ -71,38 +322,191 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(response)
 }
 
+// directChanges rejects direct throughput rule writes when they must go through change requests.
+func (a *App) directChanges(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.RequireChangeRequests {
+			respondWithError(w, r, http.StatusForbidden, "Direct throughput rule changes are disabled, propose a change request instead")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// getThroughputRules lists the throughput rules matching the search
+// parameters, or with ?active=true only those in effect now.
 func (a *App) getThroughputRules(w http.ResponseWriter, r *http.Request) {
+	params, err := rulequery.Parse(r.URL.Query(), throughputRuleSearchFields)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return
+	}
+
+	var mods []qm.QueryMod
+	if active, _ := strconv.ParseBool(r.URL.Query().Get("active")); active {
+		mods = append(mods, schedule.ActiveAt(time.Now()))
+	}
+
+	throughputRules, total, err := searchThroughputRules(a.DB, params, "id", mods...)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if throughputRules == nil {
+		throughputRules = models.ThroughputRuleSlice{}
+	}
+	rulequery.SetPageHeaders(w, r, params, total)
+
+	// Tag the listing so clients can tell whether two fetches saw the same rules
+	if release.NotModified(w, r, throughputRuleListHash(throughputRules, total)) {
+		return
+	}
+
+	render.List(w, r, throughputRules, throughputRuleListColumns)
+}
+
+// throughputRuleListHash hashes the ID and version of every listed rule and the total
+// count. Every change to a rule bumps its version, so the hash changes with
+// the listing without encoding it twice.
+func throughputRuleListHash(throughputRules models.ThroughputRuleSlice, total int64) string {
+	var key strings.Builder
+	fmt.Fprintf(&key, "%d", total)
+	for _, rule := range throughputRules {
+		fmt.Fprintf(&key, ",%d:%d", rule.ID, rule.Version)
+	}
+	return release.Hash([]byte(key.String()))
+}
+
+// readThroughputRule reads and validates a full throughput rule from the request body.
+func readThroughputRule(w http.ResponseWriter, r *http.Request) (models.ThroughputRule, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule request payload")
+		return models.ThroughputRule{}, false
+	}
+
+	throughputRule, err := decodeThroughputRule(body)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return models.ThroughputRule{}, false
+	}
+
+	return throughputRule, true
+}
+
+// exportThroughputRules downloads every throughput rule as JSON, YAML or CSV.
+func (a *App) exportThroughputRules(w http.ResponseWriter, r *http.Request) {
+	format, err := bulk.ResponseFormat(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusNotAcceptable, err.Error())
+		return
+	}
+
 	throughputRules, err := getThroughputRules(a.DB)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := bulk.Write(w, format, "throughput_rules", throughputRules, throughputRuleExportColumns); err != nil {
+		log.Printf("Failed to write throughput rule export: %v", err)
+	}
+}
 
+// importThroughputRules creates, updates or replaces throughput rules from a JSON,
+// YAML or CSV file in one transaction.
+func (a *App) importThroughputRules(w http.ResponseWriter, r *http.Request) {
+	format, err := bulk.RequestFormat(r)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		respondWithError(w, r, http.StatusUnsupportedMediaType, err.Error())
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, throughputRules)
+	options, err := bulk.ParseOptions(r)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule import")
+		return
+	}
+
+	records, err := bulk.Decode(format, body, throughputRuleExportColumns)
+	if err != nil {
+		problem.Invalid(w, r, err)
+		return
+	}
+
+	log.Printf("Importing %d throughput rules with mode %s", len(records), options.Mode)
+	result, err := importThroughputRules(a.DB, records, options)
+	if err != nil {
+		if importErr, ok := err.(bulk.ImportError); ok {
+			problem.Invalid(w, r, importErr)
+			return
+		}
+		if duplicate, ok := err.(problem.DuplicateError); ok {
+			respondWithDuplicate(w, r, duplicate)
+			return
+		}
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, result)
 }
 
 func (a *App) createThroughputRule(w http.ResponseWriter, r *http.Request) {
-	var throughputRule models.ThroughputRule
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&throughputRule); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid throughput rule request payload")
+	throughputRule, ok := readThroughputRule(w, r)
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
 
-	if err := createThroughputRule(a.DB, throughputRule); err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+	if err := createThroughputRule(a.DB, &throughputRule); err != nil {
+		if duplicate, ok := err.(problem.DuplicateError); ok {
+			respondWithDuplicate(w, r, duplicate)
+			return
+		}
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	w.Header().Set("ETag", concurrency.ETag(throughputRule.Version))
 	respondWithJSON(w, http.StatusCreated, throughputRule)
 }
 
+// getEffectiveThroughputRule returns the throughput rule in effect for an MX domain.
+func (a *App) getEffectiveThroughputRule(w http.ResponseWriter, r *http.Request) {
+	mxDomain := r.URL.Query().Get("mx_domain")
+	if mxDomain == "" {
+		respondWithError(w, r, http.StatusBadRequest, "mx_domain is required")
+		return
+	}
+
+	throughputRules, err := getActiveThroughputRules(a.DB, time.Now())
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	throughputRule := effectiveThroughputRule(throughputRules, mxDomain)
+	if throughputRule == nil {
+		respondWithError(w, r, http.StatusNotFound, "No throughput rule applies to the MX domain")
+		return
+	}
+
+	respondWithJSON(w, http.StatusOK, throughputRule)
+}
+
 func (a *App) getThroughputRule(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid throughput rule ID")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule ID")
 		return
 	}
 
@@ -111,60 +515,124 @@ func (a *App) getThroughputRule(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		switch err {
 		case sql.ErrNoRows:
-			respondWithError(w, http.StatusNotFound, "Throughput rule not found")
+			respondWithError(w, r, http.StatusNotFound, "Throughput rule not found")
 		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		}
 		return
 	}
 
+	// The ETag is the rule's version, which PUT and DELETE accept in If-Match
+	if release.NotModified(w, r, strconv.Itoa(throughputRule.Version)) {
+		return
+	}
+
 	respondWithJSON(w, http.StatusOK, throughputRule)
 }
 
 func (a *App) updateThroughputRule(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid throughput rule ID")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule ID")
 		return
 	}
 
-	var throughputRule models.ThroughputRule
-	throughputRule.ID = id
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&throughputRule); err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid throughput rule request payload")
+	throughputRule, ok := readThroughputRule(w, r)
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
+	throughputRule.ID = id
 
 	log.Printf("Updating throughput rule with id %d", id)
-	if err := updateThroughputRule(a.DB, throughputRule); err != nil {
+	updatedThroughputRule, err := updateThroughputRule(a.DB, throughputRule, concurrency.IfMatch(r))
+	if err != nil {
+		if conflict, ok := err.(concurrency.ConflictError); ok {
+			concurrency.RespondWithConflict(w, r, conflict)
+			return
+		}
+		if duplicate, ok := err.(problem.DuplicateError); ok {
+			respondWithDuplicate(w, r, duplicate)
+			return
+		}
 		switch err {
 		case sql.ErrNoRows:
-			respondWithError(w, http.StatusNotFound, "Throughput rule not found")
+			respondWithError(w, r, http.StatusNotFound, "Throughput rule not found")
 		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		}
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, throughputRule)
+	w.Header().Set("ETag", concurrency.ETag(updatedThroughputRule.Version))
+	respondWithJSON(w, http.StatusOK, updatedThroughputRule)
+}
+
+// patchThroughputRule applies an application/merge-patch+json or
+// application/json-patch+json body to a throughput rule.
+func (a *App) patchThroughputRule(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule ID")
+		return
+	}
+
+	patchDocument, err := ioutil.ReadAll(r.Body)
+	defer r.Body.Close()
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule patch")
+		return
+	}
+
+	log.Printf("Patching throughput rule with id %d", id)
+	updatedThroughputRule, err := patchThroughputRule(a.DB, id, r.Header.Get("Content-Type"), patchDocument, concurrency.IfMatch(r))
+	if err != nil {
+		var conflict concurrency.ConflictError
+		var validationErr changerequest.ValidationError
+		var invalidPatch patch.InvalidPatchError
+		var duplicate problem.DuplicateError
+		switch {
+		case errors.As(err, &conflict):
+			concurrency.RespondWithConflict(w, r, conflict)
+		case errors.As(err, &duplicate):
+			respondWithDuplicate(w, r, duplicate)
+		case err == sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Throughput rule not found")
+		case err == patch.ErrUnsupportedMediaType:
+			respondWithError(w, r, http.StatusUnsupportedMediaType, err.Error())
+		case err == patch.ErrTestFailed:
+			respondWithError(w, r, http.StatusConflict, err.Error())
+		case errors.As(err, &invalidPatch):
+			respondWithError(w, r, http.StatusBadRequest, invalidPatch.Error())
+		case errors.As(err, &validationErr):
+			problem.Invalid(w, r, validationErr)
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	w.Header().Set("ETag", concurrency.ETag(updatedThroughputRule.Version))
+	respondWithJSON(w, http.StatusOK, updatedThroughputRule)
 }
 
 func (a *App) deleteThroughputRule(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid throughput rule ID")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule ID")
 		return
 	}
 
 	log.Printf("Deleting throughput rule with id %d", id)
-	if err := deleteThroughputRule(a.DB, id); err != nil {
+	if err := deleteThroughputRule(a.DB, id, concurrency.IfMatch(r)); err != nil {
+		if conflict, ok := err.(concurrency.ConflictError); ok {
+			concurrency.RespondWithConflict(w, r, conflict)
+			return
+		}
 		switch err {
 		case sql.ErrNoRows:
-			respondWithError(w, http.StatusNotFound, "Throughput rule not found")
+			respondWithError(w, r, http.StatusNotFound, "Throughput rule not found")
 		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		}
 		return
 	}
@@ -172,34 +640,93 @@ func (a *App) deleteThroughputRule(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *App) getThroughputRuleChanges(w http.ResponseWriter, r *http.Request) {
-	throughputRuleChanges, err := getThroughputRuleChanges(a.DB)
-
+	params, err := changequery.Parse(r.URL.Query(), throughputRuleChangeFilterFields)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, err.Error())
+		problem.Invalid(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, throughputRuleChanges)
+	a.writeThroughputRuleChanges(w, r, params)
 }
 
 func (a *App) getThroughputRuleChangesForThroughputRule(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(chi.URLParam(r, "id"))
 	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid throughput rule ID")
+		respondWithError(w, r, http.StatusBadRequest, "Invalid throughput rule ID")
 		return
 	}
 
-	log.Printf("Getting throughput rule changes for throughput rule with id %d", id)
-	throughputRuleChanges, err := getThroughputRuleChangesForThroughputRule(a.DB, id)
+	params, err := changequery.Parse(r.URL.Query(), throughputRuleChangeFilterFields)
 	if err != nil {
-		switch err {
-		case sql.ErrNoRows:
-			respondWithError(w, http.StatusNotFound, "Throughput rule changes for throughput rule not found")
-		default:
-			respondWithError(w, http.StatusInternalServerError, err.Error())
-		}
+		problem.Invalid(w, r, err)
 		return
 	}
 
-	respondWithJSON(w, http.StatusOK, throughputRuleChanges)
+	params.RuleID = &id
+	a.writeThroughputRuleChanges(w, r, params)
+}
+
+// writeThroughputRuleChanges streams a page of changes in the negotiated format row
+// by row, linking to the next page if there is one.
+func (a *App) writeThroughputRuleChanges(w http.ResponseWriter, r *http.Request, params changequery.Params) {
+	format, ok := render.Negotiate(w, r)
+	if !ok {
+		return
+	}
+
+	last, err := getThroughputRuleChangePageEnd(a.DB, params)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rows, err := queryThroughputRuleChanges(a.DB, params)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	if last != nil {
+		changequery.SetNextLink(w, r, params.Next(last.ID, last.UpdatedAt))
+	}
+	encoder := render.Stream(w, format, throughputRuleChangeListColumns)
+	err = eachThroughputRuleChange(rows, func(throughputRuleChange *models.ThroughputRuleChange) error {
+		return encoder.Encode(throughputRuleChange)
+	})
+	if err == nil {
+		err = encoder.Close()
+	}
+	if err != nil {
+		log.Printf("Failed to write throughput rule changes: %v", err)
+	}
+}
+
+// Stream throughput rule changes as server-sent events, using the change IDs as the event cursor.
+func (a *App) streamThroughputRuleChanges(w http.ResponseWriter, r *http.Request) {
+	stream := a.ChangeStream()
+	stream.ServeHTTP(w, r)
+}
+
+// ChangeStream polls the throughput rule change history for the server-sent event
+// and gRPC change streams.
+func (a *App) ChangeStream() *events.Stream {
+	return &events.Stream{
+		Source: func(ctx context.Context, after int, limit int) ([]events.Event, error) {
+			throughputRuleChanges, err := getThroughputRuleChangesAfter(a.DB, after, limit)
+			if err != nil {
+				return nil, err
+			}
+
+			throughputRuleEvents := make([]events.Event, 0, len(throughputRuleChanges))
+			for _, throughputRuleChange := range throughputRuleChanges {
+				throughputRuleEvents = append(throughputRuleEvents, events.Event{ID: throughputRuleChange.ID, Type: throughputRuleChange.Action, Data: throughputRuleChange, At: throughputRuleChange.UpdatedAt})
+			}
+			return throughputRuleEvents, nil
+		},
+		Head: func(ctx context.Context) (int, error) {
+			return getLatestThroughputRuleChangeID(a.DB)
+		},
+		Draining: a.Draining,
+	}
 }
@@ -0,0 +1,48 @@
+package throughputrule
+
+import (
+	"context"
+	"database/sql"
+	"gobrm/batch"
+)
+
+// BatchApplier lets batches create, update and delete throughput rules.
+func (a *App) BatchApplier() batch.Applier {
+	return throughputRuleBatchApplier{}
+}
+
+// throughputRuleBatchApplier applies batch operations on throughput rules
+// with the same functions the direct CRUD handlers use.
+type throughputRuleBatchApplier struct{}
+
+func (throughputRuleBatchApplier) Apply(ctx context.Context, tx *sql.Tx, op batch.Operation) (int, interface{}, error) {
+	switch op.Op {
+	case batch.OpCreate:
+		throughputRule, err := decodeThroughputRule(op.Rule)
+		if err != nil {
+			return 0, nil, batch.RuleError(err)
+		}
+		throughputRule.ID = 0
+		if err := createThroughputRuleTx(ctx, tx, &throughputRule); err != nil {
+			return 0, nil, err
+		}
+		return throughputRule.ID, &throughputRule, nil
+	case batch.OpUpdate:
+		throughputRule, err := decodeThroughputRule(op.Rule)
+		if err != nil {
+			return 0, nil, batch.RuleError(err)
+		}
+		throughputRule.ID = op.ID
+		updatedThroughputRule, err := updateThroughputRuleTx(ctx, tx, throughputRule, op.Precondition())
+		if err != nil {
+			return 0, nil, err
+		}
+		return op.ID, updatedThroughputRule, nil
+	default:
+		deletedThroughputRule, err := deleteThroughputRuleTx(ctx, tx, op.ID, op.Precondition())
+		if err != nil {
+			return 0, nil, err
+		}
+		return op.ID, deletedThroughputRule, nil
+	}
+}
@@ -0,0 +1,144 @@
+package throughputrule
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gobrm/bulk"
+	"gobrm/changerequest"
+	"gobrm/models"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// throughputRuleListColumns and throughputRuleChangeListColumns order every field
+// of throughput rule and change lists in YAML and CSV.
+var (
+	throughputRuleListColumns       = bulk.Columns(models.ThroughputRule{})
+	throughputRuleChangeListColumns = bulk.Columns(models.ThroughputRuleChange{})
+)
+
+// throughputRuleExportColumns orders throughput rule fields in YAML and CSV exports.
+var throughputRuleExportColumns = []bulk.Column{
+	{Name: "id", Numeric: true},
+	{Name: "mx_domain"},
+	{Name: "max_connections", Numeric: true},
+	{Name: "messages_per_connection", Numeric: true},
+	{Name: "connection_ttl_millis", Numeric: true},
+	{Name: "effective_at"},
+	{Name: "expires_at"},
+	{Name: "version", Numeric: true},
+}
+
+// throughputRuleKey is the key imports match throughput rules on, which the
+// database also keeps unique.
+func throughputRuleKey(throughputRule *models.ThroughputRule) string {
+	return throughputRule.MXDomain
+}
+
+func sameThroughputRule(a, b *models.ThroughputRule) bool {
+	return a.MXDomain == b.MXDomain &&
+		a.MaxConnections == b.MaxConnections &&
+		a.MessagesPerConnection == b.MessagesPerConnection &&
+		a.ConnectionTTLMillis == b.ConnectionTTLMillis &&
+		bulk.SameTime(a.EffectiveAt, b.EffectiveAt) &&
+		bulk.SameTime(a.ExpiresAt, b.ExpiresAt)
+}
+
+func decodeImportedThroughputRules(records []json.RawMessage) (models.ThroughputRuleSlice, error) {
+	throughputRules := make(models.ThroughputRuleSlice, len(records))
+	for i, record := range records {
+		throughputRule, err := decodeThroughputRule(record)
+		if err != nil {
+			if validationErr, ok := err.(changerequest.ValidationError); ok {
+				return nil, bulk.ImportError{Record: i + 1, Message: validationErr.Message}
+			}
+			return nil, err
+		}
+		throughputRule.ID = 0
+		throughputRules[i] = &throughputRule
+	}
+	return throughputRules, nil
+}
+
+// importThroughputRules applies an import in one transaction, auditing each
+// created, updated and deleted rule like the CRUD handlers. A dry run plans
+// the same changes and rolls them back.
+func importThroughputRules(db *sql.DB, records []json.RawMessage, options bulk.Options) (bulk.Result, error) {
+	result := bulk.NewResult(options)
+
+	throughputRules, err := decodeImportedThroughputRules(records)
+	if err != nil {
+		return result, err
+	}
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return result, err
+	}
+	defer tx.Rollback()
+
+	existingThroughputRules, err := models.ThroughputRules(qm.OrderBy("id"), qm.For("UPDATE")).All(ctx, tx)
+	if err != nil {
+		return result, err
+	}
+
+	existingKeys := make([]string, len(existingThroughputRules))
+	for i, throughputRule := range existingThroughputRules {
+		existingKeys[i] = throughputRuleKey(throughputRule)
+	}
+	importedKeys := make([]string, len(throughputRules))
+	for i, throughputRule := range throughputRules {
+		importedKeys[i] = throughputRuleKey(throughputRule)
+	}
+
+	steps, err := bulk.Plan(options.Mode, true, existingKeys, importedKeys)
+	if err != nil {
+		return result, err
+	}
+
+	for i, throughputRule := range throughputRules {
+		match := steps.Matches[i]
+		if match < 0 {
+			if !options.DryRun {
+				if err := createThroughputRuleTx(ctx, tx, throughputRule); err != nil {
+					return result, err
+				}
+			}
+			result.Add(bulk.Change{Action: bulk.ActionCreated, Record: i + 1, RuleID: throughputRule.ID, Rule: throughputRule})
+			continue
+		}
+
+		existingThroughputRule := existingThroughputRules[match]
+		if sameThroughputRule(existingThroughputRule, throughputRule) {
+			result.Unchanged++
+			continue
+		}
+
+		throughputRule.ID = existingThroughputRule.ID
+		updatedThroughputRule := throughputRule
+		if !options.DryRun {
+			if updatedThroughputRule, err = updateThroughputRuleTx(ctx, tx, *throughputRule, nil); err != nil {
+				return result, err
+			}
+		}
+		result.Add(bulk.Change{Action: bulk.ActionUpdated, Record: i + 1, RuleID: throughputRule.ID, Rule: updatedThroughputRule, Previous: existingThroughputRule})
+	}
+
+	for _, match := range steps.Deletes {
+		existingThroughputRule := existingThroughputRules[match]
+		if !options.DryRun {
+			if _, err := deleteThroughputRuleTx(ctx, tx, existingThroughputRule.ID, nil); err != nil {
+				return result, err
+			}
+		}
+		result.Add(bulk.Change{Action: bulk.ActionDeleted, RuleID: existingThroughputRule.ID, Rule: existingThroughputRule})
+	}
+
+	if options.DryRun {
+		return result, nil
+	}
+
+	return result, tx.Commit()
+}
@@ -0,0 +1,97 @@
+package throughputrule
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"gobrm/bulk"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var importedThroughputRules = []json.RawMessage{
+	json.RawMessage(`{"mx_domain":"gmail.com","max_connections":20,"messages_per_connection":50,"connection_ttl_millis":0}`),
+	json.RawMessage(`{"mx_domain":"outlook.com","max_connections":10,"messages_per_connection":25,"connection_ttl_millis":1000}`),
+}
+
+func existingThroughputRuleRows() *sqlmock.Rows {
+	return throughputRuleRows(
+		[]driver.Value{1, "gmail.com", 36, 50, 0, nil, nil, 1},
+		[]driver.Value{2, "yahoo.com", 12, 40, 0, nil, nil, 1},
+	)
+}
+
+func TestImportThroughputRules(t *testing.T) {
+	log.Print("Testing model's importThroughputRules matches rules on mx_domain")
+	const existingQuery = "SELECT \\* FROM `throughput_rule` ORDER BY id FOR UPDATE"
+
+	runMockTests(t, []mockTest{
+		{
+			name: "replace_all dry run",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(existingQuery).WillReturnRows(existingThroughputRuleRows())
+				mock.ExpectRollback()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				result, err := importThroughputRules(db, importedThroughputRules, bulk.Options{Mode: bulk.ModeReplaceAll, DryRun: true})
+				assert.NoError(t, err, "should not receive an error when planning an import")
+				assert.True(t, result.DryRun)
+				assert.Equal(t, 1, result.Created)
+				assert.Equal(t, 1, result.Updated)
+				assert.Equal(t, 1, result.Deleted)
+				assert.Equal(t, []string{"updated", "created", "deleted"}, []string{result.Changes[0].Action, result.Changes[1].Action, result.Changes[2].Action})
+				assert.Equal(t, 1, result.Changes[0].RuleID, "should update the rule with the same mx_domain")
+				assert.Equal(t, 2, result.Changes[2].RuleID, "should delete the rule missing from the import")
+			},
+		},
+		{
+			name: "upsert",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(existingQuery).WillReturnRows(existingThroughputRuleRows())
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "gmail.com", 36, 50, 0, nil, nil, 1}))
+				mock.ExpectExec("UPDATE `throughput_rule` SET").
+					WithArgs("gmail.com", 20, 50, 0, nil, nil, 2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectThroughputRuleChange(mock, "updated", 1, "gmail.com", 20, 50, 0, nil, nil)
+				mock.ExpectExec("INSERT INTO `throughput_rule`").
+					WithArgs("outlook.com", 10, 25, 1000, nil, nil, 1).
+					WillReturnResult(sqlmock.NewResult(3, 1))
+				expectThroughputRuleChange(mock, "created", 3, "outlook.com", 10, 25, 1000, nil, nil)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				result, err := importThroughputRules(db, importedThroughputRules, bulk.Options{Mode: bulk.ModeUpsert})
+				assert.NoError(t, err, "should not receive an error when importing throughput rules")
+				assert.Equal(t, 1, result.Created)
+				assert.Equal(t, 1, result.Updated)
+				assert.Equal(t, 0, result.Deleted)
+				assert.Equal(t, 3, result.Changes[1].RuleID, "should report the created rule's ID")
+			},
+		},
+		{
+			name: "append of a taken mx_domain",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(existingQuery).WillReturnRows(existingThroughputRuleRows())
+				mock.ExpectRollback()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				_, err := importThroughputRules(db, importedThroughputRules, bulk.Options{Mode: bulk.ModeAppend})
+				assert.Equal(t, bulk.ImportError{Record: 1, Message: "a rule with this key already exists, import with mode=upsert to update it"}, err)
+			},
+		},
+		{
+			name: "invalid record",
+			run: func(t *testing.T, db *sql.DB) {
+				records := append(importedThroughputRules, json.RawMessage(`{"mx_domain":"aol.com"}`))
+				_, err := importThroughputRules(db, records, bulk.Options{Mode: bulk.ModeAppend})
+				assert.Equal(t, bulk.ImportError{Record: 3, Message: "Missing required fields: max_connections, messages_per_connection, connection_ttl_millis"}, err)
+			},
+		},
+	})
+}
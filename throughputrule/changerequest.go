@@ -0,0 +1,71 @@
+package throughputrule
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"gobrm/changerequest"
+	"gobrm/models"
+)
+
+// throughputRuleApplier applies throughput rule change requests with the
+// same functions the direct CRUD handlers use.
+type throughputRuleApplier struct{}
+
+func decodeProposedThroughputRule(cr changerequest.ChangeRequest) (models.ThroughputRule, error) {
+	return decodeThroughputRule(cr.Rule)
+}
+
+func (throughputRuleApplier) Validate(ctx context.Context, db *sql.DB, cr changerequest.ChangeRequest) error {
+	if cr.Action != changerequest.ActionDelete {
+		if _, err := decodeProposedThroughputRule(cr); err != nil {
+			return err
+		}
+	}
+
+	if cr.RuleID == nil {
+		return nil
+	}
+
+	exists, err := models.ThroughputRuleExists(ctx, db, *cr.RuleID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return changerequest.ValidationError{Message: fmt.Sprintf("Throughput rule %d not found", *cr.RuleID)}
+	}
+
+	return nil
+}
+
+func (throughputRuleApplier) Apply(ctx context.Context, tx *sql.Tx, cr changerequest.ChangeRequest) (int, error) {
+	switch cr.Action {
+	case changerequest.ActionCreate:
+		throughputRule, err := decodeProposedThroughputRule(cr)
+		if err != nil {
+			return 0, err
+		}
+		throughputRule.ID = 0
+		if err := createThroughputRuleTx(ctx, tx, &throughputRule); err != nil {
+			return 0, err
+		}
+		return throughputRule.ID, nil
+	case changerequest.ActionUpdate:
+		throughputRule, err := decodeProposedThroughputRule(cr)
+		if err != nil {
+			return 0, err
+		}
+		throughputRule.ID = *cr.RuleID
+		if _, err := updateThroughputRuleTx(ctx, tx, throughputRule, nil); err != nil {
+			return 0, err
+		}
+		return throughputRule.ID, nil
+	case changerequest.ActionDelete:
+		if _, err := deleteThroughputRuleTx(ctx, tx, *cr.RuleID, nil); err != nil {
+			return 0, err
+		}
+		return *cr.RuleID, nil
+	default:
+		return 0, changerequest.ValidationError{Message: "action must be one of create, update or delete"}
+	}
+}
@@ -0,0 +1,236 @@
+package throughputrule
+
+import (
+	"context"
+	"time"
+
+	"gobrm/changerequest"
+	"gobrm/events"
+	"gobrm/grpcserver"
+	"gobrm/models"
+	"gobrm/rulespb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// throughputRuleService serves the ThroughputRules gRPC service with the
+// same domain logic as the REST handlers.
+type throughputRuleService struct {
+	rulespb.UnimplementedThroughputRulesServer
+	app *App
+}
+
+// RegisterGRPC adds the ThroughputRules service to a gRPC server.
+func (a *App) RegisterGRPC(s *grpcserver.Server) {
+	rulespb.RegisterThroughputRulesServer(s, &throughputRuleService{app: a})
+}
+
+const throughputRuleNotFound = "Throughput rule not found"
+
+func throughputRuleToProto(throughputRule *models.ThroughputRule) *rulespb.ThroughputRule {
+	return &rulespb.ThroughputRule{
+		Id:                    int32(throughputRule.ID),
+		MxDomain:              throughputRule.MXDomain,
+		MaxConnections:        int32(throughputRule.MaxConnections),
+		MessagesPerConnection: int32(throughputRule.MessagesPerConnection),
+		ConnectionTtlMillis:   int32(throughputRule.ConnectionTTLMillis),
+		EffectiveAt:           grpcserver.Timestamp(throughputRule.EffectiveAt),
+		ExpiresAt:             grpcserver.Timestamp(throughputRule.ExpiresAt),
+		Version:               int32(throughputRule.Version),
+	}
+}
+
+// throughputRuleFromProto converts and validates a throughput rule sent over gRPC.
+func throughputRuleFromProto(throughputRule *rulespb.ThroughputRule) (models.ThroughputRule, error) {
+	if throughputRule == nil {
+		return models.ThroughputRule{}, changerequest.ValidationError{Message: "throughput_rule is required"}
+	}
+	if throughputRule.MxDomain == "" {
+		return models.ThroughputRule{}, changerequest.ValidationError{Message: "Missing required fields: mx_domain"}
+	}
+
+	converted := models.ThroughputRule{
+		MXDomain:              throughputRule.MxDomain,
+		MaxConnections:        int(throughputRule.MaxConnections),
+		MessagesPerConnection: int(throughputRule.MessagesPerConnection),
+		ConnectionTTLMillis:   int(throughputRule.ConnectionTtlMillis),
+		EffectiveAt:           grpcserver.Time(throughputRule.EffectiveAt),
+		ExpiresAt:             grpcserver.Time(throughputRule.ExpiresAt),
+	}
+	return converted, validateThroughputRule(converted)
+}
+
+func throughputRuleChangeToProto(throughputRuleChange *models.ThroughputRuleChange) *rulespb.ThroughputRuleChange {
+	return &rulespb.ThroughputRuleChange{
+		Id:     int32(throughputRuleChange.ID),
+		Action: throughputRuleChange.Action,
+		ThroughputRule: &rulespb.ThroughputRule{
+			Id:                    int32(throughputRuleChange.ThroughputRuleID),
+			MxDomain:              throughputRuleChange.MXDomain,
+			MaxConnections:        int32(throughputRuleChange.MaxConnections),
+			MessagesPerConnection: int32(throughputRuleChange.MessagesPerConnection),
+			ConnectionTtlMillis:   int32(throughputRuleChange.ConnectionTTLMillis),
+			EffectiveAt:           grpcserver.Timestamp(throughputRuleChange.EffectiveAt),
+			ExpiresAt:             grpcserver.Timestamp(throughputRuleChange.ExpiresAt),
+		},
+		UpdatedAt: timestamppb.New(throughputRuleChange.UpdatedAt),
+	}
+}
+
+func throughputRuleID(id int32) (int, error) {
+	if id < 1 {
+		return 0, status.Error(codes.InvalidArgument, "Invalid throughput rule ID")
+	}
+	return int(id), nil
+}
+
+func (s *throughputRuleService) ListThroughputRules(ctx context.Context, req *rulespb.ListThroughputRulesRequest) (*rulespb.ListThroughputRulesResponse, error) {
+	var throughputRules models.ThroughputRuleSlice
+	var err error
+	if req.Active {
+		throughputRules, err = getActiveThroughputRules(s.app.DB, time.Now())
+	} else {
+		throughputRules, err = getThroughputRules(s.app.DB)
+	}
+	if err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+
+	response := &rulespb.ListThroughputRulesResponse{ThroughputRules: make([]*rulespb.ThroughputRule, 0, len(throughputRules))}
+	for _, throughputRule := range throughputRules {
+		response.ThroughputRules = append(response.ThroughputRules, throughputRuleToProto(throughputRule))
+	}
+	return response, nil
+}
+
+func (s *throughputRuleService) GetThroughputRule(ctx context.Context, req *rulespb.GetThroughputRuleRequest) (*rulespb.ThroughputRule, error) {
+	id, err := throughputRuleID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	throughputRule, err := getThroughputRule(s.app.DB, id)
+	if err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+	return throughputRuleToProto(throughputRule), nil
+}
+
+func (s *throughputRuleService) CreateThroughputRule(ctx context.Context, req *rulespb.CreateThroughputRuleRequest) (*rulespb.ThroughputRule, error) {
+	if s.app.RequireChangeRequests {
+		return nil, grpcserver.ErrDirectChangesDisabled
+	}
+
+	throughputRule, err := throughputRuleFromProto(req.ThroughputRule)
+	if err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+
+	if err := createThroughputRule(s.app.DB, &throughputRule); err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+	return throughputRuleToProto(&throughputRule), nil
+}
+
+func (s *throughputRuleService) UpdateThroughputRule(ctx context.Context, req *rulespb.UpdateThroughputRuleRequest) (*rulespb.ThroughputRule, error) {
+	if s.app.RequireChangeRequests {
+		return nil, grpcserver.ErrDirectChangesDisabled
+	}
+
+	throughputRule, err := throughputRuleFromProto(req.ThroughputRule)
+	if err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+	if throughputRule.ID, err = throughputRuleID(req.ThroughputRule.Id); err != nil {
+		return nil, err
+	}
+
+	updatedThroughputRule, err := updateThroughputRule(s.app.DB, throughputRule, grpcserver.Precondition(req.IfMatchVersion))
+	if err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+	return throughputRuleToProto(updatedThroughputRule), nil
+}
+
+func (s *throughputRuleService) DeleteThroughputRule(ctx context.Context, req *rulespb.DeleteThroughputRuleRequest) (*emptypb.Empty, error) {
+	if s.app.RequireChangeRequests {
+		return nil, grpcserver.ErrDirectChangesDisabled
+	}
+
+	id, err := throughputRuleID(req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := deleteThroughputRule(s.app.DB, id, grpcserver.Precondition(req.IfMatchVersion)); err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *throughputRuleService) ListThroughputRuleChanges(ctx context.Context, req *rulespb.ListChangesRequest) (*rulespb.ListThroughputRuleChangesResponse, error) {
+	params, err := grpcserver.ChangeParams(req, throughputRuleChangeFilterFields)
+	if err != nil {
+		return nil, err
+	}
+
+	throughputRuleChanges, err := getThroughputRuleChanges(s.app.DB, params)
+	if err != nil {
+		return nil, grpcserver.Error(err, "Throughput rule changes not found")
+	}
+
+	response := &rulespb.ListThroughputRuleChangesResponse{}
+	if len(throughputRuleChanges) > params.Limit {
+		throughputRuleChanges = throughputRuleChanges[:params.Limit]
+		last := throughputRuleChanges[len(throughputRuleChanges)-1]
+		response.NextPageToken = params.Next(last.ID, last.UpdatedAt).Encode()
+	}
+
+	response.Changes = make([]*rulespb.ThroughputRuleChange, 0, len(throughputRuleChanges))
+	for _, throughputRuleChange := range throughputRuleChanges {
+		response.Changes = append(response.Changes, throughputRuleChangeToProto(throughputRuleChange))
+	}
+	return response, nil
+}
+
+func (s *throughputRuleService) GetEffectiveThroughputRule(ctx context.Context, req *rulespb.GetEffectiveThroughputRuleRequest) (*rulespb.ThroughputRule, error) {
+	if req.MxDomain == "" {
+		return nil, status.Error(codes.InvalidArgument, "mx_domain is required")
+	}
+
+	throughputRules, err := getActiveThroughputRules(s.app.DB, time.Now())
+	if err != nil {
+		return nil, grpcserver.Error(err, throughputRuleNotFound)
+	}
+
+	throughputRule := effectiveThroughputRule(throughputRules, req.MxDomain)
+	if throughputRule == nil {
+		return nil, status.Error(codes.NotFound, "No throughput rule applies to the MX domain")
+	}
+	return throughputRuleToProto(throughputRule), nil
+}
+
+func (s *throughputRuleService) WatchThroughputRuleChanges(req *rulespb.WatchChangesRequest, stream rulespb.ThroughputRules_WatchThroughputRuleChangesServer) error {
+	ctx := stream.Context()
+	changes := s.app.ChangeStream()
+
+	var cursor int
+	if req.AfterId != nil {
+		if *req.AfterId < 0 {
+			return status.Error(codes.InvalidArgument, "Invalid after_id")
+		}
+		cursor = int(*req.AfterId)
+	} else {
+		var err error
+		if cursor, err = changes.Head(ctx); err != nil {
+			return grpcserver.Error(err, "")
+		}
+	}
+
+	return changes.Follow(ctx, cursor, func(event events.Event) error {
+		return stream.Send(throughputRuleChangeToProto(event.Data.(*models.ThroughputRuleChange)))
+	})
+}
@@ -0,0 +1,305 @@
+package throughputrule
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"log"
+	"net"
+	"testing"
+	"time"
+
+	"gobrm/grpcserver"
+	"gobrm/rulespb"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// dialThroughputRules serves the ThroughputRules service of an App over an
+// in-memory connection and returns a client for it.
+func dialThroughputRules(t *testing.T, a *App) rulespb.ThroughputRulesClient {
+	s := grpcserver.New()
+	a.RegisterGRPC(s)
+	listener := bufconn.Listen(1024 * 1024)
+	go s.Serve(listener)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithInsecure(),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return rulespb.NewThroughputRulesClient(conn)
+}
+
+// grpcTest is a gRPC call made against a stub database, answered with code.
+type grpcTest struct {
+	name   string
+	expect func(mock sqlmock.Sqlmock)
+	call   func(client rulespb.ThroughputRulesClient) error
+	code   codes.Code
+}
+
+// runGRPCTests makes each call through a client of its own App and checks
+// the status it is answered with.
+func runGRPCTests(t *testing.T, app func(db *sql.DB) *App, tests []grpcTest) {
+	var cases []mockTest
+	for _, test := range tests {
+		test := test
+		cases = append(cases, mockTest{
+			name:   test.name,
+			expect: test.expect,
+			run: func(t *testing.T, db *sql.DB) {
+				client := dialThroughputRules(t, app(db))
+				assert.Equal(t, test.code, status.Code(test.call(client)))
+			},
+		})
+	}
+	runMockTests(t, cases)
+}
+
+func TestGRPCThroughputRules(t *testing.T) {
+	log.Print("Testing the throughput rule gRPC service maps domain errors to statuses")
+	version := func(v int32) *int32 { return &v }
+	expiresAt := time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC)
+	now := time.Now()
+	activeQuery := "SELECT \\* FROM `throughput_rule` WHERE \\(\\(effective_at IS NULL OR effective_at <= \\?\\) AND \\(expires_at IS NULL OR expires_at > \\?\\)\\) ORDER BY id"
+	activeRows := func() *sqlmock.Rows {
+		return throughputRuleRows(
+			[]driver.Value{1, "google.com", 36, 50, 0, nil, nil, 1},
+			[]driver.Value{2, "aspmx.l.google.com", 12, 40, 0, nil, nil, 1},
+		)
+	}
+
+	runGRPCTests(t, func(db *sql.DB) *App { return &App{DB: db} }, []grpcTest{
+		{
+			name: "get",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("select \\* from `throughput_rule` where `id`=\\?").WithArgs(1).
+					WillReturnRows(throughputRuleRows([]driver.Value{1, "gmail.com", 36, 50, 0, nil, expiresAt, 3}))
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				throughputRule, err := client.GetThroughputRule(context.Background(), &rulespb.GetThroughputRuleRequest{Id: 1})
+				if err == nil {
+					assert.Equal(t, "gmail.com", throughputRule.MxDomain)
+					assert.Equal(t, int32(36), throughputRule.MaxConnections)
+					assert.Equal(t, int32(3), throughputRule.Version)
+					assert.Nil(t, throughputRule.EffectiveAt, "should leave a null effective_at unset")
+					assert.True(t, expiresAt.Equal(throughputRule.ExpiresAt.AsTime()))
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "get missing",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("select \\* from `throughput_rule` where `id`=\\?").WithArgs(2).WillReturnError(sql.ErrNoRows)
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.GetThroughputRule(context.Background(), &rulespb.GetThroughputRuleRequest{Id: 2})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "get invalid ID",
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.GetThroughputRule(context.Background(), &rulespb.GetThroughputRuleRequest{Id: 0})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "list",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT \\* FROM `throughput_rule`").WillReturnRows(activeRows())
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				response, err := client.ListThroughputRules(context.Background(), &rulespb.ListThroughputRulesRequest{})
+				if err == nil && assert.Len(t, response.ThroughputRules, 2) {
+					assert.Equal(t, "aspmx.l.google.com", response.ThroughputRules[1].MxDomain)
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "create",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `throughput_rule`").
+					WithArgs("gmail.com", 20, 50, 1000, nil, nil, 1).
+					WillReturnResult(sqlmock.NewResult(3, 1))
+				expectThroughputRuleChange(mock, "created", 3, "gmail.com", 20, 50, 1000, nil, nil)
+				mock.ExpectCommit()
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				throughputRule, err := client.CreateThroughputRule(context.Background(), &rulespb.CreateThroughputRuleRequest{ThroughputRule: &rulespb.ThroughputRule{
+					MxDomain:              "gmail.com",
+					MaxConnections:        20,
+					MessagesPerConnection: 50,
+					ConnectionTtlMillis:   1000,
+				}})
+				if err == nil {
+					assert.Equal(t, int32(3), throughputRule.Id)
+					assert.Equal(t, int32(1), throughputRule.Version)
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "create with a taken mx_domain",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec("INSERT INTO `throughput_rule`").
+					WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'gmail.com' for key 'mx_domain'"})
+				mock.ExpectRollback()
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.CreateThroughputRule(context.Background(), &rulespb.CreateThroughputRuleRequest{ThroughputRule: &rulespb.ThroughputRule{MxDomain: "gmail.com"}})
+				return err
+			},
+			code: codes.AlreadyExists,
+		},
+		{
+			name: "create without mx_domain",
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.CreateThroughputRule(context.Background(), &rulespb.CreateThroughputRuleRequest{ThroughputRule: &rulespb.ThroughputRule{MaxConnections: 20}})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "create expiring before it takes effect",
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.CreateThroughputRule(context.Background(), &rulespb.CreateThroughputRuleRequest{ThroughputRule: &rulespb.ThroughputRule{
+					MxDomain:    "gmail.com",
+					EffectiveAt: timestamppb.New(now),
+					ExpiresAt:   timestamppb.New(now.Add(-time.Hour)),
+				}})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "update",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "gmail.com", 36, 50, 0, nil, nil, 1}))
+				mock.ExpectExec("UPDATE `throughput_rule` SET").
+					WithArgs("gmail.com", 20, 50, 0, nil, nil, 2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectThroughputRuleChange(mock, "updated", 1, "gmail.com", 20, 50, 0, nil, nil)
+				mock.ExpectCommit()
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				throughputRule, err := client.UpdateThroughputRule(context.Background(), &rulespb.UpdateThroughputRuleRequest{
+					ThroughputRule: &rulespb.ThroughputRule{Id: 1, MxDomain: "gmail.com", MaxConnections: 20, MessagesPerConnection: 50},
+					IfMatchVersion: version(1),
+				})
+				if err == nil {
+					assert.Equal(t, int32(2), throughputRule.Version, "should bump the version")
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "update an old version",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "gmail.com", 20, 50, 0, nil, nil, 2}))
+				mock.ExpectRollback()
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.UpdateThroughputRule(context.Background(), &rulespb.UpdateThroughputRuleRequest{
+					ThroughputRule: &rulespb.ThroughputRule{Id: 1, MxDomain: "gmail.com"},
+					IfMatchVersion: version(1),
+				})
+				return err
+			},
+			code: codes.FailedPrecondition,
+		},
+		{
+			name: "delete an old version",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "gmail.com", 20, 50, 0, nil, nil, 2}))
+				mock.ExpectRollback()
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.DeleteThroughputRule(context.Background(), &rulespb.DeleteThroughputRuleRequest{Id: 1, IfMatchVersion: version(1)})
+				return err
+			},
+			code: codes.FailedPrecondition,
+		},
+		{
+			name: "delete missing",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(2).WillReturnError(sql.ErrNoRows)
+				mock.ExpectRollback()
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.DeleteThroughputRule(context.Background(), &rulespb.DeleteThroughputRuleRequest{Id: 2})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "effective rule",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(activeQuery).WillReturnRows(activeRows())
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				throughputRule, err := client.GetEffectiveThroughputRule(context.Background(), &rulespb.GetEffectiveThroughputRuleRequest{MxDomain: "alt1.aspmx.l.google.com"})
+				if err == nil {
+					assert.Equal(t, int32(2), throughputRule.Id, "should pick the longest matching suffix")
+				}
+				return err
+			},
+			code: codes.OK,
+		},
+		{
+			name: "no effective rule",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(activeQuery).WillReturnRows(activeRows())
+			},
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.GetEffectiveThroughputRule(context.Background(), &rulespb.GetEffectiveThroughputRuleRequest{MxDomain: "yahoo.com"})
+				return err
+			},
+			code: codes.NotFound,
+		},
+		{
+			name: "effective rule without mx_domain",
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.GetEffectiveThroughputRule(context.Background(), &rulespb.GetEffectiveThroughputRuleRequest{})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+	})
+
+	runGRPCTests(t, func(db *sql.DB) *App { return &App{DB: db, RequireChangeRequests: true} }, []grpcTest{
+		{
+			name: "create with change requests required",
+			call: func(client rulespb.ThroughputRulesClient) error {
+				_, err := client.CreateThroughputRule(context.Background(), &rulespb.CreateThroughputRuleRequest{ThroughputRule: &rulespb.ThroughputRule{MxDomain: "gmail.com"}})
+				return err
+			},
+			code: codes.PermissionDenied,
+		},
+	})
+}
@@ -3,9 +3,19 @@ package throughputrule
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"gobrm/batch"
+	"gobrm/changequery"
+	"gobrm/concurrency"
 	"gobrm/models"
+	"gobrm/problem"
+	"gobrm/rulequery"
+	"gobrm/webhook"
+	"strings"
 
+	"github.com/go-sql-driver/mysql"
 	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries"
 	"github.com/volatiletech/sqlboiler/v4/queries/qm"
 )
 
@@ -15,6 +25,9 @@ import (
 //   max_connections INT(11) NOT NULL,
 //   messages_per_connection INT(11) NOT NULL,
 //   connection_ttl_millis INT(11) NOT NULL,
+//   effective_at DATETIME NULL,
+//   expires_at DATETIME NULL,
+//   version INT NOT NULL DEFAULT 1,
 //   PRIMARY KEY(id)
 // );
 
@@ -29,6 +42,46 @@ func getThroughputRules(db *sql.DB) (models.ThroughputRuleSlice, error) {
 	return throughputRules, nil
 }
 
+// throughputRuleSearchFields are the columns throughput rule listings can be filtered and sorted on.
+var throughputRuleSearchFields = rulequery.Fields{
+	"mx_domain":               rulequery.Text,
+	"max_connections":         rulequery.Number,
+	"messages_per_connection": rulequery.Number,
+	"connection_ttl_millis":   rulequery.Number,
+	"version":                 rulequery.Number,
+}
+
+// searchThroughputRules returns a page of the throughput rules matching a
+// search and the number of matches. The mods narrow the search further,
+// e.g. to active rules.
+func searchThroughputRules(db *sql.DB, params rulequery.Params, defaultOrder string, mods ...qm.QueryMod) (models.ThroughputRuleSlice, int64, error) {
+	ctx := context.Background()
+	if condition, args := params.Where(); condition != "" {
+		mods = append(mods, qm.Where(condition, args...))
+	}
+
+	page := append([]qm.QueryMod{qm.OrderBy(params.OrderBy(defaultOrder))}, mods...)
+	if params.Limit > 0 {
+		page = append(page, qm.Limit(params.Limit), qm.Offset(params.Offset))
+	}
+
+	throughputRules, err := models.ThroughputRules(page...).All(ctx, db)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// A page that is neither the first nor short needs a separate count
+	total := int64(len(throughputRules))
+	if params.Limit > 0 && (params.Offset > 0 || total == int64(params.Limit)) {
+		total, err = models.ThroughputRules(mods...).Count(ctx, db)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return throughputRules, total, nil
+}
+
 func getThroughputRule(db *sql.DB, id int) (*models.ThroughputRule, error) {
 	ctx := context.Background()
 	throughputRule, err := models.FindThroughputRule(ctx, db, id)
@@ -40,95 +93,183 @@ func getThroughputRule(db *sql.DB, id int) (*models.ThroughputRule, error) {
 	return throughputRule, nil
 }
 
-func createThroughputRule(db *sql.DB, throughputRule models.ThroughputRule) error {
+// Rule changes are written in a transaction together with their audit row and
+// webhook outbox event so neither is kept for a change that was rolled back.
+func createThroughputRule(db *sql.DB, throughputRule *models.ThroughputRule) error {
 	ctx := context.Background()
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	err = throughputRule.Insert(ctx, db, boil.Infer())
+	err = createThroughputRuleTx(ctx, tx, throughputRule)
 
 	if err != nil {
 		return err
 	}
 
-	throughputRuleChange := models.ThroughputRuleChange{
-		Action:                "created",
-		ThroughputRuleID:      throughputRule.ID,
-		MXDomain:              throughputRule.MXDomain,
-		MaxConnections:        throughputRule.MaxConnections,
-		MessagesPerConnection: throughputRule.MessagesPerConnection,
-		ConnectionTTLMillis:   throughputRule.ConnectionTTLMillis,
+	return tx.Commit()
+}
+
+func createThroughputRuleTx(ctx context.Context, tx *sql.Tx, throughputRule *models.ThroughputRule) error {
+	throughputRule.Version = 1
+	err := throughputRule.Insert(ctx, tx, boil.Infer())
+
+	if err != nil {
+		return duplicateMXDomain(err, throughputRule.MXDomain)
 	}
 
-	err = throughputRuleChange.Insert(ctx, db, boil.Infer())
+	err = insertThroughputRuleChange(ctx, tx, "created", throughputRule)
 
 	if err != nil {
 		return err
 	}
 
-	tx.Commit()
+	return webhook.Enqueue(ctx, tx, throughputRuleEventType("created"), throughputRule)
+}
+
+// mysqlDuplicateEntry is the MySQL error number of a UNIQUE key violation.
+const mysqlDuplicateEntry = 1062
+
+// duplicateMXDomain reports a write that hit the UNIQUE key on mx_domain,
+// the only one a throughput rule write can violate, as a DuplicateError.
+func duplicateMXDomain(err error, mxDomain string) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return problem.DuplicateError{Field: "mx_domain", Value: mxDomain}
+	}
+	return err
+}
 
-	return nil
+// findThroughputRuleForUpdate locks a throughput rule until the transaction
+// ends so its version cannot change between the check and the write.
+func findThroughputRuleForUpdate(ctx context.Context, tx *sql.Tx, id int) (*models.ThroughputRule, error) {
+	return models.ThroughputRules(models.ThroughputRuleWhere.ID.EQ(id), qm.For("UPDATE")).One(ctx, tx)
 }
 
-func updateThroughputRule(db *sql.DB, throughputRule models.ThroughputRule) error {
+// updateThroughputRule writes a throughput rule if its version satisfies the
+// precondition and bumps the version, returning the stored rule.
+func updateThroughputRule(db *sql.DB, throughputRule models.ThroughputRule, precondition concurrency.Precondition) (*models.ThroughputRule, error) {
 	ctx := context.Background()
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer tx.Rollback()
 
-	currentThroughputRule, err := models.FindThroughputRule(ctx, db, throughputRule.ID)
+	updatedThroughputRule, err := updateThroughputRuleTx(ctx, tx, throughputRule, precondition)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	return updatedThroughputRule, tx.Commit()
+}
+
+func updateThroughputRuleTx(ctx context.Context, tx *sql.Tx, throughputRule models.ThroughputRule, precondition concurrency.Precondition) (*models.ThroughputRule, error) {
+	currentThroughputRule, err := findThroughputRuleForUpdate(ctx, tx, throughputRule.ID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := precondition.Check(currentThroughputRule.Version, currentThroughputRule); err != nil {
+		return nil, err
 	}
 
 	currentThroughputRule.MXDomain = throughputRule.MXDomain
 	currentThroughputRule.MaxConnections = throughputRule.MaxConnections
 	currentThroughputRule.MessagesPerConnection = throughputRule.MessagesPerConnection
 	currentThroughputRule.ConnectionTTLMillis = throughputRule.ConnectionTTLMillis
+	currentThroughputRule.EffectiveAt = throughputRule.EffectiveAt
+	currentThroughputRule.ExpiresAt = throughputRule.ExpiresAt
+	currentThroughputRule.Version++
 
-	_, err = currentThroughputRule.Update(ctx, db, boil.Infer())
+	_, err = currentThroughputRule.Update(ctx, tx, boil.Infer())
 
 	if err != nil {
-		return err
+		return nil, duplicateMXDomain(err, currentThroughputRule.MXDomain)
 	}
 
-	throughputRuleChange := models.ThroughputRuleChange{
-		Action:                "updated",
-		ThroughputRuleID:      currentThroughputRule.ID,
-		MXDomain:              currentThroughputRule.MXDomain,
-		MaxConnections:        currentThroughputRule.MaxConnections,
-		MessagesPerConnection: currentThroughputRule.MessagesPerConnection,
-		ConnectionTTLMillis:   currentThroughputRule.ConnectionTTLMillis,
+	err = insertThroughputRuleChange(ctx, tx, "updated", currentThroughputRule)
+
+	if err != nil {
+		return nil, err
 	}
 
-	err = throughputRuleChange.Insert(ctx, db, boil.Infer())
+	err = webhook.Enqueue(ctx, tx, throughputRuleEventType("updated"), currentThroughputRule)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	tx.Commit()
-
-	return nil
+	return currentThroughputRule, nil
 }
 
-func deleteThroughputRule(db *sql.DB, id int) error {
+func deleteThroughputRule(db *sql.DB, id int, precondition concurrency.Precondition) error {
 	ctx := context.Background()
-	throughputRule, err := models.FindThroughputRule(ctx, db, id)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	_, err = throughputRule.Delete(ctx, db)
+	_, err = deleteThroughputRuleTx(ctx, tx, id, precondition)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return tx.Commit()
+}
+
+func deleteThroughputRuleTx(ctx context.Context, tx *sql.Tx, id int, precondition concurrency.Precondition) (*models.ThroughputRule, error) {
+	throughputRule, err := findThroughputRuleForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := precondition.Check(throughputRule.Version, throughputRule); err != nil {
+		return nil, err
+	}
+
+	_, err = throughputRule.Delete(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = insertThroughputRuleChange(ctx, tx, "deleted", throughputRule)
+	if err != nil {
+		return nil, err
+	}
+
+	err = webhook.Enqueue(ctx, tx, throughputRuleEventType("deleted"), throughputRule)
+	if err != nil {
+		return nil, err
+	}
+
+	return throughputRule, nil
+}
+
+func insertThroughputRuleChange(ctx context.Context, exec boil.ContextExecutor, action string, throughputRule *models.ThroughputRule) error {
+	throughputRuleChange := models.ThroughputRuleChange{
+		Action:                action,
+		ThroughputRuleID:      throughputRule.ID,
+		MXDomain:              throughputRule.MXDomain,
+		MaxConnections:        throughputRule.MaxConnections,
+		MessagesPerConnection: throughputRule.MessagesPerConnection,
+		ConnectionTTLMillis:   throughputRule.ConnectionTTLMillis,
+		EffectiveAt:           throughputRule.EffectiveAt,
+		ExpiresAt:             throughputRule.ExpiresAt,
+		BatchID:               batch.ID(ctx),
+	}
+
+	return throughputRuleChange.Insert(ctx, exec, boil.Infer())
+}
+
+// throughputRuleEventType names webhook events after the change action, e.g. "throughput_rule.created".
+func throughputRuleEventType(action string) string {
+	return models.TableNames.ThroughputRule + "." + action
 }
 
 // CREATE TABLE throughput_rule_change (
@@ -139,14 +280,31 @@ func deleteThroughputRule(db *sql.DB, id int) error {
 //   max_connections INT(11) NOT NULL,
 //   messages_per_connection INT(11) NOT NULL,
 //   connection_ttl_millis INT(11) NOT NULL,
+//   effective_at DATETIME NULL,
+//   expires_at DATETIME NULL,
 //   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-//   PRIMARY KEY (id),
-//   FOREIGN KEY (throughput_rule_id) REFERENCES throughput_rule(id) ON DELETE CASCADE
+//   batch_id VARCHAR(36) NULL,
+//   PRIMARY KEY (id)
 // );
 
-func getThroughputRuleChanges(db *sql.DB) (models.ThroughputRuleChangeSlice, error) {
+// throughputRuleChangeFilterFields are the change columns that can be filtered on by exact value.
+var throughputRuleChangeFilterFields = []string{"mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis", "batch_id"}
+
+// throughputRuleChangeMods select the changes matching the parameters in page order.
+func throughputRuleChangeMods(params changequery.Params) []qm.QueryMod {
+	conditions, args := params.Where("throughput_rule_id")
+	mods := []qm.QueryMod{qm.OrderBy(params.OrderBy())}
+	if len(conditions) > 0 {
+		mods = append(mods, qm.Where(strings.Join(conditions, " AND "), args...))
+	}
+	return mods
+}
+
+func getThroughputRuleChanges(db *sql.DB, params changequery.Params) (models.ThroughputRuleChangeSlice, error) {
 	ctx := context.Background()
-	throughputRuleChanges, err := models.ThroughputRuleChanges().All(ctx, db)
+	mods := append(throughputRuleChangeMods(params), qm.Limit(params.FetchLimit()))
+
+	throughputRuleChanges, err := models.ThroughputRuleChanges(mods...).All(ctx, db)
 
 	if err != nil {
 		return nil, err
@@ -155,9 +313,53 @@ func getThroughputRuleChanges(db *sql.DB) (models.ThroughputRuleChangeSlice, err
 	return throughputRuleChanges, nil
 }
 
-func getThroughputRuleChangesForThroughputRule(db *sql.DB, id int) (models.ThroughputRuleChangeSlice, error) {
+// getThroughputRuleChangePageEnd returns the last change of the page when another page
+// follows it, or nil, so the next link can be sent before the page is streamed.
+func getThroughputRuleChangePageEnd(db *sql.DB, params changequery.Params) (*models.ThroughputRuleChange, error) {
 	ctx := context.Background()
-	throughputRuleChanges, err := models.ThroughputRuleChanges(qm.Where("throughput_rule_id=?", id)).All(ctx, db)
+	mods := append(throughputRuleChangeMods(params), qm.Limit(2), qm.Offset(params.Limit-1))
+
+	throughputRuleChanges, err := models.ThroughputRuleChanges(mods...).All(ctx, db)
+
+	if err != nil || len(throughputRuleChanges) < 2 {
+		return nil, err
+	}
+
+	return throughputRuleChanges[0], nil
+}
+
+// queryThroughputRuleChanges starts reading a page of changes for eachThroughputRuleChange.
+func queryThroughputRuleChanges(db *sql.DB, params changequery.Params) (*sql.Rows, error) {
+	ctx := context.Background()
+	mods := append(throughputRuleChangeMods(params), qm.Limit(params.Limit))
+	return models.ThroughputRuleChanges(mods...).QueryContext(ctx, db)
+}
+
+// eachThroughputRuleChange calls fn with every change as it is read from rows, so
+// long change histories are never held in memory.
+func eachThroughputRuleChange(rows *sql.Rows, fn func(*models.ThroughputRuleChange) error) error {
+	for {
+		var throughputRuleChange models.ThroughputRuleChange
+		err := queries.Bind(rows, &throughputRuleChange)
+		if err == sql.ErrNoRows {
+			return rows.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(&throughputRuleChange); err != nil {
+			return err
+		}
+	}
+}
+
+func getThroughputRuleChangesAfter(db *sql.DB, afterID int, limit int) (models.ThroughputRuleChangeSlice, error) {
+	ctx := context.Background()
+	throughputRuleChanges, err := models.ThroughputRuleChanges(
+		qm.Where("id > ?", afterID),
+		qm.OrderBy("id"),
+		qm.Limit(limit),
+	).All(ctx, db)
 
 	if err != nil {
 		return nil, err
@@ -165,3 +367,17 @@ func getThroughputRuleChangesForThroughputRule(db *sql.DB, id int) (models.Throu
 
 	return throughputRuleChanges, nil
 }
+
+func getLatestThroughputRuleChangeID(db *sql.DB) (int, error) {
+	ctx := context.Background()
+	throughputRuleChange, err := models.ThroughputRuleChanges(qm.OrderBy("id DESC")).One(ctx, db)
+
+	switch err {
+	case nil:
+		return throughputRuleChange.ID, nil
+	case sql.ErrNoRows:
+		return 0, nil
+	default:
+		return 0, err
+	}
+}
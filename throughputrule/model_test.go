@@ -0,0 +1,316 @@
+package throughputrule
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"gobrm/apiversion"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/models"
+	"gobrm/patch"
+	"gobrm/problem"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+var throughputRuleColumns = []string{"id", "mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis", "effective_at", "expires_at", "version"}
+
+const lockThroughputRuleQuery = "SELECT \\* FROM `throughput_rule` WHERE \\(`throughput_rule`.`id` = \\?\\) LIMIT 1 FOR UPDATE"
+
+// mockTest is a case run against its own stub database: expect sets up the
+// queries the case must make and run exercises the code under test.
+type mockTest struct {
+	name   string
+	expect func(mock sqlmock.Sqlmock)
+	run    func(t *testing.T, db *sql.DB)
+}
+
+// runMockTests runs each case and checks it made every expected query.
+func runMockTests(t *testing.T, tests []mockTest) {
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+			defer db.Close()
+
+			if test.expect != nil {
+				test.expect(mock)
+			}
+			test.run(t, db)
+
+			mockErr := mock.ExpectationsWereMet()
+			assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+		})
+	}
+}
+
+// throughputRuleRows returns rows of throughput rules, each given as its
+// column values.
+func throughputRuleRows(rules ...[]driver.Value) *sqlmock.Rows {
+	rows := sqlmock.NewRows(throughputRuleColumns)
+	for _, rule := range rules {
+		rows.AddRow(rule...)
+	}
+	return rows
+}
+
+// expectThroughputRuleChange expects the audit row and webhook event of a
+// throughput rule write. rule holds the ID and columns up to expires_at.
+func expectThroughputRuleChange(mock sqlmock.Sqlmock, action string, rule ...driver.Value) {
+	args := append(append([]driver.Value{action}, rule...), sqlmock.AnyArg(), nil)
+	mock.ExpectExec("INSERT INTO `throughput_rule_change`").WithArgs(args...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO webhook_outbox").WithArgs("throughput_rule."+action, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// serveThroughputRules sends a request to the throughput rule routes, under
+// any API version.
+func serveThroughputRules(db *sql.DB, req *http.Request) *httptest.ResponseRecorder {
+	a := App{}
+	a.InitializeWithDB(db)
+	a.Router = chi.NewRouter()
+	a.Mount(a.Router)
+
+	rr := httptest.NewRecorder()
+	apiversion.Policy{}.Middleware(a.Router).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestThroughputRuleListHash(t *testing.T) {
+	log.Print("Testing listings are tagged by their rules' IDs and versions")
+	throughputRules := models.ThroughputRuleSlice{{ID: 1, Version: 1}, {ID: 2, Version: 3}}
+	hash := throughputRuleListHash(throughputRules, 2)
+
+	assert.NotEqual(t, hash, throughputRuleListHash(models.ThroughputRuleSlice{{ID: 1, Version: 2}, {ID: 2, Version: 3}}, 2), "should change when a rule does")
+	assert.NotEqual(t, hash, throughputRuleListHash(throughputRules, 3), "should change when the total does")
+}
+
+func TestWriteThroughputRule(t *testing.T) {
+	log.Print("Testing model's throughput rule writes bump the version and record their change")
+	throughputRule := models.ThroughputRule{ID: 1, MXDomain: "example.com", MaxConnections: 40, MessagesPerConnection: 60, ConnectionTTLMillis: 1000}
+
+	runMockTests(t, []mockTest{
+		{
+			name: "update",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "example.com", 36, 50, 0, nil, nil, 1}))
+				mock.ExpectExec("UPDATE `throughput_rule` SET").
+					WithArgs("example.com", 40, 60, 1000, nil, nil, 2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectThroughputRuleChange(mock, "updated", 1, "example.com", 40, 60, 1000, nil, nil)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				updatedThroughputRule, err := updateThroughputRule(db, throughputRule, concurrency.Precondition{1})
+				assert.NoError(t, err, "should not receive an error when updating throughput rule")
+				assert.Equal(t, 2, updatedThroughputRule.Version, "should bump the throughput rule version")
+			},
+		},
+		{
+			name: "delete",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "example.com", 36, 50, 0, nil, nil, 1}))
+				mock.ExpectExec("DELETE FROM `throughput_rule` WHERE `id`=\\?").WithArgs(1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectThroughputRuleChange(mock, "deleted", 1, "example.com", 36, 50, 0, nil, nil)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				assert.NoError(t, deleteThroughputRule(db, 1, nil), "should record the deleted change")
+			},
+		},
+	})
+}
+
+func TestThroughputRuleVersionConflict(t *testing.T) {
+	log.Print("Testing PUT and DELETE of a throughput rule fail when If-Match names an old version")
+	var tests []mockTest
+	for _, method := range []string{"PUT", "DELETE"} {
+		method := method
+		tests = append(tests, mockTest{
+			name: method,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "example.com", 36, 50, 0, nil, nil, 3}))
+				mock.ExpectRollback()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				req := httptest.NewRequest(method, "/throughput_rules/1", strings.NewReader(`{"mx_domain":"example.com","max_connections":40,"messages_per_connection":60,"connection_ttl_millis":1000}`))
+				req.Header.Set("If-Match", concurrency.ETag(2))
+				rr := serveThroughputRules(db, req)
+
+				assert.Equal(t, http.StatusPreconditionFailed, rr.Code)
+				assert.Equal(t, concurrency.ETag(3), rr.Header().Get("ETag"))
+				var body map[string]interface{}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				assert.Equal(t, float64(3), body["current_version"], "should carry the current version")
+			},
+		})
+	}
+	runMockTests(t, tests)
+}
+
+func TestPatchThroughputRule(t *testing.T) {
+	log.Print("Testing model's patchThroughputRule with merge patches and JSON patches")
+	patchTest := func(name string, contentType string, patchDocument string, maxConnections int, messagesPerConnection int) mockTest {
+		return mockTest{
+			name: name,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "example.com", 36, 50, 0, nil, nil, 1}))
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "example.com", 36, 50, 0, nil, nil, 1}))
+				mock.ExpectExec("UPDATE `throughput_rule` SET").
+					WithArgs("example.com", maxConnections, messagesPerConnection, 0, nil, nil, 2, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				expectThroughputRuleChange(mock, "updated", 1, "example.com", maxConnections, messagesPerConnection, 0, nil, nil)
+				mock.ExpectCommit()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				patchedThroughputRule, err := patchThroughputRule(db, 1, contentType, []byte(patchDocument), concurrency.Precondition{1})
+				if assert.NoError(t, err, "should not receive an error when patching throughput rule") {
+					assert.Equal(t, maxConnections, patchedThroughputRule.MaxConnections)
+					assert.Equal(t, messagesPerConnection, patchedThroughputRule.MessagesPerConnection)
+					assert.Equal(t, 2, patchedThroughputRule.Version, "should bump the throughput rule version")
+				}
+			},
+		}
+	}
+
+	runMockTests(t, []mockTest{
+		patchTest("merge patch", patch.MergePatch, `{"max_connections":20}`, 20, 50),
+		patchTest("JSON patch", patch.JSONPatch, `[{"op":"test","path":"/mx_domain","value":"example.com"},{"op":"replace","path":"/messages_per_connection","value":10}]`, 36, 10),
+		{
+			name: "removing a required field",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(1).WillReturnRows(throughputRuleRows([]driver.Value{1, "example.com", 36, 50, 0, nil, nil, 1}))
+				mock.ExpectRollback()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				_, err := patchThroughputRule(db, 1, patch.JSONPatch, []byte(`[{"op":"remove","path":"/max_connections"}]`), nil)
+				assert.Equal(t, changerequest.ValidationError{
+					Message: "Missing required fields: max_connections",
+					Fields:  []problem.FieldError{{Field: "max_connections", Message: "is required"}},
+				}, err)
+			},
+		},
+		{
+			name: "PUT missing a required field",
+			run: func(t *testing.T, db *sql.DB) {
+				req := httptest.NewRequest("PUT", "/throughput_rules/1", strings.NewReader(`{"mx_domain":"example.com","max_connections":40,"messages_per_connection":60}`))
+				rr := serveThroughputRules(db, req)
+
+				assert.Equal(t, http.StatusBadRequest, rr.Code, "should reject the payload before touching the database")
+				assert.Contains(t, rr.Body.String(), "connection_ttl_millis")
+			},
+		},
+	})
+}
+
+func TestDecodeThroughputRuleRequiresFullPayload(t *testing.T) {
+	log.Print("Testing full throughput rule payloads must name every required field")
+	_, err := decodeThroughputRule([]byte(`{"mx_domain":"example.com","max_connections":36}`))
+	assert.Equal(t, changerequest.ValidationError{
+		Message: "Missing required fields: messages_per_connection, connection_ttl_millis",
+		Fields:  []problem.FieldError{{Field: "messages_per_connection", Message: "is required"}, {Field: "connection_ttl_millis", Message: "is required"}},
+	}, err)
+
+	_, err = decodeThroughputRule([]byte(`{"mx_domain":"example.com","max_connections":36,"messages_per_connection":50,"connection_ttl_millis":0,"extra":true}`))
+	assert.Equal(t, changerequest.ValidationError{
+		Message: "Invalid throughput rule request payload",
+		Fields:  []problem.FieldError{{Field: "extra", Message: "is not a known field"}},
+	}, err, "should reject unknown fields")
+
+	throughputRule, err := decodeThroughputRule([]byte(`{"mx_domain":"example.com","max_connections":36,"messages_per_connection":50,"connection_ttl_millis":0}`))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, throughputRule.ConnectionTTLMillis, "should allow an explicit zero")
+}
+
+func TestThroughputRuleDuplicateMXDomain(t *testing.T) {
+	log.Print("Testing POST and PUT of a throughput rule with a taken mx_domain answer 409")
+	duplicateEntry := &mysql.MySQLError{Number: 1062, Message: "Duplicate entry 'example.com' for key 'mx_domain'"}
+	duplicateTest := func(method string, path string, expect func(mock sqlmock.Sqlmock)) mockTest {
+		return mockTest{
+			name: method,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				expect(mock)
+				mock.ExpectRollback()
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				req := httptest.NewRequest(method, path, strings.NewReader(`{"mx_domain":"example.com","max_connections":40,"messages_per_connection":60,"connection_ttl_millis":1000}`))
+				rr := serveThroughputRules(db, req)
+
+				assert.Equal(t, http.StatusConflict, rr.Code)
+				var duplicate struct {
+					Type   string
+					Detail string
+					Errors []struct{ Field string }
+				}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &duplicate))
+				assert.Equal(t, "urn:gobrm:problem:duplicate", duplicate.Type)
+				assert.Contains(t, duplicate.Detail, "example.com", "should show the taken value")
+				if assert.Len(t, duplicate.Errors, 1) {
+					assert.Equal(t, "mx_domain", duplicate.Errors[0].Field)
+				}
+			},
+		}
+	}
+
+	runMockTests(t, []mockTest{
+		duplicateTest("POST", "/v2/throughput_rules", func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("INSERT INTO `throughput_rule`").WillReturnError(duplicateEntry)
+		}),
+		duplicateTest("PUT", "/v2/throughput_rules/2", func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery(lockThroughputRuleQuery).WithArgs(2).WillReturnRows(throughputRuleRows([]driver.Value{2, "example.org", 36, 50, 0, nil, nil, 1}))
+			mock.ExpectExec("UPDATE `throughput_rule` SET").WillReturnError(duplicateEntry)
+		}),
+	})
+}
+
+func TestThroughputRuleErrorsByVersion(t *testing.T) {
+	log.Print("Testing throughput rule errors keep the legacy body on v1 and are problems on v2")
+	notFoundTest := func(path string, contentType string, check func(t *testing.T, body map[string]interface{})) mockTest {
+		return mockTest{
+			name: path,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("select \\* from `throughput_rule` where `id`=\\?").WithArgs(9).WillReturnError(sql.ErrNoRows)
+			},
+			run: func(t *testing.T, db *sql.DB) {
+				rr := serveThroughputRules(db, httptest.NewRequest("GET", path, nil))
+
+				assert.Equal(t, http.StatusNotFound, rr.Code)
+				assert.Equal(t, contentType, rr.Header().Get("Content-Type"))
+				var body map[string]interface{}
+				assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				check(t, body)
+			},
+		}
+	}
+	legacy := func(t *testing.T, body map[string]interface{}) {
+		assert.Equal(t, map[string]interface{}{"error": "Throughput rule not found"}, body)
+	}
+
+	runMockTests(t, []mockTest{
+		notFoundTest("/throughput_rules/9", "application/json", legacy),
+		notFoundTest("/v1/throughput_rules/9", "application/json", legacy),
+		notFoundTest("/v2/throughput_rules/9", problem.ContentType, func(t *testing.T, body map[string]interface{}) {
+			assert.Equal(t, "urn:gobrm:problem:not-found", body["type"])
+			assert.Equal(t, "Throughput rule not found", body["detail"])
+			assert.Equal(t, "/v2/throughput_rules/9", body["instance"])
+		}),
+	})
+}
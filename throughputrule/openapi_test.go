@@ -0,0 +1,29 @@
+package throughputrule
+
+import (
+	"gobrm/openapi"
+	"log"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	log.Print("Testing every throughput rule route is in the OpenAPI document")
+	db, _, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	a := App{}
+	a.InitializeWithDB(db)
+	a.Router = chi.NewRouter()
+	a.initializeOpenAPI()
+	a.Mount(a.Router)
+	a.initializeSharedRoutes()
+
+	doc, err := openapi.Load()
+	assert.NoError(t, err)
+	assert.Empty(t, openapi.Missing(doc, openapi.ChiRoutes(a.Router)), "add these routes to openapi/openapi.yaml")
+}
@@ -0,0 +1,96 @@
+package throughputrule
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"gobrm/changerequest"
+	"gobrm/concurrency"
+	"gobrm/models"
+	"gobrm/patch"
+	"gobrm/problem"
+	"gobrm/schedule"
+	"strings"
+)
+
+// throughputRuleRequiredFields must be present in every full throughput rule payload,
+// otherwise a left out limit would silently become 0.
+var throughputRuleRequiredFields = []string{"mx_domain", "max_connections", "messages_per_connection", "connection_ttl_millis"}
+
+// decodeThroughputRule decodes and validates a full throughput rule payload, as sent
+// to POST and PUT, proposed in a change request or produced by a PATCH.
+func decodeThroughputRule(body []byte) (models.ThroughputRule, error) {
+	var throughputRule models.ThroughputRule
+
+	missing, err := patch.MissingFields(body, throughputRuleRequiredFields)
+	if err != nil {
+		return throughputRule, changerequest.ValidationError{Message: "Invalid throughput rule request payload"}
+	}
+	if len(missing) > 0 {
+		fields := make([]problem.FieldError, 0, len(missing))
+		for _, field := range missing {
+			fields = append(fields, problem.FieldError{Field: field, Message: "is required"})
+		}
+		return throughputRule, changerequest.ValidationError{Message: "Missing required fields: " + strings.Join(missing, ", "), Fields: fields}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&throughputRule); err != nil {
+		return throughputRule, changerequest.ValidationError{Message: "Invalid throughput rule request payload", Fields: problem.DecodeErrors(err)}
+	}
+
+	return throughputRule, validateThroughputRule(throughputRule)
+}
+
+// validateThroughputRule checks a decoded throughput rule, whichever API it came from.
+func validateThroughputRule(throughputRule models.ThroughputRule) error {
+	if err := schedule.ValidateWindow(throughputRule.EffectiveAt, throughputRule.ExpiresAt); err != nil {
+		return changerequest.ValidationError{Message: err.Error(), Fields: []problem.FieldError{schedule.ErrInvalidWindow}}
+	}
+	return nil
+}
+
+// patchThroughputRule applies a merge patch or JSON patch to a throughput rule and
+// writes the result like a full update.
+func patchThroughputRule(db *sql.DB, id int, contentType string, patchDocument []byte, precondition concurrency.Precondition) (*models.ThroughputRule, error) {
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	currentThroughputRule, err := findThroughputRuleForUpdate(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := precondition.Check(currentThroughputRule.Version, currentThroughputRule); err != nil {
+		return nil, err
+	}
+
+	document, err := json.Marshal(currentThroughputRule)
+	if err != nil {
+		return nil, err
+	}
+
+	patched, err := patch.Apply(contentType, document, patchDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	throughputRule, err := decodeThroughputRule(patched)
+	if err != nil {
+		return nil, err
+	}
+
+	throughputRule.ID = id
+	updatedThroughputRule, err := updateThroughputRuleTx(ctx, tx, throughputRule, precondition)
+	if err != nil {
+		return nil, err
+	}
+
+	return updatedThroughputRule, tx.Commit()
+}
@@ -0,0 +1,38 @@
+package throughputrule
+
+import (
+	"context"
+	"database/sql"
+	"gobrm/models"
+	"gobrm/release"
+
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// snapshotThroughputRules reads every throughput rule and the throughput rule changes made after afterChangeID.
+func snapshotThroughputRules(ctx context.Context, tx *sql.Tx, afterChangeID int) (release.Snapshot, error) {
+	throughputRules, err := models.ThroughputRules(qm.OrderBy("id")).All(ctx, tx)
+	if err != nil {
+		return release.Snapshot{}, err
+	}
+
+	throughputRuleChanges, err := models.ThroughputRuleChanges(
+		qm.Select("id"),
+		qm.Where("id > ?", afterChangeID),
+		qm.OrderBy("id"),
+	).All(ctx, tx)
+	if err != nil {
+		return release.Snapshot{}, err
+	}
+
+	changeIDs := make([]int, 0, len(throughputRuleChanges))
+	for _, throughputRuleChange := range throughputRuleChanges {
+		changeIDs = append(changeIDs, throughputRuleChange.ID)
+	}
+
+	if throughputRules == nil {
+		throughputRules = models.ThroughputRuleSlice{}
+	}
+
+	return release.Snapshot{Rules: throughputRules, ChangeIDs: changeIDs}, nil
+}
@@ -0,0 +1,72 @@
+package throughputrule
+
+import (
+	"context"
+	"database/sql"
+	"gobrm/models"
+	"gobrm/schedule"
+	"gobrm/webhook"
+	"strings"
+	"time"
+
+	"github.com/volatiletech/sqlboiler/v4/boil"
+	"github.com/volatiletech/sqlboiler/v4/queries/qm"
+)
+
+// throughputRuleTransitions lists the throughput rules taking effect or expiring in (from, to].
+func throughputRuleTransitions(ctx context.Context, exec boil.ContextExecutor, from, to time.Time) ([]schedule.Transition, error) {
+	throughputRules, err := models.ThroughputRules(schedule.InWindow(from, to), qm.OrderBy("id")).All(ctx, exec)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions := []schedule.Transition{}
+	for _, throughputRule := range throughputRules {
+		transitions = append(transitions, schedule.Transitions(throughputRule.ID, throughputRule, throughputRule.EffectiveAt, throughputRule.ExpiresAt, from, to)...)
+	}
+	schedule.Sort(transitions)
+
+	return transitions, nil
+}
+
+// recordThroughputRuleTransition writes the activated or expired change and webhook event for a throughput rule.
+func recordThroughputRuleTransition(ctx context.Context, tx *sql.Tx, t schedule.Transition) error {
+	throughputRule := t.Rule.(*models.ThroughputRule)
+
+	if err := insertThroughputRuleChange(ctx, tx, t.Action, throughputRule); err != nil {
+		return err
+	}
+
+	return webhook.Enqueue(ctx, tx, throughputRuleEventType(t.Action), throughputRule)
+}
+
+// getActiveThroughputRules reads the throughput rules that apply at now.
+func getActiveThroughputRules(db *sql.DB, now time.Time) (models.ThroughputRuleSlice, error) {
+	ctx := context.Background()
+	throughputRules, err := models.ThroughputRules(schedule.ActiveAt(now), qm.OrderBy("id")).All(ctx, db)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return throughputRules, nil
+}
+
+// effectiveThroughputRule returns the rule for an MX domain: an exact match,
+// or else the rule for its closest parent domain, or nil.
+func effectiveThroughputRule(throughputRules models.ThroughputRuleSlice, mxDomain string) *models.ThroughputRule {
+	mxDomain = strings.ToLower(strings.TrimSuffix(mxDomain, "."))
+
+	var effective *models.ThroughputRule
+	for _, throughputRule := range throughputRules {
+		ruleDomain := strings.ToLower(throughputRule.MXDomain)
+		if ruleDomain == mxDomain {
+			return throughputRule
+		}
+		if strings.HasSuffix(mxDomain, "."+ruleDomain) && (effective == nil || len(ruleDomain) > len(effective.MXDomain)) {
+			effective = throughputRule
+		}
+	}
+
+	return effective
+}
@@ -0,0 +1,89 @@
+package throughputrule
+
+import (
+	"database/sql"
+	"gobrm/models"
+	"gobrm/schedule"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+)
+
+func TestEffectiveThroughputRule(t *testing.T) {
+	log.Print("Testing MX domains get the exact rule or the closest parent domain's")
+	throughputRules := models.ThroughputRuleSlice{
+		{ID: 1, MXDomain: "example.com"},
+		{ID: 2, MXDomain: "mail.example.com"},
+		{ID: 3, MXDomain: "MX.Other.net"},
+		{ID: 4, MXDomain: "com"},
+	}
+
+	tests := []struct {
+		mxDomain string
+		want     int
+	}{
+		{"example.com", 1},
+		{"mail.example.com", 2},
+		{"mx1.mail.example.com", 2},
+		{"mx1.example.com", 1},
+		{"mx.other.net.", 3},
+		{"MAIL.EXAMPLE.COM", 2},
+		{"notexample.com", 4},
+		{"other.net", 0},
+		{"example.org", 0},
+	}
+	for _, test := range tests {
+		throughputRule := effectiveThroughputRule(throughputRules, test.mxDomain)
+		if test.want == 0 {
+			assert.Nil(t, throughputRule, test.mxDomain)
+			continue
+		}
+		if assert.NotNil(t, throughputRule, test.mxDomain) {
+			assert.Equal(t, test.want, throughputRule.ID, test.mxDomain)
+		}
+	}
+}
+
+func TestGetActiveThroughputRules(t *testing.T) {
+	log.Print("Testing expired and not yet effective throughput rules do not apply")
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+	windows := []struct {
+		id          int
+		mxDomain    string
+		effectiveAt null.Time
+		expiresAt   null.Time
+	}{
+		{1, "example.com", null.Time{}, null.Time{}},
+		{2, "mail.example.com", null.Time{}, null.TimeFrom(now.Add(-time.Hour))},
+		{3, "mx.example.com", null.TimeFrom(now.Add(time.Hour)), null.Time{}},
+		{4, "other.net", null.TimeFrom(now.Add(-time.Hour)), null.TimeFrom(now.Add(time.Hour))},
+	}
+
+	runMockTests(t, []mockTest{{
+		name: "active window",
+		expect: func(mock sqlmock.Sqlmock) {
+			// The database applies the window; return what it would
+			rows := throughputRuleRows()
+			for _, w := range windows {
+				if schedule.Active(w.effectiveAt, w.expiresAt, now) {
+					rows.AddRow(w.id, w.mxDomain, 36, 50, 0, w.effectiveAt, w.expiresAt, 1)
+				}
+			}
+			mock.ExpectQuery("SELECT \\* FROM `throughput_rule` WHERE \\(\\(effective_at IS NULL OR effective_at <= \\?\\) AND \\(expires_at IS NULL OR expires_at > \\?\\)\\) ORDER BY id").
+				WithArgs(now, now).
+				WillReturnRows(rows)
+		},
+		run: func(t *testing.T, db *sql.DB) {
+			throughputRules, err := getActiveThroughputRules(db, now)
+			assert.NoError(t, err)
+			assert.Len(t, throughputRules, 2)
+			assert.Equal(t, 1, effectiveThroughputRule(throughputRules, "mail.example.com").ID, "should fall back to the parent when the exact rule expired")
+			assert.Equal(t, 1, effectiveThroughputRule(throughputRules, "mx.example.com").ID, "should fall back to the parent until the exact rule takes effect")
+			assert.Equal(t, 4, effectiveThroughputRule(throughputRules, "other.net").ID)
+		},
+	}})
+}
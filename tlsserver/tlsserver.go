@@ -0,0 +1,239 @@
+// Package tlsserver serves HTTPS and gRPC over TLS, optionally verifying
+// client certificates, and reloads the certificate and client CA files when
+// they change on disk, e.g. after a renewal. Plaintext is only served when
+// it is explicitly allowed.
+package tlsserver
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// reloadDelay lets writes to the certificate and key settle before they
+// are read, as renewals usually replace both.
+const reloadDelay = 100 * time.Millisecond
+
+// Config selects how a server is reached.
+type Config struct {
+	// CertFile and KeyFile hold the PEM encoded server certificate chain and key.
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM encoded CAs client certificates are verified
+	// against. Without it client certificates are not asked for.
+	ClientCAFile string
+	// RequireClientCert rejects connections without a verified client
+	// certificate. Otherwise clients may authenticate with credentials instead.
+	RequireClientCert bool
+	// Plaintext allows serving without TLS when no CertFile is set.
+	Plaintext bool
+}
+
+// Validate checks that the config either serves TLS or allows plaintext.
+func (c Config) Validate() error {
+	switch {
+	case c.CertFile == "" && c.KeyFile == "" && c.ClientCAFile == "":
+		if !c.Plaintext {
+			return errors.New("TLS needs a certificate and key file; plaintext must be enabled explicitly")
+		}
+		return nil
+	case c.CertFile == "" || c.KeyFile == "":
+		return errors.New("TLS needs both a certificate and a key file")
+	case c.RequireClientCert && c.ClientCAFile == "":
+		return errors.New("requiring client certificates needs a client CA file")
+	}
+	return nil
+}
+
+// Credentials holds the current certificate and client CAs of a server.
+type Credentials struct {
+	config Config
+
+	mu          sync.RWMutex
+	certificate *tls.Certificate
+	clientCAs   *x509.CertPool
+}
+
+// New loads the files of a config. It returns nil credentials when the
+// config allows plaintext and sets no certificate.
+func New(config Config) (*Credentials, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.CertFile == "" {
+		return nil, nil
+	}
+
+	c := &Credentials{config: config}
+	if err := c.Reload(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Reload reads the certificate, key and client CA files again. The old
+// ones are kept when any of them fails to load.
+func (c *Credentials) Reload() error {
+	certificate, err := tls.LoadX509KeyPair(c.config.CertFile, c.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS certificate: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if c.config.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(c.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("reading client CA file: %w", err)
+		}
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s holds no PEM encoded certificates", c.config.ClientCAFile)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.certificate = &certificate
+	c.clientCAs = clientCAs
+	return nil
+}
+
+// getCertificate returns the latest loaded certificate.
+func (c *Credentials) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.certificate, nil
+}
+
+// ServerConfig returns a TLS config that always uses the latest loaded
+// certificate and client CAs.
+func (c *Credentials) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: c.getCertificate,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			c.mu.RLock()
+			defer c.mu.RUnlock()
+
+			config := &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: c.getCertificate,
+				NextProtos:     []string{"h2", "http/1.1"},
+			}
+			if c.clientCAs != nil {
+				config.ClientCAs = c.clientCAs
+				config.ClientAuth = tls.VerifyClientCertIfGiven
+				if c.config.RequireClientCert {
+					config.ClientAuth = tls.RequireAndVerifyClientCert
+				}
+			}
+			return config, nil
+		},
+	}
+}
+
+// GRPCOption serves a gRPC server over TLS with the same credentials.
+func (c *Credentials) GRPCOption() grpc.ServerOption {
+	return grpc.Creds(credentials.NewTLS(c.ServerConfig()))
+}
+
+// Watch reloads the credentials whenever their files change until ctx is
+// canceled. It watches their directories, so files replaced by a rename or
+// a symlink swap, as Kubernetes does with mounted secrets, are seen too.
+func (c *Credentials) Watch(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	watched := map[string]bool{}
+	for _, file := range []string{c.config.CertFile, c.config.KeyFile, c.config.ClientCAFile} {
+		if file == "" {
+			continue
+		}
+		dir := filepath.Dir(file)
+		if watched[dir] {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watching %s: %w", dir, err)
+		}
+		watched[dir] = true
+	}
+
+	// Events come in bursts, so reloads wait for them to settle
+	timer := time.NewTimer(reloadDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				timer.Reset(reloadDelay)
+			}
+		case err := <-watcher.Errors:
+			log.Printf("Failed to watch TLS files: %v", err)
+		case <-timer.C:
+			if err := c.Reload(); err != nil {
+				log.Printf("Failed to reload TLS files, keeping the old ones: %v", err)
+				continue
+			}
+			log.Printf("Reloaded TLS certificate %s", c.config.CertFile)
+		}
+	}
+}
+
+// Timeouts bound how long a connection may take over each part of a
+// request; zero is no limit.
+type Timeouts struct {
+	// ReadHeader bounds reading the request headers, Read the whole request.
+	ReadHeader time.Duration
+	Read       time.Duration
+	// Write bounds writing the response. Event streams lift it, as they
+	// stay open for as long as the client listens.
+	Write time.Duration
+	// Idle bounds how long a kept alive connection waits for the next request.
+	Idle time.Duration
+}
+
+// NewServer returns a server of handler on addr with timeouts. It serves TLS
+// with c, or plaintext when c is nil.
+func NewServer(addr string, handler http.Handler, c *Credentials, timeouts Timeouts) *http.Server {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: timeouts.ReadHeader,
+		ReadTimeout:       timeouts.Read,
+		WriteTimeout:      timeouts.Write,
+		IdleTimeout:       timeouts.Idle,
+	}
+	if c != nil {
+		server.TLSConfig = c.ServerConfig()
+	}
+	return server
+}
+
+// Serve serves until server is shut down, over TLS when it has a TLS config.
+// Like the http.Server methods, it returns http.ErrServerClosed after Shutdown.
+func Serve(server *http.Server) error {
+	if server.TLSConfig == nil {
+		log.Printf("Serving plaintext HTTP on %s", server.Addr)
+		return server.ListenAndServe()
+	}
+	log.Printf("Serving HTTPS on %s", server.Addr)
+	return server.ListenAndServeTLS("", "")
+}
@@ -0,0 +1,141 @@
+package tlsserver
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// issue creates a certificate for commonName signed by parent, or a
+// self-signed CA when parent is nil.
+func issue(t *testing.T, commonName string, serial int64, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, []byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	assert.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return certificate, key,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func write(t *testing.T, path string, data []byte) {
+	assert.NoError(t, ioutil.WriteFile(path, data, 0600))
+}
+
+func TestConfigValidate(t *testing.T) {
+	log.Print("Testing plaintext is only allowed when enabled")
+	assert.Error(t, Config{}.Validate())
+	assert.NoError(t, Config{Plaintext: true}.Validate())
+	assert.Error(t, Config{CertFile: "server.pem"}.Validate())
+	assert.NoError(t, Config{CertFile: "server.pem", KeyFile: "server.key"}.Validate())
+	assert.Error(t, Config{CertFile: "server.pem", KeyFile: "server.key", RequireClientCert: true}.Validate())
+
+	credentials, err := New(Config{Plaintext: true})
+	assert.NoError(t, err)
+	assert.Nil(t, credentials)
+}
+
+func TestMutualTLSAndReload(t *testing.T) {
+	log.Print("Testing client certificates are verified and renewed certificates are served")
+	dir := t.TempDir()
+	ca, caKey, caPEM, _ := issue(t, "gobrm CA", 1, nil, nil)
+	_, _, serverPEM, serverKeyPEM := issue(t, "server", 2, ca, caKey)
+	_, _, clientPEM, clientKeyPEM := issue(t, "deployer", 3, ca, caKey)
+
+	config := Config{
+		CertFile:          filepath.Join(dir, "server.pem"),
+		KeyFile:           filepath.Join(dir, "server.key"),
+		ClientCAFile:      filepath.Join(dir, "ca.pem"),
+		RequireClientCert: true,
+	}
+	write(t, config.CertFile, serverPEM)
+	write(t, config.KeyFile, serverKeyPEM)
+	write(t, config.ClientCAFile, caPEM)
+
+	credentials, err := New(config)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go credentials.Watch(ctx)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", credentials.ServerConfig())
+	assert.NoError(t, err)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.VerifiedChains[0][0].Subject.CommonName))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	clientCertificate, err := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	assert.NoError(t, err)
+	dial := func(certificates []tls.Certificate) (*tls.ConnectionState, string, error) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates}}}
+		resp, err := client.Get("https://" + listener.Addr().String())
+		if err != nil {
+			return nil, "", err
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return resp.TLS, string(body), nil
+	}
+
+	state, body, err := dial([]tls.Certificate{clientCertificate})
+	assert.NoError(t, err)
+	assert.Equal(t, "deployer", body)
+	assert.Equal(t, int64(2), state.PeerCertificates[0].SerialNumber.Int64())
+
+	_, _, err = dial(nil)
+	assert.Error(t, err, "should reject clients without a certificate")
+
+	_, _, renewedPEM, renewedKeyPEM := issue(t, "server", 4, ca, caKey)
+	write(t, config.KeyFile, renewedKeyPEM)
+	write(t, config.CertFile, renewedPEM)
+	assert.Eventually(t, func() bool {
+		state, _, err := dial([]tls.Certificate{clientCertificate})
+		return err == nil && state.PeerCertificates[0].SerialNumber.Int64() == 4
+	}, 5*time.Second, 50*time.Millisecond, "should serve the renewed certificate")
+
+	write(t, config.CertFile, []byte("not a certificate"))
+	time.Sleep(3 * reloadDelay)
+	state, _, err = dial([]tls.Certificate{clientCertificate})
+	assert.NoError(t, err, "should keep the old certificate when the new one is broken")
+	assert.Equal(t, int64(4), state.PeerCertificates[0].SerialNumber.Int64())
+}
@@ -0,0 +1,231 @@
+package webhook
+
+import (
+	"database/sql"
+	"encoding/json"
+	"gobrm/bulk"
+	"gobrm/problem"
+	"gobrm/render"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// API serves webhook subscription management. Both rule managers mount it on
+// their own router, so URL parameters are read through URLParam.
+type API struct {
+	DB       *sql.DB
+	URLParam func(r *http.Request, key string) string
+}
+
+// subscriptionListColumns and deliveryListColumns order the fields of
+// webhook lists in YAML and CSV.
+var (
+	subscriptionListColumns = bulk.Columns(Subscription{})
+	deliveryListColumns     = bulk.Columns(Delivery{})
+)
+
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	problem.Respond(w, r, code, message)
+}
+
+func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
+	if code == http.StatusNoContent {
+		w.WriteHeader(code)
+		return
+	}
+	response, _ := json.Marshal(payload)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(response)
+}
+
+func validateSubscription(s Subscription) string {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "Webhook url must be an absolute http or https URL"
+	}
+	return ""
+}
+
+func (api *API) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := getSubscriptions(r.Context(), api.DB)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	render.List(w, r, subscriptions, subscriptionListColumns)
+}
+
+// CreateSubscription registers an endpoint. The signing secret is generated
+// when not given and is only ever returned from this call.
+func (api *API) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	subscription := Subscription{Active: true}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&subscription); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if message := validateSubscription(subscription); message != "" {
+		respondWithError(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	if subscription.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+			return
+		}
+		subscription.Secret = secret
+	}
+
+	if err := createSubscription(r.Context(), api.DB, &subscription); err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	respondWithJSON(w, http.StatusCreated, subscription)
+}
+
+func (api *API) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	subscription, err := getSubscription(r.Context(), api.DB, id)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Webhook not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	subscription.Secret = ""
+	respondWithJSON(w, http.StatusOK, subscription)
+}
+
+func (api *API) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	subscription := Subscription{Active: true}
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&subscription); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if message := validateSubscription(subscription); message != "" {
+		respondWithError(w, r, http.StatusBadRequest, message)
+		return
+	}
+
+	subscription.ID = id
+	rotatedSecret := subscription.Secret
+	if err := updateSubscription(r.Context(), api.DB, &subscription); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Webhook not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	subscription.Secret = rotatedSecret
+	respondWithJSON(w, http.StatusOK, subscription)
+}
+
+func (api *API) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	if err := deleteSubscription(r.Context(), api.DB, id); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Webhook not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusNoContent, nil)
+}
+
+// GetDeliveries returns the delivery log for a subscription, newest first.
+func (api *API) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	if _, err := getSubscription(r.Context(), api.DB, id); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Webhook not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	deliveries, err := getDeliveries(r.Context(), api.DB, id)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	render.List(w, r, deliveries, deliveryListColumns)
+}
+
+// Redeliver queues a delivery to be sent again on the dispatcher's next pass.
+func (api *API) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(api.URLParam(r, "id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(api.URLParam(r, "delivery_id"), 10, 64)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid webhook delivery ID")
+		return
+	}
+
+	log.Printf("Redelivering webhook delivery %d for webhook %d", deliveryID, id)
+	delivery, err := redeliver(r.Context(), api.DB, id, deliveryID)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			respondWithError(w, r, http.StatusNotFound, "Webhook delivery not found")
+		default:
+			respondWithError(w, r, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, delivery)
+}
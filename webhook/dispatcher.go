@@ -0,0 +1,297 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultMaxAttempts  = 8
+	defaultBaseBackoff  = 10 * time.Second
+	defaultMaxBackoff   = time.Hour
+	defaultLease        = time.Minute
+	batchSize           = 50
+)
+
+// Dispatcher fans outbox events out to subscriptions and delivers them,
+// retrying failed deliveries with exponential backoff. Several dispatchers
+// may share one database; rows are claimed with SKIP LOCKED and leases.
+type Dispatcher struct {
+	DB           *sql.DB
+	Client       *http.Client
+	PollInterval time.Duration
+	MaxAttempts  int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+}
+
+// Run dispatches and delivers events until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	pollInterval := d.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.dispatchOutbox(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to dispatch webhook outbox: %s", err)
+			}
+			if err := d.deliverDue(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Failed to deliver webhooks: %s", err)
+			}
+		}
+	}
+}
+
+// dispatchOutbox turns undispatched outbox events into one delivery per matching subscription.
+func (d *Dispatcher) dispatchOutbox(ctx context.Context) error {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, event_type, payload, created_at FROM webhook_outbox WHERE dispatched_at IS NULL ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED",
+		batchSize)
+	if err != nil {
+		return err
+	}
+
+	outboxEvents := []outboxEvent{}
+	for rows.Next() {
+		var e outboxEvent
+		if err := rows.Scan(&e.ID, &e.EventType, &e.Payload, &e.CreatedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		outboxEvents = append(outboxEvents, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(outboxEvents) == 0 {
+		return nil
+	}
+
+	subscriptions, err := getActiveSubscriptions(ctx, tx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, e := range outboxEvents {
+		for _, s := range subscriptions {
+			if !s.Matches(e.EventType) {
+				continue
+			}
+
+			_, err := tx.ExecContext(ctx,
+				"INSERT IGNORE INTO webhook_delivery (subscription_id, outbox_id, event_type, payload, status, next_attempt_at) VALUES (?, ?, ?, ?, ?, ?)",
+				s.ID, e.ID, e.EventType, envelope(e), deliveryPending, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, "UPDATE webhook_outbox SET dispatched_at = ? WHERE id = ?", now, e.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// envelope wraps the outbox payload with the event metadata receivers need.
+func envelope(e outboxEvent) string {
+	return fmt.Sprintf(`{"id":%d,"type":%q,"created_at":%q,"data":%s}`,
+		e.ID, e.EventType, e.CreatedAt.UTC().Format(time.RFC3339), e.Payload)
+}
+
+type dueDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// deliverDue claims due deliveries by pushing their next attempt out by a
+// lease, then sends them outside of the claiming transaction.
+func (d *Dispatcher) deliverDue(ctx context.Context) error {
+	due, err := d.claimDue(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, delivery := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		d.deliver(ctx, delivery)
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) claimDue(ctx context.Context) ([]dueDelivery, error) {
+	tx, err := d.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().UTC()
+	rows, err := tx.QueryContext(ctx,
+		"SELECT d.id, d.subscription_id, d.event_type, d.payload, d.attempts, s.url, s.secret "+
+			"FROM webhook_delivery d JOIN webhook_subscription s ON s.id = d.subscription_id "+
+			"WHERE d.status = ? AND d.next_attempt_at <= ? AND s.active = TRUE ORDER BY d.next_attempt_at LIMIT ? FOR UPDATE SKIP LOCKED",
+		deliveryPending, now, batchSize)
+	if err != nil {
+		return nil, err
+	}
+
+	due := []dueDelivery{}
+	for rows.Next() {
+		var dd dueDelivery
+		var payload string
+		if err := rows.Scan(&dd.ID, &dd.SubscriptionID, &dd.EventType, &payload, &dd.Attempts, &dd.URL, &dd.Secret); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		dd.Payload = []byte(payload)
+		due = append(due, dd)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, dd := range due {
+		if _, err := tx.ExecContext(ctx, "UPDATE webhook_delivery SET next_attempt_at = ? WHERE id = ?", now.Add(defaultLease), dd.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return due, tx.Commit()
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery dueDelivery) {
+	attempts := delivery.Attempts + 1
+	code, sendErr := d.send(ctx, delivery)
+
+	var err error
+	now := time.Now().UTC()
+	switch {
+	case sendErr == nil:
+		_, err = d.DB.ExecContext(ctx,
+			"UPDATE webhook_delivery SET status = ?, attempts = ?, last_response_code = ?, last_error = NULL, delivered_at = ? WHERE id = ?",
+			deliverySucceeded, attempts, code, now, delivery.ID)
+	case attempts >= d.maxAttempts():
+		log.Printf("Giving up on webhook delivery %d after %d attempts: %s", delivery.ID, attempts, sendErr)
+		_, err = d.DB.ExecContext(ctx,
+			"UPDATE webhook_delivery SET status = ?, attempts = ?, last_response_code = ?, last_error = ? WHERE id = ?",
+			deliveryFailed, attempts, nullableCode(code), truncate(sendErr.Error(), 1024), delivery.ID)
+	default:
+		_, err = d.DB.ExecContext(ctx,
+			"UPDATE webhook_delivery SET attempts = ?, next_attempt_at = ?, last_response_code = ?, last_error = ? WHERE id = ?",
+			attempts, now.Add(d.backoff(attempts)), nullableCode(code), truncate(sendErr.Error(), 1024), delivery.ID)
+	}
+
+	if err != nil {
+		log.Printf("Failed to record webhook delivery %d: %s", delivery.ID, err)
+	}
+}
+
+// send posts the signed payload and treats any 2xx response as delivered.
+func (d *Dispatcher) send(ctx context.Context, delivery dueDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.EventType)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp.Unix(), 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client().Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff doubles the wait after every failed attempt up to MaxBackoff.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	base := d.BaseBackoff
+	if base <= 0 {
+		base = defaultBaseBackoff
+	}
+	max := d.MaxBackoff
+	if max <= 0 {
+		max = defaultMaxBackoff
+	}
+
+	wait := base
+	for i := 1; i < attempts; i++ {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+	return wait
+}
+
+func (d *Dispatcher) maxAttempts() int {
+	if d.MaxAttempts > 0 {
+		return d.MaxAttempts
+	}
+	return defaultMaxAttempts
+}
+
+func (d *Dispatcher) client() *http.Client {
+	if d.Client != nil {
+		return d.Client
+	}
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func nullableCode(code int) interface{} {
+	if code == 0 {
+		return nil
+	}
+	return code
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,323 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// CREATE TABLE webhook_subscription (
+//   id INT NOT NULL AUTO_INCREMENT,
+//   url VARCHAR(2048) NOT NULL,
+//   secret VARCHAR(255) NOT NULL,
+//   event_types VARCHAR(1024) NOT NULL DEFAULT '',
+//   active BOOLEAN NOT NULL DEFAULT TRUE,
+//   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+//   PRIMARY KEY (id)
+// );
+
+// Subscription is an endpoint that receives rule change events. An empty
+// EventTypes list subscribes to every event type.
+type Subscription struct {
+	ID         int       `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret,omitempty"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Matches reports whether the subscription wants events of the given type.
+// Event types may be matched exactly or with a trailing wildcard such as "bounce_rule.*".
+func (s Subscription) Matches(eventType string) bool {
+	if len(s.EventTypes) == 0 {
+		return true
+	}
+
+	for _, pattern := range s.EventTypes {
+		if pattern == "*" || pattern == eventType {
+			return true
+		}
+		if strings.HasSuffix(pattern, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const subscriptionColumns = "id, url, secret, event_types, active, created_at, updated_at"
+
+func scanSubscription(row interface{ Scan(...interface{}) error }) (Subscription, error) {
+	var s Subscription
+	var eventTypes string
+	if err := row.Scan(&s.ID, &s.URL, &s.Secret, &eventTypes, &s.Active, &s.CreatedAt, &s.UpdatedAt); err != nil {
+		return s, err
+	}
+	s.EventTypes = splitEventTypes(eventTypes)
+	return s, nil
+}
+
+func splitEventTypes(eventTypes string) []string {
+	result := []string{}
+	for _, eventType := range strings.Split(eventTypes, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			result = append(result, eventType)
+		}
+	}
+	return result
+}
+
+func getSubscriptions(ctx context.Context, db *sql.DB) ([]Subscription, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscription ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []Subscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func getActiveSubscriptions(ctx context.Context, tx *sql.Tx) ([]Subscription, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscription WHERE active = TRUE")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := []Subscription{}
+	for rows.Next() {
+		s, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, s)
+	}
+
+	return subscriptions, rows.Err()
+}
+
+func getSubscription(ctx context.Context, db *sql.DB, id int) (Subscription, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscription WHERE id = ?", id)
+	return scanSubscription(row)
+}
+
+func createSubscription(ctx context.Context, db *sql.DB, s *Subscription) error {
+	result, err := db.ExecContext(ctx,
+		"INSERT INTO webhook_subscription (url, secret, event_types, active) VALUES (?, ?, ?, ?)",
+		s.URL, s.Secret, strings.Join(s.EventTypes, ","), s.Active)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	created, err := getSubscription(ctx, db, int(id))
+	if err != nil {
+		return err
+	}
+	*s = created
+	return nil
+}
+
+// updateSubscription replaces the URL, event types and active flag. The secret
+// is only rotated when a new one is given.
+func updateSubscription(ctx context.Context, db *sql.DB, s *Subscription) error {
+	if _, err := getSubscription(ctx, db, s.ID); err != nil {
+		return err
+	}
+
+	statement := "UPDATE webhook_subscription SET url = ?, event_types = ?, active = ? WHERE id = ?"
+	args := []interface{}{s.URL, strings.Join(s.EventTypes, ","), s.Active, s.ID}
+	if s.Secret != "" {
+		statement = "UPDATE webhook_subscription SET url = ?, event_types = ?, active = ?, secret = ? WHERE id = ?"
+		args = []interface{}{s.URL, strings.Join(s.EventTypes, ","), s.Active, s.Secret, s.ID}
+	}
+
+	if _, err := db.ExecContext(ctx, statement, args...); err != nil {
+		return err
+	}
+
+	updated, err := getSubscription(ctx, db, s.ID)
+	if err != nil {
+		return err
+	}
+	*s = updated
+	return nil
+}
+
+func deleteSubscription(ctx context.Context, db *sql.DB, id int) error {
+	result, err := db.ExecContext(ctx, "DELETE FROM webhook_subscription WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CREATE TABLE webhook_outbox (
+//   id BIGINT NOT NULL AUTO_INCREMENT,
+//   event_type VARCHAR(64) NOT NULL,
+//   payload TEXT NOT NULL,
+//   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   dispatched_at DATETIME NULL,
+//   PRIMARY KEY (id),
+//   INDEX (dispatched_at)
+// );
+
+// Execer is satisfied by *sql.Tx so events can be written in the same
+// transaction as the rule change that produced them.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Enqueue writes an event to the outbox. Callers pass the transaction that
+// writes the rule change so the event is committed or rolled back with it.
+func Enqueue(ctx context.Context, exec Execer, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = exec.ExecContext(ctx, "INSERT INTO webhook_outbox (event_type, payload) VALUES (?, ?)", eventType, string(payload))
+	return err
+}
+
+type outboxEvent struct {
+	ID        int64
+	EventType string
+	Payload   string
+	CreatedAt time.Time
+}
+
+// CREATE TABLE webhook_delivery (
+//   id BIGINT NOT NULL AUTO_INCREMENT,
+//   subscription_id INT NOT NULL,
+//   outbox_id BIGINT NOT NULL,
+//   event_type VARCHAR(64) NOT NULL,
+//   payload TEXT NOT NULL,
+//   status VARCHAR(16) NOT NULL DEFAULT 'pending',
+//   attempts INT NOT NULL DEFAULT 0,
+//   next_attempt_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   last_response_code INT NULL,
+//   last_error VARCHAR(1024) NULL,
+//   created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+//   delivered_at DATETIME NULL,
+//   PRIMARY KEY (id),
+//   UNIQUE KEY (subscription_id, outbox_id),
+//   INDEX (status, next_attempt_at),
+//   FOREIGN KEY (subscription_id) REFERENCES webhook_subscription(id) ON DELETE CASCADE
+// );
+
+const (
+	deliveryPending   = "pending"
+	deliverySucceeded = "succeeded"
+	deliveryFailed    = "failed"
+)
+
+// Delivery is one attempt history for sending an event to a subscription.
+type Delivery struct {
+	ID               int64           `json:"id"`
+	SubscriptionID   int             `json:"subscription_id"`
+	OutboxID         int64           `json:"outbox_id"`
+	EventType        string          `json:"event_type"`
+	Payload          json.RawMessage `json:"payload"`
+	Status           string          `json:"status"`
+	Attempts         int             `json:"attempts"`
+	NextAttemptAt    time.Time       `json:"next_attempt_at"`
+	LastResponseCode *int            `json:"last_response_code"`
+	LastError        *string         `json:"last_error"`
+	CreatedAt        time.Time       `json:"created_at"`
+	DeliveredAt      *time.Time      `json:"delivered_at"`
+}
+
+const deliveryColumns = "id, subscription_id, outbox_id, event_type, payload, status, attempts, next_attempt_at, last_response_code, last_error, created_at, delivered_at"
+
+func scanDelivery(row interface{ Scan(...interface{}) error }) (Delivery, error) {
+	var d Delivery
+	var payload string
+	var lastResponseCode sql.NullInt64
+	var lastError sql.NullString
+	var deliveredAt sql.NullTime
+	err := row.Scan(&d.ID, &d.SubscriptionID, &d.OutboxID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &lastResponseCode, &lastError, &d.CreatedAt, &deliveredAt)
+	if err != nil {
+		return d, err
+	}
+
+	d.Payload = json.RawMessage(payload)
+	if lastResponseCode.Valid {
+		code := int(lastResponseCode.Int64)
+		d.LastResponseCode = &code
+	}
+	if lastError.Valid {
+		d.LastError = &lastError.String
+	}
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return d, nil
+}
+
+func getDeliveries(ctx context.Context, db *sql.DB, subscriptionID int) ([]Delivery, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_delivery WHERE subscription_id = ? ORDER BY id DESC", subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// redeliver puts a delivery back in the queue with a fresh set of attempts.
+func redeliver(ctx context.Context, db *sql.DB, subscriptionID int, deliveryID int64) (Delivery, error) {
+	result, err := db.ExecContext(ctx,
+		"UPDATE webhook_delivery SET status = ?, attempts = 0, next_attempt_at = ? WHERE id = ? AND subscription_id = ?",
+		deliveryPending, time.Now().UTC(), deliveryID, subscriptionID)
+	if err != nil {
+		return Delivery{}, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return Delivery{}, err
+	}
+	if affected == 0 {
+		return Delivery{}, sql.ErrNoRows
+	}
+
+	row := db.QueryRowContext(ctx, "SELECT "+deliveryColumns+" FROM webhook_delivery WHERE id = ?", deliveryID)
+	return scanDelivery(row)
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionMatches(t *testing.T) {
+	log.Print("Testing subscription event type filters")
+	all := Subscription{}
+	assert.True(t, all.Matches("bounce_rule.created"), "empty filter should match every event")
+
+	filtered := Subscription{EventTypes: []string{"bounce_rule.*", "throughput_rule.deleted"}}
+	assert.True(t, filtered.Matches("bounce_rule.updated"))
+	assert.True(t, filtered.Matches("throughput_rule.deleted"))
+	assert.False(t, filtered.Matches("throughput_rule.created"))
+	assert.False(t, filtered.Matches("bounce_rules.created"))
+}
+
+func TestSignAndVerify(t *testing.T) {
+	log.Print("Testing webhook payload signatures")
+	timestamp := time.Unix(1600000000, 0)
+	body := []byte(`{"id":1}`)
+	signature := Sign("secret", timestamp, body)
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.True(t, Verify("secret", timestamp, body, signature))
+	assert.False(t, Verify("other", timestamp, body, signature), "should not verify with another secret")
+	assert.False(t, Verify("secret", timestamp.Add(time.Second), body, signature), "should not verify a replayed timestamp")
+}
+
+func TestBackoff(t *testing.T) {
+	log.Print("Testing webhook retry backoff")
+	d := Dispatcher{BaseBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 4*time.Second, d.backoff(3))
+	assert.Equal(t, 5*time.Second, d.backoff(4), "should cap at the max backoff")
+}
+
+func TestDeliverRetriesOnFailure(t *testing.T) {
+	log.Print("Testing failed webhook deliveries are rescheduled")
+	var received *http.Request
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = ioutil.ReadAll(r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	db, mock, err := sqlmock.New()
+	assert.NoErrorf(t, err, "an error '%s' was not expected when opening a stub database connection", err)
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE webhook_delivery SET attempts").
+		WithArgs(1, sqlmock.AnyArg(), http.StatusServiceUnavailable, "unexpected response status 503", int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	d := Dispatcher{DB: db}
+	delivery := dueDelivery{
+		Delivery: Delivery{ID: 7, EventType: "bounce_rule.created", Payload: []byte(`{"id":1}`)},
+		URL:      server.URL,
+		Secret:   "secret",
+	}
+	d.deliver(context.Background(), delivery)
+
+	assert.Equal(t, "bounce_rule.created", received.Header.Get(EventHeader))
+	timestamp, _ := strconv.ParseInt(received.Header.Get(TimestampHeader), 10, 64)
+	assert.True(t, Verify("secret", time.Unix(timestamp, 0), receivedBody, received.Header.Get(SignatureHeader)), "payload should be signed")
+
+	mockErr := mock.ExpectationsWereMet()
+	assert.NoErrorf(t, mockErr, "did not pass expectations such as %s", mockErr)
+}